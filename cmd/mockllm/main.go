@@ -0,0 +1,40 @@
+// mockllm 启动兼容 OpenAI chat-completions 协议的假大模型服务，本地开发时不需要 SILICONFLOW_API_KEY 和网络
+//
+//	go run ./cmd/mockllm                          # 使用内置规则，监听 :8090
+//	go run ./cmd/mockllm -rules rules.json        # 使用规则文件（格式见 mockllm.LoadRules）
+//
+// 然后设置 SILICONFLOW_BASE_URL=http://localhost:8090/v1，SILICONFLOW_API_KEY 随意填写一个非空值
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/mockllm"
+)
+
+func main() {
+	addr := flag.String("addr", ":8090", "监听地址")
+	rulesFile := flag.String("rules", "", "规则文件路径，不设置时使用内置规则")
+	flag.Parse()
+
+	rules := mockllm.DefaultRules()
+	if *rulesFile != "" {
+		var err error
+		if rules, err = mockllm.LoadRules(*rulesFile); err != nil {
+			log.Fatalf("加载规则失败: %v", err)
+		}
+	}
+	srv, err := mockllm.New(rules...)
+	if err != nil {
+		log.Fatalf("加载规则失败: %v", err)
+	}
+	srv.Logger = log.New(os.Stdout, "[mockllm] ", log.LstdFlags)
+
+	log.Printf("假大模型服务已启动，监听 %s，共 %d 条规则", *addr, len(rules))
+	if err := http.ListenAndServe(*addr, srv); err != nil {
+		log.Fatalf("服务启动失败: %v", err)
+	}
+}
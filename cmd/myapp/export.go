@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/config"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/database"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/export"
+)
+
+// runExport 执行 export 子命令，把一张表导出到文件或标准输出
+func runExport(cfg *config.Config, args []string) error {
+	fs := newFlagSet("export", "export -table "+strings.Join(export.Tables(), "|")+" [-format jsonl|csv] [-out 文件]")
+	table := fs.String("table", "", "要导出的表")
+	format := fs.String("format", export.FormatJSONL, "导出格式: jsonl / csv")
+	out := fs.String("out", "", "输出文件，默认输出到标准输出")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *table == "" {
+		fmt.Fprintln(fs.Output(), "必须指定 -table")
+		fs.Usage()
+		return errUsage
+	}
+
+	if err := connectDatabase(cfg); err != nil {
+		return err
+	}
+	defer database.Close()
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	bw := bufio.NewWriter(w)
+	n, err := export.Write(context.Background(), database.DB, *table, *format, bw)
+	if err != nil {
+		return err
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	// 统计信息输出到标准错误，不混入导出的数据
+	fmt.Fprintf(os.Stderr, "已导出 %s 表 %d 行\n", *table, n)
+	return nil
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/config"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/util"
+)
+
+const jwtKeysUsage = `用法: server jwt-keys <命令> [参数]
+
+  list                                        查看密钥及其状态
+  rotate [-alg EdDSA|RS256|HS256] [-delay 10m]  生成新密钥，delay 后开始签发，旧密钥再保留 JWT_TTL 后停用
+  retire -kid <kid>                           立即停用密钥 (密钥泄露时使用，它签发的令牌全部失效)
+
+密钥保存在 JWT_KEYS_FILE 指定的文件中，服务会自动重新读取，不需要重启。
+文件不存在时 rotate 会创建它，并把 JWT_SECRET 作为旧密钥保留到新密钥生效后，切换时已登录的用户不受影响`
+
+// runJWTKeys 执行 jwt-keys 子命令
+func runJWTKeys(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, jwtKeysUsage)
+		return errUsage
+	}
+	sub, args := args[0], args[1:]
+	fs := newFlagSet("jwt-keys "+sub, "jwt-keys "+sub+" [参数]")
+	var alg, kid *string
+	var delay *time.Duration
+	switch sub {
+	case "list":
+	case "rotate":
+		alg = fs.String("alg", util.AlgEdDSA, "签名算法: EdDSA / RS256 / HS256 (HS256 的密钥不会出现在 JWKS 中)")
+		delay = fs.Duration("delay", 10*time.Minute, "新密钥在多久后开始签发，期间先在 JWKS 中发布公钥；需要大于其他服务缓存 JWKS 的时间")
+	case "retire":
+		kid = fs.String("kid", "", "要停用的密钥")
+	default:
+		fmt.Fprintln(os.Stderr, jwtKeysUsage)
+		return errUsage
+	}
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	path := cfg.JWT.KeysFile
+	if path == "" {
+		return errors.New("未设置 JWT_KEYS_FILE")
+	}
+
+	now := time.Now()
+	keyring, err := util.LoadKeyring(path)
+	if errors.Is(err, os.ErrNotExist) && sub == "rotate" {
+		keyring, err = newKeyring(cfg, delay), nil
+	}
+	if err != nil {
+		return fmt.Errorf("读取密钥文件失败: %w", err)
+	}
+
+	switch sub {
+	case "list":
+		printKeys(keyring, now)
+		return nil
+	case "rotate":
+		if !slices.Contains(util.Algorithms, *alg) {
+			return fmt.Errorf("不支持的算法 %q (可选 %v)", *alg, util.Algorithms)
+		}
+		key, err := keyring.Rotate(*alg, now, *delay, cfg.JWT.TTL)
+		if err != nil {
+			return err
+		}
+		if err := keyring.Save(path); err != nil {
+			return fmt.Errorf("保存密钥文件失败: %w", err)
+		}
+		fmt.Printf("已生成密钥 %s (%s)，%s 开始签发\n", key.ID, key.Algorithm, key.NotBefore.Local().Format(time.DateTime))
+	case "retire":
+		if *kid == "" {
+			fmt.Fprintln(fs.Output(), "必须指定 -kid")
+			fs.Usage()
+			return errUsage
+		}
+		if err := keyring.Retire(*kid, now); err != nil {
+			return err
+		}
+		if _, ok := keyring.Signer(now); !ok {
+			return fmt.Errorf("停用 %s 后没有可用于签发的密钥，请先执行 jwt-keys rotate -delay 0", *kid)
+		}
+		if err := keyring.Save(path); err != nil {
+			return fmt.Errorf("保存密钥文件失败: %w", err)
+		}
+		fmt.Printf("密钥 %s 已停用\n", *kid)
+	}
+	printKeys(keyring, now)
+	return nil
+}
+
+// newKeyring 创建第一个密钥文件时沿用 JWT_SECRET，让切换到密钥文件前签发的令牌继续有效；
+// 没有 JWT_SECRET 时没有旧密钥可用，新密钥立即生效
+func newKeyring(cfg *config.Config, delay *time.Duration) *util.Keyring {
+	if cfg.JWT.Secret == "" {
+		*delay = 0
+		return util.NewKeyring()
+	}
+	return util.NewKeyring(util.SecretKey(cfg.JWT.Secret))
+}
+
+// printKeys 打印各密钥的状态
+func printKeys(keyring *util.Keyring, now time.Time) {
+	signer, _ := keyring.Signer(now)
+	for _, k := range keyring.Keys {
+		var state string
+		switch {
+		case k.Expired(now):
+			state = "已停用"
+		case k == signer:
+			state = "签发中"
+		case k.NotBefore.After(now):
+			state = "待生效 " + k.NotBefore.Local().Format(time.DateTime)
+		default:
+			state = "仅校验"
+		}
+		if k.NotAfter != nil && !k.Expired(now) {
+			state += "，" + k.NotAfter.Local().Format(time.DateTime) + " 停用"
+		}
+		fmt.Printf("%-20s %-6s %s\n", k.ID, k.Algorithm, state)
+	}
+}
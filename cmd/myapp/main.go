@@ -1,47 +1,132 @@
+// server 是心愿墙后端的命令行入口，不带命令时启动 HTTP 服务：
+//
+//	server serve                                   # 启动服务
+//	server migrate up                              # 执行数据库迁移
+//	server seed -profile demo                      # 填充演示数据
+//	server user create-admin -username 2024000001  # 创建管理员
+//	server reconcile-counters -fix                 # 修正愿望的点赞数和评论数
+//	server export -table wishes -format csv        # 导出数据
+//	server jwt-keys rotate -alg EdDSA              # 轮换访问令牌的签名密钥
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
 	"os"
 
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/config"
 	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/database"
 	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/logger"
-	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/seeder"
-	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/router"
-	"github.com/joho/godotenv"
+	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
 
+// command 是一个子命令，args 为命令名之后的参数
+type command struct {
+	name  string
+	usage string
+	run   func(cfg *config.Config, args []string) error
+}
+
+var commands = []command{
+	{"serve", "启动 HTTP 服务 (不带命令时的默认行为)", runServe},
+	{"migrate", "数据库迁移: up / down / to / status / unlock", runMigrate},
+	{"seed", "填充初始数据: seed [-profile demo|load]", runSeed},
+	{"user", "用户管理: create-admin / set-role / reset-password", runUser},
+	{"reconcile-counters", "重新统计愿望的点赞数和评论数: reconcile-counters [-fix]", runReconcileCounters},
+	{"export", "导出数据: export -table users|wishes|comments|likes [-format jsonl|csv] [-out 文件]", runExport},
+	{"jwt-keys", "访问令牌签名密钥: list / rotate / retire", runJWTKeys},
+}
+
+// errUsage 表示命令参数有误，已经打印过用法
+var errUsage = errors.New("参数错误")
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "用法: server <命令> [参数]\n\n命令:")
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %-20s %s\n", c.name, c.usage)
+	}
+	fmt.Fprintln(os.Stderr, "\n使用 server <命令> -h 查看命令的参数")
+}
+
 func main() {
-	if err := godotenv.Load(); err != nil {
-		// 在 logger 初始化之前用标准库打印警告
-		log.Println("警告：未找到 .env 文件，将使用系统环境变量")
+	name, args := "serve", os.Args[1:]
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
+	}
+	if name == "help" || name == "-h" || name == "--help" {
+		usage()
+		return
+	}
+	var cmd *command
+	for i := range commands {
+		if commands[i].name == name {
+			cmd = &commands[i]
+		}
+	}
+	if cmd == nil {
+		fmt.Fprintf(os.Stderr, "未知的命令 %q\n\n", name)
+		usage()
+		os.Exit(2)
 	}
 
-	// 初始化日志
-	logger.InitLogger()
-	zap.S().Info("日志系统初始化成功")
+	// 加载并校验配置 (.env / CONFIG_FILE / 环境变量)，配置有误时直接退出
+	cfg, err := config.Load()
+	if err != nil {
+		// 在 logger 初始化之前用标准库打印错误
+		log.Fatalf("加载配置失败: %v", err)
+	}
 
-	database.InitDB()
-	zap.S().Info("数据库初始化成功,并且执行AutoMigrate成功")
+	// 初始化日志
+	logger.InitLoggerWithMode(cfg.Release())
+	gin.SetMode(cfg.Mode)
+	for _, w := range cfg.Warnings() {
+		zap.S().Warn(w)
+	}
 
-	// 填充初始数据
-	if os.Getenv("GIN_MODE") != "release" {
-		zap.S().Info("Main: GIN_MODE 非 'release'，开始执行数据填充...")
-		seeder.SeedData(database.DB)
-	} else {
-		zap.S().Info("Main: GIN_MODE 为 'release'，跳过数据填充。")
+	err = cmd.run(cfg, args)
+	logger.Sync()
+	switch {
+	case errors.Is(err, flag.ErrHelp):
+	case errors.Is(err, errUsage):
+		os.Exit(2)
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+		os.Exit(1)
 	}
-	zap.S().Info("Main: 开始依赖注入...")
+}
 
-	//后续在这里添加路由和启动服务器的代码
-	r := router.SetupRouter(database.DB)
-	zap.S().Info("路由挂载成功")
+// newFlagSet 创建子命令的参数解析器，-h 时打印 usage 和参数说明
+func newFlagSet(name, usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "用法: server %s\n", usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
 
-	// (默认监听 8080 端口)
-	zap.S().Info("服务器开始启动，监听端口 :8080")
-	if err := r.Run(":8080"); err != nil {
-		zap.S().Fatalf("服务器启动失败: %v", err)
+// parseFlags 解析参数，-h 时返回 flag.ErrHelp，其余解析错误返回 errUsage
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return errUsage
 	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(fs.Output(), "多余的参数: %v\n", fs.Args())
+		fs.Usage()
+		return errUsage
+	}
+	return nil
+}
 
+// connectDatabase 连接数据库并校验表结构版本，供读写业务数据的命令使用，调用方负责 database.Close
+func connectDatabase(cfg *config.Config) error {
+	database.Connect(cfg.Database)
+	return database.PrepareSchema(context.Background(), false)
 }
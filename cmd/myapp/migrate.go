@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/config"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/database"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/migrate"
+)
+
+const migrateUsage = `用法: server migrate <命令>
+
+  up            执行所有未执行的迁移
+  down [N]      回滚最近的 N 个版本 (默认 1)
+  to <版本号>   迁移到指定版本，高于当前版本时向上执行，低于时回滚，0 表示全部回滚
+  status        查看各版本的执行情况
+  unlock        强制释放迁移锁 (仅在迁移进程异常退出后使用)`
+
+// runMigrate 执行 migrate 子命令
+func runMigrate(cfg *config.Config, args []string) error {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "help" {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return errUsage
+	}
+	database.Connect(cfg.Database)
+	defer database.Close()
+	m, err := migrate.New(database.DB)
+	if err != nil {
+		return fmt.Errorf("加载迁移脚本失败: %w", err)
+	}
+	ctx := context.Background()
+
+	var done []migrate.Migration
+	switch args[0] {
+	case "up":
+		done, err = m.Up(ctx)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps <= 0 {
+				return fmt.Errorf("回滚的版本数不合法: %s", args[1])
+			}
+		}
+		done, err = m.Down(ctx, steps)
+	case "to":
+		if len(args) < 2 {
+			fmt.Fprintln(os.Stderr, migrateUsage)
+			return errUsage
+		}
+		target, perr := strconv.ParseUint(args[1], 10, 32)
+		if perr != nil {
+			return fmt.Errorf("版本号不合法: %s", args[1])
+		}
+		done, err = m.To(ctx, uint(target))
+	case "status":
+		return printStatus(ctx, m)
+	case "unlock":
+		if err := m.Unlock(ctx); err != nil {
+			return fmt.Errorf("释放迁移锁失败: %w", err)
+		}
+		fmt.Println("迁移锁已释放")
+		return nil
+	default:
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return errUsage
+	}
+
+	for _, mig := range done {
+		fmt.Printf("%04d_%s\n", mig.Version, mig.Name)
+	}
+	if err != nil {
+		return fmt.Errorf("迁移失败: %w", err)
+	}
+	version, _ := m.Version(ctx)
+	fmt.Printf("执行了 %d 个迁移，当前版本 %d (最新版本 %d)\n", len(done), version, m.Latest())
+	return nil
+}
+
+// printStatus 打印各版本的执行情况
+func printStatus(ctx context.Context, m *migrate.Migrator) error {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return fmt.Errorf("读取迁移状态失败: %w", err)
+	}
+	for _, s := range statuses {
+		state := "未执行"
+		if s.Applied {
+			state = "已执行 " + s.AppliedAt.Local().Format("2006-01-02 15:04:05")
+		}
+		if s.Unknown {
+			state += " (程序中没有该版本的脚本)"
+		}
+		fmt.Printf("%04d_%-32s %s\n", s.Version, s.Name, state)
+	}
+	if err := m.Check(ctx); err != nil {
+		fmt.Printf("\n%v\n", err)
+	}
+	return nil
+}
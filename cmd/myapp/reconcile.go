@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/service"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/config"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/database"
+)
+
+// runReconcileCounters 执行 reconcile-counters 子命令：对比愿望上记录的点赞数、评论数与实际记录，
+// 默认只报告不一致的愿望，带 -fix 时写回实际值
+func runReconcileCounters(cfg *config.Config, args []string) error {
+	fs := newFlagSet("reconcile-counters", "reconcile-counters [-fix]")
+	fix := fs.Bool("fix", false, "写回实际的点赞数和评论数")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	if err := connectDatabase(cfg); err != nil {
+		return err
+	}
+	defer database.Close()
+	mismatches, err := service.ReconcileCounters(context.Background(), database.DB, *fix)
+	for _, m := range mismatches {
+		fmt.Printf("愿望 #%-6d 点赞 %d -> %d  评论 %d -> %d\n", m.WishID, m.LikeCount, m.ActualLikes, m.CommentCount, m.ActualComments)
+	}
+	if err != nil {
+		return err
+	}
+	switch {
+	case len(mismatches) == 0:
+		fmt.Println("所有愿望的计数都是正确的")
+	case *fix:
+		fmt.Printf("已修正 %d 条愿望的计数\n", len(mismatches))
+	default:
+		fmt.Printf("%d 条愿望的计数不一致，使用 -fix 修正\n", len(mismatches))
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/config"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/database"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/seeder"
+)
+
+// runSeed 执行 seed 子命令，填充指定方案的初始数据；已经填充过的方案会跳过
+func runSeed(cfg *config.Config, args []string) error {
+	var names []string
+	for _, p := range seeder.Profiles() {
+		names = append(names, p.Name)
+	}
+	fs := newFlagSet("seed", "seed [-profile "+strings.Join(names, "|")+"]")
+	profile := fs.String("profile", "demo", "数据填充方案")
+	list := fs.Bool("list", false, "列出全部数据填充方案")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *list {
+		for _, p := range seeder.Profiles() {
+			fmt.Printf("%-8s %s\n", p.Name, p.Description)
+		}
+		return nil
+	}
+
+	if err := connectDatabase(cfg); err != nil {
+		return err
+	}
+	defer database.Close()
+	if err := seeder.Run(database.DB, *profile); err != nil {
+		return err
+	}
+	fmt.Printf("数据填充方案 %s 执行完成\n", *profile)
+	return nil
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/repository"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/service"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/buildinfo"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/config"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/database"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/lifecycle"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/logger"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/metrics"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/seeder"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/util"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/router"
+	"go.uber.org/zap"
+)
+
+// runServe 启动 HTTP 服务，直到收到退出信号
+func runServe(cfg *config.Config, args []string) error {
+	if err := parseFlags(newFlagSet("serve", "serve"), args); err != nil {
+		return err
+	}
+	logger.Log.Infow("版本信息", "build", buildinfo.Get())
+	logger.Log.Infow("生效配置", "config", cfg.Redacted())
+	if err := serve(cfg); err != nil {
+		zap.S().Errorf("服务异常退出: %v", err)
+		return err
+	}
+	zap.S().Info("服务已停止")
+	return nil
+}
+
+// serve 按顺序启动数据库、表结构校验、数据填充、内容审核和 HTTP 服务，
+// 收到 SIGINT/SIGTERM 后在 SERVER_SHUTDOWN_TIMEOUT 内按相反顺序停止：
+// 先停止接收请求并等待处理中的请求完成，再停止后台审核和重置码发送，最后关闭数据库连接池
+func serve(cfg *config.Config) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	srv := newHTTPServer(cfg.Server)
+	var worker *service.ModerationWorker
+	var passwords *service.PasswordService
+
+	app := lifecycle.New()
+	app.Append(lifecycle.Hook{
+		Name: "database",
+		Start: func(ctx context.Context) error {
+			database.Connect(cfg.Database)
+			if cfg.Metrics.Enabled {
+				return metrics.InstrumentDB(database.DB)
+			}
+			return nil
+		},
+		Stop: func(ctx context.Context) error { return database.Close() },
+	})
+	app.Append(lifecycle.Hook{
+		Name:  "migrations",
+		Start: func(ctx context.Context) error { return database.PrepareSchema(ctx, cfg.Database.AutoMigrate) },
+	})
+	app.Append(lifecycle.Hook{
+		Name: "seeder",
+		Start: func(ctx context.Context) error {
+			// 填充初始数据 (SEED_PROFILE，release 模式默认不填充)
+			return seeder.Run(database.DB, cfg.Database.SeedProfile)
+		},
+	})
+	app.Append(lifecycle.Hook{
+		Name: "jwt-keys",
+		Start: func(ctx context.Context) error {
+			if cfg.JWT.KeysFile == "" {
+				return nil
+			}
+			// 密钥文件有误时拒绝启动，而不是启动后所有登录都失败
+			_, err := util.OpenKeyringFile(cfg.JWT.KeysFile)
+			return err
+		},
+	})
+	app.Append(lifecycle.Hook{
+		Name: "password-reset",
+		Start: func(ctx context.Context) error {
+			throttle := service.NewThrottleStoreFromConfig(database.DB, cfg.LoginLimit)
+			passwords = service.NewPasswordServiceFromConfig(repository.NewGormStore(database.DB), cfg.PasswordReset, throttle)
+			return nil
+		},
+		// 自助申请的重置码在后台签发和发送，关闭数据库前等待它们完成
+		Stop: func(ctx context.Context) error { return lifecycle.Wait(ctx, passwords.Wait) },
+	})
+	app.Append(lifecycle.Hook{
+		Name: "moderation",
+		Start: func(ctx context.Context) error {
+			// 组装内容审核链（LLM 不可用时降级到本地审核器），并按管理员配置的类别阈值判定结论
+			moderator := service.NewThresholdModerator(
+				service.NewModeratorFromConfig(database.DB, cfg.Moderation),
+				service.NewThresholdStore(database.DB, cfg.Moderation.ThresholdRefresh),
+			)
+
+			// 开启异步审核时启动后台审核协程池（会把遗留的 pending 内容重新入队）
+			var queue service.ModerationQueue
+			if worker = service.NewModerationWorkerFromConfig(database.DB, moderator, cfg.Moderation); worker != nil {
+				worker.Start()
+				queue = worker
+			}
+
+			handler, err := router.SetupRouter(cfg, database.DB, moderator, queue, passwords)
+			if err != nil {
+				return err
+			}
+			srv.Handler = handler
+			zap.S().Info("路由挂载成功")
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			// 管理员启动的重新审核任务会暂停并保存检查点，之后可以继续
+			err := service.StopBackgroundRemoderations(ctx)
+			if worker != nil {
+				// 未审核完的内容保持 pending，下次启动时重新入队
+				if werr := lifecycle.Wait(ctx, worker.Stop); werr != nil {
+					return werr
+				}
+			}
+			return err
+		},
+	})
+	app.Serve("http", srv)
+
+	return app.Run(ctx, cfg.Server.ShutdownTimeout)
+}
+
+// newHTTPServer 按配置创建 HTTP 服务，Handler 在路由挂载后设置
+func newHTTPServer(cfg config.ServerConfig) *http.Server {
+	return &http.Server{
+		Addr:              cfg.Addr,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		MaxHeaderBytes:    cfg.MaxHeaderBytes,
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/model"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/repository"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/service"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/config"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/database"
+)
+
+const userUsage = `用法: server user <命令> [参数]
+
+  create-admin    -username <学号> [-nickname 昵称] [-password-stdin]   创建管理员
+  set-role        -username <学号> -role user|admin                    修改用户角色
+  reset-password  -username <学号> [-password-stdin]                   重置密码，该用户的全部设备退出登录
+
+未指定 -password-stdin 时生成随机密码并打印，密码不通过命令行参数传递，避免留在 shell 历史中。
+密码需要满足强度要求：8-72 个字符，至少包含字母、数字、符号中的两类，不包含学号`
+
+// runUser 执行 user 子命令
+func runUser(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, userUsage)
+		return errUsage
+	}
+	sub, args := args[0], args[1:]
+	fs := newFlagSet("user "+sub, "user "+sub+" [参数]")
+	username := fs.String("username", "", "学号")
+	var nickname, role *string
+	var passwordStdin *bool
+	switch sub {
+	case "create-admin":
+		nickname = fs.String("nickname", "管理员", "昵称")
+		passwordStdin = fs.Bool("password-stdin", false, "从标准输入读取密码")
+	case "set-role":
+		role = fs.String("role", "", "新角色: user / admin")
+	case "reset-password":
+		passwordStdin = fs.Bool("password-stdin", false, "从标准输入读取密码")
+	default:
+		fmt.Fprintln(os.Stderr, userUsage)
+		return errUsage
+	}
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *username == "" {
+		fmt.Fprintln(fs.Output(), "必须指定 -username")
+		fs.Usage()
+		return errUsage
+	}
+
+	if err := connectDatabase(cfg); err != nil {
+		return err
+	}
+	defer database.Close()
+	users := service.NewUserService(repository.NewGormStore(database.DB))
+	ctx := context.Background()
+
+	switch sub {
+	case "create-admin":
+		password, generated, err := readPassword(*username, *passwordStdin)
+		if err != nil {
+			return err
+		}
+		user, err := users.CreateUser(ctx, *username, password, *nickname, model.RoleAdmin)
+		if errors.Is(err, service.ErrUsernameTaken) {
+			return fmt.Errorf("%w，已有用户可以使用 user set-role -role admin 设为管理员", err)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf("已创建管理员 %s (ID %d)\n", user.Username, user.ID)
+		printGeneratedPassword(generated, password)
+	case "set-role":
+		user, err := users.SetRole(ctx, *username, *role)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("用户 %s 的角色已修改为 %s\n", user.Username, user.Role)
+	case "reset-password":
+		password, generated, err := readPassword(*username, *passwordStdin)
+		if err != nil {
+			return err
+		}
+		user, err := users.ResetPassword(ctx, *username, password)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("用户 %s 的密码已重置\n", user.Username)
+		printGeneratedPassword(generated, password)
+	}
+	return nil
+}
+
+// readPassword 从标准输入读取一行作为密码；fromStdin 为 false 时生成满足强度要求的随机密码，generated 为 true
+func readPassword(username string, fromStdin bool) (password string, generated bool, err error) {
+	if !fromStdin {
+		buf := make([]byte, 12)
+		for {
+			if _, err := rand.Read(buf); err != nil {
+				return "", false, err
+			}
+			// 随机串偶尔只包含字母，重新生成即可
+			if password = base64.RawURLEncoding.EncodeToString(buf); service.ValidatePassword(username, password) == nil {
+				return password, true, nil
+			}
+		}
+	}
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", false, fmt.Errorf("读取密码失败: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), false, nil
+}
+
+func printGeneratedPassword(generated bool, password string) {
+	if generated {
+		fmt.Printf("随机密码: %s (只显示这一次，请登录后尽快修改)\n", password)
+	}
+}
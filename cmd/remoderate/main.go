@@ -0,0 +1,103 @@
+// remoderate 用当前的审核配置重新审核已通过的存量愿望和评论，可以直接对线上数据库运行
+//
+//	go run ./cmd/remoderate -dry-run                 # 只生成报告，不修改内容
+//	go run ./cmd/remoderate -targets wish -rate 2    # 只审核愿望，每秒最多 2 条
+//	go run ./cmd/remoderate -resume 3                # 从检查点继续 3 号任务
+//
+// 按 Ctrl+C 中断时任务会保存检查点并暂停，之后可以用 -resume 继续
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/model"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/service"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/config"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/database"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/logger"
+)
+
+func main() {
+	targets := flag.String("targets", "wish,comment", "要重新审核的内容类型，逗号分隔")
+	batchSize := flag.Int("batch", 100, "每批读取的条数")
+	rate := flag.Float64("rate", 5, "每秒最多审核的条数")
+	dryRun := flag.Bool("dry-run", false, "只生成报告，不修改内容")
+	resume := flag.Uint("resume", 0, "继续指定 ID 的任务 (忽略其余参数)")
+	flag.Parse()
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("加载配置失败: %v", err)
+	}
+	logger.InitLoggerWithMode(cfg.Release())
+	for _, w := range cfg.Warnings() {
+		logger.Log.Warn(w)
+	}
+	database.InitDB(cfg.Database)
+	db := database.DB
+
+	var job *model.RemoderationJob
+	if *resume != 0 {
+		job, err = service.ResumeRemoderationJob(db, *resume)
+	} else {
+		var parsed []service.ModerationTarget
+		parsed, err = service.ParseRemoderationTargets(*targets)
+		if err == nil {
+			job, err = service.CreateRemoderationJob(db, service.RemoderationOptions{
+				Targets:   parsed,
+				BatchSize: *batchSize,
+				Rate:      *rate,
+				DryRun:    *dryRun,
+			})
+		}
+	}
+	if err != nil {
+		if errors.Is(err, service.ErrRemoderationRunning) {
+			log.Fatalf("%v，请等待其完成，或先取消该任务", err)
+		}
+		log.Fatalf("创建任务失败: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	moderator := service.NewThresholdModerator(
+		service.NewModeratorFromConfig(db, cfg.Moderation),
+		service.NewThresholdStore(db, cfg.Moderation.ThresholdRefresh),
+	)
+	job, err = service.NewRemoderator(db, moderator).Run(ctx, job.ID)
+	if err != nil {
+		log.Fatalf("任务执行失败: %v", err)
+	}
+	printReport(job)
+	if job.Status == model.RemoderationPaused {
+		fmt.Printf("\n任务已暂停（%s），使用 -resume %d 从检查点继续\n", job.Error, job.ID)
+		os.Exit(2)
+	}
+}
+
+// printReport 打印任务统计与状态发生变化的内容
+func printReport(job *model.RemoderationJob) {
+	fmt.Printf("任务 #%d  状态: %s  试运行: %v\n", job.ID, job.Status, job.DryRun)
+	fmt.Printf("已扫描 %d 条，隐藏 %d 条，转人工复核 %d 条，跳过 %d 条\n", job.Scanned, job.Hidden, job.Flagged, job.Skipped)
+
+	var changes []model.RemoderationChange
+	if err := database.DB.Where("job_id = ?", job.ID).Order("id").Find(&changes).Error; err != nil {
+		log.Printf("读取报告失败: %v", err)
+		return
+	}
+	if len(changes) == 0 {
+		return
+	}
+	fmt.Println()
+	for _, ch := range changes {
+		fmt.Printf("%-7s #%-6d 用户 %-6d %s -> %-12s %s  %q\n", ch.SubjectType, ch.SubjectID, ch.UserID, ch.OldStatus, ch.NewStatus, ch.Reason, ch.Content)
+	}
+}
@@ -2,7 +2,6 @@ package handler
 
 import (
 	"net/http"
-	"time"
 
 	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/model"
 	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/service"
@@ -12,26 +11,29 @@ import (
 	"gorm.io/gorm"
 )
 
-// 在保存到数据库前，会调用 service.CheckContent 进行内容 AI 审核
-func CreateWish(c *gin.Context, db *gorm.DB) {
+// 在保存到数据库前，会调用注入的 moderator 进行内容审核；
+// 开启异步审核（queue 非 nil）时，愿望先以 pending 状态保存，再交给后台审核
+func CreateWish(c *gin.Context, db *gorm.DB, wishes *service.WishService, moderator service.Moderator, queue service.ModerationQueue) {
 	// 1. 检查登录用户
 	userIDInterface, exists := c.Get("userID")
 	if !exists {
-		logger.Log.Error("创建愿望失败:未找到用户ID")
+		logger.FromContext(c.Request.Context()).Error("创建愿望失败:未找到用户ID")
 		c.JSON(http.StatusUnauthorized, gin.H{
-			"code":    apperr.ERROR_UNAUTHORIZED,
-			"message": apperr.GetMsg(apperr.ERROR_UNAUTHORIZED),
-			"data":    gin.H{},
+			"code":      apperr.ERROR_UNAUTHORIZED,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_UNAUTHORIZED),
+			"data":      gin.H{},
 		})
 		return
 	}
 	userID, ok := userIDInterface.(uint)
 	if !ok {
-		logger.Log.Error("创建愿望失败:用户ID类型转换错误")
+		logger.FromContext(c.Request.Context()).Error("创建愿望失败:用户ID类型转换错误")
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":    apperr.ERROR_SERVER_ERROR,
-			"message": apperr.GetMsg(apperr.ERROR_SERVER_ERROR),
-			"data":    gin.H{},
+			"code":      apperr.ERROR_SERVER_ERROR,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_SERVER_ERROR),
+			"data":      gin.H{},
 		})
 		return
 	}
@@ -44,102 +46,76 @@ func CreateWish(c *gin.Context, db *gorm.DB) {
 		Tags       []string `json:"tags"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		logger.Log.Warnw("创建愿望失败：参数绑定错误", "error", err)
+		logger.FromContext(c.Request.Context()).Warnw("创建愿望失败：参数绑定错误", "error", err)
 		c.JSON(http.StatusBadRequest, gin.H{
-			"code":    apperr.ERROR_PARAM_INVALID,
-			"message": apperr.GetMsg(apperr.ERROR_PARAM_INVALID),
-			"data":    gin.H{"error": "请求参数不合法"},
+			"code":      apperr.ERROR_PARAM_INVALID,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_PARAM_INVALID),
+			"data":      gin.H{"error": "请求参数不合法"},
 		})
 		return
 	}
 
 	//  AI 内容审核（在保存前调用）
-	isViolating, aiErr := service.CheckContent(req.Content)
-	if aiErr != nil {
-		// 审核过程中出现明确错误（如内容为空/过长，或 AI 无法判断等），把错误信息返回给客户端
-		logger.Log.Warnw("创建愿望被拒绝：内容审核出错或无法判断", "userID", userID, "error", aiErr)
-		c.JSON(http.StatusBadRequest, gin.H{
-			"code":    apperr.ERROR_PARAM_INVALID,
-			"message": apperr.GetMsg(apperr.ERROR_PARAM_INVALID),
-			"data":    gin.H{"error": aiErr.Error()},
-		})
-		return
-	}
-	if isViolating {
-		// AI 明确判定为不安全内容
-		logger.Log.Infow("创建愿望被拒绝：AI 判定不安全", "userID", userID)
-		c.JSON(http.StatusBadRequest, gin.H{
-			"code":    apperr.ERROR_PARAM_INVALID,
-			"message": apperr.GetMsg(apperr.ERROR_PARAM_INVALID),
-			"data":    gin.H{"error": "内容未通过审核"},
-		})
+	outcome, ok := moderateContent(c, db, moderator, queue, service.KindWish, req.Content, "创建愿望", userID)
+	if !ok {
 		return
 	}
 
-	// 查询当前用户信息（用于写入冗余的用户昵称/头像，便于列表直接展示）
-	var author model.User
-	if err := db.First(&author, userID).Error; err != nil {
-		logger.Log.Errorw("创建愿望失败：查询作者信息失败", "userID", userID, "error", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":    apperr.ERROR_SERVER_ERROR,
-			"message": apperr.GetMsg(apperr.ERROR_SERVER_ERROR),
-			"data":    gin.H{},
-		})
-		return
+	// 标签同样需要审核：任一标签违规时拒绝整个请求；审核器无法判断的标签先不保存，管理员复核通过后再补上
+	var tagOutcomes []moderationOutcome
+	var tagNames []string
+	for _, tag := range req.Tags {
+		tagOutcome, ok := moderateContent(c, db, moderator, nil, service.KindTag, tag, "创建愿望标签", userID)
+		if !ok {
+			return
+		}
+		tagOutcomes = append(tagOutcomes, tagOutcome)
+		if tagOutcome.status == model.ModerationApproved {
+			tagNames = append(tagNames, tagOutcome.contentToSave(tag))
+		}
 	}
 
-	//  构造 Wish 并保存
+	//  保存愿望与标签（同时写入冗余的用户昵称/头像，便于列表直接展示）
 	isPublic := true
 	if req.IsPublic != nil {
 		isPublic = *req.IsPublic
 	}
-	wish := model.Wish{
-		UserID:       userID,
-		UserNickname: author.Nickname,
-		UserAvatarID: author.AvatarID,
-		Content:      req.Content,
-		Background:   req.Background,
-		IsPublic:     isPublic,
-		CreatedAt:    time.Now(),
-		UpdatedAt:    time.Now(),
-	}
-	if err := db.Transaction(func(tx *gorm.DB) error {
-		// 1. 创建 Wish
-		if err := tx.Create(&wish).Error; err != nil {
-			return err
-		}
-
-		// 2. 循环创建 Tags
-		if len(req.Tags) > 0 {
-			var tagsToCreate []model.WishTag
-			for _, tagName := range req.Tags {
-				tagsToCreate = append(tagsToCreate, model.WishTag{
-					WishID:  wish.ID, // 关联新创建的 wish ID
-					TagName: tagName, // 存入标签名
-				})
-			}
-			if err := tx.Create(&tagsToCreate).Error; err != nil {
-				return err // 如果创建标签失败，整个事务回滚
-			}
-		}
-		return nil
-	}); err != nil {
-		logger.Log.Errorw("创建愿望失败：保存到数据库出错", "userID", userID, "error", err)
+	wish, err := wishes.Create(c.Request.Context(), service.CreateWishInput{
+		UserID:     userID,
+		Content:    outcome.contentToSave(req.Content),
+		Background: req.Background,
+		IsPublic:   isPublic,
+		Tags:       tagNames,
+		Status:     outcome.status,
+	})
+	if err != nil {
+		logger.FromContext(c.Request.Context()).Errorw("创建愿望失败：保存到数据库出错", "userID", userID, "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":    apperr.ERROR_SERVER_ERROR,
-			"message": apperr.GetMsg(apperr.ERROR_SERVER_ERROR),
-			"data":    gin.H{},
+			"code":      apperr.ERROR_SERVER_ERROR,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_SERVER_ERROR),
+			"data":      gin.H{},
 		})
 		return
 	}
 
+	outcome.audit(db, wish.ID, userID)
+	for _, tagOutcome := range tagOutcomes {
+		tagOutcome.audit(db, wish.ID, userID)
+	}
+	if outcome.status == model.ModerationPending {
+		queue.Enqueue(service.ModerationTask{Target: service.TargetWish, ID: wish.ID})
+	}
+
 	//  返回成功
 	c.JSON(http.StatusOK, gin.H{
 		"code":    apperr.SUCCESS,
 		"message": apperr.GetMsg(apperr.SUCCESS),
 		"data": gin.H{
-			"wishID":    wish.ID,
-			"createdAt": wish.CreatedAt,
+			"wishID":           wish.ID,
+			"createdAt":        wish.CreatedAt,
+			"moderationStatus": wish.ModerationStatus,
 		},
 	})
 }
@@ -6,23 +6,23 @@ import (
 	"strconv"
 	"time"
 
-	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/model"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/service"
 	apperr "github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/err"
 	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/logger"
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
-func DeleteWish(c *gin.Context, db *gorm.DB) {
+func DeleteWish(c *gin.Context, wishes *service.WishService) {
 	//  解析愿望ID
 	wishIDStr := c.Param("id")
 	wishID64, err := strconv.ParseUint(wishIDStr, 10, 32)
 	if err != nil {
-		logger.Log.Warnw("删除愿望失败:愿望ID无效", "wishID", wishIDStr, "error", err)
+		logger.FromContext(c.Request.Context()).Warnw("删除愿望失败:愿望ID无效", "wishID", wishIDStr, "error", err)
 		c.JSON(http.StatusBadRequest, gin.H{
-			"code":    apperr.ERROR_PARAM_INVALID,
-			"message": apperr.GetMsg(apperr.ERROR_PARAM_INVALID),
-			"data":    gin.H{"error": "愿望ID无效"},
+			"code":      apperr.ERROR_PARAM_INVALID,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_PARAM_INVALID),
+			"data":      gin.H{"error": "愿望ID无效"},
 		})
 		return
 	}
@@ -31,104 +31,69 @@ func DeleteWish(c *gin.Context, db *gorm.DB) {
 	// 获取用户ID (从中间件)
 	userIDInterface, exists := c.Get("userID")
 	if !exists {
-		logger.Log.Error("删除愿望失败：未找到用户ID")
+		logger.FromContext(c.Request.Context()).Error("删除愿望失败：未找到用户ID")
 		c.JSON(http.StatusUnauthorized, gin.H{
-			"code":    apperr.ERROR_UNAUTHORIZED,
-			"message": apperr.GetMsg(apperr.ERROR_UNAUTHORIZED),
-			"data":    gin.H{},
+			"code":      apperr.ERROR_UNAUTHORIZED,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_UNAUTHORIZED),
+			"data":      gin.H{},
 		})
 		return
 	}
 	userID, ok := userIDInterface.(uint)
 	if !ok {
-		logger.Log.Error("删除愿望失败：用户ID类型转换错误")
+		logger.FromContext(c.Request.Context()).Error("删除愿望失败：用户ID类型转换错误")
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":    apperr.ERROR_SERVER_ERROR,
-			"message": apperr.GetMsg(apperr.ERROR_SERVER_ERROR),
-			"data":    gin.H{},
+			"code":      apperr.ERROR_SERVER_ERROR,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_SERVER_ERROR),
+			"data":      gin.H{},
 		})
 		return
 	}
 
-	// 获取当前用户信息 (用于权限校验)
-	var currentUser model.User
-	if err := db.First(&currentUser, userID).Error; err != nil {
-		logger.Log.Errorw("删除愿望失败：无法获取当前用户信息", "userID", userID, "error", err)
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"code":    apperr.ERROR_UNAUTHORIZED,
-			"message": apperr.GetMsg(apperr.ERROR_UNAUTHORIZED),
-			"data":    gin.H{},
-		})
-		return
-	}
-
-	//  查找愿望并校验权限，然后删除（事务）
-	var deletedAt time.Time
-	if err := db.Transaction(func(tx *gorm.DB) error {
-		var wish model.Wish
-		if err := tx.First(&wish, wishID).Error; err != nil {
-			return err
-		}
-
-		// 权限校验：必须是作者 (isOwner) 或 管理员 (isAdmin)
-		isOwner := (wish.UserID == userID)
-		isAdmin := (currentUser.Role == "admin")
-
-		if !isOwner && !isAdmin {
-			return errors.New("not_authorized") // 修改错误标识
-		}
-
-		// 级联硬删除：由于模型使用 DeletedAt 默认是软删除，这里显式使用 Unscoped() 做物理删除。
-		// 1. 删除关联的评论回复 (comments)
-		if err := tx.Unscoped().Where("wish_id = ?", wishID).Delete(&model.Comment{}).Error; err != nil {
-			return err
-		}
-		// 2. 删除关联点赞 (likes)
-		if err := tx.Unscoped().Where("wish_id = ?", wishID).Delete(&model.Like{}).Error; err != nil {
-			return err
-		}
-		// 3. 删除关联标签 (wish_tags)
-		if err := tx.Unscoped().Where("wish_id = ?", wishID).Delete(&model.WishTag{}).Error; err != nil {
-			return err
-		}
-		// 4. 删除愿望本身 (wishes)
-		if err := tx.Unscoped().Delete(&wish).Error; err != nil {
-			return err
-		}
-
-		deletedAt = time.Now()
-		return nil
-	}); err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			logger.Log.Warnw("删除愿望失败：愿望不存在", "wishID", wishID)
+	// 删除愿望（作者或管理员），同时删除关联的评论、点赞、标签
+	if err := wishes.Delete(c.Request.Context(), userID, wishID); err != nil {
+		switch {
+		case errors.Is(err, service.ErrUserNotFound):
+			logger.FromContext(c.Request.Context()).Errorw("删除愿望失败：无法获取当前用户信息", "userID", userID, "error", err)
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"code":      apperr.ERROR_UNAUTHORIZED,
+				"requestId": c.GetString("requestID"),
+				"message":   apperr.GetMsg(apperr.ERROR_UNAUTHORIZED),
+				"data":      gin.H{},
+			})
+		case errors.Is(err, service.ErrWishNotFound):
+			logger.FromContext(c.Request.Context()).Warnw("删除愿望失败：愿望不存在", "wishID", wishID)
 			c.JSON(http.StatusBadRequest, gin.H{
-				"code":    apperr.ERROR_WISH_NOT_FOUND,
-				"message": apperr.GetMsg(apperr.ERROR_WISH_NOT_FOUND),
-				"data":    gin.H{},
+				"code":      apperr.ERROR_WISH_NOT_FOUND,
+				"requestId": c.GetString("requestID"),
+				"message":   apperr.GetMsg(apperr.ERROR_WISH_NOT_FOUND),
+				"data":      gin.H{},
 			})
-			return
-		}
-		// 捕获新的错误标识
-		if err.Error() == "not_authorized" {
-			logger.Log.Warnw("删除愿望失败：非愿望所有者或管理员", "wishID", wishID, "userID", userID, "role", currentUser.Role)
+		case errors.Is(err, service.ErrForbidden):
+			logger.FromContext(c.Request.Context()).Warnw("删除愿望失败：非愿望所有者或管理员", "wishID", wishID, "userID", userID)
 			c.JSON(http.StatusUnauthorized, gin.H{
-				"code":    apperr.ERROR_UNAUTHORIZED,
-				"message": "没有权限删除该愿望",
-				"data":    gin.H{},
+				"code":      apperr.ERROR_UNAUTHORIZED,
+				"requestId": c.GetString("requestID"),
+				"message":   "没有权限删除该愿望",
+				"data":      gin.H{},
+			})
+		default:
+			logger.FromContext(c.Request.Context()).Errorw("删除愿望事务失败", "wishID", wishID, "userID", userID, "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code":      apperr.ERROR_SERVER_ERROR,
+				"requestId": c.GetString("requestID"),
+				"message":   apperr.GetMsg(apperr.ERROR_SERVER_ERROR),
+				"data":      gin.H{},
 			})
-			return
 		}
-		logger.Log.Errorw("删除愿望事务失败", "wishID", wishID, "userID", userID, "error", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":    apperr.ERROR_SERVER_ERROR,
-			"message": apperr.GetMsg(apperr.ERROR_SERVER_ERROR),
-			"data":    gin.H{},
-		})
 		return
 	}
+	deletedAt := time.Now()
 
 	// 成功返回
-	logger.Log.Infow("删除愿望成功", "wishID", wishID, "userID", userID)
+	logger.FromContext(c.Request.Context()).Infow("删除愿望成功", "wishID", wishID, "userID", userID)
 	c.JSON(http.StatusOK, gin.H{
 		"code":    apperr.SUCCESS,
 		"message": apperr.GetMsg(apperr.SUCCESS),
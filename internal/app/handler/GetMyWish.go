@@ -4,34 +4,37 @@ import (
 	"net/http"
 	"strconv"
 
-	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/model"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/repository"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/service"
 	apperr "github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/err"
 	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/logger"
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
 // 请求参数说明（与前端接口定义一致）：page、pageSize
 // 返回当前登录用户自己发布的愿望列表（包含基础信息及该用户是否对每条愿望已点赞）
-func GetMyWishes(c *gin.Context, db *gorm.DB) {
+// 作者能看到自己所有审核状态的愿望（含 pending），并通过 moderationStatus 区分
+func GetMyWishes(c *gin.Context, wishes *service.WishService) {
 	// 从上下文获取用户ID（由认证中间件设置）
 	userIDInterface, exists := c.Get("userID")
 	if !exists {
-		logger.Log.Error("获取我的愿望失败:未找到用户ID")
+		logger.FromContext(c.Request.Context()).Error("获取我的愿望失败:未找到用户ID")
 		c.JSON(http.StatusUnauthorized, gin.H{
-			"code":    apperr.ERROR_UNAUTHORIZED,
-			"message": apperr.GetMsg(apperr.ERROR_UNAUTHORIZED),
-			"data":    gin.H{},
+			"code":      apperr.ERROR_UNAUTHORIZED,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_UNAUTHORIZED),
+			"data":      gin.H{},
 		})
 		return
 	}
 	userID, ok := userIDInterface.(uint)
 	if !ok {
-		logger.Log.Error("获取我的愿望失败:用户ID类型转换错误")
+		logger.FromContext(c.Request.Context()).Error("获取我的愿望失败:用户ID类型转换错误")
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":    apperr.ERROR_SERVER_ERROR,
-			"message": apperr.GetMsg(apperr.ERROR_SERVER_ERROR),
-			"data":    gin.H{},
+			"code":      apperr.ERROR_SERVER_ERROR,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_SERVER_ERROR),
+			"data":      gin.H{},
 		})
 		return
 	}
@@ -42,76 +45,44 @@ func GetMyWishes(c *gin.Context, db *gorm.DB) {
 
 	page, err := strconv.Atoi(pageStr)
 	if err != nil || page < 1 {
-		logger.Log.Warnw("获取我的愿望失败：页码无效", "page", pageStr, "error", err)
+		logger.FromContext(c.Request.Context()).Warnw("获取我的愿望失败：页码无效", "page", pageStr, "error", err)
 		c.JSON(http.StatusBadRequest, gin.H{
-			"code":    apperr.ERROR_PARAM_INVALID,
-			"message": apperr.GetMsg(apperr.ERROR_PARAM_INVALID),
-			"data":    gin.H{"error": "页码无效"},
+			"code":      apperr.ERROR_PARAM_INVALID,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_PARAM_INVALID),
+			"data":      gin.H{"error": "页码无效"},
 		})
 		return
 	}
 	pageSize, err := strconv.Atoi(pageSizeStr)
 	if err != nil || pageSize < 1 || pageSize > 100 {
-		logger.Log.Warnw("获取我的愿望失败：分页大小无效", "pageSize", pageSizeStr, "error", err)
+		logger.FromContext(c.Request.Context()).Warnw("获取我的愿望失败：分页大小无效", "pageSize", pageSizeStr, "error", err)
 		c.JSON(http.StatusBadRequest, gin.H{
-			"code":    apperr.ERROR_PARAM_INVALID,
-			"message": apperr.GetMsg(apperr.ERROR_PARAM_INVALID),
-			"data":    gin.H{"error": "分页大小无效"},
+			"code":      apperr.ERROR_PARAM_INVALID,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_PARAM_INVALID),
+			"data":      gin.H{"error": "分页大小无效"},
 		})
 		return
 	}
 	offset := (page - 1) * pageSize
 
-	//  统计总数
-	var total int64
-	if err := db.Model(&model.Wish{}).Where("user_id = ?", userID).Count(&total).Error; err != nil {
-		logger.Log.Errorw("获取我的愿望失败：统计总数出错", "userID", userID, "error", err)
+	//  查询愿望列表、总数及该用户针对这些愿望的点赞状态
+	list, err := wishes.ListMine(c.Request.Context(), userID, repository.Page{Offset: offset, Limit: pageSize})
+	if err != nil {
+		logger.FromContext(c.Request.Context()).Errorw("获取我的愿望失败：查询愿望出错", "userID", userID, "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":    apperr.ERROR_SERVER_ERROR,
-			"message": apperr.GetMsg(apperr.ERROR_SERVER_ERROR),
-			"data":    gin.H{},
+			"code":      apperr.ERROR_SERVER_ERROR,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_SERVER_ERROR),
+			"data":      gin.H{},
 		})
 		return
 	}
 
-	//  查询愿望列表
-	var wishes []model.Wish
-	if err := db.Where("user_id = ?", userID).
-		Order("created_at desc").
-		Offset(offset).
-		Limit(pageSize).
-		Preload("User").
-		Find(&wishes).Error; err != nil {
-		logger.Log.Errorw("获取我的愿望失败：查询愿望出错", "userID", userID, "error", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":    apperr.ERROR_SERVER_ERROR,
-			"message": apperr.GetMsg(apperr.ERROR_SERVER_ERROR),
-			"data":    gin.H{},
-		})
-		return
-	}
-
-	//  查询该用户针对这些愿望的点赞状态（如果有愿望）
-	likedMap := make(map[uint]bool)
-	if len(wishes) > 0 {
-		wishIDs := make([]uint, 0, len(wishes))
-		for _, w := range wishes {
-			wishIDs = append(wishIDs, w.ID)
-		}
-		var likes []model.Like
-		if err := db.Where("user_id = ? AND wish_id IN ?", userID, wishIDs).Find(&likes).Error; err != nil {
-			// 点赞查询失败不会影响主流程，只记录日志
-			logger.Log.Errorw("获取我的愿望：查询点赞状态出错", "userID", userID, "error", err)
-		} else {
-			for _, l := range likes {
-				likedMap[l.WishID] = true
-			}
-		}
-	}
-
 	// 构造响应数据
-	items := make([]gin.H, 0, len(wishes))
-	for _, w := range wishes {
+	items := make([]gin.H, 0, len(list.Wishes))
+	for _, w := range list.Wishes {
 		item := gin.H{
 			"id":           w.ID,
 			"content":      w.Content,
@@ -124,12 +95,11 @@ func GetMyWishes(c *gin.Context, db *gorm.DB) {
 			"userAvatar":   w.User.AvatarID,
 			"createdAt":    w.CreatedAt,
 			"updatedAt":    w.UpdatedAt,
+
+			"moderationStatus": w.ModerationStatus,
+			"moderationReason": w.ModerationReason,
 		}
-		if likedMap[w.ID] {
-			item["liked"] = true
-		} else {
-			item["liked"] = false
-		}
+		item["liked"] = list.Liked[w.ID]
 		items = append(items, item)
 	}
 
@@ -138,7 +108,7 @@ func GetMyWishes(c *gin.Context, db *gorm.DB) {
 		"code":    apperr.SUCCESS,
 		"message": apperr.GetMsg(apperr.SUCCESS),
 		"data": gin.H{
-			"total":    total,
+			"total":    list.Total,
 			"page":     page,
 			"pageSize": pageSize,
 			"wishes":   items,
@@ -0,0 +1,295 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/model"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/service"
+	apperr "github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/err"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/logger"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ListModerationQueue 人工复核队列：自动审核无法判断、尚未被管理员处理的记录，按提交时间先后排列
+// GET /api/admin/moderation/queue?page=1&pageSize=20
+func ListModerationQueue(c *gin.Context, db *gorm.DB) {
+	query := db.Model(&model.ModerationRecord{}).
+		Where("verdict = ? AND review_status = ?", model.ModerationNeedsReview, "")
+	listModerationRecords(c, query, "created_at asc")
+}
+
+// ListModerationRecords 审核记录（审计日志），支持按 verdict / subjectType / userId 过滤
+// GET /api/admin/moderation/records?verdict=rejected&subjectType=wish&userId=1
+func ListModerationRecords(c *gin.Context, db *gorm.DB) {
+	query := db.Model(&model.ModerationRecord{})
+	if v := c.Query("verdict"); v != "" {
+		query = query.Where("verdict = ?", v)
+	}
+	if v := c.Query("subjectType"); v != "" {
+		query = query.Where("subject_type = ?", v)
+	}
+	if v := c.Query("userId"); v != "" {
+		userID, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":      apperr.ERROR_PARAM_INVALID,
+				"requestId": c.GetString("requestID"),
+				"message":   apperr.GetMsg(apperr.ERROR_PARAM_INVALID),
+				"data":      gin.H{"error": "userId 无效"},
+			})
+			return
+		}
+		query = query.Where("user_id = ?", userID)
+	}
+	listModerationRecords(c, query, "created_at desc")
+}
+
+// ListUserRejections 某个用户被拒绝的历史（自动拒绝或人工驳回）
+// GET /api/admin/moderation/users/:id/rejections
+func ListUserRejections(c *gin.Context, db *gorm.DB) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":      apperr.ERROR_PARAM_INVALID,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_PARAM_INVALID),
+			"data":      gin.H{"error": "用户ID无效"},
+		})
+		return
+	}
+	query := db.Model(&model.ModerationRecord{}).
+		Where("user_id = ?", userID).
+		Where(db.Where("verdict = ? AND review_status = ?", model.ModerationRejected, "").
+			Or("review_status = ?", model.ModerationRejected))
+	listModerationRecords(c, query, "created_at desc")
+}
+
+// listModerationRecords 分页返回审核记录
+func listModerationRecords(c *gin.Context, query *gorm.DB, order string) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":      apperr.ERROR_PARAM_INVALID,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_PARAM_INVALID),
+			"data":      gin.H{"error": "页码无效"},
+		})
+		return
+	}
+	pageSize, err := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+	if err != nil || pageSize < 1 || pageSize > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":      apperr.ERROR_PARAM_INVALID,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_PARAM_INVALID),
+			"data":      gin.H{"error": "分页大小无效"},
+		})
+		return
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		logger.FromContext(c.Request.Context()).Errorw("查询审核记录失败：统计总数出错", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":      apperr.ERROR_SERVER_ERROR,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_SERVER_ERROR),
+			"data":      gin.H{},
+		})
+		return
+	}
+	records := make([]model.ModerationRecord, 0, pageSize)
+	if err := query.Order(order).Offset((page - 1) * pageSize).Limit(pageSize).Find(&records).Error; err != nil {
+		logger.FromContext(c.Request.Context()).Errorw("查询审核记录失败", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":      apperr.ERROR_SERVER_ERROR,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_SERVER_ERROR),
+			"data":      gin.H{},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    apperr.SUCCESS,
+		"message": apperr.GetMsg(apperr.SUCCESS),
+		"data": gin.H{
+			"total":    total,
+			"page":     page,
+			"pageSize": pageSize,
+			"items":    records,
+		},
+	})
+}
+
+// ReviewModeration 管理员通过或驳回一条审核记录，结论会同步到对应的愿望/评论/昵称
+// POST /api/admin/moderation/records/:id/approve
+// POST /api/admin/moderation/records/:id/reject
+// 请求体（可选）：{ "note": "误判，已放行" }
+func ReviewModeration(c *gin.Context, db *gorm.DB, approve bool) {
+	recordID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":      apperr.ERROR_PARAM_INVALID,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_PARAM_INVALID),
+			"data":      gin.H{"error": "记录ID无效"},
+		})
+		return
+	}
+	var req struct {
+		Note string `json:"note" binding:"max=255"`
+	}
+	// 请求体可以为空
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":      apperr.ERROR_PARAM_INVALID,
+				"requestId": c.GetString("requestID"),
+				"message":   apperr.GetMsg(apperr.ERROR_PARAM_INVALID),
+				"data":      gin.H{"error": err.Error()},
+			})
+			return
+		}
+	}
+	reviewerID := c.MustGet("userID").(uint)
+
+	record, err := service.ReviewModerationRecord(db, uint(recordID), reviewerID, approve, req.Note)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":      apperr.ERROR_MODERATION_RECORD_NOT_FOUND,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_MODERATION_RECORD_NOT_FOUND),
+			"data":      gin.H{},
+		})
+		return
+	case errors.Is(err, service.ErrAlreadyReviewed):
+		c.JSON(http.StatusConflict, gin.H{
+			"code":      apperr.ERROR_ALREADY_REVIEWED,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_ALREADY_REVIEWED),
+			"data":      gin.H{},
+		})
+		return
+	case err != nil:
+		logger.FromContext(c.Request.Context()).Errorw("人工复核失败", "recordID", recordID, "reviewerID", reviewerID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":      apperr.ERROR_SERVER_ERROR,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_SERVER_ERROR),
+			"data":      gin.H{},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    apperr.SUCCESS,
+		"message": apperr.GetMsg(apperr.SUCCESS),
+		"data":    record,
+	})
+}
+
+// GetModerationThresholds 查看各违规类别当前生效的审核阈值
+// GET /api/admin/moderation/thresholds
+func GetModerationThresholds(c *gin.Context, db *gorm.DB) {
+	thresholds, err := service.ListThresholds(db)
+	if err != nil {
+		logger.FromContext(c.Request.Context()).Errorw("查询审核阈值失败", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":      apperr.ERROR_SERVER_ERROR,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_SERVER_ERROR),
+			"data":      gin.H{},
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code":    apperr.SUCCESS,
+		"message": apperr.GetMsg(apperr.SUCCESS),
+		"data":    gin.H{"thresholds": thresholds},
+	})
+}
+
+// UpdateModerationThresholds 修改违规类别的审核阈值，只需提交要修改的类别
+// PUT /api/admin/moderation/thresholds
+// 请求体：{ "thresholds": [{ "category": "辱骂", "rejectThreshold": 0.7, "reviewThreshold": 0.4 }] }
+func UpdateModerationThresholds(c *gin.Context, db *gorm.DB) {
+	var req struct {
+		Thresholds []struct {
+			Category        string  `json:"category" binding:"required"`
+			RejectThreshold float64 `json:"rejectThreshold"`
+			ReviewThreshold float64 `json:"reviewThreshold"`
+		} `json:"thresholds" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":      apperr.ERROR_PARAM_INVALID,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_PARAM_INVALID),
+			"data":      gin.H{"error": err.Error()},
+		})
+		return
+	}
+	adminID := c.MustGet("userID").(uint)
+
+	thresholds := make([]model.ModerationThreshold, 0, len(req.Thresholds))
+	for _, t := range req.Thresholds {
+		thresholds = append(thresholds, model.ModerationThreshold{
+			Category:        t.Category,
+			RejectThreshold: t.RejectThreshold,
+			ReviewThreshold: t.ReviewThreshold,
+		})
+	}
+	if err := service.SaveThresholds(db, thresholds, adminID); err != nil {
+		if errors.Is(err, service.ErrInvalidThreshold) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":      apperr.ERROR_PARAM_INVALID,
+				"requestId": c.GetString("requestID"),
+				"message":   apperr.GetMsg(apperr.ERROR_PARAM_INVALID),
+				"data":      gin.H{"error": err.Error()},
+			})
+			return
+		}
+		logger.FromContext(c.Request.Context()).Errorw("保存审核阈值失败", "adminID", adminID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":      apperr.ERROR_SERVER_ERROR,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_SERVER_ERROR),
+			"data":      gin.H{},
+		})
+		return
+	}
+	logger.FromContext(c.Request.Context()).Infow("审核阈值已更新", "adminID", adminID, "thresholds", thresholds)
+
+	GetModerationThresholds(c, db)
+}
+
+// GetModerationCacheStats 查看大模型审核结论缓存的命中统计，hits 即节省下来的大模型调用次数
+// GET /api/admin/moderation/cache
+func GetModerationCacheStats(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"code":    apperr.SUCCESS,
+		"message": apperr.GetMsg(apperr.SUCCESS),
+		"data":    service.ModerationCacheStats(),
+	})
+}
+
+// GetModerationHealth 查看内容审核链路的健康状况：审核链组成、各熔断器状态与缓存命中统计
+// GET /api/admin/moderation/health
+func GetModerationHealth(c *gin.Context) {
+	status, degraded := service.ModerationHealth()
+	c.JSON(http.StatusOK, gin.H{
+		"code":    apperr.SUCCESS,
+		"message": apperr.GetMsg(apperr.SUCCESS),
+		"data": gin.H{
+			"degraded":  degraded,
+			"moderator": status.Moderator,
+			"breakers":  status.Breakers,
+			"cache":     status.Cache,
+		},
+	})
+}
@@ -0,0 +1,174 @@
+package handler_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/model"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/service"
+	apperr "github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/err"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getJSON(path, token string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", path, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	testRouter.ServeHTTP(w, req)
+	return w
+}
+
+func putJSON(r *gin.Engine, path, token string, payload gin.H) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(payload)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", path, bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestAdminModeration(t *testing.T) {
+	t.Run("普通用户无权访问管理接口", func(t *testing.T) {
+		cleanup(testDB)
+		user := createUser("plain_user", "pass")
+
+		w := getJSON("/api/admin/moderation/queue", createToken(user.ID))
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Equal(t, float64(apperr.ERROR_FORBIDDEN_ADMIN), parseResponse(t, w)["code"])
+	})
+
+	t.Run("AI 无法判断的愿望进入复核队列，管理员通过后公开", func(t *testing.T) {
+		cleanup(testDB)
+		author := createUser("review_author", "pass")
+		admin := createUserWithRole("review_admin", "pass", "admin")
+		adminToken := createToken(admin.ID)
+
+		w := postJSON(testRouter, "/api/wishes", createToken(author.ID), gin.H{"content": "这句话模棱两可"})
+		require.Equal(t, http.StatusOK, w.Code)
+		data := parseResponse(t, w)["data"].(map[string]interface{})
+		assert.Equal(t, model.ModerationNeedsReview, data["moderationStatus"])
+		wishID := uint(data["wishID"].(float64))
+
+		w = getJSON("/api/admin/moderation/queue", adminToken)
+		require.Equal(t, http.StatusOK, w.Code)
+		queue := parseResponse(t, w)["data"].(map[string]interface{})
+		require.Equal(t, float64(1), queue["total"])
+		item := queue["items"].([]interface{})[0].(map[string]interface{})
+		assert.Equal(t, "wish", item["subjectType"])
+		assert.Equal(t, float64(wishID), item["subjectId"])
+		assert.Equal(t, "不确定", item["rawAnswer"])
+		recordID := uint(item["id"].(float64))
+
+		w = postJSON(testRouter, fmt.Sprintf("/api/admin/moderation/records/%d/approve", recordID), adminToken, gin.H{"note": "误判"})
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, model.ModerationApproved, wishStatus(wishID))
+
+		// 已处理的记录离开队列，且不能重复复核
+		w = getJSON("/api/admin/moderation/queue", adminToken)
+		assert.Equal(t, float64(0), parseResponse(t, w)["data"].(map[string]interface{})["total"])
+		w = postJSON(testRouter, fmt.Sprintf("/api/admin/moderation/records/%d/reject", recordID), adminToken, gin.H{})
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Equal(t, float64(apperr.ERROR_ALREADY_REVIEWED), parseResponse(t, w)["code"])
+	})
+
+	t.Run("记录不存在时返回 404", func(t *testing.T) {
+		cleanup(testDB)
+		admin := createUserWithRole("review_admin", "pass", "admin")
+
+		w := postJSON(testRouter, "/api/admin/moderation/records/9999/approve", createToken(admin.ID), gin.H{})
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, float64(apperr.ERROR_MODERATION_RECORD_NOT_FOUND), parseResponse(t, w)["code"])
+	})
+
+	t.Run("查询用户被拒绝的历史", func(t *testing.T) {
+		cleanup(testDB)
+		author := createUser("rejected_author", "pass")
+		admin := createUserWithRole("review_admin", "pass", "admin")
+		adminToken := createToken(admin.ID)
+		token := createToken(author.ID)
+
+		w := postJSON(testRouter, "/api/wishes", token, gin.H{"content": "我恨这个世界"})
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		w = postJSON(testRouter, "/api/wishes", token, gin.H{"content": "希望一切顺利"})
+		require.Equal(t, http.StatusOK, w.Code)
+
+		w = getJSON(fmt.Sprintf("/api/admin/moderation/users/%d/rejections", author.ID), adminToken)
+		require.Equal(t, http.StatusOK, w.Code)
+		data := parseResponse(t, w)["data"].(map[string]interface{})
+		require.Equal(t, float64(1), data["total"])
+		item := data["items"].([]interface{})[0].(map[string]interface{})
+		assert.Equal(t, model.ModerationRejected, item["verdict"])
+		assert.Equal(t, "我恨这个世界", item["content"])
+
+		// 审计日志里两次审核都有记录
+		w = getJSON(fmt.Sprintf("/api/admin/moderation/records?userId=%d", author.ID), adminToken)
+		assert.Equal(t, float64(2), parseResponse(t, w)["data"].(map[string]interface{})["total"])
+	})
+
+	t.Run("查看与修改类别阈值", func(t *testing.T) {
+		cleanup(testDB)
+		admin := createUserWithRole("threshold_admin", "pass", "admin")
+		adminToken := createToken(admin.ID)
+
+		thresholdOf := func(w *httptest.ResponseRecorder, category string) map[string]interface{} {
+			list := parseResponse(t, w)["data"].(map[string]interface{})["thresholds"].([]interface{})
+			for _, item := range list {
+				if m := item.(map[string]interface{}); m["category"] == category {
+					return m
+				}
+			}
+			t.Fatalf("缺少类别 %s", category)
+			return nil
+		}
+
+		// 未配置时返回默认阈值
+		w := getJSON("/api/admin/moderation/thresholds", adminToken)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, service.DefaultThreshold.Reject, thresholdOf(w, "辱骂")["rejectThreshold"])
+
+		w = putJSON(testRouter, "/api/admin/moderation/thresholds", adminToken, gin.H{
+			"thresholds": []gin.H{{"category": "辱骂", "rejectThreshold": 0.7, "reviewThreshold": 0.4}},
+		})
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, 0.7, thresholdOf(w, "辱骂")["rejectThreshold"])
+		assert.Equal(t, float64(admin.ID), thresholdOf(w, "辱骂")["updatedBy"])
+
+		// 再次修改同一类别时覆盖原值
+		w = putJSON(testRouter, "/api/admin/moderation/thresholds", adminToken, gin.H{
+			"thresholds": []gin.H{{"category": "辱骂", "rejectThreshold": 0.95, "reviewThreshold": 0.6}},
+		})
+		require.Equal(t, http.StatusOK, w.Code)
+		saved, err := service.LoadThresholds(testDB)
+		require.NoError(t, err)
+		assert.Equal(t, service.Threshold{Reject: 0.95, Review: 0.6}, saved.Threshold("辱骂"))
+
+		// 复核阈值高于拒绝阈值、未知类别都会被拒绝
+		w = putJSON(testRouter, "/api/admin/moderation/thresholds", adminToken, gin.H{
+			"thresholds": []gin.H{{"category": "辱骂", "rejectThreshold": 0.3, "reviewThreshold": 0.6}},
+		})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		w = putJSON(testRouter, "/api/admin/moderation/thresholds", adminToken, gin.H{
+			"thresholds": []gin.H{{"category": "其他", "rejectThreshold": 0.9, "reviewThreshold": 0.6}},
+		})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("查看审核缓存统计", func(t *testing.T) {
+		cleanup(testDB)
+		admin := createUserWithRole("cache_admin", "pass", "admin")
+
+		w := getJSON("/api/admin/moderation/cache", createToken(admin.ID))
+		require.Equal(t, http.StatusOK, w.Code)
+		data := parseResponse(t, w)["data"].(map[string]interface{})
+		// 测试路由使用的审核器没有经过 NewModeratorFromConfig，缓存未开启
+		assert.Equal(t, false, data["enabled"])
+		assert.Equal(t, float64(0), data["hits"])
+	})
+}
@@ -2,7 +2,6 @@ package handler
 
 import (
 	"net/http"
-	"os"
 
 	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/service"
 	apperr "github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/err" //诶诶还有命名冲突
@@ -11,17 +10,17 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func GetAppState(c *gin.Context) {
-	//从环境变量读取激活的活动配置
-	activeActivity := os.Getenv("ACTIVE_ACTIVITY")
+// GetAppState 返回当前激活的活动，activeActivity 来自配置项 activeActivity (环境变量 ACTIVE_ACTIVITY)
+func GetAppState(c *gin.Context, activeActivity string) {
 
 	// 检查是否配置
 	if activeActivity == "" {
-		logger.Log.Error("获取应用状态失败：环境变量 ACTIVE_ACTIVITY 未设置")
+		logger.FromContext(c.Request.Context()).Error("获取应用状态失败：配置项 ACTIVE_ACTIVITY 未设置")
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":    apperr.ERROR_SERVER_ERROR, // 标准错误码是10
-			"message": "服务器配置错误，请联系管理员",
-			"data":    gin.H{},
+			"code":      apperr.ERROR_SERVER_ERROR, // 标准错误码是10
+			"requestId": c.GetString("requestID"),
+			"message":   "服务器配置错误，请联系管理员",
+			"data":      gin.H{},
 		})
 		return
 	}
@@ -37,7 +36,7 @@ func GetAppState(c *gin.Context) {
 
 }
 
-func TestAI(c *gin.Context) {
+func TestAI(c *gin.Context, moderator service.Moderator) {
 	var req struct {
 		Content string `json:"content"`
 	}
@@ -46,8 +45,8 @@ func TestAI(c *gin.Context) {
 		return
 	}
 
-	// 直接调用 AI Service
-	isViolating, aiErr := service.CheckContent(req.Content)
+	// 直接调用注入的审核器
+	verdict, aiErr := moderator.Check(c.Request.Context(), service.KindWish, req.Content)
 	if aiErr != nil {
 		// 与业务处理保持一致：审核出错时按参数问题处理
 		c.JSON(http.StatusBadRequest, gin.H{"error": aiErr.Error()})
@@ -57,6 +56,7 @@ func TestAI(c *gin.Context) {
 	// 把 AI 的原始结果直接返回给你
 	c.JSON(200, gin.H{
 		"input_content": req.Content,
-		"is_violating":  isViolating, // (true=违规, false=安全)
+		"is_violating":  verdict.Violating, // (true=违规, false=安全)
+		"provider":      verdict.Provider,
 	})
 }
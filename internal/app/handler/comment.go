@@ -1,11 +1,13 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/model"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/repository"
 	service "github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/service"
 	apperr "github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/err"
 	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/logger"
@@ -41,8 +43,8 @@ type CommentResponse struct {
 // CreateComment 创建评论：用户需登录（中间件将 userID 写入上下文）
 // 校验请求体
 // 校验愿望是否存在
-// 创建评论并将 wish.comment_count +1
-func CreateComment(c *gin.Context, db *gorm.DB) {
+// 创建评论并将 wish.comment_count +1（异步审核模式下在审核通过后才计数）
+func CreateComment(c *gin.Context, db *gorm.DB, comments *service.CommentService, moderator service.Moderator, queue service.ModerationQueue) {
 	// 允许两种方式指定 wishId：
 	// 1) 路由 /wishes/:id/comment 中的 :id
 	// 2) 请求体 JSON 中的 wishId 字段（当前路由是 POST /api/comments）
@@ -53,11 +55,12 @@ func CreateComment(c *gin.Context, db *gorm.DB) {
 		if id64, err := strconv.ParseUint(idStr, 10, 64); err == nil {
 			wishID = uint(id64)
 		} else {
-			logger.Log.Warnw("CreateComment: URL wishId 解析失败", "error", err)
+			logger.FromContext(c.Request.Context()).Warnw("CreateComment: URL wishId 解析失败", "error", err)
 			c.JSON(http.StatusBadRequest, gin.H{
-				"code":    apperr.ERROR_PARAM_INVALID,
-				"message": apperr.GetMsg(apperr.ERROR_PARAM_INVALID),
-				"data":    gin.H{"error": "Wish ID 无效"},
+				"code":      apperr.ERROR_PARAM_INVALID,
+				"requestId": c.GetString("requestID"),
+				"message":   apperr.GetMsg(apperr.ERROR_PARAM_INVALID),
+				"data":      gin.H{"error": "Wish ID 无效"},
 			})
 			return
 		}
@@ -69,11 +72,12 @@ func CreateComment(c *gin.Context, db *gorm.DB) {
 		Content string `json:"content" binding:"required"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		logger.Log.Warnw("CreateComment: 参数绑定失败", "error", err)
+		logger.FromContext(c.Request.Context()).Warnw("CreateComment: 参数绑定失败", "error", err)
 		c.JSON(http.StatusBadRequest, gin.H{
-			"code":    apperr.ERROR_PARAM_INVALID,
-			"message": apperr.GetMsg(apperr.ERROR_PARAM_INVALID),
-			"data":    gin.H{"error": err.Error()},
+			"code":      apperr.ERROR_PARAM_INVALID,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_PARAM_INVALID),
+			"data":      gin.H{"error": err.Error()},
 		})
 		return
 	}
@@ -83,110 +87,73 @@ func CreateComment(c *gin.Context, db *gorm.DB) {
 	}
 	if wishID == 0 {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"code":    apperr.ERROR_PARAM_INVALID,
-			"message": apperr.GetMsg(apperr.ERROR_PARAM_INVALID),
-			"data":    gin.H{"error": "缺少 wishId"},
+			"code":      apperr.ERROR_PARAM_INVALID,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_PARAM_INVALID),
+			"data":      gin.H{"error": "缺少 wishId"},
 		})
 		return
 	}
 
 	userIDi, ok := c.Get("userID")
 	if !ok {
-		logger.Log.Warn("CreateComment: 未找到 userID 上下文")
+		logger.FromContext(c.Request.Context()).Warn("CreateComment: 未找到 userID 上下文")
 		c.JSON(http.StatusUnauthorized, gin.H{
-			"code":    apperr.ERROR_UNAUTHORIZED,
-			"message": apperr.GetMsg(apperr.ERROR_UNAUTHORIZED),
-			"data":    gin.H{},
+			"code":      apperr.ERROR_UNAUTHORIZED,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_UNAUTHORIZED),
+			"data":      gin.H{},
 		})
 		return
 	}
 	userID := userIDi.(uint)
 
-	// 校验愿望是否存在
-	var wish model.Wish
-	if err := db.First(&wish, wishID).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			logger.Log.Infow("CreateComment: wish 未找到", "wishId", wishID)
-			c.JSON(http.StatusBadRequest, gin.H{
-				"code":    apperr.ERROR_PARAM_INVALID,
-				"message": apperr.GetMsg(apperr.ERROR_PARAM_INVALID),
-				"data":    gin.H{},
+	// 校验能否评论该愿望（愿望存在、对当前用户可见，私密愿望只有作者可以评论）
+	ctx := c.Request.Context()
+	if err := comments.CheckCommentable(ctx, userID, wishID); err != nil {
+		if !respondCommentTargetError(c, err, wishID, userID) {
+			logger.FromContext(c.Request.Context()).Errorw("CreateComment: 查询 wish 失败", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code":      apperr.ERROR_WISH_NOT_FOUND,
+				"requestId": c.GetString("requestID"),
+				"message":   apperr.GetMsg(apperr.ERROR_WISH_NOT_FOUND),
+				"data":      gin.H{},
 			})
-			return
 		}
-		logger.Log.Errorw("CreateComment: 查询 wish 失败", "error", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":    apperr.ERROR_WISH_NOT_FOUND,
-			"message": apperr.GetMsg(apperr.ERROR_WISH_NOT_FOUND),
-			"data":    gin.H{},
-		})
-		return
-	}
-
-	// 检查是否允许评论
-	if !wish.IsPublic && wish.UserID != userID {
-		logger.Log.Infow("CreateComment: 评论被拒绝，尝试评论私有愿望", "wishId", wishID, "userID", userID)
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"code":    apperr.ERROR_FORBIDDEN_COMMENT, // <-- 对应 code 13
-			"message": apperr.GetMsg(apperr.ERROR_FORBIDDEN_COMMENT),
-			"data":    gin.H{},
-		})
 		return
 	}
 
-	isViolating, aiErr := service.CheckContent(req.Content)
-	if aiErr != nil {
-		// AI 服务本身出错（如内容为空/过长 或无法判断）
-		logger.Log.Warnw("创建评论被拒绝：内容审核出错", "userID", userID, "error", aiErr)
-		c.JSON(http.StatusBadRequest, gin.H{
-			"code":    apperr.ERROR_PARAM_INVALID,
-			"message": apperr.GetMsg(apperr.ERROR_PARAM_INVALID),
-			"data":    gin.H{"error": aiErr.Error()},
-		})
-		return
-	}
-	if isViolating {
-		// AI 明确判定为不安全内容
-		logger.Log.Infow("创建评论被拒绝:AI 判定不安全", "userID", userID)
-		c.JSON(http.StatusBadRequest, gin.H{
-			"code":    apperr.ERROR_PARAM_INVALID,
-			"message": apperr.GetMsg(apperr.ERROR_PARAM_INVALID),
-			"data":    gin.H{"error": "内容未通过审核"},
-		})
+	outcome, ok := moderateContent(c, db, moderator, queue, service.KindComment, req.Content, "创建评论", userID)
+	if !ok {
 		return
 	}
 
-	// 创建评论（使用事务，确保 comment_count 与 comment 保持一致）
-	var comment model.Comment
-	if err := db.Transaction(func(tx *gorm.DB) error {
-		comment = model.Comment{
-			WishID:  wishID,
-			UserID:  userID,
-			Content: req.Content,
-		}
-		if err := tx.Create(&comment).Error; err != nil {
-			return err
-		}
-		if err := tx.Model(&model.Wish{}).Where("id = ?", wishID).
-			UpdateColumn("comment_count", gorm.Expr("comment_count + ?", 1)).Error; err != nil {
-			return err
+	// 创建评论（事务中同时维护 comment_count，异步审核模式下在审核通过后才计数）
+	comment, err := comments.Create(ctx, service.CreateCommentInput{
+		WishID:  wishID,
+		UserID:  userID,
+		Content: outcome.contentToSave(req.Content),
+		Status:  outcome.status,
+	})
+	if err != nil {
+		if respondCommentTargetError(c, err, wishID, userID) {
+			return
 		}
-		return nil
-	}); err != nil {
-		logger.Log.Errorw("CreateComment: 创建评论失败", "error", err)
+		logger.FromContext(c.Request.Context()).Errorw("CreateComment: 创建评论失败", "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":    apperr.ERROR_COMMENT_FAILED,
-			"message": apperr.GetMsg(apperr.ERROR_COMMENT_FAILED),
-			"data":    gin.H{},
+			"code":      apperr.ERROR_COMMENT_FAILED,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_COMMENT_FAILED),
+			"data":      gin.H{},
 		})
 		return
 	}
 
-	// 为避免“幽灵用户”，在事务成功后重新查询并预加载 User
-	if err := db.Preload("User").First(&comment, comment.ID).Error; err != nil {
-		// 预加载失败不是致命错误，但要记录日志
-		logger.Log.Warnw("CreateComment: 重新查询评论并预加载用户失败，可能返回无用户信息", "error", err, "commentID", comment.ID)
+	outcome.audit(db, comment.ID, userID)
+	if outcome.status == model.ModerationPending {
+		queue.Enqueue(service.ModerationTask{Target: service.TargetComment, ID: comment.ID})
 	}
+
 	//配合前端扁平化
 	/*resp := CommentResponse{
 		ID:        comment.ID,
@@ -211,6 +178,8 @@ func CreateComment(c *gin.Context, db *gorm.DB) {
 		"userAvatar":   comment.User.AvatarID, // (确保 User.AvatarID 已被 Preload)
 		"likeCount":    0,                     // 前端需要，暂时给 0
 		"isOwn":        true,                  // 刚创建的为true
+
+		"moderationStatus": comment.ModerationStatus,
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -221,23 +190,25 @@ func CreateComment(c *gin.Context, db *gorm.DB) {
 }
 
 // DeleteComment 删除评论：仅 评论作者、心愿主人 或 管理员 可删除
-func DeleteComment(c *gin.Context, db *gorm.DB) {
+func DeleteComment(c *gin.Context, comments *service.CommentService) {
 	idStr := c.Param("id")
 	if idStr == "" {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"code":    apperr.ERROR_PARAM_INVALID,
-			"message": apperr.GetMsg(apperr.ERROR_PARAM_INVALID),
-			"data":    gin.H{"error": "缺少评论ID"},
+			"code":      apperr.ERROR_PARAM_INVALID,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_PARAM_INVALID),
+			"data":      gin.H{"error": "缺少评论ID"},
 		})
 		return
 	}
 	idUint64, err := strconv.ParseUint(idStr, 10, 64)
 	if err != nil {
-		logger.Log.Warnw("DeleteComment: id 解析失败", "id", idStr, "error", err)
+		logger.FromContext(c.Request.Context()).Warnw("DeleteComment: id 解析失败", "id", idStr, "error", err)
 		c.JSON(http.StatusBadRequest, gin.H{
-			"code":    apperr.ERROR_PARAM_INVALID,
-			"message": apperr.GetMsg(apperr.ERROR_PARAM_INVALID),
-			"data":    gin.H{"error": "评论ID格式无效"},
+			"code":      apperr.ERROR_PARAM_INVALID,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_PARAM_INVALID),
+			"data":      gin.H{"error": "评论ID格式无效"},
 		})
 		return
 	}
@@ -246,97 +217,51 @@ func DeleteComment(c *gin.Context, db *gorm.DB) {
 	userIDi, ok := c.Get("userID")
 	if !ok {
 		c.JSON(http.StatusUnauthorized, gin.H{
-			"code":    apperr.ERROR_UNAUTHORIZED,
-			"message": apperr.GetMsg(apperr.ERROR_UNAUTHORIZED),
-			"data":    gin.H{},
+			"code":      apperr.ERROR_UNAUTHORIZED,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_UNAUTHORIZED),
+			"data":      gin.H{},
 		})
 		return
 	}
 	userID := userIDi.(uint)
 
-	// 查询评论
-	var comment model.Comment
-	if err := db.First(&comment, commentID).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
+	// 删除评论：评论作者、心愿主人或管理员可删除；审核通过的评论同时减少 wish.comment_count
+	if err := comments.Delete(c.Request.Context(), userID, commentID); err != nil {
+		switch {
+		case errors.Is(err, service.ErrCommentNotFound):
 			// 使用 code 14
 			c.JSON(http.StatusBadRequest, gin.H{
-				"code":    apperr.ERROR_COMMENT_NOT_FOUND,
-				"message": apperr.GetMsg(apperr.ERROR_COMMENT_NOT_FOUND),
-				"data":    gin.H{},
+				"code":      apperr.ERROR_COMMENT_NOT_FOUND,
+				"requestId": c.GetString("requestID"),
+				"message":   apperr.GetMsg(apperr.ERROR_COMMENT_NOT_FOUND),
+				"data":      gin.H{},
 			})
-			return
-		}
-		logger.Log.Errorw("DeleteComment: 查询评论失败", "error", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":    apperr.ERROR_SERVER_ERROR,
-			"message": apperr.GetMsg(apperr.ERROR_SERVER_ERROR),
-			"data":    gin.H{},
-		})
-		return
-	}
-
-	// 检查 1: 是否为评论作者
-	if comment.UserID == userID {
-		// 是评论作者，允许删除
-	} else {
-		// 检查 2: 是否为心愿主人
-		var wish model.Wish
-		if err := db.First(&wish, comment.WishID).Error; err != nil {
-			logger.Log.Errorw("DeleteComment: 无法找到评论所属的愿望", "error", err, "wishID", comment.WishID)
+		case errors.Is(err, service.ErrUserNotFound):
+			logger.FromContext(c.Request.Context()).Errorw("DeleteComment: 查询当前用户信息失败", "error", err, "userID", userID)
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"code":      apperr.ERROR_UNAUTHORIZED, // 无法验证用户身份
+				"requestId": c.GetString("requestID"),
+				"message":   apperr.GetMsg(apperr.ERROR_UNAUTHORIZED),
+				"data":      gin.H{},
+			})
+		case errors.Is(err, service.ErrForbidden):
+			// 三者都不是，禁止删除
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"code":      apperr.ERROR_FORBIDDEN_DELETE, // <-- 对应 code 2
+				"requestId": c.GetString("requestID"),
+				"message":   apperr.GetMsg(apperr.ERROR_FORBIDDEN_DELETE),
+				"data":      gin.H{},
+			})
+		default:
+			logger.FromContext(c.Request.Context()).Errorw("DeleteComment: 删除评论失败", "error", err, "commentID", commentID)
 			c.JSON(http.StatusInternalServerError, gin.H{
-				"code":    apperr.ERROR_SERVER_ERROR,
-				"message": "数据关联错误", // 服务器内部错误
-				"data":    gin.H{},
+				"code":      apperr.ERROR_SERVER_ERROR,
+				"requestId": c.GetString("requestID"),
+				"message":   apperr.GetMsg(apperr.ERROR_SERVER_ERROR),
+				"data":      gin.H{},
 			})
-			return
-		}
-
-		if wish.UserID == userID {
-			// 是心愿主人，允许删除
-		} else {
-			// 检查 3: 是否为管理员
-			var currentUser model.User
-			if err := db.First(&currentUser, userID).Error; err != nil {
-				logger.Log.Errorw("DeleteComment: 查询当前用户信息失败", "error", err, "userID", userID)
-				c.JSON(http.StatusUnauthorized, gin.H{
-					"code":    apperr.ERROR_UNAUTHORIZED, // 无法验证用户身份
-					"message": apperr.GetMsg(apperr.ERROR_UNAUTHORIZED),
-					"data":    gin.H{},
-				})
-				return
-			}
-
-			if currentUser.Role == "admin" {
-				// 是管理员，允许删除
-			} else {
-				// 最终: 三者都不是，禁止删除
-				c.JSON(http.StatusUnauthorized, gin.H{
-					"code":    apperr.ERROR_FORBIDDEN_DELETE, // <-- 对应 code 2
-					"message": apperr.GetMsg(apperr.ERROR_FORBIDDEN_DELETE),
-					"data":    gin.H{},
-				})
-				return
-			}
-		}
-	}
-
-	// 删除并减少 wish.comment_count（事务）
-	if err := db.Transaction(func(tx *gorm.DB) error {
-		if err := tx.Delete(&comment).Error; err != nil {
-			return err
-		}
-		if err := tx.Model(&model.Wish{}).Where("id = ?", comment.WishID).
-			UpdateColumn("comment_count", gorm.Expr("GREATEST(comment_count - ?, 0)", 1)).Error; err != nil {
-			return err
 		}
-		return nil
-	}); err != nil {
-		logger.Log.Errorw("DeleteComment: 删除评论事务失败", "error", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":    apperr.ERROR_SERVER_ERROR,
-			"message": apperr.GetMsg(apperr.ERROR_SERVER_ERROR),
-			"data":    gin.H{},
-		})
 		return
 	}
 
@@ -348,7 +273,7 @@ func DeleteComment(c *gin.Context, db *gorm.DB) {
 }
 
 // ListCommentsByWish 列出某个愿望的评论，支持分页
-func ListCommentsByWish(c *gin.Context, db *gorm.DB) {
+func ListCommentsByWish(c *gin.Context, comments *service.CommentService) {
 	wishIDStr := c.Param("wishId")
 	if wishIDStr == "" {
 		// 兼容路由参数 :id
@@ -356,19 +281,21 @@ func ListCommentsByWish(c *gin.Context, db *gorm.DB) {
 	}
 	if wishIDStr == "" {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"code":    apperr.ERROR_PARAM_INVALID,
-			"message": apperr.GetMsg(apperr.ERROR_PARAM_INVALID),
-			"data":    gin.H{},
+			"code":      apperr.ERROR_PARAM_INVALID,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_PARAM_INVALID),
+			"data":      gin.H{},
 		})
 		return
 	}
 	wishIDUint64, err := strconv.ParseUint(wishIDStr, 10, 64)
 	if err != nil {
-		logger.Log.Warnw("ListCommentsByWish: wishId 解析失败", "wishId", wishIDStr, "error", err)
+		logger.FromContext(c.Request.Context()).Warnw("ListCommentsByWish: wishId 解析失败", "wishId", wishIDStr, "error", err)
 		c.JSON(http.StatusBadRequest, gin.H{
-			"code":    apperr.ERROR_PARAM_INVALID,
-			"message": apperr.GetMsg(apperr.ERROR_PARAM_INVALID),
-			"data":    gin.H{},
+			"code":      apperr.ERROR_PARAM_INVALID,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_PARAM_INVALID),
+			"data":      gin.H{},
 		})
 		return
 	}
@@ -389,57 +316,30 @@ func ListCommentsByWish(c *gin.Context, db *gorm.DB) {
 	}
 	offset := (page - 1) * pageSize
 
-	// 检查 wish 是否存在
-	var wish model.Wish
-	if err := db.First(&wish, wishID).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
+	// 只展示审核通过的评论，并预加载用户信息
+	list, total, err := comments.ListByWish(c.Request.Context(), wishID, repository.Page{Offset: offset, Limit: pageSize})
+	if err != nil {
+		if errors.Is(err, service.ErrWishNotFound) {
 			c.JSON(http.StatusBadRequest, gin.H{
-				"code":    apperr.ERROR_PARAM_INVALID,
-				"message": apperr.GetMsg(apperr.ERROR_PARAM_INVALID),
-				"data":    gin.H{},
+				"code":      apperr.ERROR_PARAM_INVALID,
+				"requestId": c.GetString("requestID"),
+				"message":   apperr.GetMsg(apperr.ERROR_PARAM_INVALID),
+				"data":      gin.H{},
 			})
 			return
 		}
-		logger.Log.Errorw("ListCommentsByWish: 查询 wish 失败", "error", err)
+		logger.FromContext(c.Request.Context()).Errorw("ListCommentsByWish: 查询评论失败", "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":    apperr.ERROR_SERVER_ERROR,
-			"message": apperr.GetMsg(apperr.ERROR_SERVER_ERROR),
-			"data":    gin.H{},
+			"code":      apperr.ERROR_SERVER_ERROR,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_SERVER_ERROR),
+			"data":      gin.H{},
 		})
 		return
 	}
 
-	var total int64
-	var comments []model.Comment
-
-	if err := db.Model(&model.Comment{}).Where("wish_id = ?", wishID).Count(&total).Error; err != nil {
-		logger.Log.Errorw("ListCommentsByWish: 计数失败", "error", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":    apperr.ERROR_SERVER_ERROR,
-			"message": apperr.GetMsg(apperr.ERROR_SERVER_ERROR),
-			"data":    gin.H{},
-		})
-		return
-	}
-
-	// 查询并预加载用户信息
-	if err := db.Where("wish_id = ?", wishID).
-		Preload("User").
-		Order("created_at asc").
-		Offset(offset).
-		Limit(pageSize).
-		Find(&comments).Error; err != nil {
-		logger.Log.Errorw("ListCommentsByWish: 查询评论失败", "error", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":    apperr.ERROR_SERVER_ERROR,
-			"message": apperr.GetMsg(apperr.ERROR_SERVER_ERROR),
-			"data":    gin.H{},
-		})
-		return
-	}
-
-	respComments := make([]CommentResponse, 0, len(comments))
-	for _, cm := range comments {
+	respComments := make([]CommentResponse, 0, len(list))
+	for _, cm := range list {
 		respComments = append(respComments, CommentResponse{
 			ID:        cm.ID,
 			WishID:    cm.WishID,
@@ -466,4 +366,30 @@ func ListCommentsByWish(c *gin.Context, db *gorm.DB) {
 	})
 }
 
+// respondCommentTargetError 处理评论目标的业务错误，已响应时返回 true：
+// 愿望不存在（或对当前用户不可见）按参数错误处理，私密愿望返回禁止评论
+func respondCommentTargetError(c *gin.Context, err error, wishID, userID uint) bool {
+	switch {
+	case errors.Is(err, service.ErrWishNotFound), errors.Is(err, service.ErrCommentNotFound):
+		logger.FromContext(c.Request.Context()).Infow("评论失败：愿望或父评论不存在", "wishId", wishID, "userID", userID)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":      apperr.ERROR_PARAM_INVALID,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_PARAM_INVALID),
+			"data":      gin.H{},
+		})
+	case errors.Is(err, service.ErrCommentNotAllowed):
+		logger.FromContext(c.Request.Context()).Infow("评论失败：尝试评论私有愿望", "wishId", wishID, "userID", userID)
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":      apperr.ERROR_FORBIDDEN_COMMENT, // <-- 对应 code 13
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_FORBIDDEN_COMMENT),
+			"data":      gin.H{},
+		})
+	default:
+		return false
+	}
+	return true
+}
+
 // (UpdateComment 函数删了)
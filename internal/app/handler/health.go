@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/service"
+	apperr "github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/err"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// readinessTimeout 是一次就绪检查的最长耗时，数据库无响应时按失败处理，不让探针一直等待
+const readinessTimeout = 2 * time.Second
+
+// Healthz 存活检查：进程能够处理 HTTP 请求即返回 200，不检查任何依赖
+// GET /healthz
+func Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"code":    apperr.SUCCESS,
+		"message": apperr.GetMsg(apperr.SUCCESS),
+		"data":    gin.H{"status": service.HealthOK},
+	})
+}
+
+// Readyz 就绪检查：检查数据库连接、表结构版本与审核链路，有检查项失败时返回 503。
+// 默认只返回整体状态；带 ?verbose=1 时返回每一项的状态、耗时、错误与版本信息，供运维排查
+// GET /readyz
+func Readyz(c *gin.Context, health *service.HealthChecker) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), readinessTimeout)
+	defer cancel()
+	report := health.CheckReadiness(ctx)
+
+	httpStatus, code := http.StatusOK, apperr.SUCCESS
+	if !report.Ready() {
+		httpStatus, code = http.StatusServiceUnavailable, apperr.ERROR_SERVER_ERROR
+		for _, check := range report.Checks {
+			if check.Status == service.HealthFail {
+				logger.FromContext(c.Request.Context()).Warnw("就绪检查失败", "check", check.Name, "error", check.Error, "latencyMs", check.LatencyMs)
+			}
+		}
+	}
+
+	data := gin.H{"status": report.Status}
+	if verbose, _ := strconv.ParseBool(c.Query("verbose")); verbose {
+		data["checkedAt"] = report.CheckedAt
+		data["checks"] = report.Checks
+		data["build"] = report.Build
+	}
+	c.JSON(httpStatus, gin.H{
+		"code":      code,
+		"requestId": c.GetString("requestID"),
+		"message":   apperr.GetMsg(code),
+		"data":      data,
+	})
+}
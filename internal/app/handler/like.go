@@ -4,27 +4,25 @@ import (
 	"errors"
 	"net/http"
 	"strconv"
-	"strings"
 
-	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/model"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/service"
 	apperr "github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/err"
 	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/logger"
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
-	"gorm.io/gorm/clause"
 )
 
 // LikeWish handles POST /api/wishes/:id/like - toggle like on a wish
-func LikeWish(c *gin.Context, db *gorm.DB) {
+func LikeWish(c *gin.Context, likes *service.LikeService) {
 	// 1. Get wish ID from URL parameter
 	wishIDStr := c.Param("id")
 	wishID64, err := strconv.ParseUint(wishIDStr, 10, 32)
 	if err != nil {
-		logger.Log.Warnw("点赞失败：愿望ID无效", "wishID", wishIDStr, "error", err)
+		logger.FromContext(c.Request.Context()).Warnw("点赞失败：愿望ID无效", "wishID", wishIDStr, "error", err)
 		c.JSON(http.StatusBadRequest, gin.H{
-			"code":    apperr.ERROR_PARAM_INVALID,
-			"message": apperr.GetMsg(apperr.ERROR_PARAM_INVALID),
-			"data":    gin.H{"error": "愿望ID无效"},
+			"code":      apperr.ERROR_PARAM_INVALID,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_PARAM_INVALID),
+			"data":      gin.H{"error": "愿望ID无效"},
 		})
 		return
 	}
@@ -33,136 +31,53 @@ func LikeWish(c *gin.Context, db *gorm.DB) {
 	// 2. Get user ID from JWT context (set by middleware)
 	userIDInterface, exists := c.Get("userID")
 	if !exists {
-		logger.Log.Error("点赞失败：未找到用户ID")
+		logger.FromContext(c.Request.Context()).Error("点赞失败：未找到用户ID")
 		c.JSON(http.StatusUnauthorized, gin.H{
-			"code":    apperr.ERROR_UNAUTHORIZED,
-			"message": apperr.GetMsg(apperr.ERROR_UNAUTHORIZED),
-			"data":    gin.H{},
+			"code":      apperr.ERROR_UNAUTHORIZED,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_UNAUTHORIZED),
+			"data":      gin.H{},
 		})
 		return
 	}
 	userID, ok := userIDInterface.(uint)
 	if !ok {
-		logger.Log.Error("点赞失败:用户ID类型转换错误")
+		logger.FromContext(c.Request.Context()).Error("点赞失败:用户ID类型转换错误")
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":    apperr.ERROR_SERVER_ERROR,
-			"message": apperr.GetMsg(apperr.ERROR_SERVER_ERROR),
-			"data":    gin.H{},
+			"code":      apperr.ERROR_SERVER_ERROR,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_SERVER_ERROR),
+			"data":      gin.H{},
 		})
 		return
 	}
 
-	// Variables to return after transaction
-	var (
-		finalLikeCount int
-		finalLiked     bool
-	)
-
-	// 3. Run transaction to toggle like atomically
-	txErr := db.Transaction(func(tx *gorm.DB) error {
-		// Load wish with FOR UPDATE lock to prevent race conditions
-		var wish model.Wish
-		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&wish, wishID).Error; err != nil {
-			// propagate ErrRecordNotFound so outer code can map to WISH_NOT_FOUND
-			return err
-		}
-
-		// Check if like already exists within the transaction
-		var existingLike model.Like
-		if err := tx.Where("wish_id = ? AND user_id = ?", wishID, userID).First(&existingLike).Error; err == nil {
-
-			// Soft delete would retain the row and keep uniqueIndex (wish_id,user_id) blocked, causing duplicate key errors on re-like.
-			if err := tx.Unscoped().Delete(&existingLike).Error; err != nil {
-				logger.Log.Errorw("取消点赞失败", "wishID", wishID, "userID", userID, "error", err)
-				return err
-			}
-			if err := tx.Model(&wish).Update("like_count", gorm.Expr("GREATEST(like_count - ?, 0)", 1)).Error; err != nil {
-				logger.Log.Errorw("取消点赞失败：更新点赞数出错", "wishID", wishID, "error", err)
-				return err
-			}
-			// Reload wish to get updated like_count
-			if err := tx.First(&wish, wishID).Error; err != nil {
-				logger.Log.Errorw("取消点赞失败：重新加载愿望出错", "wishID", wishID, "error", err)
-				return err
-			}
-			finalLikeCount = wish.LikeCount
-			finalLiked = false
-			return nil
-		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
-			// Query error
-			logger.Log.Errorw("查询点赞记录出错", "wishID", wishID, "userID", userID, "error", err)
-			return err
-		}
-
-		// Like: create a new like record and increment like_count
-		newLike := model.Like{
-			WishID: wishID,
-			UserID: userID,
-		}
-		if err := tx.Create(&newLike).Error; err != nil {
-			logger.Log.Errorw("点赞失败：创建点赞记录出错", "wishID", wishID, "userID", userID, "error", err)
-			return err
-		}
-		if err := tx.Model(&wish).Update("like_count", gorm.Expr("like_count + 1")).Error; err != nil {
-			logger.Log.Errorw("点赞失败：更新点赞数出错", "wishID", wishID, "error", err)
-			return err
-		}
-		// Reload wish to get updated like_count
-		if err := tx.First(&wish, wishID).Error; err != nil {
-			logger.Log.Errorw("点赞失败：重新加载愿望出错", "wishID", wishID, "error", err)
-			return err
-		}
-		finalLikeCount = wish.LikeCount
-		finalLiked = true
-		return nil
-	})
-
-	// 4. Handle transaction result
-	if txErr != nil {
-		if errors.Is(txErr, gorm.ErrRecordNotFound) {
-			logger.Log.Warnw("点赞失败：愿望不存在", "wishID", wishID)
+	// 3. Toggle like (service runs it in a transaction)
+	result, err := likes.Toggle(c.Request.Context(), userID, wishID)
+	if err != nil {
+		if errors.Is(err, service.ErrWishNotFound) {
+			logger.FromContext(c.Request.Context()).Warnw("点赞失败：愿望不存在", "wishID", wishID)
 			c.JSON(http.StatusBadRequest, gin.H{
-				"code":    apperr.ERROR_WISH_NOT_FOUND,
-				"message": apperr.GetMsg(apperr.ERROR_WISH_NOT_FOUND),
-				"data":    gin.H{},
+				"code":      apperr.ERROR_WISH_NOT_FOUND,
+				"requestId": c.GetString("requestID"),
+				"message":   apperr.GetMsg(apperr.ERROR_WISH_NOT_FOUND),
+				"data":      gin.H{},
 			})
 			return
 		}
-
-		if strings.Contains(txErr.Error(), "Error 1062") {
-			logger.Log.Warnw("点赞失败：重复点赞（并发冲突）", "wishID", wishID, "userID", userID, "error", txErr)
-
-			// 这是一个并发冲突，不是服务器错误。
-			// 我们不应该返回 500，而是应该告诉前端 "操作已完成"（即已经点赞了）。
-			// 我们重新查询一次，获取最终的正确状态并返回。
-			var wish model.Wish
-			if err := db.First(&wish, wishID).Error; err == nil {
-				// 成功查询到最新的 likeCount
-				RespondLike(c, wish.LikeCount, true, wishID) // 告诉前端：你已经点赞了
-			} else {
-				// 如果连重新查询都失败了，才返回 500
-				logger.Log.Errorw("点赞并发冲突后，重新查询失败", "wishID", wishID, "error", err)
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"code":    apperr.ERROR_LIKE_FAILED,
-					"message": apperr.GetMsg(apperr.ERROR_LIKE_FAILED),
-					"data":    gin.H{},
-				})
-			}
-			return // 阻止执行下面的 500 错误
-		}
-
-		logger.Log.Errorw("点赞事务失败", "wishID", wishID, "userID", userID, "error", txErr)
+		logger.FromContext(c.Request.Context()).Errorw("点赞事务失败", "wishID", wishID, "userID", userID, "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":    apperr.ERROR_LIKE_FAILED,
-			"message": apperr.GetMsg(apperr.ERROR_LIKE_FAILED),
-			"data":    gin.H{},
+			"code":      apperr.ERROR_LIKE_FAILED,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_LIKE_FAILED),
+			"data":      gin.H{},
 		})
 		return
 	}
 
-	// 5. 成功返回
-	logger.Log.Infow("点赞状态变更成功", "wishID", wishID, "userID", userID, "liked", finalLiked, "likeCount", finalLikeCount)
-	RespondLike(c, finalLikeCount, finalLiked, wishID)
+	// 4. 成功返回
+	logger.FromContext(c.Request.Context()).Infow("点赞状态变更成功", "wishID", wishID, "userID", userID, "liked", result.Liked, "likeCount", result.LikeCount)
+	RespondLike(c, result.LikeCount, result.Liked, wishID)
 }
 
 // RespondLike 返回点赞/取消点赞的标准响应
@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/service"
+	apperr "github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/err"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// LoginLockResponse 是被锁定的学号、IP 或用户
+type LoginLockResponse struct {
+	Subject     string    `json:"subject"`  // 学号、IP 或用户 ID
+	Lockouts    int       `json:"lockouts"` // 连续锁定的次数，锁定时长随之翻倍
+	LockedUntil time.Time `json:"lockedUntil"`
+	RetryAfter  int       `json:"retryAfter"` // 距离解锁的秒数
+}
+
+// ListLoginLocks 列出因登录 (或重置密码) 失败次数过多而被锁定的学号和 IP，
+// 以及修改密码时原密码错误次数过多而被锁定的用户
+// GET /api/admin/login-locks
+func ListLoginLocks(c *gin.Context, limiter *service.LoginLimiter) {
+	accounts, err := limiter.LockedAccounts(c.Request.Context())
+	var ips, users []service.LoginLock
+	if err == nil {
+		ips, err = limiter.LockedIPs(c.Request.Context())
+	}
+	if err == nil {
+		users, err = limiter.LockedUsers(c.Request.Context())
+	}
+	if err != nil {
+		logger.FromContext(c.Request.Context()).Errorw("查询登录锁定失败", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":      apperr.ERROR_SERVER_ERROR,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_SERVER_ERROR),
+			"data":      gin.H{},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    apperr.SUCCESS,
+		"message": apperr.GetMsg(apperr.SUCCESS),
+		"data":    gin.H{"accounts": loginLockList(accounts), "ips": loginLockList(ips), "users": loginLockList(users)},
+	})
+}
+
+// UnlockLogin 解除学号的登录锁定，管理员核实是本人操作后使用
+// DELETE /api/admin/login-locks/:username
+func UnlockLogin(c *gin.Context, limiter *service.LoginLimiter) {
+	if err := limiter.Unlock(c.Request.Context(), c.Param("username")); err != nil {
+		if errors.Is(err, service.ErrInvalidUsername) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":      apperr.ERROR_PARAM_INVALID,
+				"requestId": c.GetString("requestID"),
+				"message":   apperr.GetMsg(apperr.ERROR_PARAM_INVALID),
+				"data":      gin.H{"error": err.Error()},
+			})
+			return
+		}
+		logger.FromContext(c.Request.Context()).Errorw("解除登录锁定失败", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":      apperr.ERROR_SERVER_ERROR,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_SERVER_ERROR),
+			"data":      gin.H{},
+		})
+		return
+	}
+
+	logger.FromContext(c.Request.Context()).Infow("管理员解除登录锁定", "username", c.Param("username"), "adminID", c.GetUint("userID"))
+	c.JSON(http.StatusOK, gin.H{
+		"code":    apperr.SUCCESS,
+		"message": apperr.GetMsg(apperr.SUCCESS),
+		"data":    gin.H{},
+	})
+}
+
+func loginLockList(locks []service.LoginLock) []LoginLockResponse {
+	list := make([]LoginLockResponse, 0, len(locks))
+	for _, lock := range locks {
+		list = append(list, LoginLockResponse{
+			Subject:     lock.Subject(),
+			Lockouts:    lock.Lockouts,
+			LockedUntil: lock.LockedUntil,
+			RetryAfter:  retryAfterSeconds(time.Until(lock.LockedUntil)),
+		})
+	}
+	return list
+}
+
+// respondTooManyAttempts 响应 429，Retry-After 为需要等待的秒数
+func respondTooManyAttempts(c *gin.Context, wait time.Duration) {
+	secs := retryAfterSeconds(wait)
+	c.Header("Retry-After", strconv.Itoa(secs))
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"code":      apperr.ERROR_TOO_MANY_ATTEMPTS,
+		"requestId": c.GetString("requestID"),
+		"message":   apperr.GetMsg(apperr.ERROR_TOO_MANY_ATTEMPTS),
+		"data":      gin.H{"retryAfter": secs},
+	})
+}
+
+// retryAfterSeconds 向上取整到秒，至少 1 秒
+func retryAfterSeconds(wait time.Duration) int {
+	return max(1, int(math.Ceil(wait.Seconds())))
+}
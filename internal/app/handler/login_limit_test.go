@@ -0,0 +1,196 @@
+package handler_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	apperr "github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/err"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/router"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLoginLimit 测试登录失败次数过多时锁定学号，以及管理员查看和解除锁定
+func TestLoginLimit(t *testing.T) {
+	cleanup(testDB)
+	admin := createUserWithRole("2024000099", "admin-pass-99", "admin")
+	user := createUser("2024000001", "snow-wish-42")
+	cfg := newTestConfig()
+	cfg.LoginLimit.AccountMaxFailures = 3
+	r := newRouter(t, cfg, fakeModerator{}, nil)
+	login := func(password string) map[string]interface{} {
+		w := postJSON(r, "/api/login", "", gin.H{"username": "2024000001", "password": password})
+		resp := parseResponse(t, w)
+		resp["status"] = float64(w.Code)
+		resp["retryAfter"] = w.Header().Get("Retry-After")
+		return resp
+	}
+	unlock := func(username, token string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("DELETE", "/api/admin/login-locks/"+username, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("达到上限后锁定", func(t *testing.T) {
+		for range 2 {
+			assert.Equal(t, float64(http.StatusUnauthorized), login("wrong-pass-1")["status"])
+		}
+		resp := login("wrong-pass-1")
+		assert.Equal(t, float64(http.StatusTooManyRequests), resp["status"])
+		assert.Equal(t, float64(apperr.ERROR_TOO_MANY_ATTEMPTS), resp["code"])
+		assert.Equal(t, "60", resp["retryAfter"])
+		assert.Equal(t, float64(60), resp["data"].(map[string]interface{})["retryAfter"])
+
+		resp = login("snow-wish-42")
+		assert.Equal(t, float64(http.StatusTooManyRequests), resp["status"], "锁定期间密码正确也不能登录")
+		assert.NotEmpty(t, resp["retryAfter"])
+	})
+
+	// 锁定记录保存在数据库中，其他实例 (这里是 testRouter) 也能看到
+	t.Run("管理员查看锁定", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, getJSON("/api/admin/login-locks", createToken(user.ID)).Code)
+
+		w := getJSON("/api/admin/login-locks", createToken(admin.ID))
+		require.Equal(t, http.StatusOK, w.Code)
+		data := parseResponse(t, w)["data"].(map[string]interface{})
+		accounts := data["accounts"].([]interface{})
+		require.Len(t, accounts, 1)
+		lock := accounts[0].(map[string]interface{})
+		assert.Equal(t, "2024000001", lock["subject"])
+		assert.Equal(t, float64(1), lock["lockouts"])
+		assert.NotEmpty(t, lock["lockedUntil"])
+		assert.Empty(t, data["ips"])
+	})
+
+	t.Run("管理员解除锁定", func(t *testing.T) {
+		w := unlock("abc", createToken(admin.ID))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = unlock("2024000001", createToken(admin.ID))
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, float64(http.StatusOK), login("snow-wish-42")["status"])
+	})
+}
+
+// TestLoginLimitByIP 测试同一 IP 尝试大量学号时按 IP 锁定
+func TestLoginLimitByIP(t *testing.T) {
+	cleanup(testDB)
+	createUser("2024000001", "snow-wish-42")
+	cfg := newTestConfig()
+	cfg.LoginLimit.IPMaxFailures = 3
+	cfg.Server.TrustedProxies = []string{"127.0.0.0/8", "172.28.0.10"}
+	r := newRouter(t, cfg, fakeModerator{}, nil)
+	login := func(remoteAddr, forwardedFor, username, password string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(gin.H{"username": username, "password": password})
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/login", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.RemoteAddr = remoteAddr
+		if forwardedFor != "" {
+			req.Header.Set("X-Forwarded-For", forwardedFor)
+		}
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// 不受信任的客户端伪造 X-Forwarded-For 无法换 IP
+	for i := range 2 {
+		w := login("203.0.113.7:5000", "198.51.100."+strconv.Itoa(i), "20240001"+strconv.Itoa(10+i), "wrong-pass-1")
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	}
+	w := login("203.0.113.7:5000", "198.51.100.9", "2024000120", "wrong-pass-1")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	w = login("203.0.113.7:5001", "", "2024000001", "snow-wish-42")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code, "同一 IP 的其他学号也被锁定")
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+
+	// 受信任的反向代理 (docker 网络中固定地址的 nginx) 转发的真实 IP 分别统计
+	w = login("172.28.0.10:5000", "203.0.113.8", "2024000001", "snow-wish-42")
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = login("172.28.0.10:5000", "203.0.113.7", "2024000001", "snow-wish-42")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+
+	// 经发布端口进入的外部请求来源是网桥网关，不受信任，伪造 X-Forwarded-For 无法换 IP
+	for i := range 2 {
+		w = login("172.28.0.1:5000", "198.51.100."+strconv.Itoa(40+i), "20240003"+strconv.Itoa(10+i), "wrong-pass-1")
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	}
+	w = login("172.28.0.1:5000", "198.51.100.50", "2024000320", "wrong-pass-1")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+
+	// 不信任校园网等其他内网，网段内的客户端伪造 X-Forwarded-For 同样无法换 IP
+	for i := range 2 {
+		w = login("10.1.2.3:5000", "198.51.100."+strconv.Itoa(20+i), "20240002"+strconv.Itoa(10+i), "wrong-pass-1")
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	}
+	w = login("10.1.2.3:5000", "198.51.100.30", "2024000220", "wrong-pass-1")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+}
+
+// TestPasswordEndpointsLimit 测试重置密码和修改密码同样受失败次数限制
+func TestPasswordEndpointsLimit(t *testing.T) {
+	cleanup(testDB)
+	admin := createUserWithRole("2024000099", "admin-pass-99", "admin")
+	user := createUser("2024000001", "snow-wish-42")
+	cfg := newTestConfig()
+	cfg.LoginLimit.AccountMaxFailures = 3
+	r := newRouter(t, cfg, fakeModerator{}, nil)
+
+	t.Run("穷举重置码", func(t *testing.T) {
+		reset := func(code string) *httptest.ResponseRecorder {
+			return postJSON(r, "/api/password/reset", "", gin.H{"username": "2024000001", "code": code, "newPassword": "reset-wish-44"})
+		}
+		for range 2 {
+			assert.Equal(t, http.StatusBadRequest, reset("AAAA-BBBB-CCCC").Code)
+		}
+		w := reset("AAAA-BBBB-CCCC")
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, float64(apperr.ERROR_TOO_MANY_ATTEMPTS), parseResponse(t, w)["code"])
+		assert.NotEmpty(t, w.Header().Get("Retry-After"))
+
+		// 与登录共用学号的计数：锁定期间正确的重置码和密码都不校验
+		w = postJSON(r, "/api/admin/users/"+strconv.Itoa(int(user.ID))+"/password-reset", createToken(admin.ID), gin.H{})
+		require.Equal(t, http.StatusOK, w.Code)
+		code := parseResponse(t, w)["data"].(map[string]interface{})["code"].(string)
+		assert.Equal(t, http.StatusTooManyRequests, reset(code).Code)
+		assert.Equal(t, http.StatusTooManyRequests, postJSON(r, "/api/login", "", gin.H{"username": "2024000001", "password": "snow-wish-42"}).Code)
+
+		testDB.Exec("DELETE FROM login_lockouts")
+		assert.Equal(t, http.StatusOK, reset(code).Code, "解锁后重置码仍然可用")
+	})
+
+	t.Run("用访问令牌猜原密码", func(t *testing.T) {
+		token := createTokenPair(user.ID).AccessToken
+		change := func(oldPassword string) *httptest.ResponseRecorder {
+			return putJSON(r, "/api/user/password", token, gin.H{"oldPassword": oldPassword, "newPassword": "new-wish-43"})
+		}
+		for range 2 {
+			assert.Equal(t, http.StatusBadRequest, change("wrong-pass-1").Code)
+		}
+		w := change("wrong-pass-1")
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, float64(apperr.ERROR_TOO_MANY_ATTEMPTS), parseResponse(t, w)["code"])
+		assert.Equal(t, http.StatusTooManyRequests, change("reset-wish-44").Code, "锁定期间原密码正确也不能修改")
+
+		w = getJSON("/api/admin/login-locks", createToken(admin.ID))
+		require.Equal(t, http.StatusOK, w.Code)
+		users := parseResponse(t, w)["data"].(map[string]interface{})["users"].([]interface{})
+		require.Len(t, users, 1)
+		assert.Equal(t, strconv.Itoa(int(user.ID)), users[0].(map[string]interface{})["subject"])
+	})
+}
+
+// TestSetupRouterInvalidTrustedProxies 测试可信代理设置失败时拒绝创建路由
+func TestSetupRouterInvalidTrustedProxies(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.Server.TrustedProxies = []string{"not-an-ip"}
+	_, err := router.SetupRouter(cfg, testDB, fakeModerator{}, nil, newPasswordService(cfg))
+	assert.Error(t, err)
+}
@@ -1,27 +1,45 @@
 package handler_test
 
 import (
+	"context"
 	"encoding/json"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/model"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/repository"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/service"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/config"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/database"
 	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/logger"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/migrate"
 	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/util"
 	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/router"
 	"github.com/gin-gonic/gin"
-	"github.com/joho/godotenv"
+	"github.com/stretchr/testify/require"
 	"golang.org/x/crypto/bcrypt"
-	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 )
 
 var (
-	testDB     *gorm.DB    // 全局测试数据库连接
-	testRouter *gin.Engine // 全局测试路由
+	testDB     *gorm.DB       // 全局测试数据库连接
+	testRouter *gin.Engine    // 全局测试路由
+	testConfig *config.Config // 测试路由使用的配置，不读取 .env 和环境变量
 )
 
+// newTestConfig 返回测试用的配置：读写模式 (v1)，固定的 JWT 密钥
+func newTestConfig() *config.Config {
+	cfg := config.Default()
+	cfg.Mode = config.ModeTest
+	cfg.ActiveActivity = "v1"
+	cfg.JWT.Secret = "my_strong_secret_key!"
+	// 登录失败记录保存在数据库中，cleanup 时一并清空，避免前面测试的失败次数触发锁定
+	cfg.LoginLimit.Store = "database"
+	return cfg
+}
+
 // TestMain 设置测试环境
 func TestMain(m *testing.M) {
 	//设置Gin为测试模式（减少不必要的日志）
@@ -29,37 +47,34 @@ func TestMain(m *testing.M) {
 	// 初始化日志系统
 	logger.InitLogger()
 
-	// internal/app/handler 目录，
-	if err := godotenv.Load("../../../.env"); err != nil {
-		logger.Log.Fatalf("加载 .env 文件失败 : %v", err)
-	}
+	testConfig = newTestConfig()
 
-	//连接测试数据库
-	dsn := os.Getenv("MYSQL_TEST_DSN")
-	if dsn == "" {
-		logger.Log.Fatalf("MYSQL_TEST_DSN 环境变量未设置，请检查 .env 文件")
+	//连接测试数据库：设置了 MYSQL_TEST_DSN 时使用 MySQL，否则使用内存 SQLite，不依赖任何外部服务
+	driver, dsn := database.DriverSQLite, "file::memory:?cache=shared"
+	if mysqlDSN := os.Getenv("MYSQL_TEST_DSN"); mysqlDSN != "" {
+		driver, dsn = database.DriverMySQL, mysqlDSN
 	}
 	var err error
-	testDB, err = gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	testDB, err = database.Open(driver, dsn)
 	if err != nil {
 		logger.Log.Fatalf("测试数据库连接失败: %v", err)
 	}
 
-	//自动迁移
-	err = testDB.AutoMigrate(
-		&model.User{},
-		&model.Wish{},
-		&model.Like{},
-		&model.Comment{},
-		&model.WishTag{},
-	)
+	//执行数据库迁移，与生产环境使用同一套脚本
+	migrator, err := migrate.New(testDB)
 	if err != nil {
+		logger.Log.Fatalf("加载迁移脚本失败: %v", err)
+	}
+	if _, err = migrator.Up(context.Background()); err != nil {
 		logger.Log.Fatalf("测试数据库迁移失败: %v", err)
 	}
 
 	//设置测试路由
-	// 因为 .env 已加载, os.Getenv("ACTIVE_ACTIVITY") 现在可以读到 "v1" 了
-	testRouter = router.SetupRouter(testDB)
+	// 注入假审核器，测试不再依赖 SILICONFLOW_API_KEY 和网络
+	testRouter, err = router.SetupRouter(testConfig, testDB, fakeModerator{}, nil, newPasswordService(testConfig))
+	if err != nil {
+		logger.Log.Fatalf("测试路由初始化失败: %v", err)
+	}
 
 	//运行测试
 	exitCode := m.Run()
@@ -69,12 +84,58 @@ func TestMain(m *testing.M) {
 
 	os.Exit(exitCode)
 }
+
+// fakeModerator 是测试用的审核器：包含 "我恨这个世界" 的内容视为违规，
+// 包含 "加我微信" 的内容视为广告，包含 "模棱两可" 的内容视为无法判断（转人工复核），其余放行
+type fakeModerator struct{}
+
+func (fakeModerator) Name() string { return "fake" }
+
+func (f fakeModerator) Check(ctx context.Context, kind service.ContentKind, content string) (service.Verdict, error) {
+	// 复用内置审核器的输入校验（内容为空/过长）
+	if _, err := (service.AllowAllModerator{}).Check(ctx, kind, content); err != nil {
+		return service.Verdict{Violating: true, Provider: f.Name()}, err
+	}
+	if strings.Contains(content, "加我微信") {
+		return service.Verdict{Violating: true, Provider: f.Name(), Category: "广告", Categories: []string{"广告"}, Confidence: 0.9}, nil
+	}
+	if strings.Contains(content, "模棱两可") {
+		return service.Verdict{Violating: true, Provider: f.Name(), Raw: "不确定"}, service.ErrAmbiguous
+	}
+	return service.Verdict{Violating: strings.Contains(content, "我恨这个世界"), Provider: f.Name()}, nil
+}
+
+// newRouter 按 cfg 创建独立的测试路由，测试结束时等待后台发送中的重置码
+func newRouter(t *testing.T, cfg *config.Config, moderator service.Moderator, queue service.ModerationQueue) *gin.Engine {
+	t.Helper()
+	passwords := newPasswordService(cfg)
+	t.Cleanup(passwords.Wait)
+	r, err := router.SetupRouter(cfg, testDB, moderator, queue, passwords)
+	require.NoError(t, err)
+	return r
+}
+
+func newPasswordService(cfg *config.Config) *service.PasswordService {
+	throttle := service.NewThrottleStoreFromConfig(testDB, cfg.LoginLimit)
+	return service.NewPasswordServiceFromConfig(repository.NewGormStore(testDB), cfg.PasswordReset, throttle)
+}
+
 func cleanup(db *gorm.DB) {
 	//删除所有表数据,从外键开始删
+	db.Exec("DELETE FROM remoderation_changes")
+	db.Exec("DELETE FROM remoderation_jobs")
+	db.Exec("DELETE FROM moderation_cache")
+	db.Exec("DELETE FROM moderation_thresholds")
+	db.Exec("DELETE FROM moderation_records")
 	db.Exec("DELETE FROM wish_tags")
 	db.Exec("DELETE FROM comments")
 	db.Exec("DELETE FROM likes")
 	db.Exec("DELETE FROM wishes")
+	db.Exec("DELETE FROM refresh_tokens")
+	db.Exec("DELETE FROM sessions")
+	db.Exec("DELETE FROM password_resets")
+	db.Exec("DELETE FROM login_failures")
+	db.Exec("DELETE FROM login_lockouts")
 	db.Exec("DELETE FROM users")
 }
 
@@ -100,13 +161,18 @@ func createUser(username, password string) *model.User {
 	return &user
 }
 
+// createToken 为用户开启一个登录会话，返回访问令牌
 func createToken(userID uint) string {
-	os.Setenv("JWT_SECRET", "my_strong_secret_key!")
-	token, err := util.GenerateToken(userID)
+	return createTokenPair(userID).AccessToken
+}
+
+func createTokenPair(userID uint) *service.TokenPair {
+	tokens := service.NewTokenService(repository.NewGormStore(testDB), util.NewJWT(testConfig.JWT.Secret, testConfig.JWT.TTL), testConfig.JWT.RefreshTTL)
+	pair, err := tokens.Issue(context.Background(), userID, service.ClientInfo{UserAgent: "go-test"})
 	if err != nil {
 		logger.Log.Fatalf("生成测试Token失败: %v", err)
 	}
-	return token
+	return pair
 }
 
 func createUserWithRole(username, password, role string) *model.User {
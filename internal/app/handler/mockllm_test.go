@@ -0,0 +1,65 @@
+package handler_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/model"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/service"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/config"
+	apperr "github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/err"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/mockllm"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestModerationWithMockLLM 用假大模型服务走一遍真实的审核链：配置 → LLMModerator → go-openai → HTTP
+func TestModerationWithMockLLM(t *testing.T) {
+	srv := mockllm.NewTestServer(mockllm.DefaultRules()...)
+	defer srv.Close()
+	cfg := config.Default().Moderation
+	cfg.LLM.APIKey = "test"
+	cfg.LLM.BaseURL = srv.BaseURL()
+	cfg.LLM.MaxRetries = 0
+	cfg.Providers = []string{"llm"}
+	cfg.Prefilter = false
+	cfg.CacheSize = 0
+	cfg.BreakerThreshold = 0
+
+	moderator := service.NewThresholdModerator(service.NewModeratorFromConfig(testDB, cfg), service.NewThresholdStore(testDB, cfg.ThresholdRefresh))
+	r := newRouter(t, testConfig, moderator, nil)
+
+	cleanup(testDB)
+	user := createUser("mockllm_user", "pass")
+	token := createToken(user.ID)
+
+	w := postJSON(r, "/api/wishes", token, gin.H{"content": "希望期末考试顺利通过"})
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = postJSON(r, "/api/wishes", token, gin.H{"content": "想赚钱的加我微信"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, float64(apperr.ERROR_CONTENT_AD), parseResponse(t, w)["code"])
+
+	w = postJSON(r, "/api/wishes", token, gin.H{"content": "这句话模棱两可"})
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, model.ModerationNeedsReview, parseResponse(t, w)["data"].(map[string]interface{})["moderationStatus"])
+
+	var record model.ModerationRecord
+	require.NoError(t, testDB.Where("verdict = ?", model.ModerationRejected).First(&record).Error)
+	assert.Equal(t, "llm", record.Provider)
+	assert.Equal(t, "广告", record.Category)
+	assert.Contains(t, record.RawAnswer, `"safe":false`)
+	assert.Len(t, srv.Requests(), 3)
+
+	// 审核服务出错时愿望按 closed 策略拒绝，昵称按 open 策略放行
+	require.NoError(t, srv.SetRules(mockllm.Rule{Status: http.StatusServiceUnavailable}))
+	w = postJSON(r, "/api/wishes", token, gin.H{"content": "希望明天不下雨"})
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	resp := parseResponse(t, w)
+	assert.Equal(t, float64(apperr.ERROR_SERVER_UNAVAILABLE), resp["code"])
+	assert.Equal(t, service.ErrModeratorUnavailable.Error(), resp["data"].(map[string]interface{})["error"], "不把上游的错误信息返回给客户端")
+
+	w = putJSON(r, "/api/user", token, gin.H{"nickname": "新昵称"})
+	assert.Equal(t, http.StatusOK, w.Code)
+}
@@ -0,0 +1,235 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/model"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/service"
+	apperr "github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/err"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/logger"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// rejectMessage 生成审核不通过时返回给前端的提示
+// 审核器给出了违规类别时返回更精确的提示（如 "内容包含辱骂信息，请修改"），否则返回 fallback
+func rejectMessage(verdict service.Verdict, subject, fallback string) string {
+	if verdict.Category == "" {
+		return fallback
+	}
+	return fmt.Sprintf("%s包含%s信息，请修改", subject, verdict.Category)
+}
+
+// categoryCodes 把违规类别映射为返回给前端的错误码，前端据此给出针对性的提示
+var categoryCodes = map[string]int{
+	"色情": apperr.ERROR_CONTENT_PORN,
+	"暴力": apperr.ERROR_CONTENT_VIOLENCE,
+	"辱骂": apperr.ERROR_CONTENT_ABUSE,
+	"政治": apperr.ERROR_CONTENT_POLITICS,
+	"广告": apperr.ERROR_CONTENT_AD,
+}
+
+// rejectCode 返回审核不通过时的错误码：没有类别时沿用 ERROR_PARAM_INVALID，未知类别返回 ERROR_CONTENT_REJECTED
+func rejectCode(verdict service.Verdict) int {
+	if verdict.Category == "" {
+		return apperr.ERROR_PARAM_INVALID
+	}
+	if code, ok := categoryCodes[verdict.Category]; ok {
+		return code
+	}
+	return apperr.ERROR_CONTENT_REJECTED
+}
+
+// moderationOutcome 是内容落库前的审核结果
+type moderationOutcome struct {
+	verdict service.Verdict
+	status  string                  // 内容落库时的审核状态
+	record  *model.ModerationRecord // 同步审核的审核记录，等内容落库拿到 ID 后由 audit 写入
+}
+
+// contentToSave 返回应当落库的内容（有打码结果时用打码结果）
+func (o moderationOutcome) contentToSave(original string) string {
+	return o.verdict.ContentToSave(original)
+}
+
+// audit 补全审核记录的内容 ID 与作者并写入数据库；异步审核时记录由后台协程写入，这里什么都不做
+func (o moderationOutcome) audit(db *gorm.DB, subjectID, userID uint) {
+	if o.record == nil {
+		return
+	}
+	o.record.SubjectID = subjectID
+	o.record.UserID = userID
+	service.SaveModerationRecord(db, o.record)
+}
+
+// moderateContent 在内容落库前按 kind 对应的审核策略完成审核，scene 用于日志（如 "创建愿望"）
+// 同步模式（queue 为 nil，或审核的是昵称/简介/标签）：直接调用审核器，通过时返回 approved，
+// 审核器无法判断时返回 needs_review（内容照常落库，进入人工复核队列）；
+// 异步模式：只做输入校验（内容为空/过长），返回 pending，落库后由调用方交给后台审核；
+// 策略关闭了审核（Moderated 为 false）时只做输入校验，直接返回 approved 且不写审核记录
+// 返回 ok=false 时已经写入了错误响应
+func moderateContent(c *gin.Context, db *gorm.DB, moderator service.Moderator, queue service.ModerationQueue, kind service.ContentKind, content, scene string, userID uint) (outcome moderationOutcome, ok bool) {
+	policy := service.PolicyFor(kind)
+	target := kind.Target()
+	subject, fallback := policy.Name, "内容未通过审核"
+	if target == service.TargetNickname {
+		fallback = "昵称包含不当内容，请修改"
+	}
+
+	async := queue != nil && (target == service.TargetWish || target == service.TargetComment)
+	if async || !policy.Moderated {
+		if err := service.ValidateContent(c.Request.Context(), kind, content); err != nil {
+			logger.FromContext(c.Request.Context()).Warnw(scene+"被拒绝：内容校验失败", "userID", userID, "error", err)
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":      apperr.ERROR_PARAM_INVALID,
+				"requestId": c.GetString("requestID"),
+				"message":   apperr.GetMsg(apperr.ERROR_PARAM_INVALID),
+				"data":      gin.H{"error": err.Error()},
+			})
+			return outcome, false
+		}
+		outcome.status = model.ModerationApproved
+		if async && policy.Moderated {
+			outcome.status = model.ModerationPending
+		}
+		return outcome, true
+	}
+
+	start := time.Now()
+	verdict, aiErr := moderator.Check(c.Request.Context(), kind, content)
+	latency := time.Since(start)
+	outcome.verdict = verdict
+	if errors.Is(aiErr, service.ErrAmbiguous) {
+		// 审核器无法判断：不直接拒绝，先落库再交给管理员复核
+		logger.FromContext(c.Request.Context()).Infow(scene+"：审核器无法判断，转人工复核", "userID", userID, "provider", verdict.Provider)
+		outcome.status = model.ModerationNeedsReview
+		outcome.record = service.NewModerationRecord(target, 0, userID, content, verdict, outcome.status, aiErr.Error(), latency)
+		return outcome, true
+	}
+	if errors.Is(aiErr, service.ErrModeratorUnavailable) {
+		// 审核服务不可用是服务端的问题：上游的错误信息只写日志，不返回给客户端
+		logger.FromContext(c.Request.Context()).Errorw(scene+"被拒绝：审核服务不可用", "userID", userID, "error", aiErr)
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"code":      apperr.ERROR_SERVER_UNAVAILABLE,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_SERVER_UNAVAILABLE),
+			"data":      gin.H{"error": service.ErrModeratorUnavailable.Error()},
+		})
+		return outcome, false
+	}
+	if aiErr != nil {
+		// 内容校验错误（如内容为空/过长），把错误信息返回给客户端
+		logger.FromContext(c.Request.Context()).Warnw(scene+"被拒绝：内容审核出错", "userID", userID, "error", aiErr)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":      apperr.ERROR_PARAM_INVALID,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_PARAM_INVALID),
+			"data":      gin.H{"error": aiErr.Error()},
+		})
+		return outcome, false
+	}
+	if verdict.Violating {
+		message := rejectMessage(verdict, subject, fallback)
+		logger.FromContext(c.Request.Context()).Infow(scene+"被拒绝：审核判定不安全", "userID", userID, "provider", verdict.Provider, "category", verdict.Category)
+		// 被拒绝的内容不会落库，审核记录里只保留内容快照
+		service.SaveModerationRecord(db, service.NewModerationRecord(target, 0, userID, content, verdict, model.ModerationRejected, message, latency))
+		code := rejectCode(verdict)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":      code,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(code),
+			"data":      gin.H{"error": message, "category": verdict.Category},
+		})
+		return outcome, false
+	}
+	outcome.status = model.ModerationApproved
+	outcome.record = service.NewModerationRecord(target, 0, userID, content, verdict, outcome.status, "", latency)
+	return outcome, true
+}
+
+// GetWishModeration 查询自己愿望的审核状态，供前端在异步审核模式下轮询
+// GET /api/wishes/:id/moderation
+func GetWishModeration(c *gin.Context, db *gorm.DB) {
+	getModeration(c, db, service.TargetWish)
+}
+
+// GetCommentModeration 查询自己评论的审核状态
+// GET /api/comments/:id/moderation
+func GetCommentModeration(c *gin.Context, db *gorm.DB) {
+	getModeration(c, db, service.TargetComment)
+}
+
+func getModeration(c *gin.Context, db *gorm.DB, target service.ModerationTarget) {
+	id64, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":      apperr.ERROR_PARAM_INVALID,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_PARAM_INVALID),
+			"data":      gin.H{"error": "ID 无效"},
+		})
+		return
+	}
+	userIDi, ok := c.Get("userID")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":      apperr.ERROR_UNAUTHORIZED,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_UNAUTHORIZED),
+			"data":      gin.H{},
+		})
+		return
+	}
+	userID := userIDi.(uint)
+
+	var (
+		ownerID uint
+		status  string
+		reason  string
+		code    = apperr.ERROR_WISH_NOT_FOUND
+	)
+	if target == service.TargetWish {
+		var wish model.Wish
+		err = db.First(&wish, id64).Error
+		ownerID, status, reason = wish.UserID, wish.ModerationStatus, wish.ModerationReason
+	} else {
+		var comment model.Comment
+		err = db.First(&comment, id64).Error
+		ownerID, status, reason = comment.UserID, comment.ModerationStatus, comment.ModerationReason
+		code = apperr.ERROR_COMMENT_NOT_FOUND
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.FromContext(c.Request.Context()).Errorw("查询审核状态失败", "target", target, "id", id64, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":      apperr.ERROR_SERVER_ERROR,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_SERVER_ERROR),
+			"data":      gin.H{},
+		})
+		return
+	}
+	// 不存在与不是自己的内容返回同样的结果，避免泄露他人待审核内容是否存在
+	if errors.Is(err, gorm.ErrRecordNotFound) || ownerID != userID {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":      code,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(code),
+			"data":      gin.H{},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    apperr.SUCCESS,
+		"message": apperr.GetMsg(apperr.SUCCESS),
+		"data": gin.H{
+			"id":               id64,
+			"moderationStatus": status,
+			"moderationReason": reason,
+		},
+	})
+}
@@ -0,0 +1,233 @@
+package handler_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/model"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/service"
+	apperr "github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/err"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// gatedModerator 在 release 关闭前阻塞，用于观察 pending 状态
+type gatedModerator struct {
+	fakeModerator
+	release chan struct{}
+}
+
+func (g gatedModerator) Check(ctx context.Context, kind service.ContentKind, content string) (service.Verdict, error) {
+	select {
+	case <-g.release:
+	case <-ctx.Done():
+		return service.Verdict{Violating: true}, ctx.Err()
+	}
+	return g.fakeModerator.Check(ctx, kind, content)
+}
+
+// downModerator 模拟一直不可用的审核服务
+type downModerator struct{}
+
+func (downModerator) Name() string { return "down" }
+
+func (downModerator) Check(ctx context.Context, kind service.ContentKind, content string) (service.Verdict, error) {
+	return service.Verdict{Violating: true}, fmt.Errorf("%w: 503", service.ErrModeratorUnavailable)
+}
+
+// newAsyncRouter 创建开启异步审核的测试路由
+func newAsyncRouter(t *testing.T, moderator service.Moderator) *gin.Engine {
+	worker := service.NewModerationWorker(testDB, moderator, nil, service.ModerationWorkerConfig{
+		Workers:      2,
+		MaxRetries:   2,
+		RetryBackoff: 10 * time.Millisecond,
+	})
+	worker.Start()
+	t.Cleanup(worker.Stop)
+	return newRouter(t, testConfig, moderator, worker)
+}
+
+func postJSON(r *gin.Engine, path, token string, payload gin.H) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(payload)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", path, bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func wishStatus(id uint) string {
+	var wish model.Wish
+	testDB.First(&wish, id)
+	return wish.ModerationStatus
+}
+
+func TestAsyncModeration(t *testing.T) {
+	t.Run("愿望先以 pending 保存，审核通过后出现在公共墙", func(t *testing.T) {
+		cleanup(testDB)
+		gate := gatedModerator{release: make(chan struct{})}
+		r := newAsyncRouter(t, gate)
+		user := createUser("async_author", "pass")
+		token := createToken(user.ID)
+
+		w := postJSON(r, "/api/wishes", token, gin.H{"content": "希望明天不下雨"})
+		require.Equal(t, http.StatusOK, w.Code)
+		data := parseResponse(t, w)["data"].(map[string]interface{})
+		assert.Equal(t, model.ModerationPending, data["moderationStatus"])
+		wishID := uint(data["wishID"].(float64))
+
+		// pending 时公共墙不可见
+		w = httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/wishes/public", nil)
+		r.ServeHTTP(w, req)
+		publicData := parseResponse(t, w)["data"].(map[string]interface{})
+		assert.Equal(t, float64(0), publicData["total"])
+
+		// 作者在个人列表中能看到 pending 的愿望
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("GET", "/api/wishes/me", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		r.ServeHTTP(w, req)
+		myWishes := parseResponse(t, w)["data"].(map[string]interface{})["wishes"].([]interface{})
+		require.Len(t, myWishes, 1)
+		assert.Equal(t, model.ModerationPending, myWishes[0].(map[string]interface{})["moderationStatus"])
+
+		close(gate.release)
+		assert.Eventually(t, func() bool { return wishStatus(wishID) == model.ModerationApproved }, 2*time.Second, 10*time.Millisecond)
+
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("GET", "/api/wishes/public", nil)
+		r.ServeHTTP(w, req)
+		publicData = parseResponse(t, w)["data"].(map[string]interface{})
+		assert.Equal(t, float64(1), publicData["total"])
+	})
+
+	t.Run("违规内容被后台拒绝，作者可轮询到结论", func(t *testing.T) {
+		cleanup(testDB)
+		r := newAsyncRouter(t, fakeModerator{})
+		user := createUser("async_rejected", "pass")
+		token := createToken(user.ID)
+
+		w := postJSON(r, "/api/wishes", token, gin.H{"content": "我恨这个世界"})
+		require.Equal(t, http.StatusOK, w.Code)
+		wishID := uint(parseResponse(t, w)["data"].(map[string]interface{})["wishID"].(float64))
+		assert.Eventually(t, func() bool { return wishStatus(wishID) == model.ModerationRejected }, 2*time.Second, 10*time.Millisecond)
+
+		w = httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/api/wishes/%d/moderation", wishID), nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		data := parseResponse(t, w)["data"].(map[string]interface{})
+		assert.Equal(t, model.ModerationRejected, data["moderationStatus"])
+		assert.Equal(t, "内容未通过审核", data["moderationReason"])
+
+		// 其他用户查询时等同于不存在
+		other := createUser("async_other", "pass")
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("GET", fmt.Sprintf("/api/wishes/%d/moderation", wishID), nil)
+		req.Header.Set("Authorization", "Bearer "+createToken(other.ID))
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("审核服务持续不可用时重试后转人工复核", func(t *testing.T) {
+		cleanup(testDB)
+		r := newAsyncRouter(t, downModerator{})
+		user := createUser("async_down", "pass")
+		token := createToken(user.ID)
+
+		w := postJSON(r, "/api/wishes", token, gin.H{"content": "希望早日毕业"})
+		require.Equal(t, http.StatusOK, w.Code)
+		wishID := uint(parseResponse(t, w)["data"].(map[string]interface{})["wishID"].(float64))
+		assert.Eventually(t, func() bool { return wishStatus(wishID) == model.ModerationNeedsReview }, 2*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("评论审核通过后才计入评论数并出现在列表", func(t *testing.T) {
+		cleanup(testDB)
+		gate := gatedModerator{release: make(chan struct{})}
+		r := newAsyncRouter(t, gate)
+		user := createUser("async_commenter", "pass")
+		token := createToken(user.ID)
+		wish := createWish(user.ID, "已经审核过的愿望")
+
+		w := postJSON(r, "/api/comments", token, gin.H{"wishId": wish.ID, "content": "加油"})
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, model.ModerationPending, parseResponse(t, w)["data"].(map[string]interface{})["moderationStatus"])
+
+		var pending model.Wish
+		testDB.First(&pending, wish.ID)
+		assert.Equal(t, 0, pending.CommentCount)
+
+		close(gate.release)
+		assert.Eventually(t, func() bool {
+			var updated model.Wish
+			testDB.First(&updated, wish.ID)
+			return updated.CommentCount == 1
+		}, 2*time.Second, 10*time.Millisecond)
+
+		w = httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/api/wishes/%d/comments", wish.ID), nil)
+		r.ServeHTTP(w, req)
+		assert.Equal(t, float64(apperr.SUCCESS), parseResponse(t, w)["code"])
+		assert.Equal(t, float64(1), parseResponse(t, w)["data"].(map[string]interface{})["total"])
+	})
+
+	t.Run("内容为空时仍在请求内直接拒绝", func(t *testing.T) {
+		cleanup(testDB)
+		r := newAsyncRouter(t, fakeModerator{})
+		user := createUser("async_empty", "pass")
+
+		w := postJSON(r, "/api/wishes", createToken(user.ID), gin.H{"content": "   "})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		data := parseResponse(t, w)["data"].(map[string]interface{})
+		assert.Equal(t, "内容不能为空", data["error"])
+	})
+}
+
+func TestRejectCategoryCode(t *testing.T) {
+	cleanup(testDB)
+	user := createUser("category_author", "pass")
+
+	w := postJSON(testRouter, "/api/wishes", createToken(user.ID), gin.H{"content": "想要兼职的加我微信"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	resp := parseResponse(t, w)
+	assert.Equal(t, float64(apperr.ERROR_CONTENT_AD), resp["code"])
+	data := resp["data"].(map[string]interface{})
+	assert.Equal(t, "广告", data["category"])
+	assert.Equal(t, "内容包含广告信息，请修改", data["error"])
+}
+
+func TestDBVerdictStore(t *testing.T) {
+	cleanup(testDB)
+	store := service.NewDBVerdictStore(testDB)
+	verdict := service.Verdict{Violating: true, Provider: "llm", Category: "辱骂", Categories: []string{"辱骂", "暴力"}, Confidence: 0.9, Raw: "{}"}
+
+	store.Save("k1", verdict, time.Now().Add(time.Hour))
+	got, _, ok := store.Load("k1")
+	require.True(t, ok)
+	assert.Equal(t, verdict, got)
+
+	// 重复写入同一个 key 时覆盖
+	verdict.Violating = false
+	store.Save("k1", verdict, time.Now().Add(time.Hour))
+	got, _, ok = store.Load("k1")
+	require.True(t, ok)
+	assert.False(t, got.Violating)
+
+	// 过期的结论读不到，并会被清理
+	store.Save("k2", verdict, time.Now().Add(-time.Minute))
+	_, _, ok = store.Load("k2")
+	assert.False(t, ok)
+	n, err := store.PurgeExpired()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+}
@@ -0,0 +1,264 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/service"
+	apperr "github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/err"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+type ChangePasswordRequest struct {
+	OldPassword string `json:"oldPassword" binding:"required"`
+	NewPassword string `json:"newPassword" binding:"required"`
+}
+
+type ForgotPasswordRequest struct {
+	Username string `json:"username" binding:"required"`
+}
+
+type ResetPasswordRequest struct {
+	Username    string `json:"username" binding:"required"`
+	Code        string `json:"code" binding:"required"`
+	NewPassword string `json:"newPassword" binding:"required"`
+}
+
+// ChangePassword 校验原密码后修改密码，其他设备随之退出登录，当前设备保持登录；
+// 原密码错误次数过多时按用户锁定，防止用盗取的访问令牌猜原密码
+// PUT /api/user/password
+func ChangePassword(c *gin.Context, passwords *service.PasswordService, limiter *service.LoginLimiter) {
+	var req ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":      apperr.ERROR_PARAM_INVALID,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_PARAM_INVALID),
+			"data":      gin.H{"error": "原密码和新密码均不能为空"},
+		})
+		return
+	}
+
+	userID := c.GetUint("userID")
+	wait, err := limiter.CheckUser(c.Request.Context(), userID)
+	if err != nil {
+		logger.FromContext(c.Request.Context()).Errorw("查询修改密码限制失败", "error", err)
+	}
+	if wait > 0 {
+		respondTooManyAttempts(c, wait)
+		return
+	}
+	err = passwords.ChangePassword(c.Request.Context(), userID, c.GetString("sessionID"), req.OldPassword, req.NewPassword)
+	if err != nil {
+		if errors.Is(err, service.ErrWrongPassword) {
+			wait, failErr := limiter.FailUser(c.Request.Context(), userID)
+			if failErr != nil {
+				logger.FromContext(c.Request.Context()).Errorw("记录原密码错误次数失败", "error", failErr)
+			}
+			if wait > 0 {
+				respondTooManyAttempts(c, wait)
+				return
+			}
+		}
+		if respondPasswordError(c, err) {
+			return
+		}
+		if errors.Is(err, service.ErrUserNotFound) {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"code":      apperr.ERROR_UNAUTHORIZED,
+				"requestId": c.GetString("requestID"),
+				"message":   apperr.GetMsg(apperr.ERROR_UNAUTHORIZED),
+				"data":      gin.H{},
+			})
+			return
+		}
+		logger.FromContext(c.Request.Context()).Errorw("修改密码失败", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":      apperr.ERROR_SERVER_ERROR,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_SERVER_ERROR),
+			"data":      gin.H{},
+		})
+		return
+	}
+
+	if err := limiter.SucceedUser(c.Request.Context(), userID); err != nil {
+		logger.FromContext(c.Request.Context()).Errorw("清除原密码错误次数失败", "error", err)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code":    apperr.SUCCESS,
+		"message": apperr.GetMsg(apperr.SUCCESS),
+		"data":    gin.H{},
+	})
+}
+
+// ForgotPassword 自助申请重置码，重置码在后台签发并通过配置的通知渠道发送给用户本人。
+// 无论学号是否已注册都立即返回成功，避免被用来探测学号；同一学号或 IP 申请过于频繁时返回 429
+// POST /api/password/forgot
+func ForgotPassword(c *gin.Context, passwords *service.PasswordService) {
+	var req ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":      apperr.ERROR_PARAM_INVALID,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_PARAM_INVALID),
+			"data":      gin.H{"error": "学号不能为空"},
+		})
+		return
+	}
+
+	wait, err := passwords.RequestReset(c.Request.Context(), req.Username, c.ClientIP())
+	if err != nil {
+		if errors.Is(err, service.ErrResetUnavailable) {
+			c.JSON(http.StatusNotImplemented, gin.H{
+				"code":      apperr.ERROR_RESET_UNAVAILABLE,
+				"requestId": c.GetString("requestID"),
+				"message":   apperr.GetMsg(apperr.ERROR_RESET_UNAVAILABLE),
+				"data":      gin.H{},
+			})
+			return
+		}
+		logger.FromContext(c.Request.Context()).Errorw("受理找回密码申请失败", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":      apperr.ERROR_SERVER_ERROR,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_SERVER_ERROR),
+			"data":      gin.H{},
+		})
+		return
+	}
+	if wait > 0 {
+		respondTooManyAttempts(c, wait)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    apperr.SUCCESS,
+		"message": "如果该学号已注册，重置码已发送",
+		"data":    gin.H{},
+	})
+}
+
+// ResetPassword 使用重置码设置新密码，该用户的全部设备随之退出登录，需要用新密码重新登录；
+// 重置码错误与登录失败共用学号和 IP 的计数，防止穷举重置码
+// POST /api/password/reset
+func ResetPassword(c *gin.Context, passwords *service.PasswordService, limiter *service.LoginLimiter) {
+	var req ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":      apperr.ERROR_PARAM_INVALID,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_PARAM_INVALID),
+			"data":      gin.H{"error": "学号、重置码和新密码均不能为空"},
+		})
+		return
+	}
+
+	ip := c.ClientIP()
+	wait, err := limiter.Check(c.Request.Context(), req.Username, ip)
+	if err != nil {
+		logger.FromContext(c.Request.Context()).Errorw("查询登录限制失败", "error", err)
+	}
+	if wait > 0 {
+		respondTooManyAttempts(c, wait)
+		return
+	}
+	if err := passwords.ResetWithCode(c.Request.Context(), req.Username, req.Code, req.NewPassword); err != nil {
+		if errors.Is(err, service.ErrInvalidResetCode) {
+			wait, failErr := limiter.Fail(c.Request.Context(), req.Username, ip)
+			if failErr != nil {
+				logger.FromContext(c.Request.Context()).Errorw("记录重置码错误次数失败", "error", failErr)
+			}
+			if wait > 0 {
+				respondTooManyAttempts(c, wait)
+				return
+			}
+		}
+		if respondPasswordError(c, err) {
+			return
+		}
+		logger.FromContext(c.Request.Context()).Errorw("重置密码失败", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":      apperr.ERROR_SERVER_ERROR,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_SERVER_ERROR),
+			"data":      gin.H{},
+		})
+		return
+	}
+
+	if err := limiter.Succeed(c.Request.Context(), req.Username); err != nil {
+		logger.FromContext(c.Request.Context()).Errorw("清除登录失败次数失败", "error", err)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code":    apperr.SUCCESS,
+		"message": apperr.GetMsg(apperr.SUCCESS),
+		"data":    gin.H{},
+	})
+}
+
+// IssueResetCode 管理员为用户签发重置码，由管理员转告用户；该用户之前未使用的重置码作废
+// POST /api/admin/users/:id/password-reset
+func IssueResetCode(c *gin.Context, passwords *service.PasswordService) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":      apperr.ERROR_PARAM_INVALID,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_PARAM_INVALID),
+			"data":      gin.H{"error": "用户ID无效"},
+		})
+		return
+	}
+
+	adminID := c.GetUint("userID")
+	code, expiresAt, err := passwords.IssueResetCode(c.Request.Context(), uint(id), &adminID, service.ResetChannelAdmin)
+	if err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"code":      apperr.ERROR_PARAM_INVALID,
+				"requestId": c.GetString("requestID"),
+				"message":   apperr.GetMsg(apperr.ERROR_PARAM_INVALID),
+				"data":      gin.H{"error": err.Error()},
+			})
+			return
+		}
+		logger.FromContext(c.Request.Context()).Errorw("签发重置码失败", "targetUserID", id, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":      apperr.ERROR_SERVER_ERROR,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_SERVER_ERROR),
+			"data":      gin.H{},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    apperr.SUCCESS,
+		"message": apperr.GetMsg(apperr.SUCCESS),
+		"data":    gin.H{"code": code, "expiresAt": expiresAt},
+	})
+}
+
+// respondPasswordError 处理密码强度不足、原密码错误和重置码无效，已响应时返回 true
+func respondPasswordError(c *gin.Context, err error) bool {
+	code := apperr.ERROR_WEAK_PASSWORD
+	switch {
+	case errors.Is(err, service.ErrWeakPassword), errors.Is(err, service.ErrEmptyPassword):
+	case errors.Is(err, service.ErrWrongPassword):
+		code = apperr.ERROR_WRONG_PASSWORD
+	case errors.Is(err, service.ErrInvalidResetCode):
+		code = apperr.ERROR_RESET_CODE_INVALID
+	default:
+		return false
+	}
+	c.JSON(http.StatusBadRequest, gin.H{
+		"code":      code,
+		"requestId": c.GetString("requestID"),
+		"message":   apperr.GetMsg(code),
+		"data":      gin.H{"error": err.Error()},
+	})
+	return true
+}
@@ -0,0 +1,170 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/service"
+	apperr "github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/err"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestChangePassword 测试修改密码
+func TestChangePassword(t *testing.T) {
+	cleanup(testDB)
+	user := createUser("2024000001", "snow-wish-42")
+	current, other := createTokenPair(user.ID), createTokenPair(user.ID)
+
+	t.Run("原密码错误", func(t *testing.T) {
+		w := putJSON(testRouter, "/api/user/password", current.AccessToken, gin.H{"oldPassword": "wrong-pass-1", "newPassword": "new-wish-43"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, float64(apperr.ERROR_WRONG_PASSWORD), parseResponse(t, w)["code"])
+	})
+
+	t.Run("新密码强度不足", func(t *testing.T) {
+		w := putJSON(testRouter, "/api/user/password", current.AccessToken, gin.H{"oldPassword": "snow-wish-42", "newPassword": "12345678"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		resp := parseResponse(t, w)
+		assert.Equal(t, float64(apperr.ERROR_WEAK_PASSWORD), resp["code"])
+		assert.NotEmpty(t, resp["data"].(map[string]interface{})["error"])
+	})
+
+	t.Run("修改成功后其他设备退出登录", func(t *testing.T) {
+		w := putJSON(testRouter, "/api/user/password", current.AccessToken, gin.H{"oldPassword": "snow-wish-42", "newPassword": "new-wish-43"})
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, http.StatusOK, getJSON("/api/user/me", current.AccessToken).Code, "当前设备保持登录")
+		assert.Equal(t, http.StatusUnauthorized, getJSON("/api/user/me", other.AccessToken).Code)
+
+		w = postJSON(testRouter, "/api/login", "", gin.H{"username": "2024000001", "password": "new-wish-43"})
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("未登录", func(t *testing.T) {
+		w := putJSON(testRouter, "/api/user/password", "", gin.H{"oldPassword": "new-wish-43", "newPassword": "another-wish-44"})
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
+
+// TestAdminPasswordReset 测试管理员签发重置码与使用重置码重置密码
+func TestAdminPasswordReset(t *testing.T) {
+	cleanup(testDB)
+	admin := createUserWithRole("2024000099", "admin-pass-99", "admin")
+	user := createUser("2024000001", "snow-wish-42")
+	session := createTokenPair(user.ID)
+	issue := func(id string, token string) *httptest.ResponseRecorder {
+		return postJSON(testRouter, "/api/admin/users/"+id+"/password-reset", token, gin.H{})
+	}
+
+	t.Run("普通用户不能签发重置码", func(t *testing.T) {
+		w := issue(strconv.Itoa(int(user.ID)), session.AccessToken)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("用户不存在", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, issue("99999", createToken(admin.ID)).Code)
+		assert.Equal(t, http.StatusBadRequest, issue("abc", createToken(admin.ID)).Code)
+	})
+
+	t.Run("使用重置码重置密码", func(t *testing.T) {
+		w := issue(strconv.Itoa(int(user.ID)), createToken(admin.ID))
+		require.Equal(t, http.StatusOK, w.Code)
+		data := parseResponse(t, w)["data"].(map[string]interface{})
+		code, _ := data["code"].(string)
+		require.NotEmpty(t, code)
+		assert.NotEmpty(t, data["expiresAt"])
+
+		// 重置码与学号不匹配
+		w = postJSON(testRouter, "/api/password/reset", "", gin.H{"username": "2024000099", "code": code, "newPassword": "reset-wish-44"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, float64(apperr.ERROR_RESET_CODE_INVALID), parseResponse(t, w)["code"])
+
+		w = postJSON(testRouter, "/api/password/reset", "", gin.H{"username": "2024000001", "code": code, "newPassword": "reset-wish-44"})
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, http.StatusUnauthorized, getJSON("/api/user/me", session.AccessToken).Code, "重置后全部设备退出登录")
+		w = postJSON(testRouter, "/api/login", "", gin.H{"username": "2024000001", "password": "reset-wish-44"})
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		// 重置码只能使用一次
+		w = postJSON(testRouter, "/api/password/reset", "", gin.H{"username": "2024000001", "code": code, "newPassword": "again-wish-45"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, float64(apperr.ERROR_RESET_CODE_INVALID), parseResponse(t, w)["code"])
+	})
+
+	t.Run("参数缺失", func(t *testing.T) {
+		w := postJSON(testRouter, "/api/password/reset", "", gin.H{"username": "2024000001"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, float64(apperr.ERROR_PARAM_INVALID), parseResponse(t, w)["code"])
+	})
+}
+
+// TestForgotPassword 测试自助找回密码
+func TestForgotPassword(t *testing.T) {
+	cleanup(testDB)
+	createUser("2024000001", "snow-wish-42")
+
+	t.Run("未配置通知渠道", func(t *testing.T) {
+		w := postJSON(testRouter, "/api/password/forgot", "", gin.H{"username": "2024000001"})
+		assert.Equal(t, http.StatusNotImplemented, w.Code)
+		assert.Equal(t, float64(apperr.ERROR_RESET_UNAVAILABLE), parseResponse(t, w)["code"])
+	})
+
+	t.Run("通过 webhook 发送重置码", func(t *testing.T) {
+		sent := make(chan service.ResetCodeMessage, 4)
+		push := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var msg service.ResetCodeMessage
+			if err := json.NewDecoder(r.Body).Decode(&msg); err == nil {
+				sent <- msg
+			}
+		}))
+		defer push.Close()
+		cfg := newTestConfig()
+		cfg.PasswordReset.WebhookURL = push.URL
+		r := newRouter(t, cfg, fakeModerator{}, nil)
+
+		// 已注册与未注册的学号响应相同，重置码在后台发送
+		registered := postJSON(r, "/api/password/forgot", "", gin.H{"username": "2024000001"})
+		unknown := postJSON(r, "/api/password/forgot", "", gin.H{"username": "2024000009"})
+		assert.Equal(t, http.StatusOK, registered.Code)
+		assert.Equal(t, registered.Code, unknown.Code)
+		assert.Equal(t, parseResponse(t, registered)["message"], parseResponse(t, unknown)["message"])
+
+		var msg service.ResetCodeMessage
+		select {
+		case msg = <-sent:
+		case <-time.After(5 * time.Second):
+			t.Fatal("没有发送重置码")
+		}
+		assert.Equal(t, "2024000001", msg.Username)
+		select {
+		case other := <-sent:
+			t.Fatalf("只给已注册的用户发送，却发给了 %s", other.Username)
+		case <-time.After(100 * time.Millisecond):
+		}
+
+		w := postJSON(r, "/api/password/reset", "", gin.H{"username": "2024000001", "code": msg.Code, "newPassword": "self-wish-47"})
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("申请过于频繁", func(t *testing.T) {
+		push := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		defer push.Close()
+		cfg := newTestConfig()
+		cfg.PasswordReset.WebhookURL = push.URL
+		cfg.PasswordReset.AccountRequestLimit = 2
+		r := newRouter(t, cfg, fakeModerator{}, nil)
+
+		for range 2 {
+			assert.Equal(t, http.StatusOK, postJSON(r, "/api/password/forgot", "", gin.H{"username": "2024000002"}).Code)
+		}
+		w := postJSON(r, "/api/password/forgot", "", gin.H{"username": "2024000002"})
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, float64(apperr.ERROR_TOO_MANY_ATTEMPTS), parseResponse(t, w)["code"])
+		assert.Equal(t, strconv.Itoa(int(time.Hour.Seconds())), w.Header().Get("Retry-After"))
+	})
+}
@@ -0,0 +1,93 @@
+package handler_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/model"
+	apperr "github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/err"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func wishTagNames(wishID uint) []string {
+	var names []string
+	testDB.Model(&model.WishTag{}).Where("wish_id = ?", wishID).Pluck("tag_name", &names)
+	return names
+}
+
+// TestContentKindModeration 测试标签、个人简介按各自的审核策略审核
+func TestContentKindModeration(t *testing.T) {
+	t.Run("违规标签拒绝整个愿望", func(t *testing.T) {
+		cleanup(testDB)
+		user := createUser("tag_user", "pass")
+
+		w := postJSON(testRouter, "/api/wishes", createToken(user.ID), gin.H{
+			"content": "希望期末考试顺利通过",
+			"tags":    []string{"学习", "加我微信"},
+		})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		resp := parseResponse(t, w)
+		assert.Equal(t, float64(apperr.ERROR_CONTENT_AD), resp["code"])
+		assert.Equal(t, "标签包含广告信息，请修改", resp["data"].(map[string]interface{})["error"])
+
+		var count int64
+		testDB.Model(&model.Wish{}).Count(&count)
+		assert.Equal(t, int64(0), count)
+	})
+
+	t.Run("无法判断的标签先不保存，管理员通过后补上", func(t *testing.T) {
+		cleanup(testDB)
+		user := createUser("tag_author", "pass")
+		admin := createUserWithRole("tag_admin", "pass", "admin")
+		adminToken := createToken(admin.ID)
+
+		w := postJSON(testRouter, "/api/wishes", createToken(user.ID), gin.H{
+			"content": "希望期末考试顺利通过",
+			"tags":    []string{"学习", "模棱两可"},
+		})
+		require.Equal(t, http.StatusOK, w.Code)
+		data := parseResponse(t, w)["data"].(map[string]interface{})
+		assert.Equal(t, model.ModerationApproved, data["moderationStatus"])
+		wishID := uint(data["wishID"].(float64))
+		assert.Equal(t, []string{"学习"}, wishTagNames(wishID))
+
+		w = getJSON("/api/admin/moderation/queue", adminToken)
+		require.Equal(t, http.StatusOK, w.Code)
+		queue := parseResponse(t, w)["data"].(map[string]interface{})
+		require.Equal(t, float64(1), queue["total"])
+		item := queue["items"].([]interface{})[0].(map[string]interface{})
+		assert.Equal(t, "tag", item["subjectType"])
+		assert.Equal(t, float64(wishID), item["subjectId"])
+
+		w = postJSON(testRouter, fmt.Sprintf("/api/admin/moderation/records/%d/approve", uint(item["id"].(float64))), adminToken, gin.H{})
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.ElementsMatch(t, []string{"学习", "模棱两可"}, wishTagNames(wishID))
+	})
+
+	t.Run("更新个人简介需要审核", func(t *testing.T) {
+		cleanup(testDB)
+		user := createUser("bio_user", "pass")
+		token := createToken(user.ID)
+
+		w := putJSON(testRouter, "/api/user", token, gin.H{"bio": "有事加我微信"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "个人简介包含广告信息，请修改", parseResponse(t, w)["data"].(map[string]interface{})["error"])
+
+		w = putJSON(testRouter, "/api/user", token, gin.H{"bio": "喜欢看雪"})
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "喜欢看雪", parseResponse(t, w)["data"].(map[string]interface{})["bio"])
+
+		var saved model.User
+		testDB.First(&saved, user.ID)
+		require.NotNil(t, saved.Bio)
+		assert.Equal(t, "喜欢看雪", *saved.Bio)
+
+		// 无法判断时保留原简介
+		w = putJSON(testRouter, "/api/user", token, gin.H{"bio": "模棱两可的简介"})
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "喜欢看雪", parseResponse(t, w)["data"].(map[string]interface{})["bio"])
+	})
+}
@@ -0,0 +1,238 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/model"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/service"
+	apperr "github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/err"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/logger"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// StartRemoderation 创建重新审核任务，在后台用当前审核器重新审核已通过的存量愿望/评论
+// POST /api/admin/moderation/remoderate
+// 请求体（均可选）：{ "targets": ["wish", "comment"], "batchSize": 100, "rate": 5, "dryRun": true }
+func StartRemoderation(c *gin.Context, db *gorm.DB, moderator service.Moderator) {
+	var req struct {
+		Targets   []string `json:"targets"`
+		BatchSize int      `json:"batchSize"`
+		Rate      float64  `json:"rate"`
+		DryRun    bool     `json:"dryRun"`
+	}
+	// 请求体可以为空
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":      apperr.ERROR_PARAM_INVALID,
+				"requestId": c.GetString("requestID"),
+				"message":   apperr.GetMsg(apperr.ERROR_PARAM_INVALID),
+				"data":      gin.H{"error": err.Error()},
+			})
+			return
+		}
+	}
+	adminID := c.MustGet("userID").(uint)
+
+	opts := service.RemoderationOptions{
+		BatchSize: req.BatchSize,
+		Rate:      req.Rate,
+		DryRun:    req.DryRun,
+		StartedBy: adminID,
+	}
+	for _, target := range req.Targets {
+		opts.Targets = append(opts.Targets, service.ModerationTarget(target))
+	}
+	job, err := service.CreateRemoderationJob(db, opts)
+	if err != nil {
+		respondRemoderationError(c, err, 0)
+		return
+	}
+	service.RunRemoderationInBackground(db, moderator, job.ID)
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"code":    apperr.SUCCESS,
+		"message": apperr.GetMsg(apperr.SUCCESS),
+		"data":    job,
+	})
+}
+
+// ResumeRemoderation 从检查点继续被暂停（或执行进程已退出）的重新审核任务
+// POST /api/admin/moderation/remoderate/:id/resume
+func ResumeRemoderation(c *gin.Context, db *gorm.DB, moderator service.Moderator) {
+	jobID, ok := parseRemoderationJobID(c)
+	if !ok {
+		return
+	}
+	job, err := service.ResumeRemoderationJob(db, jobID)
+	if err != nil {
+		respondRemoderationError(c, err, jobID)
+		return
+	}
+	service.RunRemoderationInBackground(db, moderator, job.ID)
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"code":    apperr.SUCCESS,
+		"message": apperr.GetMsg(apperr.SUCCESS),
+		"data":    job,
+	})
+}
+
+// CancelRemoderation 取消重新审核任务，已经变更的内容不会回滚
+// POST /api/admin/moderation/remoderate/:id/cancel
+func CancelRemoderation(c *gin.Context, db *gorm.DB) {
+	jobID, ok := parseRemoderationJobID(c)
+	if !ok {
+		return
+	}
+	job, err := service.CancelRemoderationJob(db, jobID)
+	if err != nil {
+		respondRemoderationError(c, err, jobID)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code":    apperr.SUCCESS,
+		"message": apperr.GetMsg(apperr.SUCCESS),
+		"data":    job,
+	})
+}
+
+// ListRemoderationJobs 重新审核任务列表，最近创建的在前
+// GET /api/admin/moderation/remoderate
+func ListRemoderationJobs(c *gin.Context, db *gorm.DB) {
+	jobs := make([]model.RemoderationJob, 0)
+	if err := db.Order("id desc").Limit(50).Find(&jobs).Error; err != nil {
+		logger.FromContext(c.Request.Context()).Errorw("查询重新审核任务失败", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":      apperr.ERROR_SERVER_ERROR,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_SERVER_ERROR),
+			"data":      gin.H{},
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code":    apperr.SUCCESS,
+		"message": apperr.GetMsg(apperr.SUCCESS),
+		"data":    gin.H{"items": jobs},
+	})
+}
+
+// GetRemoderationReport 重新审核任务的进度与报告：任务统计 + 状态发生变化的内容（分页）
+// GET /api/admin/moderation/remoderate/:id?page=1&pageSize=20
+func GetRemoderationReport(c *gin.Context, db *gorm.DB) {
+	jobID, ok := parseRemoderationJobID(c)
+	if !ok {
+		return
+	}
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":      apperr.ERROR_PARAM_INVALID,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_PARAM_INVALID),
+			"data":      gin.H{"error": "页码无效"},
+		})
+		return
+	}
+	pageSize, err := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+	if err != nil || pageSize < 1 || pageSize > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":      apperr.ERROR_PARAM_INVALID,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_PARAM_INVALID),
+			"data":      gin.H{"error": "分页大小无效"},
+		})
+		return
+	}
+
+	var job model.RemoderationJob
+	if err := db.First(&job, jobID).Error; err != nil {
+		respondRemoderationError(c, err, jobID)
+		return
+	}
+	var total int64
+	changes := make([]model.RemoderationChange, 0, pageSize)
+	query := db.Model(&model.RemoderationChange{}).Where("job_id = ?", jobID)
+	err = query.Session(&gorm.Session{}).Count(&total).Error
+	if err == nil {
+		err = query.Order("id asc").Offset((page - 1) * pageSize).Limit(pageSize).Find(&changes).Error
+	}
+	if err != nil {
+		respondRemoderationError(c, err, jobID)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    apperr.SUCCESS,
+		"message": apperr.GetMsg(apperr.SUCCESS),
+		"data": gin.H{
+			"job": job,
+			"changes": gin.H{
+				"total":    total,
+				"page":     page,
+				"pageSize": pageSize,
+				"items":    changes,
+			},
+		},
+	})
+}
+
+func parseRemoderationJobID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":      apperr.ERROR_PARAM_INVALID,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_PARAM_INVALID),
+			"data":      gin.H{"error": "任务ID无效"},
+		})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// respondRemoderationError 把重新审核相关的错误转换为响应
+func respondRemoderationError(c *gin.Context, err error, jobID uint) {
+	switch {
+	case errors.Is(err, service.ErrInvalidRemoderation):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":      apperr.ERROR_PARAM_INVALID,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_PARAM_INVALID),
+			"data":      gin.H{"error": err.Error()},
+		})
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":      apperr.ERROR_REMODERATION_JOB_NOT_FOUND,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_REMODERATION_JOB_NOT_FOUND),
+			"data":      gin.H{},
+		})
+	case errors.Is(err, service.ErrRemoderationRunning):
+		c.JSON(http.StatusConflict, gin.H{
+			"code":      apperr.ERROR_REMODERATION_RUNNING,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_REMODERATION_RUNNING),
+			"data":      gin.H{},
+		})
+	case errors.Is(err, service.ErrRemoderationNotResumable):
+		c.JSON(http.StatusConflict, gin.H{
+			"code":      apperr.ERROR_REMODERATION_FINISHED,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_REMODERATION_FINISHED),
+			"data":      gin.H{},
+		})
+	default:
+		logger.FromContext(c.Request.Context()).Errorw("重新审核任务操作失败", "jobID", jobID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":      apperr.ERROR_SERVER_ERROR,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_SERVER_ERROR),
+			"data":      gin.H{},
+		})
+	}
+}
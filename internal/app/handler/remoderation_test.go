@@ -0,0 +1,188 @@
+package handler_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/model"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/service"
+	apperr "github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/err"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// seedApprovedContent 模拟按旧审核标准已通过的存量内容：一条正常愿望、一条广告愿望、一条无法判断的愿望，
+// 以及正常愿望下的一条违规评论
+func seedApprovedContent(t *testing.T, userID uint) (normal, ad, unsure model.Wish, comment model.Comment) {
+	normal = model.Wish{UserID: userID, Content: "希望期末考试顺利通过", CommentCount: 1, ModerationStatus: model.ModerationApproved}
+	ad = model.Wish{UserID: userID, Content: "想赚钱的加我微信", ModerationStatus: model.ModerationApproved}
+	unsure = model.Wish{UserID: userID, Content: "这句话模棱两可", ModerationStatus: model.ModerationApproved}
+	for _, w := range []*model.Wish{&normal, &ad, &unsure} {
+		require.NoError(t, testDB.Create(w).Error)
+	}
+	comment = model.Comment{WishID: normal.ID, UserID: userID, Content: "我恨这个世界", ModerationStatus: model.ModerationApproved}
+	require.NoError(t, testDB.Create(&comment).Error)
+	return
+}
+
+// waitForJob 轮询任务直到不再是 running
+func waitForJob(t *testing.T, token string, jobID uint) map[string]interface{} {
+	for i := 0; i < 100; i++ {
+		w := getJSON(fmt.Sprintf("/api/admin/moderation/remoderate/%d?pageSize=100", jobID), token)
+		require.Equal(t, http.StatusOK, w.Code)
+		data := parseResponse(t, w)["data"].(map[string]interface{})
+		if data["job"].(map[string]interface{})["status"] != model.RemoderationRunning {
+			return data
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("任务 %d 未在预期时间内结束", jobID)
+	return nil
+}
+
+func commentStatus(id uint) string {
+	var comment model.Comment
+	testDB.First(&comment, id)
+	return comment.ModerationStatus
+}
+
+// contentEditingModerator 在审核期间修改被审核的愿望，模拟线上用户同时编辑内容
+type contentEditingModerator struct {
+	fakeModerator
+	wishID uint
+}
+
+func (m contentEditingModerator) Check(ctx context.Context, kind service.ContentKind, content string) (service.Verdict, error) {
+	testDB.Model(&model.Wish{}).Where("id = ?", m.wishID).Update("content", "已修改的内容")
+	return m.fakeModerator.Check(ctx, kind, content)
+}
+
+func TestRemoderation(t *testing.T) {
+	t.Run("试运行只生成报告，不修改内容", func(t *testing.T) {
+		cleanup(testDB)
+		admin := createUserWithRole("remod_admin", "pass", "admin")
+		token := createToken(admin.ID)
+		_, ad, unsure, comment := seedApprovedContent(t, admin.ID)
+
+		w := postJSON(testRouter, "/api/admin/moderation/remoderate", token, gin.H{"dryRun": true, "rate": 100})
+		require.Equal(t, http.StatusAccepted, w.Code)
+		jobID := uint(parseResponse(t, w)["data"].(map[string]interface{})["id"].(float64))
+
+		data := waitForJob(t, token, jobID)
+		job := data["job"].(map[string]interface{})
+		assert.Equal(t, model.RemoderationCompleted, job["status"])
+		assert.Equal(t, float64(4), job["scanned"])
+		assert.Equal(t, float64(2), job["hidden"])
+		assert.Equal(t, float64(1), job["flagged"])
+		assert.Equal(t, float64(3), data["changes"].(map[string]interface{})["total"])
+
+		assert.Equal(t, model.ModerationApproved, wishStatus(ad.ID))
+		assert.Equal(t, model.ModerationApproved, wishStatus(unsure.ID))
+		assert.Equal(t, model.ModerationApproved, commentStatus(comment.ID))
+		var records int64
+		testDB.Model(&model.ModerationRecord{}).Count(&records)
+		assert.Equal(t, int64(0), records)
+	})
+
+	t.Run("隐藏现在违规的内容，无法判断的进入复核队列", func(t *testing.T) {
+		cleanup(testDB)
+		admin := createUserWithRole("remod_admin", "pass", "admin")
+		token := createToken(admin.ID)
+		normal, ad, unsure, comment := seedApprovedContent(t, admin.ID)
+
+		w := postJSON(testRouter, "/api/admin/moderation/remoderate", token, gin.H{"rate": 100, "batchSize": 1})
+		require.Equal(t, http.StatusAccepted, w.Code)
+		jobID := uint(parseResponse(t, w)["data"].(map[string]interface{})["id"].(float64))
+
+		data := waitForJob(t, token, jobID)
+		assert.Equal(t, model.RemoderationCompleted, data["job"].(map[string]interface{})["status"])
+		items := data["changes"].(map[string]interface{})["items"].([]interface{})
+		require.Len(t, items, 3)
+		first := items[0].(map[string]interface{})
+		assert.Equal(t, float64(ad.ID), first["subjectId"])
+		assert.Equal(t, model.ModerationRejected, first["newStatus"])
+		assert.Equal(t, "内容包含广告信息", first["reason"])
+		assert.NotZero(t, first["recordId"])
+
+		assert.Equal(t, model.ModerationApproved, wishStatus(normal.ID))
+		assert.Equal(t, model.ModerationRejected, wishStatus(ad.ID))
+		assert.Equal(t, model.ModerationNeedsReview, wishStatus(unsure.ID))
+		assert.Equal(t, model.ModerationRejected, commentStatus(comment.ID))
+		var wish model.Wish
+		testDB.First(&wish, normal.ID)
+		assert.Equal(t, 0, wish.CommentCount)
+
+		w = getJSON("/api/admin/moderation/queue", token)
+		queue := parseResponse(t, w)["data"].(map[string]interface{})
+		require.Equal(t, float64(1), queue["total"])
+		assert.Equal(t, float64(unsure.ID), queue["items"].([]interface{})[0].(map[string]interface{})["subjectId"])
+	})
+
+	t.Run("同一时间只允许一个任务，已结束的任务不能继续", func(t *testing.T) {
+		cleanup(testDB)
+		admin := createUserWithRole("remod_admin", "pass", "admin")
+		token := createToken(admin.ID)
+
+		running := model.RemoderationJob{Status: model.RemoderationRunning, Targets: "wish", BatchSize: 10, Rate: 1}
+		require.NoError(t, testDB.Create(&running).Error)
+		w := postJSON(testRouter, "/api/admin/moderation/remoderate", token, gin.H{})
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Equal(t, float64(apperr.ERROR_REMODERATION_RUNNING), parseResponse(t, w)["code"])
+
+		w = postJSON(testRouter, fmt.Sprintf("/api/admin/moderation/remoderate/%d/cancel", running.ID), token, gin.H{})
+		require.Equal(t, http.StatusOK, w.Code)
+		w = postJSON(testRouter, fmt.Sprintf("/api/admin/moderation/remoderate/%d/resume", running.ID), token, gin.H{})
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Equal(t, float64(apperr.ERROR_REMODERATION_FINISHED), parseResponse(t, w)["code"])
+
+		w = postJSON(testRouter, "/api/admin/moderation/remoderate", token, gin.H{"targets": []string{"nickname"}})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		w = postJSON(testRouter, "/api/admin/moderation/remoderate/999999/resume", token, gin.H{})
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("审核服务不可用时暂停，之后从检查点继续", func(t *testing.T) {
+		cleanup(testDB)
+		admin := createUserWithRole("remod_admin", "pass", "admin")
+		token := createToken(admin.ID)
+		normal, ad, _, _ := seedApprovedContent(t, admin.ID)
+
+		job, err := service.CreateRemoderationJob(testDB, service.RemoderationOptions{Targets: []service.ModerationTarget{service.TargetWish}, Rate: 100})
+		require.NoError(t, err)
+		job, err = service.NewRemoderator(testDB, downModerator{}).Run(context.Background(), job.ID)
+		require.NoError(t, err)
+		assert.Equal(t, model.RemoderationPaused, job.Status)
+		assert.Contains(t, job.Error, "审核服务不可用")
+		assert.Equal(t, 0, job.Scanned)
+
+		// 模拟上次已审核到第一条愿望
+		testDB.Model(job).Update("last_wish_id", normal.ID)
+		w := postJSON(testRouter, fmt.Sprintf("/api/admin/moderation/remoderate/%d/resume", job.ID), token, gin.H{})
+		require.Equal(t, http.StatusAccepted, w.Code)
+		data := waitForJob(t, token, job.ID)
+		resumed := data["job"].(map[string]interface{})
+		assert.Equal(t, model.RemoderationCompleted, resumed["status"])
+		assert.Equal(t, float64(2), resumed["scanned"])
+		assert.Equal(t, model.ModerationRejected, wishStatus(ad.ID))
+	})
+
+	t.Run("审核期间被修改的内容跳过", func(t *testing.T) {
+		cleanup(testDB)
+		user := createUser("remod_user", "pass")
+		wish := model.Wish{UserID: user.ID, Content: "想赚钱的加我微信", ModerationStatus: model.ModerationApproved}
+		require.NoError(t, testDB.Create(&wish).Error)
+
+		job, err := service.CreateRemoderationJob(testDB, service.RemoderationOptions{Rate: 100})
+		require.NoError(t, err)
+		job, err = service.NewRemoderator(testDB, contentEditingModerator{wishID: wish.ID}).Run(context.Background(), job.ID)
+		require.NoError(t, err)
+		assert.Equal(t, model.RemoderationCompleted, job.Status)
+		assert.Equal(t, 1, job.Skipped)
+		assert.Equal(t, 0, job.Hidden)
+		assert.Equal(t, model.ModerationApproved, wishStatus(wish.ID))
+	})
+}
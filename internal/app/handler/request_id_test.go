@@ -0,0 +1,70 @@
+package handler_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// TestRequestID 测试请求 ID 的生成、透传，以及访问日志与错误响应中的请求 ID
+func TestRequestID(t *testing.T) {
+	cleanup(testDB)
+
+	// 捕获日志，测试结束后恢复
+	core, logs := observer.New(zap.DebugLevel)
+	original := logger.Log
+	logger.Log = zap.New(core).Sugar()
+	defer func() { logger.Log = original }()
+
+	t.Run("沿用上游传入的请求 ID", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/user/me", nil)
+		req.Header.Set("X-Request-ID", "nginx-1234.abc")
+		testRouter.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Equal(t, "nginx-1234.abc", w.Header().Get("X-Request-ID"))
+		assert.Equal(t, "nginx-1234.abc", parseResponse(t, w)["requestId"], "错误响应中带有请求 ID")
+
+		access := logs.FilterMessage("请求完成").FilterField(zap.String("requestID", "nginx-1234.abc")).All()
+		require.Len(t, access, 1)
+		fields := access[0].ContextMap()
+		assert.Equal(t, "/api/user/me", fields["route"])
+		assert.EqualValues(t, http.StatusUnauthorized, fields["status"])
+	})
+
+	t.Run("没有或格式不合法时生成新的请求 ID", func(t *testing.T) {
+		for _, header := range []string{"", "bad id\nwith newline", string(make([]byte, 100))} {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/api/app-state", nil)
+			if header != "" {
+				req.Header.Set("X-Request-ID", header)
+			}
+			testRouter.ServeHTTP(w, req)
+
+			id := w.Header().Get("X-Request-ID")
+			assert.Len(t, id, 26)
+			assert.NotEqual(t, header, id)
+		}
+	})
+
+	t.Run("鉴权后的日志带有用户 ID", func(t *testing.T) {
+		user := createUser("2024000099", "password123")
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/user/me", nil)
+		req.Header.Set("Authorization", "Bearer "+createToken(user.ID))
+		req.Header.Set("X-Request-ID", "req-with-user")
+		testRouter.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		access := logs.FilterMessage("请求完成").FilterField(zap.String("requestID", "req-with-user")).All()
+		require.Len(t, access, 1)
+		assert.EqualValues(t, user.ID, access[0].ContextMap()["userID"])
+	})
+}
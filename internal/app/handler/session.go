@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/service"
+	apperr "github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/err"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// SessionResponse 是登录设备列表中的一项
+type SessionResponse struct {
+	ID         uint      `json:"id"`
+	UserAgent  string    `json:"userAgent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastSeenAt time.Time `json:"lastSeenAt"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+	Current    bool      `json:"current"` // 是否为发起本次请求的会话
+}
+
+// ListSessions 列出当前用户已登录的设备 (未过期的登录会话)
+// GET /api/user/sessions
+func ListSessions(c *gin.Context, tokens *service.TokenService) {
+	userID := c.GetUint("userID")
+	sessions, err := tokens.Sessions(c.Request.Context(), userID)
+	if err != nil {
+		logger.FromContext(c.Request.Context()).Errorw("查询登录会话失败", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":      apperr.ERROR_SERVER_ERROR,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_SERVER_ERROR),
+			"data":      gin.H{},
+		})
+		return
+	}
+
+	current := c.GetString("sessionID")
+	list := make([]SessionResponse, 0, len(sessions))
+	for _, ss := range sessions {
+		list = append(list, SessionResponse{
+			ID:         ss.ID,
+			UserAgent:  ss.UserAgent,
+			IP:         ss.IP,
+			CreatedAt:  ss.CreatedAt,
+			LastSeenAt: ss.LastSeenAt,
+			ExpiresAt:  ss.ExpiresAt,
+			Current:    ss.JTI == current,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code":    apperr.SUCCESS,
+		"message": apperr.GetMsg(apperr.SUCCESS),
+		"data":    gin.H{"sessions": list},
+	})
+}
+
+// DeleteSession 移除当前用户的某个登录设备，该设备的访问令牌和刷新令牌立即失效；
+// 移除当前会话等同于退出登录
+// DELETE /api/user/sessions/:id
+func DeleteSession(c *gin.Context, tokens *service.TokenService) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":      apperr.ERROR_PARAM_INVALID,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_PARAM_INVALID),
+			"data":      gin.H{"error": "会话ID无效"},
+		})
+		return
+	}
+
+	userID := c.GetUint("userID")
+	if err := tokens.RevokeSession(c.Request.Context(), userID, uint(id)); err != nil {
+		if errors.Is(err, service.ErrSessionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"code":      apperr.ERROR_SESSION_NOT_FOUND,
+				"requestId": c.GetString("requestID"),
+				"message":   apperr.GetMsg(apperr.ERROR_SESSION_NOT_FOUND),
+				"data":      gin.H{},
+			})
+			return
+		}
+		logger.FromContext(c.Request.Context()).Errorw("移除登录会话失败", "sessionID", id, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":      apperr.ERROR_SERVER_ERROR,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_SERVER_ERROR),
+			"data":      gin.H{},
+		})
+		return
+	}
+
+	logger.FromContext(c.Request.Context()).Infow("用户移除登录会话", "sessionID", id)
+	c.JSON(http.StatusOK, gin.H{
+		"code":    apperr.SUCCESS,
+		"message": apperr.GetMsg(apperr.SUCCESS),
+		"data":    gin.H{},
+	})
+}
@@ -0,0 +1,80 @@
+package handler_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	apperr "github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/err"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSessions 测试登录设备列表与移除设备
+func TestSessions(t *testing.T) {
+	deleteSession := func(id, token string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("DELETE", "/api/user/sessions/"+id, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		testRouter.ServeHTTP(w, req)
+		return w
+	}
+	listSessions := func(token string) []interface{} {
+		w := getJSON("/api/user/sessions", token)
+		require.Equal(t, http.StatusOK, w.Code)
+		return parseResponse(t, w)["data"].(map[string]interface{})["sessions"].([]interface{})
+	}
+
+	cleanup(testDB)
+	user := createUser("2024000001", "password")
+	other := createUser("2024000002", "password")
+	phone, laptop := createTokenPair(user.ID), createTokenPair(user.ID)
+	otherPair := createTokenPair(other.ID)
+
+	t.Run("列出已登录的设备", func(t *testing.T) {
+		sessions := listSessions(phone.AccessToken)
+		require.Len(t, sessions, 2, "只列出自己的会话")
+		current := 0
+		for _, s := range sessions {
+			s := s.(map[string]interface{})
+			assert.Equal(t, "go-test", s["userAgent"])
+			assert.NotContains(t, s, "jti")
+			if s["current"] == true {
+				current++
+			}
+		}
+		assert.Equal(t, 1, current, "恰好一个会话是当前会话")
+	})
+
+	t.Run("不能移除其他用户的设备", func(t *testing.T) {
+		id := strconv.Itoa(int(listSessions(otherPair.AccessToken)[0].(map[string]interface{})["id"].(float64)))
+		w := deleteSession(id, phone.AccessToken)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, float64(apperr.ERROR_SESSION_NOT_FOUND), parseResponse(t, w)["code"])
+		assert.Equal(t, http.StatusOK, getJSON("/api/user/me", otherPair.AccessToken).Code)
+	})
+
+	t.Run("会话ID无效", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, deleteSession("abc", phone.AccessToken).Code)
+	})
+
+	t.Run("移除设备后其令牌立即失效", func(t *testing.T) {
+		var laptopID string
+		for _, s := range listSessions(phone.AccessToken) {
+			if s := s.(map[string]interface{}); s["current"] != true {
+				laptopID = strconv.Itoa(int(s["id"].(float64)))
+			}
+		}
+		require.NotEmpty(t, laptopID)
+
+		w := deleteSession(laptopID, phone.AccessToken)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, http.StatusUnauthorized, getJSON("/api/user/me", laptop.AccessToken).Code)
+		assert.Equal(t, http.StatusOK, getJSON("/api/user/me", phone.AccessToken).Code)
+		assert.Len(t, listSessions(phone.AccessToken), 1)
+
+		// 再次移除同一会话
+		assert.Equal(t, http.StatusNotFound, deleteSession(laptopID, phone.AccessToken).Code)
+	})
+}
@@ -0,0 +1,93 @@
+package handler_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/handler"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/model"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/repository"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/service"
+	apperr "github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/err"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestHandlersWithMemoryStore 使用内存仓储构造 service，直接测试 handler，不经过数据库
+func TestHandlersWithMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	store := repository.NewMemoryStore()
+	author := model.User{Username: "2024100001", Nickname: "作者"}
+	other := model.User{Username: "2024100002", Nickname: "路人"}
+	require.NoError(t, store.Users().Create(ctx, &author))
+	require.NoError(t, store.Users().Create(ctx, &other))
+	wish := model.Wish{UserID: author.ID, Content: "内存里的愿望", IsPublic: true}
+	require.NoError(t, store.Wishes().Create(ctx, &wish, []string{"学习"}))
+
+	// 用请求头模拟鉴权中间件写入的 userID
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		if id, err := strconv.ParseUint(c.GetHeader("X-User-ID"), 10, 64); err == nil {
+			c.Set("userID", uint(id))
+		}
+	})
+	wishes := service.NewWishService(store)
+	likes := service.NewLikeService(store)
+	r.POST("/wishes/:id/like", func(c *gin.Context) { handler.LikeWish(c, likes) })
+	r.DELETE("/wishes/:id", func(c *gin.Context) { handler.DeleteWish(c, wishes) })
+	r.GET("/wishes/public", func(c *gin.Context) { handler.GetPublicWishes(c, wishes) })
+
+	do := func(method, path string, userID uint) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, nil)
+		req.Header.Set("X-User-ID", strconv.Itoa(int(userID)))
+		r.ServeHTTP(w, req)
+		return w
+	}
+	wishPath := "/wishes/" + strconv.Itoa(int(wish.ID))
+
+	t.Run("点赞与取消点赞", func(t *testing.T) {
+		w := do("POST", wishPath+"/like", other.ID)
+		require.Equal(t, http.StatusOK, w.Code)
+		data := parseResponse(t, w)["data"].(map[string]interface{})
+		assert.Equal(t, true, data["liked"])
+		assert.Equal(t, float64(1), data["likeCount"])
+
+		w = do("GET", "/wishes/public?tag=学习", other.ID)
+		require.Equal(t, http.StatusOK, w.Code)
+		data = parseResponse(t, w)["data"].(map[string]interface{})
+		assert.Equal(t, float64(1), data["total"])
+		items := data["wishes"].([]interface{})
+		require.Len(t, items, 1)
+		assert.Equal(t, true, items[0].(map[string]interface{})["liked"])
+		assert.Equal(t, "作者", items[0].(map[string]interface{})["userNickname"])
+
+		w = do("POST", wishPath+"/like", other.ID)
+		require.Equal(t, http.StatusOK, w.Code)
+		data = parseResponse(t, w)["data"].(map[string]interface{})
+		assert.Equal(t, false, data["liked"])
+		assert.Equal(t, float64(0), data["likeCount"])
+	})
+
+	t.Run("非作者不能删除愿望", func(t *testing.T) {
+		w := do("DELETE", wishPath, other.ID)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		_, err := store.Wishes().FindByID(ctx, wish.ID)
+		assert.NoError(t, err)
+	})
+
+	t.Run("作者删除愿望", func(t *testing.T) {
+		w := do("DELETE", wishPath, author.ID)
+		require.Equal(t, http.StatusOK, w.Code)
+		_, err := store.Wishes().FindByID(ctx, wish.ID)
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+
+		w = do("POST", wishPath+"/like", other.ID)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, float64(apperr.ERROR_WISH_NOT_FOUND), parseResponse(t, w)["code"])
+	})
+}
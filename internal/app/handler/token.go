@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/service"
+	apperr "github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/err"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/logger"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/util"
+	"github.com/gin-gonic/gin"
+)
+
+// RefreshTokenRequest 是 /api/token/refresh 的请求体
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
+}
+
+// RefreshToken 用刷新令牌换取新的访问令牌，同时换发刷新令牌 (旧的刷新令牌立即作废)
+func RefreshToken(c *gin.Context, tokens *service.TokenService) {
+	var req RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":      apperr.ERROR_PARAM_INVALID,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_PARAM_INVALID),
+			"data":      gin.H{"error": "refreshToken 不能为空"},
+		})
+		return
+	}
+
+	pair, err := tokens.Refresh(c.Request.Context(), req.RefreshToken, clientInfo(c))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidRefreshToken) || errors.Is(err, service.ErrRefreshTokenReused) {
+			logger.FromContext(c.Request.Context()).Infow("刷新令牌失败", "error", err)
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"code":      apperr.ERROR_UNAUTHORIZED,
+				"requestId": c.GetString("requestID"),
+				"message":   apperr.GetMsg(apperr.ERROR_UNAUTHORIZED),
+				"data":      gin.H{"error": err.Error()},
+			})
+			return
+		}
+		logger.FromContext(c.Request.Context()).Errorw("刷新令牌时出错", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":      apperr.ERROR_SERVER_ERROR,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_SERVER_ERROR),
+			"data":      gin.H{},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    apperr.SUCCESS,
+		"message": apperr.GetMsg(apperr.SUCCESS),
+		"data":    tokenData(pair, nil),
+	})
+}
+
+// Logout 退出当前登录会话：该会话的访问令牌和刷新令牌立即失效，其他设备不受影响
+func Logout(c *gin.Context, tokens *service.TokenService) {
+	userID := c.GetUint("userID")
+	if err := tokens.Logout(c.Request.Context(), userID, c.GetString("sessionID")); err != nil {
+		logger.FromContext(c.Request.Context()).Errorw("退出登录失败", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":      apperr.ERROR_SERVER_ERROR,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_SERVER_ERROR),
+			"data":      gin.H{},
+		})
+		return
+	}
+	logger.FromContext(c.Request.Context()).Infow("用户退出登录", "sessionID", c.GetString("sessionID"))
+	c.JSON(http.StatusOK, gin.H{
+		"code":    apperr.SUCCESS,
+		"message": apperr.GetMsg(apperr.SUCCESS),
+		"data":    gin.H{},
+	})
+}
+
+// LogoutAll 退出全部设备上的登录会话 (包括当前会话)
+func LogoutAll(c *gin.Context, tokens *service.TokenService) {
+	userID := c.GetUint("userID")
+	revoked, err := tokens.LogoutAll(c.Request.Context(), userID)
+	if err != nil {
+		logger.FromContext(c.Request.Context()).Errorw("退出全部设备失败", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":      apperr.ERROR_SERVER_ERROR,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_SERVER_ERROR),
+			"data":      gin.H{},
+		})
+		return
+	}
+	logger.FromContext(c.Request.Context()).Infow("用户退出全部设备", "sessions", revoked)
+	c.JSON(http.StatusOK, gin.H{
+		"code":    apperr.SUCCESS,
+		"message": apperr.GetMsg(apperr.SUCCESS),
+		"data":    gin.H{"sessions": revoked},
+	})
+}
+
+// JWKS 输出访问令牌签名密钥的公钥 (RFC 7517 格式，不使用统一的响应结构)，包括即将启用的密钥。
+// 只包含 RS256/EdDSA 密钥；只用 JWT_SECRET 时 keys 为空
+// GET /.well-known/jwks.json
+func JWKS(c *gin.Context, tokens *util.JWT) {
+	// 密钥轮换时新公钥至少提前 -delay 发布，缓存时间需要比它短
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, tokens.Keyring().JWKS(time.Now()))
+}
+
+// clientInfo 取出请求的客户端信息，记录在刷新令牌中
+func clientInfo(c *gin.Context) service.ClientInfo {
+	return service.ClientInfo{UserAgent: c.Request.UserAgent(), IP: c.ClientIP()}
+}
+
+// tokenData 是登录、注册、刷新令牌成功时返回的令牌字段，extra 中的字段一并返回。
+// token 为访问令牌 (放在 Authorization: Bearer 中)，expiresIn 为其有效期 (秒)
+func tokenData(pair *service.TokenPair, extra gin.H) gin.H {
+	data := gin.H{
+		"token":        pair.AccessToken,
+		"refreshToken": pair.RefreshToken,
+		"expiresIn":    int64(pair.ExpiresIn.Seconds()),
+	}
+	for k, v := range extra {
+		data[k] = v
+	}
+	return data
+}
@@ -0,0 +1,132 @@
+package handler_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	apperr "github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/err"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/util"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTokenRefreshAndLogout 测试刷新令牌换发、重复使用检测与退出登录
+func TestTokenRefreshAndLogout(t *testing.T) {
+	refresh := func(refreshToken string) (int, map[string]interface{}) {
+		w := postJSON(testRouter, "/api/token/refresh", "", gin.H{"refreshToken": refreshToken})
+		return w.Code, parseResponse(t, w)
+	}
+
+	t.Run("换发令牌后旧令牌不能再用", func(t *testing.T) {
+		cleanup(testDB)
+		user := createUser("2024000001", "password")
+		pair := createTokenPair(user.ID)
+
+		code, resp := refresh(pair.RefreshToken)
+		require.Equal(t, http.StatusOK, code)
+		data := resp["data"].(map[string]interface{})
+		access, next := data["token"].(string), data["refreshToken"].(string)
+		assert.NotEqual(t, pair.RefreshToken, next)
+		assert.Equal(t, http.StatusOK, getJSON("/api/user/me", access).Code)
+
+		// 旧令牌被重复使用：整个会话被吊销，新签发的访问令牌和刷新令牌也失效
+		code, resp = refresh(pair.RefreshToken)
+		assert.Equal(t, http.StatusUnauthorized, code)
+		assert.Equal(t, float64(apperr.ERROR_UNAUTHORIZED), resp["code"])
+		assert.Equal(t, http.StatusUnauthorized, getJSON("/api/user/me", access).Code)
+		code, _ = refresh(next)
+		assert.Equal(t, http.StatusUnauthorized, code)
+
+		code, _ = refresh("")
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+
+	t.Run("退出当前设备", func(t *testing.T) {
+		cleanup(testDB)
+		user := createUser("2024000001", "password")
+		phone, laptop := createTokenPair(user.ID), createTokenPair(user.ID)
+
+		w := postJSON(testRouter, "/api/logout", phone.AccessToken, gin.H{})
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, http.StatusUnauthorized, getJSON("/api/user/me", phone.AccessToken).Code, "退出后未过期的访问令牌也不能再用")
+		code, _ := refresh(phone.RefreshToken)
+		assert.Equal(t, http.StatusUnauthorized, code)
+		assert.Equal(t, http.StatusOK, getJSON("/api/user/me", laptop.AccessToken).Code, "其他设备不受影响")
+	})
+
+	t.Run("退出全部设备", func(t *testing.T) {
+		cleanup(testDB)
+		user := createUser("2024000001", "password")
+		phone, laptop := createTokenPair(user.ID), createTokenPair(user.ID)
+
+		w := postJSON(testRouter, "/api/logout/all", phone.AccessToken, gin.H{})
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, float64(2), parseResponse(t, w)["data"].(map[string]interface{})["sessions"])
+		assert.Equal(t, http.StatusUnauthorized, getJSON("/api/user/me", phone.AccessToken).Code)
+		assert.Equal(t, http.StatusUnauthorized, getJSON("/api/user/me", laptop.AccessToken).Code)
+	})
+
+	t.Run("不属于任何会话的令牌", func(t *testing.T) {
+		cleanup(testDB)
+		user := createUser("2024000001", "password")
+		token, err := util.NewJWT(testConfig.JWT.Secret, testConfig.JWT.TTL).GenerateToken(user.ID, "unknown-session")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, getJSON("/api/user/me", token).Code)
+	})
+}
+
+// TestJWTKeyring 测试使用密钥环文件签发令牌与 JWKS 接口
+func TestJWTKeyring(t *testing.T) {
+	cleanup(testDB)
+	user := createUser("2024000001", "password")
+
+	// 只用 JWT_SECRET 时没有可以公开的公钥
+	w := getJSON("/.well-known/jwks.json", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, parseResponse(t, w)["keys"])
+
+	keyring := util.NewKeyring(util.SecretKey(testConfig.JWT.Secret))
+	key, err := keyring.Rotate(util.AlgEdDSA, time.Now(), 0, testConfig.JWT.TTL)
+	require.NoError(t, err)
+	cfg := newTestConfig()
+	cfg.JWT.KeysFile = filepath.Join(t.TempDir(), "jwt-keys.json")
+	require.NoError(t, keyring.Save(cfg.JWT.KeysFile))
+	r := newRouter(t, cfg, fakeModerator{}, nil)
+
+	w = httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/.well-known/jwks.json", nil)
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "public, max-age=300", w.Header().Get("Cache-Control"))
+	keys := parseResponse(t, w)["keys"].([]interface{})
+	require.Len(t, keys, 1, "HS256 密钥不公开")
+	jwk := keys[0].(map[string]interface{})
+	assert.Equal(t, key.ID, jwk["kid"])
+	assert.Equal(t, "OKP", jwk["kty"])
+	assert.Equal(t, "EdDSA", jwk["alg"])
+
+	// 新密钥签发的令牌
+	w = postJSON(r, "/api/login", "", gin.H{"username": "2024000001", "password": "password"})
+	require.Equal(t, http.StatusOK, w.Code)
+	access := parseResponse(t, w)["data"].(map[string]interface{})["token"].(string)
+	parsed, _, err := jwt.NewParser().ParseUnverified(access, &util.MyCustomClaims{})
+	require.NoError(t, err)
+	assert.Equal(t, key.ID, parsed.Header["kid"])
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/api/user/me", nil)
+	req.Header.Set("Authorization", "Bearer "+access)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// 轮换前用 JWT_SECRET 签发的令牌在保留期内仍然有效
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/api/user/me", nil)
+	req.Header.Set("Authorization", "Bearer "+createToken(user.ID))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
@@ -1,17 +1,15 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
-	"regexp"
 	"time"
 
 	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/model"
 	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/service"
 	apperr "github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/err"
 	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/logger"
-	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/util"
 	"github.com/gin-gonic/gin"
-	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
@@ -30,6 +28,7 @@ type LoginRequest struct {
 type UpdateUserRequest struct {
 	Nickname *string `json:"nickname"`
 	AvatarID *uint   `json:"avatar_id"` //指针可以用来区分未传和传了0
+	Bio      *string `json:"bio"`       // 传空字符串表示清空简介
 }
 
 // UserResponse 定义了注册/登录成功时返回的用户信息
@@ -38,129 +37,96 @@ type UserResponse struct {
 	Username  string    `json:"username"`
 	Nickname  string    `json:"nickname"`
 	AvatarID  *uint     `json:"avatar_id"`
+	Bio       *string   `json:"bio,omitempty"`
 	Role      string    `json:"role"`
 	CreatedAt time.Time `json:"createdAt"`
 }
 
-var isStudentId = regexp.MustCompile(`^[0-9]{10}$`)
-
 // Register 是 /api/register 接口的 Gin handler
-func Register(c *gin.Context, db *gorm.DB) {
+func Register(c *gin.Context, db *gorm.DB, users *service.UserService, moderator service.Moderator, tokens *service.TokenService) {
 	var req RegisterRequest
 
 	//  绑定 JSON 请求体
 	if err := c.ShouldBindJSON(&req); err != nil {
-		logger.Log.Warnw("注册请求参数绑定失败", "error", err.Error())
+		logger.FromContext(c.Request.Context()).Warnw("注册请求参数绑定失败", "error", err.Error())
 		c.JSON(http.StatusBadRequest, gin.H{
-			"code":    apperr.ERROR_PARAM_INVALID,
-			"message": apperr.GetMsg(apperr.ERROR_PARAM_INVALID),
-			"data":    gin.H{"error": "用户名和密码均不能为空"},
+			"code":      apperr.ERROR_PARAM_INVALID,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_PARAM_INVALID),
+			"data":      gin.H{"error": "用户名和密码均不能为空"},
 		})
 		return
 	}
 
-	//  验证业务逻辑（学号格式）
-	if !isStudentId.MatchString(req.Username) {
-		logger.Log.Warnw("注册失败：学号格式不正确", "username", req.Username)
-		c.JSON(http.StatusBadRequest, gin.H{
-			"code":    apperr.ERROR_PARAM_INVALID,
-			"message": apperr.GetMsg(apperr.ERROR_PARAM_INVALID),
-			"data":    gin.H{"error": "输入错误，请输入十位学号"},
+	//  验证业务逻辑（学号格式、是否已被注册）
+	ctx := c.Request.Context()
+	if err := users.CheckUsername(ctx, req.Username); err != nil {
+		if respondUsernameError(c, err, req.Username) {
+			return
+		}
+		logger.FromContext(c.Request.Context()).Errorw("注册时查询用户失败", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":      apperr.ERROR_SERVER_ERROR,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_SERVER_ERROR),
+			"data":      gin.H{},
 		})
 		return
 	}
 
-	// 如果昵称为空，默认使用用户名
-	if req.Nickname == "" {
-		req.Nickname = req.Username
-	}
-
-	// AI审核昵称
-	isViolating, aiErr := service.CheckContent(req.Nickname)
-	if aiErr != nil {
-		// AI 服务本身出错
-		logger.Log.Warnw("注册时昵称审核服务失败或输入无效", "nickname", req.Nickname, "error", aiErr)
-		c.JSON(http.StatusBadRequest, gin.H{
-			"code":    apperr.ERROR_PARAM_INVALID,
-			"message": apperr.GetMsg(apperr.ERROR_PARAM_INVALID),
-			"data":    gin.H{"error": aiErr.Error()}, // 将 AI service 的错误返回
-		})
-		return
-	}
-	if isViolating {
-		// AI 判定昵称违规
-		logger.Log.Warnw("注册失败：昵称违规", "nickname", req.Nickname)
-		c.JSON(http.StatusBadRequest, gin.H{
-			"code":    apperr.ERROR_PARAM_INVALID,
-			"message": apperr.GetMsg(apperr.ERROR_PARAM_INVALID),
-			"data":    gin.H{"error": "昵称包含不当内容，请修改"},
-		})
+	// 先校验密码强度，不合格时不必再审核昵称
+	if err := service.ValidatePassword(req.Username, req.Password); err != nil {
+		logger.FromContext(c.Request.Context()).Warnw("注册失败：密码强度不足", "username", req.Username)
+		respondPasswordError(c, err)
 		return
 	}
 
-	// 检查用户是否已存在
-	// 准备一个 User 模型
-	var existingUser model.User
-	//handler直接使用了db连接
-	if err := db.Where("username = ?", req.Username).First(&existingUser).Error; err == nil {
-		logger.Log.Warnw("注册失败：用户名已存在", "username", req.Username)
-		c.JSON(http.StatusBadRequest, gin.H{
-			"code":    apperr.ERROR_PARAM_INVALID,
-			"message": apperr.GetMsg(apperr.ERROR_PARAM_INVALID),
-			"data":    gin.H{"error": "该学号已被注册"},
-		})
-		return
-	} else if err != gorm.ErrRecordNotFound {
-		logger.Log.Errorw("注册时查询用户失败", "error", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":    apperr.ERROR_SERVER_ERROR,
-			"message": apperr.GetMsg(apperr.ERROR_SERVER_ERROR),
-			"data":    gin.H{},
-		})
-		return
+	// 如果昵称为空，默认使用用户名
+	if req.Nickname == "" {
+		req.Nickname = req.Username
 	}
 
-	//  调用 bcrypt 库将明文密码 mypassword 变成一长串哈希值。
-	hashedPassword, hashErr := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
-	if hashErr != nil {
-		logger.Log.Errorw("注册时密码加密失败", "error", hashErr)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":    apperr.ERROR_SERVER_ERROR,
-			"message": apperr.GetMsg(apperr.ERROR_SERVER_ERROR),
-			"data":    gin.H{},
-		})
+	// AI审核昵称（注册时用户 ID 尚未生成，审核记录在创建用户后写入）
+	outcome, ok := moderateContent(c, db, moderator, nil, service.KindNickname, req.Nickname, "注册", 0)
+	if !ok {
 		return
 	}
-
-	//  创建新用户，实例化
-	newUser := model.User{
-		Username: req.Username,
-		Password: string(hashedPassword),
-		Nickname: req.Nickname,
-		Role:     "user", //  GORM 模型里 Role 默认是 "user"
+	if outcome.status == model.ModerationNeedsReview {
+		// 审核器无法判断：先用学号作为昵称，管理员复核通过后再改为申请的昵称
+		req.Nickname = req.Username
+	} else {
+		// 命中打码词时保存打码后的昵称
+		req.Nickname = outcome.contentToSave(req.Nickname)
 	}
 
-	if createErr := db.Create(&newUser).Error; createErr != nil {
-		logger.Log.Errorw("创建用户到数据库失败", "error", createErr)
+	//  创建新用户（密码使用 bcrypt 哈希后保存）
+	newUser, err := users.Register(ctx, req.Username, req.Password, req.Nickname)
+	if err != nil {
+		if respondUsernameError(c, err, req.Username) || respondPasswordError(c, err) {
+			return
+		}
+		logger.FromContext(c.Request.Context()).Errorw("创建用户到数据库失败", "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":    apperr.ERROR_SERVER_ERROR,
-			"message": apperr.GetMsg(apperr.ERROR_SERVER_ERROR),
-			"data":    gin.H{},
+			"code":      apperr.ERROR_SERVER_ERROR,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_SERVER_ERROR),
+			"data":      gin.H{},
 		})
 		return
 	}
 
-	logger.Log.Infow("新用户注册成功", "username", newUser.Username, "userID", newUser.ID)
+	logger.FromContext(c.Request.Context()).Infow("新用户注册成功", "username", newUser.Username, "userID", newUser.ID)
+	outcome.audit(db, newUser.ID, newUser.ID)
 
-	//  生成 Token
-	// GenerateToken 使用 newUser.ID（数据库回填的 ID）和 JWT_SECRET（来自 .env）生成一个 JWT 字符串。
-	token, tokenErr := util.GenerateToken(newUser.ID)
+	//  开启登录会话，签发访问令牌和刷新令牌
+	pair, tokenErr := tokens.Issue(ctx, newUser.ID, clientInfo(c))
 	if tokenErr != nil {
-		logger.Log.Errorw("注册成功但生成 Token 失败", "error", tokenErr)
+		logger.FromContext(c.Request.Context()).Errorw("注册成功但生成 Token 失败", "error", tokenErr)
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":    apperr.ERROR_SERVER_ERROR,
-			"message": apperr.GetMsg(apperr.ERROR_SERVER_ERROR),
-			"data":    gin.H{},
+			"code":      apperr.ERROR_SERVER_ERROR,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_SERVER_ERROR),
+			"data":      gin.H{},
 		})
 		return
 	}
@@ -179,94 +145,125 @@ func Register(c *gin.Context, db *gorm.DB) {
 	c.JSON(http.StatusOK, gin.H{
 		"code":    apperr.SUCCESS, // 200
 		"message": apperr.GetMsg(apperr.SUCCESS),
-		"data": gin.H{
-			"token": token,
-			"user":  responseUser,
-		},
+		"data":    tokenData(pair, gin.H{"user": responseUser}),
+	})
+}
+
+// respondUsernameError 处理学号格式错误和学号已被注册，已响应时返回 true
+func respondUsernameError(c *gin.Context, err error, username string) bool {
+	switch {
+	case errors.Is(err, service.ErrInvalidUsername):
+		logger.FromContext(c.Request.Context()).Warnw("注册失败：学号格式不正确", "username", username)
+	case errors.Is(err, service.ErrUsernameTaken):
+		logger.FromContext(c.Request.Context()).Warnw("注册失败：用户名已存在", "username", username)
+	default:
+		return false
+	}
+	c.JSON(http.StatusBadRequest, gin.H{
+		"code":      apperr.ERROR_PARAM_INVALID,
+		"requestId": c.GetString("requestID"),
+		"message":   apperr.GetMsg(apperr.ERROR_PARAM_INVALID),
+		"data":      gin.H{"error": err.Error()},
 	})
+	return true
 }
 
-func Login(c *gin.Context, db *gorm.DB) {
+func Login(c *gin.Context, users *service.UserService, tokens *service.TokenService, limiter *service.LoginLimiter) {
 	var req LoginRequest
 
 	//  绑定 JSON 请求体
 	if err := c.ShouldBindJSON(&req); err != nil {
-		logger.Log.Warnw("登录请求参数绑定失败", "error", err.Error())
+		logger.FromContext(c.Request.Context()).Warnw("登录请求参数绑定失败", "error", err.Error())
 		c.JSON(http.StatusBadRequest, gin.H{
-			"code":    apperr.ERROR_PARAM_INVALID,
-			"message": apperr.GetMsg(apperr.ERROR_PARAM_INVALID),
-			"data":    gin.H{"error": "用户名和密码均不能为空"},
+			"code":      apperr.ERROR_PARAM_INVALID,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_PARAM_INVALID),
+			"data":      gin.H{"error": "用户名和密码均不能为空"},
 		})
 		return
 	}
-	//查找用户
-	var user model.User
-	if err := db.Where("username = ?", req.Username).First(&user).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			logger.Log.Infow("登陆失败。用户不存在", "username", req.Username)
+	// 学号或 IP 被锁定时不再校验密码；限流存储出错时放行，不影响正常登录
+	ip := c.ClientIP()
+	wait, err := limiter.Check(c.Request.Context(), req.Username, ip)
+	if err != nil {
+		logger.FromContext(c.Request.Context()).Errorw("查询登录限制失败", "error", err)
+	}
+	if wait > 0 {
+		respondTooManyAttempts(c, wait)
+		return
+	}
+	//查找用户并验证密码
+	user, err := users.Authenticate(c.Request.Context(), req.Username, req.Password)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCredentials) {
+			logger.FromContext(c.Request.Context()).Infow("登录失败。用户名或密码错误", "username", req.Username)
+			wait, failErr := limiter.Fail(c.Request.Context(), req.Username, ip)
+			if failErr != nil {
+				logger.FromContext(c.Request.Context()).Errorw("记录登录失败次数失败", "error", failErr)
+			}
+			if wait > 0 {
+				respondTooManyAttempts(c, wait)
+				return
+			}
 		} else {
-			logger.Log.Errorw("登录时查询用户失败", "error", err)
+			logger.FromContext(c.Request.Context()).Errorw("登录时查询用户失败", "error", err)
 		}
 
 		c.JSON(http.StatusUnauthorized, gin.H{
-			"code":    apperr.ERROR_LOGIN_FAILED,
-			"message": apperr.GetMsg(apperr.ERROR_LOGIN_FAILED),
-			"data":    gin.H{"error": "用户名或密码错误"},
+			"code":      apperr.ERROR_LOGIN_FAILED,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_LOGIN_FAILED),
+			"data":      gin.H{"error": "用户名或密码错误"},
 		})
 		return
-
 	}
-	//验证密码
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
-		logger.Log.Infow("登录失败。密码错误", "username", req.Username)
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"code":    apperr.ERROR_LOGIN_FAILED,
-			"message": apperr.GetMsg(apperr.ERROR_LOGIN_FAILED),
-			"data":    gin.H{"error": "用户名或密码错误"},
-		})
-		return
+	if err := limiter.Succeed(c.Request.Context(), req.Username); err != nil {
+		logger.FromContext(c.Request.Context()).Errorw("清除登录失败次数失败", "error", err)
 	}
-	//生成token
-	token, tokenErr := util.GenerateToken(user.ID)
+	//开启登录会话，签发访问令牌和刷新令牌
+	pair, tokenErr := tokens.Issue(c.Request.Context(), user.ID, clientInfo(c))
 	if tokenErr != nil {
-		logger.Log.Errorw("登陆成功但生成Token失败", "error", tokenErr)
+		logger.FromContext(c.Request.Context()).Errorw("登陆成功但生成Token失败", "error", tokenErr)
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":    apperr.ERROR_SERVER_ERROR,
-			"message": apperr.GetMsg(apperr.ERROR_SERVER_ERROR),
-			"data":    gin.H{},
+			"code":      apperr.ERROR_SERVER_ERROR,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_SERVER_ERROR),
+			"data":      gin.H{},
 		})
 		return
 	}
 	//登陆成功，返回token和用户信息
-	logger.Log.Infow("用户登录成功", "username", req.Username)
+	logger.FromContext(c.Request.Context()).Infow("用户登录成功", "username", req.Username)
 
 	respondUser := UserResponse{
 		ID:        user.ID,
 		Username:  user.Username,
 		Nickname:  user.Nickname,
 		AvatarID:  user.AvatarID,
+		Bio:       user.Bio,
 		Role:      user.Role,
 		CreatedAt: user.CreatedAt,
 	}
 	c.JSON(http.StatusOK, gin.H{
 		"code":    apperr.SUCCESS,
 		"message": apperr.GetMsg(apperr.SUCCESS),
-		"data":    gin.H{"token": token, "user": respondUser},
+		"data":    tokenData(pair, gin.H{"user": respondUser}),
 	})
 }
 
-func GetUserMe(c *gin.Context, db *gorm.DB) {
+func GetUserMe(c *gin.Context, users *service.UserService) {
 	//从中间件注入的上下文直接获取userID
-	userID, _ := c.Get("userID")
+	userID := c.GetUint("userID")
 
 	//查找用户
-	var user model.User
-	if err := db.First(&user, userID).Error; err != nil {
-		logger.Log.Errorw("GetUserMe: 查询用户失败", "userID", userID)
+	user, err := users.Get(c.Request.Context(), userID)
+	if err != nil {
+		logger.FromContext(c.Request.Context()).Errorw("GetUserMe: 查询用户失败", "userID", userID)
 		c.JSON(http.StatusUnauthorized, gin.H{
-			"code":    apperr.ERROR_UNAUTHORIZED,
-			"message": apperr.GetMsg(apperr.ERROR_UNAUTHORIZED),
-			"data":    gin.H{},
+			"code":      apperr.ERROR_UNAUTHORIZED,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_UNAUTHORIZED),
+			"data":      gin.H{},
 		})
 		return
 	}
@@ -277,6 +274,7 @@ func GetUserMe(c *gin.Context, db *gorm.DB) {
 		Username:  user.Username,
 		Nickname:  user.Nickname,
 		AvatarID:  user.AvatarID,
+		Bio:       user.Bio,
 		Role:      user.Role,
 		CreatedAt: user.CreatedAt,
 	}
@@ -288,96 +286,89 @@ func GetUserMe(c *gin.Context, db *gorm.DB) {
 	})
 }
 
-func UpdateUser(c *gin.Context, db *gorm.DB) {
+func UpdateUser(c *gin.Context, db *gorm.DB, users *service.UserService, moderator service.Moderator) {
 	var req UpdateUserRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		logger.Log.Warnw("更新用户请求参数绑定失败", "error", err.Error())
+		logger.FromContext(c.Request.Context()).Warnw("更新用户请求参数绑定失败", "error", err.Error())
 		c.JSON(http.StatusBadRequest, gin.H{
-			"code":    apperr.ERROR_PARAM_INVALID,
-			"message": apperr.GetMsg(apperr.ERROR_PARAM_INVALID),
-			"data":    gin.H{"error": err.Error()},
+			"code":      apperr.ERROR_PARAM_INVALID,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_PARAM_INVALID),
+			"data":      gin.H{"error": err.Error()},
 		})
 		return
 	}
 	//从中间件注入的上下文直接获取userID
-	userID, _ := c.Get("userID")
+	userID := c.GetUint("userID")
 	//查找用户
-	var user model.User
-	if err := db.First(&user, userID).Error; err != nil {
-		logger.Log.Errorw("UpdateUser: 查询用户失败", "userID", userID)
+	ctx := c.Request.Context()
+	user, err := users.Get(ctx, userID)
+	if err != nil {
+		logger.FromContext(c.Request.Context()).Errorw("UpdateUser: 查询用户失败", "userID", userID)
 		c.JSON(http.StatusUnauthorized, gin.H{
-			"code":    apperr.ERROR_UNAUTHORIZED,
-			"message": apperr.GetMsg(apperr.ERROR_UNAUTHORIZED),
-			"data":    gin.H{},
+			"code":      apperr.ERROR_UNAUTHORIZED,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_UNAUTHORIZED),
+			"data":      gin.H{},
 		})
 		return
 	}
 
-	//更新用户信息
+	//更新用户信息（昵称、简介先经过审核；审核器无法判断时保留原值，管理员复核通过后再生效）
+	var update service.ProfileUpdate
+	var nicknameOutcome moderationOutcome
 	if req.Nickname != nil {
-		// --- 3. [新] AI 审核昵称 ---
-		isViolating, aiErr := service.CheckContent(*req.Nickname)
-		if aiErr != nil {
-			// AI 服务出错或输入无效
-			logger.Log.Warnw("UpdateUser 昵称审核服务失败或输入无效", "nickname", *req.Nickname, "error", aiErr)
-			c.JSON(http.StatusBadRequest, gin.H{
-				"code":    apperr.ERROR_PARAM_INVALID,
-				"message": apperr.GetMsg(apperr.ERROR_PARAM_INVALID),
-				"data":    gin.H{"error": aiErr.Error()},
-			})
+		outcome, ok := moderateContent(c, db, moderator, nil, service.KindNickname, *req.Nickname, "更新昵称", user.ID)
+		if !ok {
 			return
 		}
-		if isViolating {
-			// AI 判定昵称违规
-			logger.Log.Warnw("UpdateUser 失败：昵称违规", "nickname", *req.Nickname)
-			c.JSON(http.StatusBadRequest, gin.H{
-				"code":    apperr.ERROR_PARAM_INVALID,
-				"message": apperr.GetMsg(apperr.ERROR_PARAM_INVALID),
-				"data":    gin.H{"error": "昵称包含不当内容，请修改"},
-			})
-			return
+		if outcome.status == model.ModerationApproved {
+			nickname := outcome.contentToSave(*req.Nickname)
+			update.Nickname = &nickname
 		}
-		// 审核通过
-		user.Nickname = *req.Nickname
-		// --- AI 审核结束 ---
+		nicknameOutcome = outcome
 	}
 
-	if req.AvatarID != nil {
-		user.AvatarID = req.AvatarID
+	var bioOutcome moderationOutcome
+	if req.Bio != nil {
+		if *req.Bio == "" {
+			update.Bio = req.Bio
+		} else {
+			outcome, ok := moderateContent(c, db, moderator, nil, service.KindBio, *req.Bio, "更新简介", user.ID)
+			if !ok {
+				return
+			}
+			if outcome.status == model.ModerationApproved {
+				bio := outcome.contentToSave(*req.Bio)
+				update.Bio = &bio
+			}
+			bioOutcome = outcome
+		}
 	}
-	if err := db.Save(&user).Error; err != nil {
-		logger.Log.Errorw("UpdateUser: 更新用户信息失败", "error", err)
+	update.AvatarID = req.AvatarID
+
+	// 保存并同步该用户已发布愿望中的冗余字段（昵称、头像）
+	if err := users.UpdateProfile(ctx, user, update); err != nil {
+		logger.FromContext(c.Request.Context()).Errorw("UpdateUser: 更新用户信息失败", "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":    apperr.ERROR_SERVER_ERROR,
-			"message": apperr.GetMsg(apperr.ERROR_SERVER_ERROR),
-			"data":    gin.H{},
+			"code":      apperr.ERROR_SERVER_ERROR,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_SERVER_ERROR),
+			"data":      gin.H{},
 		})
 		return
 	}
+	nicknameOutcome.audit(db, user.ID, user.ID)
+	bioOutcome.audit(db, user.ID, user.ID)
 
-	// 同步更新该用户已发布愿望中的冗余字段（昵称、头像）
-	// 为保证性能，这里一次性批量更新，不逐条加载。
-	if req.Nickname != nil || req.AvatarID != nil {
-		updates := map[string]interface{}{}
-		if req.Nickname != nil {
-			updates["user_nickname"] = user.Nickname
-		}
-		if req.AvatarID != nil {
-			updates["user_avatar_id"] = user.AvatarID
-		}
-		if len(updates) > 0 {
-			if err := db.Model(&model.Wish{}).Where("user_id = ?", user.ID).Updates(updates).Error; err != nil {
-				logger.Log.Errorw("UpdateUser: 同步更新愿望冗余用户信息失败", "userID", user.ID, "error", err)
-			}
-		}
-	}
 	//返回更新后的用户信息
 	responseUser := UserResponse{
 		ID:        user.ID,
 		Username:  user.Username,
 		Nickname:  user.Nickname,
 		AvatarID:  user.AvatarID,
+		Bio:       user.Bio,
 		Role:      user.Role,
 		CreatedAt: user.CreatedAt,
 	}
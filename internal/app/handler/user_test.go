@@ -5,7 +5,6 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
-	"os"
 	"testing"
 
 	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/model"
@@ -16,12 +15,10 @@ import (
 
 // 注册测试
 func TestRegister(t *testing.T) {
-
-	os.Setenv("JWT_SECRET", "my_strong_secret_key!")
 	t.Run("注册成功", func(t *testing.T) {
 		cleanup(testDB) // 每次测试前清理数据库
 		// 昵称为空，应默认使用学号
-		reqBody := `{"username":"1234567890","password":"testpassword", "nickname": ""}`
+		reqBody := `{"username":"1234567890","password":"test-password-1", "nickname": ""}`
 		w := httptest.NewRecorder()
 		req, _ := http.NewRequest("POST", "/api/register", bytes.NewBufferString(reqBody))
 		req.Header.Set("Content-Type", "application/json")
@@ -48,7 +45,7 @@ func TestRegister(t *testing.T) {
 		cleanup(testDB)
 		createUser("1234567890", "existingpass") // 先创建一个用户
 
-		reqBody := `{"username":"1234567890","password":"testpassword"}`
+		reqBody := `{"username":"1234567890","password":"test-password-1"}`
 		w := httptest.NewRecorder()
 		req, _ := http.NewRequest("POST", "/api/register", bytes.NewBufferString(reqBody))
 		req.Header.Set("Content-Type", "application/json")
@@ -65,7 +62,7 @@ func TestRegister(t *testing.T) {
 
 	t.Run("学号不为10位", func(t *testing.T) {
 		cleanup(testDB)
-		reqBody := `{"username":"123","password":"testpassword"}`
+		reqBody := `{"username":"123","password":"test-password-1"}`
 		w := httptest.NewRecorder()
 		req, _ := http.NewRequest("POST", "/api/register", bytes.NewBufferString(reqBody))
 		req.Header.Set("Content-Type", "application/json")
@@ -79,13 +76,25 @@ func TestRegister(t *testing.T) {
 		assert.Equal(t, "输入错误，请输入十位学号", data["error"])
 	})
 
+	t.Run("密码强度不足", func(t *testing.T) {
+		cleanup(testDB)
+		reqBody := `{"username":"1234567890","password":"testpassword"}`
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/register", bytes.NewBufferString(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		testRouter.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		resp := parseResponse(t, w)
+		assert.Equal(t, float64(apperr.ERROR_WEAK_PASSWORD), resp["code"])
+		data, _ := resp["data"].(map[string]interface{})
+		assert.Contains(t, data["error"], "至少两类")
+	})
+
 	t.Run("注册失败 (昵称违规)", func(t *testing.T) {
-		if os.Getenv("SILICONFLOW_API_KEY") == "" {
-			t.Skip("SILICONFLOW_API_KEY 环境变量未设置, 跳过 AI 违规测试")
-		}
 		cleanup(testDB)
 		// "我恨这个世界" 在 app_test.go 中被视为违规
-		reqBody := `{"username":"9876543210","password":"testpassword", "nickname": "我恨这个世界，我要跳楼了"}`
+		reqBody := `{"username":"9876543210","password":"test-password-1", "nickname": "我恨这个世界，我要跳楼了"}`
 		w := httptest.NewRecorder()
 		req, _ := http.NewRequest("POST", "/api/register", bytes.NewBufferString(reqBody))
 		req.Header.Set("Content-Type", "application/json")
@@ -104,7 +113,7 @@ func TestRegister(t *testing.T) {
 		longNickname := string(make([]byte, 1001)) // 1001 字节
 		reqBody := gin.H{
 			"username": "9876543211",
-			"password": "testpassword",
+			"password": "test-password-1",
 			"nickname": longNickname,
 		}
 		body, _ := json.Marshal(reqBody)
@@ -118,15 +127,13 @@ func TestRegister(t *testing.T) {
 		resp := parseResponse(t, w)
 		assert.Equal(t, float64(apperr.ERROR_PARAM_INVALID), resp["code"])
 		data, _ := resp["data"].(map[string]interface{})
-		// 验证错误是否从 ai_service 传递上来
-		assert.Equal(t, "内容长度不能超过1000个字符", data["error"])
+		// 验证错误是否从 ai_service 传递上来（昵称的长度上限由审核策略决定）
+		assert.Equal(t, "内容长度不能超过20个字符", data["error"])
 	})
 }
 
 // TestLogin 测试登录功能
 func TestLogin(t *testing.T) {
-	os.Setenv("JWT_SECRET", "my_strong_secret_key!")
-
 	t.Run("登录成功", func(t *testing.T) {
 		cleanup(testDB)
 		user := createUser("1000000001", "doublegood_password") // 创建用户
@@ -147,6 +154,8 @@ func TestLogin(t *testing.T) {
 
 		data, _ := resp["data"].(map[string]interface{})
 		assert.NotEmpty(t, data["token"])
+		assert.NotEmpty(t, data["refreshToken"])
+		assert.Equal(t, testConfig.JWT.TTL.Seconds(), data["expiresIn"])
 		respUser, _ := data["user"].(map[string]interface{})
 		assert.Equal(t, user.Username, respUser["username"])
 	})
@@ -262,9 +271,6 @@ func TestUpdateUser(t *testing.T) {
 
 	// AI 审核测试
 	t.Run("更新失败 (昵称违规)", func(t *testing.T) {
-		if os.Getenv("SILICONFLOW_API_KEY") == "" {
-			t.Skip("SILICONFLOW_API_KEY 环境变量未设置, 跳过 AI 违规测试")
-		}
 		cleanup(testDB)
 		user := createUser("3000000002", "password")
 		token := createToken(user.ID)
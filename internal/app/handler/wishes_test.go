@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
-	"os"
 	"strconv"
 	"testing"
 
@@ -54,9 +53,6 @@ func TestCreateWish(t *testing.T) {
 	})
 
 	t.Run("创建愿望失败 (AI 违规)", func(t *testing.T) {
-		if os.Getenv("SILICONFLOW_API_KEY") == "" {
-			t.Skip("SILICONFLOW_API_KEY 环境变量未设置, 跳过 AI 违规测试")
-		}
 		cleanup(testDB)
 		user := createUser("wish_creator_ai", "pass")
 		token := createToken(user.ID)
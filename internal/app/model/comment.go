@@ -18,6 +18,10 @@ type Comment struct {
 	UpdatedAt time.Time      `json:"updatedAt"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 
+	// 审核状态，取值见 moderation.go；存量数据默认为 approved
+	ModerationStatus string `gorm:"size:16;not null;default:'approved';index" json:"moderationStatus"`
+	ModerationReason string `gorm:"size:255;not null;default:''" json:"moderationReason,omitempty"` // 不通过/待复核的原因
+
 	// 关联关系
 	Wish    *Wish      `gorm:"foreignKey:WishID" json:"wish,omitempty"`
 	User    *User      `gorm:"foreignKey:UserID" json:"user,omitempty"`
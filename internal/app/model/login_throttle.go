@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+// LoginFailure 是一次登录失败记录，按滑动窗口统计学号或 IP 的失败次数；多个实例共享同一张表。
+// ThrottleKey 为 "account:<学号>" 或 "ip:<地址>"
+type LoginFailure struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	ThrottleKey string    `gorm:"size:128;not null;index:idx_login_failures_key_created,priority:1" json:"throttleKey"`
+	CreatedAt   time.Time `gorm:"not null;index;index:idx_login_failures_key_created,priority:2" json:"createdAt"`
+}
+
+// TableName 指定表名
+func (LoginFailure) TableName() string {
+	return "login_failures"
+}
+
+// LoginLockout 是学号或 IP 的锁定状态。Lockouts 为连续锁定的次数，决定下一次锁定的时长
+type LoginLockout struct {
+	ThrottleKey string    `gorm:"primaryKey;size:128" json:"throttleKey"`
+	Lockouts    int       `gorm:"not null;default:0" json:"lockouts"`
+	LockedUntil time.Time `gorm:"not null;index" json:"lockedUntil"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// TableName 指定表名
+func (LoginLockout) TableName() string {
+	return "login_lockouts"
+}
@@ -0,0 +1,10 @@
+package model
+
+// 内容审核状态
+// 同步审核模式下内容直接以 approved 落库；异步审核模式下先以 pending 落库，由后台审核协程更新
+const (
+	ModerationPending     = "pending"      // 等待审核
+	ModerationApproved    = "approved"     // 审核通过，对所有人可见
+	ModerationRejected    = "rejected"     // 审核不通过，仅作者可见
+	ModerationNeedsReview = "needs_review" // 自动审核无法给出结论，等待人工复核，仅作者可见
+)
@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+// ModerationCacheEntry 是持久化的审核结论缓存，服务重启后仍可复用
+// CacheKey 为 "提示词版本 + 内容类型 + 归一化内容" 的 SHA-256，不保存原文
+type ModerationCacheEntry struct {
+	CacheKey   string    `gorm:"primaryKey;size:64" json:"cacheKey"`
+	Violating  bool      `gorm:"not null" json:"violating"`
+	Provider   string    `gorm:"size:64;not null;default:''" json:"provider"`
+	Category   string    `gorm:"size:32;not null;default:''" json:"category"`
+	Categories string    `gorm:"size:255;not null;default:''" json:"categories"` // 逗号分隔
+	Confidence float64   `gorm:"not null;default:0" json:"confidence"`
+	Raw        string    `gorm:"type:text" json:"raw"`
+	ExpiresAt  time.Time `gorm:"not null;index" json:"expiresAt"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// TableName 指定表名
+func (ModerationCacheEntry) TableName() string {
+	return "moderation_cache"
+}
@@ -0,0 +1,34 @@
+package model
+
+import "time"
+
+// ModerationRecord 是一次内容审核的审计记录，也是人工复核队列的数据来源
+// 同步审核被拒绝的内容不会落库，此时 SubjectID 为 0，只保留记录中的内容快照
+type ModerationRecord struct {
+	ID          uint    `gorm:"primaryKey" json:"id"`
+	SubjectType string  `gorm:"size:16;not null;index:idx_moderation_subject" json:"subjectType"` // wish / comment / nickname
+	SubjectID   uint    `gorm:"not null;default:0;index:idx_moderation_subject" json:"subjectId"`
+	UserID      uint    `gorm:"not null;index" json:"userId"`
+	ContentHash string  `gorm:"size:64;not null;index" json:"contentHash"` // 内容的 SHA-256，便于统计重复提交
+	Content     string  `gorm:"type:text;not null" json:"content"`
+	Provider    string  `gorm:"size:64;not null;default:''" json:"provider"`
+	RawAnswer   string  `gorm:"type:text" json:"rawAnswer"`
+	Verdict     string  `gorm:"size:16;not null;index" json:"verdict"` // 自动审核结论：approved / rejected / needs_review
+	Category    string  `gorm:"size:32;not null;default:''" json:"category,omitempty"`
+	Reason      string  `gorm:"size:255;not null;default:''" json:"reason,omitempty"`
+	Confidence  float64 `gorm:"not null;default:0" json:"confidence"` // 审核器给出的违规把握，没有时为 0
+	LatencyMs   int64   `gorm:"not null;default:0" json:"latencyMs"`
+
+	// 人工复核，ReviewStatus 为空表示尚未复核
+	ReviewStatus string     `gorm:"size:16;not null;default:'';index" json:"reviewStatus"`
+	ReviewerID   *uint      `json:"reviewerId,omitempty"`
+	ReviewNote   string     `gorm:"size:255;not null;default:''" json:"reviewNote,omitempty"`
+	ReviewedAt   *time.Time `json:"reviewedAt,omitempty"`
+
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// TableName 指定表名
+func (ModerationRecord) TableName() string {
+	return "moderation_records"
+}
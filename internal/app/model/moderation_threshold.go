@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// ModerationThreshold 是某个违规类别的审核阈值，由管理员配置
+// 大模型给出的违规把握 >= RejectThreshold 时直接拒绝，介于 ReviewThreshold 与 RejectThreshold 之间时转人工复核，
+// 低于 ReviewThreshold 时放行；表中没有的类别使用默认阈值
+type ModerationThreshold struct {
+	Category        string    `gorm:"primaryKey;size:32" json:"category"`
+	RejectThreshold float64   `gorm:"not null" json:"rejectThreshold"`
+	ReviewThreshold float64   `gorm:"not null" json:"reviewThreshold"`
+	UpdatedBy       uint      `gorm:"not null;default:0" json:"updatedBy"`
+	UpdatedAt       time.Time `json:"updatedAt"`
+}
+
+// TableName 指定表名
+func (ModerationThreshold) TableName() string {
+	return "moderation_thresholds"
+}
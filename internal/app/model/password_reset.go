@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// PasswordReset 是一个密码重置码，由管理员签发或通过通知渠道发给用户。
+// 只保存重置码的哈希；使用一次、过期或签发了新的重置码后失效
+type PasswordReset struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `gorm:"not null;index" json:"userId"`
+	CodeHash  string     `gorm:"size:64;not null;uniqueIndex" json:"-"`      // 重置码的 SHA-256 (十六进制)
+	IssuedBy  *uint      `json:"issuedBy"`                                   // 签发的管理员，用户自助申请或命令行签发时为空
+	Channel   string     `gorm:"size:32;not null;default:''" json:"channel"` // 交付方式：admin / cli / 通知渠道名
+	ExpiresAt time.Time  `gorm:"not null" json:"expiresAt"`
+	UsedAt    *time.Time `json:"usedAt"` // 已使用或已作废的时间
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+// TableName 指定表名
+func (PasswordReset) TableName() string {
+	return "password_resets"
+}
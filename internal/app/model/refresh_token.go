@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// RefreshToken 是服务端保存的刷新令牌，只保存令牌的 SHA-256 哈希。
+// 一次登录产生一个令牌族 (FamilyID，同时作为访问令牌中的会话 ID)，每次刷新都会在族内换发新令牌、作废旧令牌；
+// 已作废的令牌被再次使用说明令牌可能泄露，整个令牌族会被吊销
+type RefreshToken struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	UserID       uint       `gorm:"not null;index" json:"userId"`
+	FamilyID     string     `gorm:"size:32;not null;index" json:"familyId"`
+	TokenHash    string     `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	UserAgent    string     `gorm:"size:255;not null;default:''" json:"userAgent"`
+	IP           string     `gorm:"size:64;not null;default:''" json:"ip"`
+	ExpiresAt    time.Time  `gorm:"not null" json:"expiresAt"`
+	RevokedAt    *time.Time `json:"revokedAt,omitempty"`    // 已换发或已退出登录
+	ReplacedByID *uint      `json:"replacedById,omitempty"` // 换发出的新令牌
+	CreatedAt    time.Time  `json:"createdAt"`
+}
+
+// TableName 指定表名
+func (RefreshToken) TableName() string {
+	return "refresh_tokens"
+}
@@ -0,0 +1,63 @@
+package model
+
+import "time"
+
+// 重新审核任务状态
+const (
+	RemoderationRunning   = "running"   // 正在执行
+	RemoderationPaused    = "paused"    // 被中断（进程退出、审核服务不可用），可以从检查点继续
+	RemoderationCompleted = "completed" // 已扫描完所有内容
+	RemoderationCancelled = "cancelled" // 被管理员取消
+)
+
+// RemoderationJob 是一次对存量愿望/评论的重新审核任务
+// LastWishID / LastCommentID 是检查点：按 ID 递增扫描，中断后从检查点之后继续
+type RemoderationJob struct {
+	ID            uint    `gorm:"primaryKey" json:"id"`
+	Status        string  `gorm:"size:16;not null;index" json:"status"`
+	Targets       string  `gorm:"size:32;not null" json:"targets"`      // 逗号分隔：wish,comment
+	DryRun        bool    `gorm:"not null;default:false" json:"dryRun"` // 只生成报告，不修改内容
+	BatchSize     int     `gorm:"not null" json:"batchSize"`
+	Rate          float64 `gorm:"not null" json:"rate"` // 每秒最多审核的条数
+	PromptVersion string  `gorm:"size:16;not null;default:''" json:"promptVersion"`
+	StartedBy     uint    `gorm:"not null;default:0" json:"startedBy"` // 发起的管理员，命令行发起时为 0
+
+	LastWishID    uint `gorm:"not null;default:0" json:"lastWishId"`
+	LastCommentID uint `gorm:"not null;default:0" json:"lastCommentId"`
+
+	Scanned int    `gorm:"not null;default:0" json:"scanned"`
+	Hidden  int    `gorm:"not null;default:0" json:"hidden"`  // 改为 rejected 的条数
+	Flagged int    `gorm:"not null;default:0" json:"flagged"` // 改为 needs_review 的条数
+	Skipped int    `gorm:"not null;default:0" json:"skipped"` // 审核期间内容被修改、删除或已有新结论而跳过的条数
+	Error   string `gorm:"size:255;not null;default:''" json:"error,omitempty"`
+
+	CreatedAt  time.Time  `json:"createdAt"`
+	UpdatedAt  time.Time  `json:"updatedAt"` // 兼作心跳，长时间未更新的 running 任务视为已中断
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+}
+
+// TableName 指定表名
+func (RemoderationJob) TableName() string {
+	return "remoderation_jobs"
+}
+
+// RemoderationChange 是重新审核任务报告中的一条：某条内容的审核状态发生了（或试运行时将会发生的）变化
+type RemoderationChange struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	JobID       uint      `gorm:"not null;index" json:"jobId"`
+	SubjectType string    `gorm:"size:16;not null" json:"subjectType"` // wish / comment
+	SubjectID   uint      `gorm:"not null" json:"subjectId"`
+	UserID      uint      `gorm:"not null" json:"userId"`
+	Content     string    `gorm:"type:text;not null" json:"content"`
+	OldStatus   string    `gorm:"size:16;not null" json:"oldStatus"`
+	NewStatus   string    `gorm:"size:16;not null" json:"newStatus"`
+	Category    string    `gorm:"size:32;not null;default:''" json:"category,omitempty"`
+	Reason      string    `gorm:"size:255;not null;default:''" json:"reason,omitempty"`
+	RecordID    uint      `gorm:"not null;default:0" json:"recordId,omitempty"` // 对应的审核记录，试运行时为 0
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// TableName 指定表名
+func (RemoderationChange) TableName() string {
+	return "remoderation_changes"
+}
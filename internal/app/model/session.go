@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// Session 是一次登录产生的登录会话，对应访问令牌中的 jti 和刷新令牌族 (RefreshToken.FamilyID)。
+// 退出登录或在设备列表中移除时删除该记录，访问令牌随即失效
+type Session struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	UserID     uint      `gorm:"not null;index" json:"userId"`
+	JTI        string    `gorm:"column:jti;size:32;not null;uniqueIndex" json:"-"`
+	UserAgent  string    `gorm:"size:255;not null;default:''" json:"userAgent"`
+	IP         string    `gorm:"size:64;not null;default:''" json:"ip"` // 最近一次登录或刷新令牌时的地址
+	CreatedAt  time.Time `json:"createdAt"`
+	LastSeenAt time.Time `gorm:"not null" json:"lastSeenAt"` // 最近一次访问，按分钟粒度更新
+	ExpiresAt  time.Time `gorm:"not null" json:"expiresAt"`  // 与当前刷新令牌同时过期
+}
+
+// TableName 指定表名
+func (Session) TableName() string {
+	return "sessions"
+}
@@ -27,6 +27,18 @@ type User struct {
 
 }
 
+// 用户角色
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin" // 可以删除任意愿望和评论、处理审核队列
+	RoleBot   = "bot"   // 数据填充创建的机器人用户，不能登录
+)
+
+// ValidRole 判断角色是否合法
+func ValidRole(role string) bool {
+	return role == RoleUser || role == RoleAdmin || role == RoleBot
+}
+
 // TableName 指定表名
 func (User) TableName() string {
 	return "users"
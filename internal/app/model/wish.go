@@ -21,6 +21,10 @@ type Wish struct {
 	UpdatedAt    time.Time      `json:"updatedAt"`
 	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
 
+	// 审核状态，取值见 moderation.go；存量数据默认为 approved
+	ModerationStatus string `gorm:"size:16;not null;default:'approved';index" json:"moderationStatus"`
+	ModerationReason string `gorm:"size:255;not null;default:''" json:"moderationReason,omitempty"` // 不通过/待复核的原因
+
 	// 关联关系
 	User     User      `gorm:"foreignKey:UserID" json:"user,omitempty"`
 	Tags     []WishTag `gorm:"foreignKey:WishID" json:"tags,omitempty"`
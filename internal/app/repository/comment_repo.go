@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/model"
+	"gorm.io/gorm"
+)
+
+type gormCommentRepo struct{ db *gorm.DB }
+
+func (r gormCommentRepo) FindByID(ctx context.Context, id uint) (*model.Comment, error) {
+	var comment model.Comment
+	if err := r.db.WithContext(ctx).Preload("User").First(&comment, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &comment, nil
+}
+
+func (r gormCommentRepo) Create(ctx context.Context, comment *model.Comment) error {
+	return translate(r.db.WithContext(ctx).Create(comment).Error)
+}
+
+func (r gormCommentRepo) Delete(ctx context.Context, id uint, approved bool) (bool, error) {
+	op := "<>"
+	if approved {
+		op = "="
+	}
+	res := r.db.WithContext(ctx).Where("id = ? AND moderation_status "+op+" ?", id, model.ModerationApproved).Delete(&model.Comment{})
+	return res.RowsAffected > 0, res.Error
+}
+
+func (r gormCommentRepo) DeleteByWish(ctx context.Context, wishID uint) error {
+	return r.db.WithContext(ctx).Unscoped().Where("wish_id = ?", wishID).Delete(&model.Comment{}).Error
+}
+
+func (r gormCommentRepo) ListApprovedByWish(ctx context.Context, wishID uint, page Page) ([]model.Comment, int64, error) {
+	query := r.db.WithContext(ctx).Model(&model.Comment{}).Where("wish_id = ? AND moderation_status = ?", wishID, model.ModerationApproved)
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var comments []model.Comment
+	err := paginate(query.Order("created_at asc"), page).Preload("User").Find(&comments).Error
+	return comments, total, err
+}
@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/model"
+	"gorm.io/gorm"
+)
+
+type gormLikeRepo struct{ db *gorm.DB }
+
+func (r gormLikeRepo) Exists(ctx context.Context, wishID, userID uint) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.Like{}).Where("wish_id = ? AND user_id = ?", wishID, userID).Count(&count).Error
+	return count > 0, err
+}
+
+func (r gormLikeRepo) Create(ctx context.Context, like *model.Like) error {
+	return translate(r.db.WithContext(ctx).Create(like).Error)
+}
+
+func (r gormLikeRepo) Delete(ctx context.Context, wishID, userID uint) error {
+	return r.db.WithContext(ctx).Unscoped().Where("wish_id = ? AND user_id = ?", wishID, userID).Delete(&model.Like{}).Error
+}
+
+func (r gormLikeRepo) DeleteByWish(ctx context.Context, wishID uint) error {
+	return r.db.WithContext(ctx).Unscoped().Where("wish_id = ?", wishID).Delete(&model.Like{}).Error
+}
+
+func (r gormLikeRepo) LikedWishIDs(ctx context.Context, userID uint, wishIDs []uint) (map[uint]bool, error) {
+	liked := make(map[uint]bool)
+	if len(wishIDs) == 0 {
+		return liked, nil
+	}
+	var ids []uint
+	if err := r.db.WithContext(ctx).Model(&model.Like{}).
+		Where("user_id = ? AND wish_id IN ?", userID, wishIDs).
+		Pluck("wish_id", &ids).Error; err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		liked[id] = true
+	}
+	return liked, nil
+}
@@ -0,0 +1,780 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/model"
+)
+
+// MemoryStore 是内存中的 Store，供单元测试使用，不需要数据库。
+// 约束与 GORM 实现保持一致：用户名唯一、同一用户对同一愿望只能点赞一次、计数不低于 0。
+// Transaction 期间独占整个 Store，fn 返回错误时恢复到事务开始前的数据。
+type MemoryStore struct {
+	mu   *sync.RWMutex
+	data *memData
+	inTx bool
+}
+
+type likeKey struct{ wishID, userID uint }
+
+type memData struct {
+	users    map[uint]model.User
+	wishes   map[uint]model.Wish
+	tags     map[uint][]string // wishID -> 标签名
+	likes    map[likeKey]model.Like
+	comments map[uint]model.Comment
+	tokens   map[uint]model.RefreshToken
+	sessions map[uint]model.Session
+	resets   map[uint]model.PasswordReset
+	nextID   uint
+}
+
+// NewMemoryStore 创建空的内存 Store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		mu: &sync.RWMutex{},
+		data: &memData{
+			users:    make(map[uint]model.User),
+			wishes:   make(map[uint]model.Wish),
+			tags:     make(map[uint][]string),
+			likes:    make(map[likeKey]model.Like),
+			comments: make(map[uint]model.Comment),
+			tokens:   make(map[uint]model.RefreshToken),
+			sessions: make(map[uint]model.Session),
+			resets:   make(map[uint]model.PasswordReset),
+		},
+	}
+}
+
+func (s *MemoryStore) Users() UserRepository                   { return memUserRepo{s} }
+func (s *MemoryStore) Wishes() WishRepository                  { return memWishRepo{s} }
+func (s *MemoryStore) Likes() LikeRepository                   { return memLikeRepo{s} }
+func (s *MemoryStore) Comments() CommentRepository             { return memCommentRepo{s} }
+func (s *MemoryStore) RefreshTokens() RefreshTokenRepository   { return memRefreshTokenRepo{s} }
+func (s *MemoryStore) Sessions() SessionRepository             { return memSessionRepo{s} }
+func (s *MemoryStore) PasswordResets() PasswordResetRepository { return memPasswordResetRepo{s} }
+
+// Transaction 在数据副本上执行 fn，成功后整体替换，失败则丢弃副本
+func (s *MemoryStore) Transaction(ctx context.Context, fn func(tx Store) error) error {
+	if s.inTx {
+		// 嵌套事务：与外层事务共享同一份副本
+		return fn(s)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tx := &MemoryStore{mu: s.mu, data: s.data.clone(), inTx: true}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	s.data = tx.data
+	return nil
+}
+
+// read / write 在非事务中加锁；事务中锁已由 Transaction 持有
+func (s *MemoryStore) read(fn func(d *memData) error) error {
+	if !s.inTx {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+	}
+	return fn(s.data)
+}
+
+func (s *MemoryStore) write(fn func(d *memData) error) error {
+	if !s.inTx {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+	}
+	return fn(s.data)
+}
+
+func (d *memData) clone() *memData {
+	c := &memData{
+		users:    make(map[uint]model.User, len(d.users)),
+		wishes:   make(map[uint]model.Wish, len(d.wishes)),
+		tags:     make(map[uint][]string, len(d.tags)),
+		likes:    make(map[likeKey]model.Like, len(d.likes)),
+		comments: make(map[uint]model.Comment, len(d.comments)),
+		tokens:   make(map[uint]model.RefreshToken, len(d.tokens)),
+		sessions: make(map[uint]model.Session, len(d.sessions)),
+		resets:   make(map[uint]model.PasswordReset, len(d.resets)),
+		nextID:   d.nextID,
+	}
+	for k, v := range d.users {
+		c.users[k] = v
+	}
+	for k, v := range d.wishes {
+		c.wishes[k] = v
+	}
+	for k, v := range d.tags {
+		c.tags[k] = append([]string(nil), v...)
+	}
+	for k, v := range d.likes {
+		c.likes[k] = v
+	}
+	for k, v := range d.comments {
+		c.comments[k] = v
+	}
+	for k, v := range d.tokens {
+		c.tokens[k] = v
+	}
+	for k, v := range d.sessions {
+		c.sessions[k] = v
+	}
+	for k, v := range d.resets {
+		c.resets[k] = v
+	}
+	return c
+}
+
+// newID 分配自增 ID；为简单起见所有表共用一个序列
+func (d *memData) newID() uint {
+	d.nextID++
+	return d.nextID
+}
+
+func stamp(createdAt, updatedAt *time.Time) {
+	now := time.Now()
+	if createdAt.IsZero() {
+		*createdAt = now
+	}
+	if updatedAt != nil && updatedAt.IsZero() {
+		*updatedAt = now
+	}
+}
+
+// page 截取一页数据
+func page[T any](items []T, p Page) []T {
+	if p.Limit <= 0 {
+		return items
+	}
+	if p.Offset >= len(items) {
+		return []T{}
+	}
+	return items[p.Offset:min(p.Offset+p.Limit, len(items))]
+}
+
+type memUserRepo struct{ s *MemoryStore }
+
+func (r memUserRepo) FindByID(ctx context.Context, id uint) (*model.User, error) {
+	var user model.User
+	err := r.s.read(func(d *memData) error {
+		u, ok := d.users[id]
+		if !ok {
+			return ErrNotFound
+		}
+		user = u
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r memUserRepo) FindByUsername(ctx context.Context, username string) (*model.User, error) {
+	var user model.User
+	err := r.s.read(func(d *memData) error {
+		for _, u := range d.users {
+			if u.Username == username {
+				user = u
+				return nil
+			}
+		}
+		return ErrNotFound
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r memUserRepo) Create(ctx context.Context, user *model.User) error {
+	return r.s.write(func(d *memData) error {
+		for _, u := range d.users {
+			if u.Username == user.Username {
+				return ErrDuplicate
+			}
+		}
+		if user.ID == 0 {
+			user.ID = d.newID()
+		}
+		if user.Role == "" {
+			user.Role = "user"
+		}
+		stamp(&user.CreatedAt, &user.UpdatedAt)
+		d.users[user.ID] = *user
+		return nil
+	})
+}
+
+func (r memUserRepo) Save(ctx context.Context, user *model.User) error {
+	return r.s.write(func(d *memData) error {
+		if user.ID == 0 {
+			user.ID = d.newID()
+		}
+		stamp(&user.CreatedAt, nil)
+		user.UpdatedAt = time.Now()
+		d.users[user.ID] = *user
+		return nil
+	})
+}
+
+type memWishRepo struct{ s *MemoryStore }
+
+func (r memWishRepo) FindByID(ctx context.Context, id uint) (*model.Wish, error) {
+	var wish model.Wish
+	err := r.s.read(func(d *memData) error {
+		w, ok := d.wishes[id]
+		if !ok {
+			return ErrNotFound
+		}
+		wish = w
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &wish, nil
+}
+
+func (r memWishRepo) LockByID(ctx context.Context, id uint) (*model.Wish, error) {
+	return r.FindByID(ctx, id)
+}
+
+func (r memWishRepo) Create(ctx context.Context, wish *model.Wish, tags []string) error {
+	return r.s.write(func(d *memData) error {
+		if wish.ID == 0 {
+			wish.ID = d.newID()
+		}
+		if wish.ModerationStatus == "" {
+			wish.ModerationStatus = model.ModerationApproved
+		}
+		stamp(&wish.CreatedAt, &wish.UpdatedAt)
+		d.wishes[wish.ID] = *wish
+		if len(tags) > 0 {
+			d.tags[wish.ID] = append([]string(nil), tags...)
+		}
+		return nil
+	})
+}
+
+func (r memWishRepo) Delete(ctx context.Context, id uint) error {
+	return r.s.write(func(d *memData) error {
+		delete(d.wishes, id)
+		delete(d.tags, id)
+		return nil
+	})
+}
+
+func (r memWishRepo) ListPublic(ctx context.Context, tag string, p Page) ([]model.Wish, int64, error) {
+	return r.list(p, func(d *memData, w model.Wish) bool {
+		if !w.IsPublic || w.ModerationStatus != model.ModerationApproved {
+			return false
+		}
+		if tag == "" {
+			return true
+		}
+		for _, t := range d.tags[w.ID] {
+			if t == tag {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+func (r memWishRepo) ListByUser(ctx context.Context, userID uint, p Page) ([]model.Wish, int64, error) {
+	return r.list(p, func(d *memData, w model.Wish) bool { return w.UserID == userID })
+}
+
+// list 过滤愿望并按创建时间倒序分页，同时填充作者信息
+func (r memWishRepo) list(p Page, keep func(d *memData, w model.Wish) bool) ([]model.Wish, int64, error) {
+	var wishes []model.Wish
+	err := r.s.read(func(d *memData) error {
+		for _, w := range d.wishes {
+			if keep(d, w) {
+				w.User = d.users[w.UserID]
+				wishes = append(wishes, w)
+			}
+		}
+		return nil
+	})
+	sort.Slice(wishes, func(i, j int) bool {
+		if !wishes[i].CreatedAt.Equal(wishes[j].CreatedAt) {
+			return wishes[i].CreatedAt.After(wishes[j].CreatedAt)
+		}
+		return wishes[i].ID > wishes[j].ID
+	})
+	return page(wishes, p), int64(len(wishes)), err
+}
+
+func (r memWishRepo) AddLikeCount(ctx context.Context, id uint, delta int) (int, error) {
+	var count int
+	err := r.s.write(func(d *memData) error {
+		w, ok := d.wishes[id]
+		if !ok {
+			return ErrNotFound
+		}
+		w.LikeCount = max(w.LikeCount+delta, 0)
+		d.wishes[id] = w
+		count = w.LikeCount
+		return nil
+	})
+	return count, err
+}
+
+func (r memWishRepo) AddCommentCount(ctx context.Context, id uint, delta int) error {
+	return r.s.write(func(d *memData) error {
+		if w, ok := d.wishes[id]; ok {
+			w.CommentCount = max(w.CommentCount+delta, 0)
+			d.wishes[id] = w
+		}
+		return nil
+	})
+}
+
+func (r memWishRepo) SyncAuthor(ctx context.Context, user *model.User) error {
+	return r.s.write(func(d *memData) error {
+		for id, w := range d.wishes {
+			if w.UserID == user.ID {
+				w.UserNickname = user.Nickname
+				w.UserAvatarID = user.AvatarID
+				d.wishes[id] = w
+			}
+		}
+		return nil
+	})
+}
+
+type memLikeRepo struct{ s *MemoryStore }
+
+func (r memLikeRepo) Exists(ctx context.Context, wishID, userID uint) (bool, error) {
+	var ok bool
+	err := r.s.read(func(d *memData) error {
+		_, ok = d.likes[likeKey{wishID, userID}]
+		return nil
+	})
+	return ok, err
+}
+
+func (r memLikeRepo) Create(ctx context.Context, like *model.Like) error {
+	return r.s.write(func(d *memData) error {
+		key := likeKey{like.WishID, like.UserID}
+		if _, ok := d.likes[key]; ok {
+			return ErrDuplicate
+		}
+		if like.ID == 0 {
+			like.ID = d.newID()
+		}
+		stamp(&like.CreatedAt, nil)
+		d.likes[key] = *like
+		return nil
+	})
+}
+
+func (r memLikeRepo) Delete(ctx context.Context, wishID, userID uint) error {
+	return r.s.write(func(d *memData) error {
+		delete(d.likes, likeKey{wishID, userID})
+		return nil
+	})
+}
+
+func (r memLikeRepo) DeleteByWish(ctx context.Context, wishID uint) error {
+	return r.s.write(func(d *memData) error {
+		for key := range d.likes {
+			if key.wishID == wishID {
+				delete(d.likes, key)
+			}
+		}
+		return nil
+	})
+}
+
+func (r memLikeRepo) LikedWishIDs(ctx context.Context, userID uint, wishIDs []uint) (map[uint]bool, error) {
+	liked := make(map[uint]bool)
+	err := r.s.read(func(d *memData) error {
+		for _, id := range wishIDs {
+			if _, ok := d.likes[likeKey{id, userID}]; ok {
+				liked[id] = true
+			}
+		}
+		return nil
+	})
+	return liked, err
+}
+
+type memCommentRepo struct{ s *MemoryStore }
+
+func (r memCommentRepo) FindByID(ctx context.Context, id uint) (*model.Comment, error) {
+	var comment model.Comment
+	err := r.s.read(func(d *memData) error {
+		cm, ok := d.comments[id]
+		if !ok {
+			return ErrNotFound
+		}
+		comment = withUser(d, cm)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+func (r memCommentRepo) Create(ctx context.Context, comment *model.Comment) error {
+	return r.s.write(func(d *memData) error {
+		if comment.ID == 0 {
+			comment.ID = d.newID()
+		}
+		if comment.ModerationStatus == "" {
+			comment.ModerationStatus = model.ModerationApproved
+		}
+		stamp(&comment.CreatedAt, &comment.UpdatedAt)
+		d.comments[comment.ID] = *comment
+		return nil
+	})
+}
+
+func (r memCommentRepo) Delete(ctx context.Context, id uint, approved bool) (bool, error) {
+	var deleted bool
+	err := r.s.write(func(d *memData) error {
+		cm, ok := d.comments[id]
+		if !ok || (cm.ModerationStatus == model.ModerationApproved) != approved {
+			return nil
+		}
+		delete(d.comments, id)
+		deleted = true
+		return nil
+	})
+	return deleted, err
+}
+
+func (r memCommentRepo) DeleteByWish(ctx context.Context, wishID uint) error {
+	return r.s.write(func(d *memData) error {
+		for id, cm := range d.comments {
+			if cm.WishID == wishID {
+				delete(d.comments, id)
+			}
+		}
+		return nil
+	})
+}
+
+func (r memCommentRepo) ListApprovedByWish(ctx context.Context, wishID uint, p Page) ([]model.Comment, int64, error) {
+	var comments []model.Comment
+	err := r.s.read(func(d *memData) error {
+		for _, cm := range d.comments {
+			if cm.WishID == wishID && cm.ModerationStatus == model.ModerationApproved {
+				comments = append(comments, withUser(d, cm))
+			}
+		}
+		return nil
+	})
+	sort.Slice(comments, func(i, j int) bool {
+		if !comments[i].CreatedAt.Equal(comments[j].CreatedAt) {
+			return comments[i].CreatedAt.Before(comments[j].CreatedAt)
+		}
+		return comments[i].ID < comments[j].ID
+	})
+	return page(comments, p), int64(len(comments)), err
+}
+
+// withUser 模拟 Preload("User")
+func withUser(d *memData, cm model.Comment) model.Comment {
+	if u, ok := d.users[cm.UserID]; ok {
+		cm.User = &u
+	}
+	return cm
+}
+
+type memRefreshTokenRepo struct{ s *MemoryStore }
+
+func (r memRefreshTokenRepo) FindByHash(ctx context.Context, hash string) (*model.RefreshToken, error) {
+	var token model.RefreshToken
+	err := r.s.read(func(d *memData) error {
+		for _, t := range d.tokens {
+			if t.TokenHash == hash {
+				token = t
+				return nil
+			}
+		}
+		return ErrNotFound
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r memRefreshTokenRepo) Create(ctx context.Context, token *model.RefreshToken) error {
+	return r.s.write(func(d *memData) error {
+		for _, t := range d.tokens {
+			if t.TokenHash == token.TokenHash {
+				return ErrDuplicate
+			}
+		}
+		if token.ID == 0 {
+			token.ID = d.newID()
+		}
+		stamp(&token.CreatedAt, nil)
+		d.tokens[token.ID] = *token
+		return nil
+	})
+}
+
+func (r memRefreshTokenRepo) Rotate(ctx context.Context, id, replacedByID uint, at time.Time) (bool, error) {
+	var rotated bool
+	err := r.s.write(func(d *memData) error {
+		t, ok := d.tokens[id]
+		if !ok || t.RevokedAt != nil {
+			return nil
+		}
+		t.RevokedAt, t.ReplacedByID = &at, &replacedByID
+		d.tokens[id] = t
+		rotated = true
+		return nil
+	})
+	return rotated, err
+}
+
+func (r memRefreshTokenRepo) RevokeFamily(ctx context.Context, userID uint, familyID string, at time.Time) (int64, error) {
+	return r.revoke(at, func(t model.RefreshToken) bool { return t.UserID == userID && t.FamilyID == familyID })
+}
+
+func (r memRefreshTokenRepo) RevokeByUser(ctx context.Context, userID uint, at time.Time) (int64, error) {
+	return r.revoke(at, func(t model.RefreshToken) bool { return t.UserID == userID })
+}
+
+func (r memRefreshTokenRepo) revoke(at time.Time, match func(t model.RefreshToken) bool) (int64, error) {
+	var n int64
+	err := r.s.write(func(d *memData) error {
+		for id, t := range d.tokens {
+			if t.RevokedAt == nil && match(t) {
+				t.RevokedAt = &at
+				d.tokens[id] = t
+				n++
+			}
+		}
+		return nil
+	})
+	return n, err
+}
+
+func (r memRefreshTokenRepo) DeleteExpired(ctx context.Context, userID uint, before time.Time) error {
+	return r.s.write(func(d *memData) error {
+		for id, t := range d.tokens {
+			if t.UserID == userID && t.ExpiresAt.Before(before) {
+				delete(d.tokens, id)
+			}
+		}
+		return nil
+	})
+}
+
+type memSessionRepo struct{ s *MemoryStore }
+
+func (r memSessionRepo) FindByID(ctx context.Context, id uint) (*model.Session, error) {
+	return r.find(func(ss model.Session) bool { return ss.ID == id })
+}
+
+func (r memSessionRepo) FindByJTI(ctx context.Context, jti string) (*model.Session, error) {
+	return r.find(func(ss model.Session) bool { return ss.JTI == jti })
+}
+
+func (r memSessionRepo) find(match func(ss model.Session) bool) (*model.Session, error) {
+	var session model.Session
+	err := r.s.read(func(d *memData) error {
+		for _, ss := range d.sessions {
+			if match(ss) {
+				session = ss
+				return nil
+			}
+		}
+		return ErrNotFound
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r memSessionRepo) Create(ctx context.Context, session *model.Session) error {
+	return r.s.write(func(d *memData) error {
+		for _, ss := range d.sessions {
+			if ss.JTI == session.JTI {
+				return ErrDuplicate
+			}
+		}
+		if session.ID == 0 {
+			session.ID = d.newID()
+		}
+		stamp(&session.CreatedAt, &session.LastSeenAt)
+		d.sessions[session.ID] = *session
+		return nil
+	})
+}
+
+func (r memSessionRepo) ListActiveByUser(ctx context.Context, userID uint, at time.Time) ([]model.Session, error) {
+	var sessions []model.Session
+	err := r.s.read(func(d *memData) error {
+		for _, ss := range d.sessions {
+			if ss.UserID == userID && ss.ExpiresAt.After(at) {
+				sessions = append(sessions, ss)
+			}
+		}
+		return nil
+	})
+	sort.Slice(sessions, func(i, j int) bool {
+		if !sessions[i].LastSeenAt.Equal(sessions[j].LastSeenAt) {
+			return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt)
+		}
+		return sessions[i].ID > sessions[j].ID
+	})
+	return sessions, err
+}
+
+func (r memSessionRepo) Touch(ctx context.Context, id uint, at, staleBefore time.Time) error {
+	return r.s.write(func(d *memData) error {
+		if ss, ok := d.sessions[id]; ok && ss.LastSeenAt.Before(staleBefore) {
+			ss.LastSeenAt = at
+			d.sessions[id] = ss
+		}
+		return nil
+	})
+}
+
+func (r memSessionRepo) Renew(ctx context.Context, id uint, expiresAt time.Time, ip string) error {
+	return r.s.write(func(d *memData) error {
+		if ss, ok := d.sessions[id]; ok {
+			ss.ExpiresAt, ss.IP = expiresAt, ip
+			d.sessions[id] = ss
+		}
+		return nil
+	})
+}
+
+func (r memSessionRepo) Delete(ctx context.Context, id uint) error {
+	return r.s.write(func(d *memData) error {
+		delete(d.sessions, id)
+		return nil
+	})
+}
+
+func (r memSessionRepo) DeleteByUser(ctx context.Context, userID uint) (int64, error) {
+	var n int64
+	err := r.s.write(func(d *memData) error {
+		for id, ss := range d.sessions {
+			if ss.UserID == userID {
+				delete(d.sessions, id)
+				n++
+			}
+		}
+		return nil
+	})
+	return n, err
+}
+
+func (r memSessionRepo) DeleteExpired(ctx context.Context, userID uint, before time.Time) error {
+	return r.s.write(func(d *memData) error {
+		for id, ss := range d.sessions {
+			if ss.UserID == userID && ss.ExpiresAt.Before(before) {
+				delete(d.sessions, id)
+			}
+		}
+		return nil
+	})
+}
+
+type memPasswordResetRepo struct{ s *MemoryStore }
+
+func (r memPasswordResetRepo) FindByHash(ctx context.Context, hash string) (*model.PasswordReset, error) {
+	var reset model.PasswordReset
+	err := r.s.read(func(d *memData) error {
+		for _, pr := range d.resets {
+			if pr.CodeHash == hash {
+				reset = pr
+				return nil
+			}
+		}
+		return ErrNotFound
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &reset, nil
+}
+
+func (r memPasswordResetRepo) Create(ctx context.Context, reset *model.PasswordReset) error {
+	return r.s.write(func(d *memData) error {
+		for _, pr := range d.resets {
+			if pr.CodeHash == reset.CodeHash {
+				return ErrDuplicate
+			}
+		}
+		if reset.ID == 0 {
+			reset.ID = d.newID()
+		}
+		stamp(&reset.CreatedAt, nil)
+		d.resets[reset.ID] = *reset
+		return nil
+	})
+}
+
+func (r memPasswordResetRepo) MarkUsed(ctx context.Context, id uint, at time.Time) (bool, error) {
+	var marked bool
+	err := r.s.write(func(d *memData) error {
+		pr, ok := d.resets[id]
+		if !ok || pr.UsedAt != nil {
+			return nil
+		}
+		pr.UsedAt = &at
+		d.resets[id] = pr
+		marked = true
+		return nil
+	})
+	return marked, err
+}
+
+func (r memPasswordResetRepo) InvalidateByUser(ctx context.Context, userID uint, at time.Time) (int64, error) {
+	var n int64
+	err := r.s.write(func(d *memData) error {
+		for id, pr := range d.resets {
+			if pr.UserID == userID && pr.UsedAt == nil {
+				pr.UsedAt = &at
+				d.resets[id] = pr
+				n++
+			}
+		}
+		return nil
+	})
+	return n, err
+}
+
+func (r memPasswordResetRepo) InvalidateByUserChannel(ctx context.Context, userID uint, channel string, at time.Time) (int64, error) {
+	var n int64
+	err := r.s.write(func(d *memData) error {
+		for id, pr := range d.resets {
+			if pr.UserID == userID && pr.Channel == channel && pr.UsedAt == nil {
+				pr.UsedAt = &at
+				d.resets[id] = pr
+				n++
+			}
+		}
+		return nil
+	})
+	return n, err
+}
+
+func (r memPasswordResetRepo) DeleteExpired(ctx context.Context, userID uint, before time.Time) error {
+	return r.s.write(func(d *memData) error {
+		for id, pr := range d.resets {
+			if pr.UserID == userID && pr.ExpiresAt.Before(before) {
+				delete(d.resets, id)
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("用户名唯一", func(t *testing.T) {
+		s := NewMemoryStore()
+		require.NoError(t, s.Users().Create(ctx, &model.User{Username: "2024000001"}))
+		err := s.Users().Create(ctx, &model.User{Username: "2024000001"})
+		assert.ErrorIs(t, err, ErrDuplicate)
+
+		_, err = s.Users().FindByUsername(ctx, "2024000002")
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("事务失败时回滚", func(t *testing.T) {
+		s := NewMemoryStore()
+		wish := model.Wish{UserID: 1, Content: "愿望", IsPublic: true}
+		require.NoError(t, s.Wishes().Create(ctx, &wish, nil))
+
+		boom := errors.New("boom")
+		err := s.Transaction(ctx, func(tx Store) error {
+			require.NoError(t, tx.Likes().Create(ctx, &model.Like{WishID: wish.ID, UserID: 2}))
+			_, err := tx.Wishes().AddLikeCount(ctx, wish.ID, 1)
+			require.NoError(t, err)
+			return boom
+		})
+		assert.ErrorIs(t, err, boom)
+
+		liked, err := s.Likes().Exists(ctx, wish.ID, 2)
+		require.NoError(t, err)
+		assert.False(t, liked)
+		got, err := s.Wishes().FindByID(ctx, wish.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 0, got.LikeCount)
+	})
+
+	t.Run("重复点赞与计数下限", func(t *testing.T) {
+		s := NewMemoryStore()
+		wish := model.Wish{UserID: 1, Content: "愿望"}
+		require.NoError(t, s.Wishes().Create(ctx, &wish, nil))
+
+		require.NoError(t, s.Likes().Create(ctx, &model.Like{WishID: wish.ID, UserID: 2}))
+		assert.ErrorIs(t, s.Likes().Create(ctx, &model.Like{WishID: wish.ID, UserID: 2}), ErrDuplicate)
+
+		count, err := s.Wishes().AddLikeCount(ctx, wish.ID, -1)
+		require.NoError(t, err)
+		assert.Equal(t, 0, count)
+	})
+
+	t.Run("公共愿望墙过滤、排序与分页", func(t *testing.T) {
+		s := NewMemoryStore()
+		author := model.User{Username: "2024000001", Nickname: "作者"}
+		require.NoError(t, s.Users().Create(ctx, &author))
+
+		base := time.Now()
+		create := func(content string, public bool, status string, offset time.Duration, tags ...string) {
+			w := model.Wish{UserID: author.ID, Content: content, IsPublic: public, ModerationStatus: status, CreatedAt: base.Add(offset)}
+			require.NoError(t, s.Wishes().Create(ctx, &w, tags))
+		}
+		create("旧的", true, model.ModerationApproved, 0, "学习")
+		create("新的", true, model.ModerationApproved, time.Minute)
+		create("私密", false, model.ModerationApproved, 2*time.Minute, "学习")
+		create("待审", true, model.ModerationPending, 3*time.Minute, "学习")
+
+		wishes, total, err := s.Wishes().ListPublic(ctx, "", Page{Limit: 1})
+		require.NoError(t, err)
+		assert.EqualValues(t, 2, total)
+		require.Len(t, wishes, 1)
+		assert.Equal(t, "新的", wishes[0].Content)
+		assert.Equal(t, "作者", wishes[0].User.Nickname)
+
+		wishes, total, err = s.Wishes().ListPublic(ctx, "学习", Page{})
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, total)
+		require.Len(t, wishes, 1)
+		assert.Equal(t, "旧的", wishes[0].Content)
+
+		mine, total, err := s.Wishes().ListByUser(ctx, author.ID, Page{Offset: 3, Limit: 10})
+		require.NoError(t, err)
+		assert.EqualValues(t, 4, total)
+		assert.Len(t, mine, 1)
+	})
+
+	t.Run("删除愿望级联", func(t *testing.T) {
+		s := NewMemoryStore()
+		wish := model.Wish{UserID: 1, Content: "愿望"}
+		require.NoError(t, s.Wishes().Create(ctx, &wish, []string{"标签"}))
+		require.NoError(t, s.Comments().Create(ctx, &model.Comment{WishID: wish.ID, UserID: 2, Content: "评论"}))
+		require.NoError(t, s.Likes().Create(ctx, &model.Like{WishID: wish.ID, UserID: 2}))
+
+		require.NoError(t, s.Transaction(ctx, func(tx Store) error {
+			if err := tx.Comments().DeleteByWish(ctx, wish.ID); err != nil {
+				return err
+			}
+			if err := tx.Likes().DeleteByWish(ctx, wish.ID); err != nil {
+				return err
+			}
+			return tx.Wishes().Delete(ctx, wish.ID)
+		}))
+
+		_, err := s.Wishes().FindByID(ctx, wish.ID)
+		assert.ErrorIs(t, err, ErrNotFound)
+		comments, total, err := s.Comments().ListApprovedByWish(ctx, wish.ID, Page{})
+		require.NoError(t, err)
+		assert.Zero(t, total)
+		assert.Empty(t, comments)
+	})
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/model"
+	"gorm.io/gorm"
+)
+
+type gormPasswordResetRepo struct{ db *gorm.DB }
+
+func (r gormPasswordResetRepo) FindByHash(ctx context.Context, hash string) (*model.PasswordReset, error) {
+	var reset model.PasswordReset
+	if err := r.db.WithContext(ctx).Where("code_hash = ?", hash).First(&reset).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &reset, nil
+}
+
+func (r gormPasswordResetRepo) Create(ctx context.Context, reset *model.PasswordReset) error {
+	return translate(r.db.WithContext(ctx).Create(reset).Error)
+}
+
+func (r gormPasswordResetRepo) MarkUsed(ctx context.Context, id uint, at time.Time) (bool, error) {
+	// 条件更新：并发使用同一个重置码时只有一个请求能够成功
+	res := r.db.WithContext(ctx).Model(&model.PasswordReset{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", at)
+	return res.RowsAffected > 0, res.Error
+}
+
+func (r gormPasswordResetRepo) InvalidateByUser(ctx context.Context, userID uint, at time.Time) (int64, error) {
+	res := r.db.WithContext(ctx).Model(&model.PasswordReset{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Update("used_at", at)
+	return res.RowsAffected, res.Error
+}
+
+func (r gormPasswordResetRepo) InvalidateByUserChannel(ctx context.Context, userID uint, channel string, at time.Time) (int64, error) {
+	res := r.db.WithContext(ctx).Model(&model.PasswordReset{}).
+		Where("user_id = ? AND channel = ? AND used_at IS NULL", userID, channel).
+		Update("used_at", at)
+	return res.RowsAffected, res.Error
+}
+
+func (r gormPasswordResetRepo) DeleteExpired(ctx context.Context, userID uint, before time.Time) error {
+	return r.db.WithContext(ctx).Where("user_id = ? AND expires_at < ?", userID, before).Delete(&model.PasswordReset{}).Error
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/model"
+	"gorm.io/gorm"
+)
+
+type gormRefreshTokenRepo struct{ db *gorm.DB }
+
+func (r gormRefreshTokenRepo) FindByHash(ctx context.Context, hash string) (*model.RefreshToken, error) {
+	var token model.RefreshToken
+	if err := r.db.WithContext(ctx).Where("token_hash = ?", hash).First(&token).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &token, nil
+}
+
+func (r gormRefreshTokenRepo) Create(ctx context.Context, token *model.RefreshToken) error {
+	return translate(r.db.WithContext(ctx).Create(token).Error)
+}
+
+func (r gormRefreshTokenRepo) Rotate(ctx context.Context, id, replacedByID uint, at time.Time) (bool, error) {
+	// 条件更新：并发使用同一个令牌刷新时只有一个请求能够成功
+	res := r.db.WithContext(ctx).Model(&model.RefreshToken{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Updates(map[string]interface{}{"revoked_at": at, "replaced_by_id": replacedByID})
+	return res.RowsAffected > 0, res.Error
+}
+
+func (r gormRefreshTokenRepo) RevokeFamily(ctx context.Context, userID uint, familyID string, at time.Time) (int64, error) {
+	res := r.db.WithContext(ctx).Model(&model.RefreshToken{}).
+		Where("user_id = ? AND family_id = ? AND revoked_at IS NULL", userID, familyID).
+		Update("revoked_at", at)
+	return res.RowsAffected, res.Error
+}
+
+func (r gormRefreshTokenRepo) RevokeByUser(ctx context.Context, userID uint, at time.Time) (int64, error) {
+	res := r.db.WithContext(ctx).Model(&model.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", at)
+	return res.RowsAffected, res.Error
+}
+
+func (r gormRefreshTokenRepo) DeleteExpired(ctx context.Context, userID uint, before time.Time) error {
+	return r.db.WithContext(ctx).Where("user_id = ? AND expires_at < ?", userID, before).Delete(&model.RefreshToken{}).Error
+}
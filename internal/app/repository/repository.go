@@ -0,0 +1,159 @@
+// Package repository 封装用户、愿望、点赞、评论的数据访问。
+// handler 与 service 只依赖这里的接口：生产环境使用 GORM 实现（NewGormStore），
+// 单元测试可以使用内存实现（NewMemoryStore），不需要 MySQL。
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/model"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrNotFound 表示记录不存在（GORM 实现中对应 gorm.ErrRecordNotFound）
+	ErrNotFound = errors.New("记录不存在")
+	// ErrDuplicate 表示违反唯一约束（如重复注册的用户名、重复的点赞）
+	ErrDuplicate = errors.New("记录已存在")
+)
+
+// Page 是分页参数，Limit <= 0 表示不限制条数
+type Page struct {
+	Offset int
+	Limit  int
+}
+
+// UserRepository 用户仓储
+type UserRepository interface {
+	FindByID(ctx context.Context, id uint) (*model.User, error)
+	FindByUsername(ctx context.Context, username string) (*model.User, error)
+	// Create 创建用户，用户名已存在时返回 ErrDuplicate
+	Create(ctx context.Context, user *model.User) error
+	// Save 保存用户的全部字段
+	Save(ctx context.Context, user *model.User) error
+}
+
+// WishRepository 愿望仓储；列表查询会同时加载作者（Wish.User）
+type WishRepository interface {
+	FindByID(ctx context.Context, id uint) (*model.Wish, error)
+	// LockByID 查询愿望并加行锁，在事务中使用，防止并发修改计数
+	LockByID(ctx context.Context, id uint) (*model.Wish, error)
+	// Create 创建愿望及其标签
+	Create(ctx context.Context, wish *model.Wish, tags []string) error
+	// Delete 物理删除愿望及其标签；评论、点赞由各自的仓储删除
+	Delete(ctx context.Context, id uint) error
+	// ListPublic 公共愿望墙：公开且审核通过的愿望，按创建时间倒序；tag 非空时只返回带该标签的愿望
+	ListPublic(ctx context.Context, tag string, page Page) ([]model.Wish, int64, error)
+	// ListByUser 某个用户的全部愿望（含各种审核状态），按创建时间倒序
+	ListByUser(ctx context.Context, userID uint, page Page) ([]model.Wish, int64, error)
+	// AddLikeCount 调整点赞数（不低于 0），返回调整后的点赞数
+	AddLikeCount(ctx context.Context, id uint, delta int) (int, error)
+	// AddCommentCount 调整评论数（不低于 0）
+	AddCommentCount(ctx context.Context, id uint, delta int) error
+	// SyncAuthor 把用户当前的昵称、头像同步到其所有愿望的冗余字段
+	SyncAuthor(ctx context.Context, user *model.User) error
+}
+
+// LikeRepository 点赞仓储
+type LikeRepository interface {
+	Exists(ctx context.Context, wishID, userID uint) (bool, error)
+	// Create 创建点赞，重复点赞时返回 ErrDuplicate
+	Create(ctx context.Context, like *model.Like) error
+	// Delete 物理删除点赞（软删除会占用 (wish_id, user_id) 唯一索引，导致无法再次点赞）
+	Delete(ctx context.Context, wishID, userID uint) error
+	DeleteByWish(ctx context.Context, wishID uint) error
+	// LikedWishIDs 返回 wishIDs 中 userID 点过赞的愿望
+	LikedWishIDs(ctx context.Context, userID uint, wishIDs []uint) (map[uint]bool, error)
+}
+
+// CommentRepository 评论仓储；查询会同时加载评论者（Comment.User）
+type CommentRepository interface {
+	FindByID(ctx context.Context, id uint) (*model.Comment, error)
+	Create(ctx context.Context, comment *model.Comment) error
+	// Delete 按删除时的审核状态条件删除评论：approved 为 true 时只删除审核通过的评论，为 false 时只删除其他状态的评论，
+	// 返回是否删除。调用方据此决定是否减少愿望的评论数，不受读取后状态被后台审核修改的影响
+	Delete(ctx context.Context, id uint, approved bool) (bool, error)
+	// DeleteByWish 物理删除愿望下的全部评论与回复
+	DeleteByWish(ctx context.Context, wishID uint) error
+	// ListApprovedByWish 愿望下审核通过的评论，按创建时间正序
+	ListApprovedByWish(ctx context.Context, wishID uint, page Page) ([]model.Comment, int64, error)
+}
+
+// RefreshTokenRepository 刷新令牌仓储；令牌以哈希形式保存，族 ID 即登录会话的 jti
+type RefreshTokenRepository interface {
+	// FindByHash 按令牌哈希查询，包括已作废和已过期的令牌
+	FindByHash(ctx context.Context, hash string) (*model.RefreshToken, error)
+	Create(ctx context.Context, token *model.RefreshToken) error
+	// Rotate 把令牌标记为已由 replacedByID 换发；令牌已经作废时不修改并返回 false
+	Rotate(ctx context.Context, id, replacedByID uint, at time.Time) (bool, error)
+	// RevokeFamily 作废用户某个令牌族中尚未作废的令牌，返回作废的条数
+	RevokeFamily(ctx context.Context, userID uint, familyID string, at time.Time) (int64, error)
+	// RevokeByUser 作废用户全部尚未作废的令牌，返回作废的条数
+	RevokeByUser(ctx context.Context, userID uint, at time.Time) (int64, error)
+	// DeleteExpired 物理删除用户在 before 之前过期的令牌
+	DeleteExpired(ctx context.Context, userID uint, before time.Time) error
+}
+
+// SessionRepository 登录会话仓储
+type SessionRepository interface {
+	FindByID(ctx context.Context, id uint) (*model.Session, error)
+	FindByJTI(ctx context.Context, jti string) (*model.Session, error)
+	Create(ctx context.Context, session *model.Session) error
+	// ListActiveByUser 用户在 at 时仍未过期的会话，按最近访问时间倒序
+	ListActiveByUser(ctx context.Context, userID uint, at time.Time) ([]model.Session, error)
+	// Touch 把最近访问时间更新为 at；只在记录的时间早于 staleBefore 时更新，避免每个请求都写数据库
+	Touch(ctx context.Context, id uint, at, staleBefore time.Time) error
+	// Renew 换发刷新令牌后延长会话的有效期，并记录最新的客户端地址
+	Renew(ctx context.Context, id uint, expiresAt time.Time, ip string) error
+	Delete(ctx context.Context, id uint) error
+	// DeleteByUser 删除用户的全部会话，返回删除的条数
+	DeleteByUser(ctx context.Context, userID uint) (int64, error)
+	// DeleteExpired 删除用户在 before 之前过期的会话
+	DeleteExpired(ctx context.Context, userID uint, before time.Time) error
+}
+
+// PasswordResetRepository 密码重置码仓储；重置码以哈希形式保存
+type PasswordResetRepository interface {
+	// FindByHash 按重置码哈希查询，包括已使用和已过期的重置码
+	FindByHash(ctx context.Context, hash string) (*model.PasswordReset, error)
+	Create(ctx context.Context, reset *model.PasswordReset) error
+	// MarkUsed 把重置码标记为已使用；已经使用或作废时不修改并返回 false
+	MarkUsed(ctx context.Context, id uint, at time.Time) (bool, error)
+	// InvalidateByUser 作废用户全部未使用的重置码，返回作废的条数
+	InvalidateByUser(ctx context.Context, userID uint, at time.Time) (int64, error)
+	// InvalidateByUserChannel 作废用户通过 channel 签发的未使用的重置码，返回作废的条数
+	InvalidateByUserChannel(ctx context.Context, userID uint, channel string, at time.Time) (int64, error)
+	// DeleteExpired 物理删除用户在 before 之前过期的重置码
+	DeleteExpired(ctx context.Context, userID uint, before time.Time) error
+}
+
+// Store 汇总所有仓储，并提供工作单元（Unit of Work）：
+// Transaction 中通过 tx 访问的仓储共享同一个事务，fn 返回错误时全部回滚
+type Store interface {
+	Users() UserRepository
+	Wishes() WishRepository
+	Likes() LikeRepository
+	Comments() CommentRepository
+	RefreshTokens() RefreshTokenRepository
+	Sessions() SessionRepository
+	PasswordResets() PasswordResetRepository
+	Transaction(ctx context.Context, fn func(tx Store) error) error
+}
+
+// translate 把 GORM / 数据库驱动的错误转换为本包的错误
+func translate(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return ErrNotFound
+	case errors.Is(err, gorm.ErrDuplicatedKey),
+		strings.Contains(err.Error(), "Error 1062"),               // MySQL
+		strings.Contains(err.Error(), "UNIQUE constraint failed"): // SQLite
+		return ErrDuplicate
+	}
+	return err
+}
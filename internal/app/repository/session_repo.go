@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/model"
+	"gorm.io/gorm"
+)
+
+type gormSessionRepo struct{ db *gorm.DB }
+
+func (r gormSessionRepo) FindByID(ctx context.Context, id uint) (*model.Session, error) {
+	var session model.Session
+	if err := r.db.WithContext(ctx).First(&session, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &session, nil
+}
+
+func (r gormSessionRepo) FindByJTI(ctx context.Context, jti string) (*model.Session, error) {
+	var session model.Session
+	if err := r.db.WithContext(ctx).Where("jti = ?", jti).First(&session).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &session, nil
+}
+
+func (r gormSessionRepo) Create(ctx context.Context, session *model.Session) error {
+	return translate(r.db.WithContext(ctx).Create(session).Error)
+}
+
+func (r gormSessionRepo) ListActiveByUser(ctx context.Context, userID uint, at time.Time) ([]model.Session, error) {
+	var sessions []model.Session
+	err := r.db.WithContext(ctx).Where("user_id = ? AND expires_at > ?", userID, at).
+		Order("last_seen_at DESC, id DESC").Find(&sessions).Error
+	return sessions, err
+}
+
+func (r gormSessionRepo) Touch(ctx context.Context, id uint, at, staleBefore time.Time) error {
+	return r.db.WithContext(ctx).Model(&model.Session{}).
+		Where("id = ? AND last_seen_at < ?", id, staleBefore).
+		Update("last_seen_at", at).Error
+}
+
+func (r gormSessionRepo) Renew(ctx context.Context, id uint, expiresAt time.Time, ip string) error {
+	return r.db.WithContext(ctx).Model(&model.Session{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"expires_at": expiresAt, "ip": ip}).Error
+}
+
+func (r gormSessionRepo) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&model.Session{}, id).Error
+}
+
+func (r gormSessionRepo) DeleteByUser(ctx context.Context, userID uint) (int64, error) {
+	res := r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&model.Session{})
+	return res.RowsAffected, res.Error
+}
+
+func (r gormSessionRepo) DeleteExpired(ctx context.Context, userID uint, before time.Time) error {
+	return r.db.WithContext(ctx).Where("user_id = ? AND expires_at < ?", userID, before).Delete(&model.Session{}).Error
+}
@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// GormStore 是基于 GORM 的 Store
+type GormStore struct {
+	db *gorm.DB
+}
+
+// NewGormStore 创建基于 GORM 的 Store
+func NewGormStore(db *gorm.DB) *GormStore {
+	return &GormStore{db: db}
+}
+
+func (s *GormStore) Users() UserRepository                   { return gormUserRepo{db: s.db} }
+func (s *GormStore) Wishes() WishRepository                  { return gormWishRepo{db: s.db} }
+func (s *GormStore) Likes() LikeRepository                   { return gormLikeRepo{db: s.db} }
+func (s *GormStore) Comments() CommentRepository             { return gormCommentRepo{db: s.db} }
+func (s *GormStore) RefreshTokens() RefreshTokenRepository   { return gormRefreshTokenRepo{db: s.db} }
+func (s *GormStore) Sessions() SessionRepository             { return gormSessionRepo{db: s.db} }
+func (s *GormStore) PasswordResets() PasswordResetRepository { return gormPasswordResetRepo{db: s.db} }
+
+// Transaction 在数据库事务中执行 fn；在事务中再次调用时使用保存点
+func (s *GormStore) Transaction(ctx context.Context, fn func(tx Store) error) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&GormStore{db: tx})
+	})
+}
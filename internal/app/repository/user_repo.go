@@ -1,4 +1,34 @@
 package repository
 
-//很遗憾这个文件没有发挥上他应有的功能，当我发现的时候我已经在做集成测试并且部分成功了，秉持着能跑就不要动的原则我没有选择重构。
-//（下次我会注意的，保留他是为了说明我现在大概想明白怎么避免耦合进一步优化项目结构了）
+import (
+	"context"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/model"
+	"gorm.io/gorm"
+)
+
+type gormUserRepo struct{ db *gorm.DB }
+
+func (r gormUserRepo) FindByID(ctx context.Context, id uint) (*model.User, error) {
+	var user model.User
+	if err := r.db.WithContext(ctx).First(&user, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &user, nil
+}
+
+func (r gormUserRepo) FindByUsername(ctx context.Context, username string) (*model.User, error) {
+	var user model.User
+	if err := r.db.WithContext(ctx).Where("username = ?", username).First(&user).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &user, nil
+}
+
+func (r gormUserRepo) Create(ctx context.Context, user *model.User) error {
+	return translate(r.db.WithContext(ctx).Create(user).Error)
+}
+
+func (r gormUserRepo) Save(ctx context.Context, user *model.User) error {
+	return translate(r.db.WithContext(ctx).Save(user).Error)
+}
@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type gormWishRepo struct{ db *gorm.DB }
+
+func (r gormWishRepo) FindByID(ctx context.Context, id uint) (*model.Wish, error) {
+	var wish model.Wish
+	if err := r.db.WithContext(ctx).First(&wish, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &wish, nil
+}
+
+func (r gormWishRepo) LockByID(ctx context.Context, id uint) (*model.Wish, error) {
+	var wish model.Wish
+	if err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).First(&wish, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &wish, nil
+}
+
+func (r gormWishRepo) Create(ctx context.Context, wish *model.Wish, tags []string) error {
+	return translate(r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(wish).Error; err != nil {
+			return err
+		}
+		if len(tags) == 0 {
+			return nil
+		}
+		rows := make([]model.WishTag, 0, len(tags))
+		for _, tag := range tags {
+			rows = append(rows, model.WishTag{WishID: wish.ID, TagName: tag})
+		}
+		return tx.Create(&rows).Error
+	}))
+}
+
+func (r gormWishRepo) Delete(ctx context.Context, id uint) error {
+	return translate(r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("wish_id = ?", id).Delete(&model.WishTag{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&model.Wish{}, id).Error
+	}))
+}
+
+func (r gormWishRepo) ListPublic(ctx context.Context, tag string, page Page) ([]model.Wish, int64, error) {
+	db := r.db.WithContext(ctx)
+	query := db.Model(&model.Wish{}).Where("is_public = ? AND moderation_status = ?", true, model.ModerationApproved)
+	if tag != "" {
+		query = query.Joins("JOIN wish_tags wt ON wt.wish_id = wishes.id AND wt.tag_name = ?", tag)
+	}
+
+	// 带标签时同一愿望可能有重复标签，按愿望 ID 去重后计数
+	var total int64
+	countQuery := query.Session(&gorm.Session{})
+	if tag != "" {
+		countQuery = db.Table("(?) as sub", query.Session(&gorm.Session{}).Select("wishes.id").Group("wishes.id"))
+	}
+	if err := countQuery.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var wishes []model.Wish
+	err := paginate(query.Select("wishes.*").Order("wishes.created_at desc"), page).
+		Preload("User").
+		Find(&wishes).Error
+	return wishes, total, err
+}
+
+func (r gormWishRepo) ListByUser(ctx context.Context, userID uint, page Page) ([]model.Wish, int64, error) {
+	query := r.db.WithContext(ctx).Model(&model.Wish{}).Where("user_id = ?", userID)
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var wishes []model.Wish
+	err := paginate(query.Order("created_at desc"), page).Preload("User").Find(&wishes).Error
+	return wishes, total, err
+}
+
+func (r gormWishRepo) AddLikeCount(ctx context.Context, id uint, delta int) (int, error) {
+	db := r.db.WithContext(ctx)
+	if err := db.Model(&model.Wish{}).Where("id = ?", id).
+		UpdateColumn("like_count", addExpr("like_count", delta)).Error; err != nil {
+		return 0, err
+	}
+	var wish model.Wish
+	if err := db.Select("like_count").First(&wish, id).Error; err != nil {
+		return 0, translate(err)
+	}
+	return wish.LikeCount, nil
+}
+
+func (r gormWishRepo) AddCommentCount(ctx context.Context, id uint, delta int) error {
+	return r.db.WithContext(ctx).Model(&model.Wish{}).Where("id = ?", id).
+		UpdateColumn("comment_count", addExpr("comment_count", delta)).Error
+}
+
+func (r gormWishRepo) SyncAuthor(ctx context.Context, user *model.User) error {
+	return r.db.WithContext(ctx).Model(&model.Wish{}).Where("user_id = ?", user.ID).Updates(map[string]interface{}{
+		"user_nickname":  user.Nickname,
+		"user_avatar_id": user.AvatarID,
+	}).Error
+}
+
+// addExpr 生成计数列的增减表达式，减少时不低于 0。
+// 不使用 MySQL 特有的 GREATEST，保证在 SQLite 上同样可用
+func addExpr(column string, delta int) clause.Expr {
+	if delta >= 0 {
+		return gorm.Expr(column+" + ?", delta)
+	}
+	return gorm.Expr("CASE WHEN "+column+" > ? THEN "+column+" - ? ELSE 0 END", -delta, -delta)
+}
+
+func paginate(query *gorm.DB, page Page) *gorm.DB {
+	if page.Limit > 0 {
+		query = query.Offset(page.Offset).Limit(page.Limit)
+	}
+	return query
+}
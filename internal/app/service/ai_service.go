@@ -2,105 +2,259 @@ package service
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"os"
+	"math/rand/v2"
 	"strings"
+	"time"
 
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/config"
 	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/logger"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/metrics"
 
 	"github.com/sashabaranov/go-openai"
 )
 
 var (
-	systemPrompt = `你是一个许愿墙内容审核员,中文和英文都需要检查,用户昵称审核要求可以放松一些,无意义内容可以直接放行。
+	systemPrompt = `你是一个许愿墙内容审核员,中文和英文都需要检查,无意义内容可以直接放行。
 你的唯一任务是判断用户提交的信息是否包含任何形式的：
-1. 色情或低俗内容
-2. 暴力或血腥
-3. 辱骂、人身攻击或仇恨言论
-4. 政治敏感或违法信息
-6. 广告或垃圾信息
-
-请仔细阅读 [用户信息]，然后**只回答一个词**:
-- 如果内容**安全**，请回答 "true"
-- 如果内容**不安全或违规**，请回答 "false"
+1. 色情：色情或低俗内容
+2. 暴力：暴力或血腥
+3. 辱骂：辱骂、人身攻击或仇恨言论
+4. 政治：政治敏感或违法信息
+5. 广告：广告或垃圾信息
+
+请仔细阅读 [用户信息]，然后**只输出一个 JSON 对象**，不要输出任何其他文字:
+{"safe": true 或 false, "categories": [命中的类别], "confidence": 0 到 1 之间的小数}
+- safe: 内容安全为 true，不安全或违规为 false
+- categories: 只能从 "色情"、"暴力"、"辱骂"、"政治"、"广告" 中选择，安全时为空数组
+- confidence: 你认为内容违规的把握，安全时给出一个较小的值
 `
 )
 
-// CheckContent(handler调用的函数)
-// isViolating= true 不安全，丢弃
-// isViolating= false 安全，接受
-func CheckContent(content string) (isViolating bool, err error) {
-	const maxContentLength = 100
-	trimmedContent := strings.TrimSpace(content)
-	if trimmedContent == "" {
-		logger.Log.Warnw("内容审核失败:内容为空", "content", content)
-		return true, fmt.Errorf("内容不能为空")
+// llmAnswer 是大模型按 systemPrompt 要求返回的 JSON
+type llmAnswer struct {
+	Safe       *bool    `json:"safe"`
+	Categories []string `json:"categories"`
+	Confidence *float64 `json:"confidence"`
+}
+
+// parseLLMAnswer 解析大模型的回答，ok=false 表示回答无法解析
+// 兼容模型把 JSON 包在代码块里、在 JSON 前后多说几句话，以及旧版提示词的 "true"/"false" 单词回答
+func parseLLMAnswer(respText string) (safe bool, categories []string, confidence float64, ok bool) {
+	trimmed := strings.TrimSpace(strings.ToLower(respText))
+	switch trimmed {
+	case "true":
+		return true, nil, 0, true
+	case "false":
+		return false, nil, 1, true
+	}
+
+	start, end := strings.Index(respText, "{"), strings.LastIndex(respText, "}")
+	if start < 0 || end <= start {
+		return false, nil, 0, false
 	}
-	if len([]rune(trimmedContent)) > maxContentLength {
-		logger.Log.Warnw("内容审核失败:内容过长", "content", content, "maxLength", maxContentLength)
-		return true, fmt.Errorf("内容长度不能超过%d个字符", maxContentLength)
+	var answer llmAnswer
+	if err := json.Unmarshal([]byte(respText[start:end+1]), &answer); err != nil || answer.Safe == nil {
+		return false, nil, 0, false
 	}
-	apiKey := os.Getenv("SILICONFLOW_API_KEY") //从环境变量读取API Key
-	if apiKey == "" {
-		logger.Log.Errorw("AI内容审核失败:环境变量 SILICONFLOW_API_KEY 未设置", "env_var", "SILICONFLOW_API_KEY")
-		return true, fmt.Errorf("AI service not configured")
+	for _, c := range answer.Categories {
+		if c = strings.TrimSpace(c); c != "" {
+			categories = append(categories, c)
+		}
 	}
+	switch {
+	case answer.Confidence != nil:
+		confidence = min(max(*answer.Confidence, 0), 1)
+	case !*answer.Safe:
+		// 模型判定违规但没有给出把握时按完全确定处理
+		confidence = 1
+	}
+	return *answer.Safe, categories, confidence, true
+}
+
+// LLMConfig 大模型审核器配置
+type LLMConfig struct {
+	APIKey  string
+	BaseURL string
+	Model   string
+
+	Timeout      time.Duration // 单次请求的超时时间，<=0 表示只受调用方 ctx 限制
+	MaxRetries   int           // 网络错误、超时、429、5xx 时的最大重试次数，0 表示不重试
+	RetryBackoff time.Duration // 首次重试前的最长等待时间，之后每次翻倍，实际等待时间在 [0, 上限) 内随机
+}
 
-	config := openai.DefaultConfig(apiKey)
-	baseURL := os.Getenv("SILICONFLOW_BASE_URL")
-	if baseURL == "" {
-		baseURL = "https://api.siliconflow.cn/v1"
+// LLMModerator 通过 Silicon Flow（OpenAI 兼容协议）调用大模型进行审核
+// client 在创建时构造一次，之后所有请求复用
+type LLMModerator struct {
+	client *openai.Client
+	model  string
+
+	timeout      time.Duration
+	maxRetries   int
+	retryBackoff time.Duration
+}
+
+// NewLLMModerator 根据配置创建大模型审核器；APIKey 为空时返回的审核器总是报告不可用
+func NewLLMModerator(cfg LLMConfig) *LLMModerator {
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = "https://api.siliconflow.cn/v1"
+	}
+	if cfg.Model == "" {
+		cfg.Model = "Qwen/Qwen3-VL-8B-Instruct"
+	}
+	m := &LLMModerator{model: cfg.Model, timeout: cfg.Timeout, maxRetries: cfg.MaxRetries, retryBackoff: cfg.RetryBackoff}
+	if cfg.APIKey == "" {
+		logger.Log.Errorw("AI内容审核未配置:环境变量 SILICONFLOW_API_KEY 未设置", "env_var", "SILICONFLOW_API_KEY")
+		return m
 	}
-	config.BaseURL = baseURL
+	config := openai.DefaultConfig(cfg.APIKey)
+	config.BaseURL = cfg.BaseURL
+	m.client = openai.NewClientWithConfig(config)
+	return m
+}
+
+// NewLLMModeratorFromConfig 按配置创建大模型审核器，未设置的地址和模型由 NewLLMModerator 补全默认值
+// 重试也受审核链的超时 (moderation.timeout) 限制
+func NewLLMModeratorFromConfig(cfg config.LLMConfig) *LLMModerator {
+	return NewLLMModerator(LLMConfig{
+		APIKey:       cfg.APIKey,
+		BaseURL:      cfg.BaseURL,
+		Model:        cfg.Model,
+		Timeout:      cfg.Timeout,
+		MaxRetries:   cfg.MaxRetries,
+		RetryBackoff: cfg.RetryBackoff,
+	})
+}
 
-	client := openai.NewClientWithConfig(config)
+func (m *LLMModerator) Name() string { return "llm" }
 
-	ctx := context.Background()
+// Check 调用大模型审核内容，提示词按 kind 对应的审核策略生成
+// Violating= true 不安全，丢弃
+// Violating= false 安全，接受
+func (m *LLMModerator) Check(ctx context.Context, kind ContentKind, content string) (Verdict, error) {
+	reject := Verdict{Violating: true, Provider: m.Name()}
+	if err := ValidateContent(ctx, kind, content); err != nil {
+		return reject, err
+	}
+	if m.client == nil {
+		return reject, fmt.Errorf("%w: AI service not configured", ErrModeratorUnavailable)
+	}
 
+	policy := PolicyFor(kind)
+	prompt := systemPrompt
+	if policy.Instruction != "" {
+		prompt += "\n补充要求：" + policy.Instruction + "\n"
+	}
 	messages := []openai.ChatCompletionMessage{
 		{
 			Role:    openai.ChatMessageRoleSystem,
-			Content: systemPrompt,
+			Content: prompt,
 		},
 		{
 			Role:    openai.ChatMessageRoleUser,
-			Content: fmt.Sprintf("[用户愿望]: %s", content),
+			Content: policy.render(content),
 		},
 	}
 
 	//发送给AI
-	resp, err := client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
-		Model:       "Qwen/Qwen3-VL-8B-Instruct",
+	resp, err := m.complete(ctx, openai.ChatCompletionRequest{
+		Model:       m.model,
 		Messages:    messages,
-		Temperature: 0.0, //需要确定的true或false，不能有随机性
-	},
-	)
-
+		Temperature: 0.0, //需要确定的结论，不能有随机性
+	})
 	if err != nil {
-		logger.Log.Errorw("Silicon Flow API请求失败", "error", err)
-		return true, err
+		contentLogger(ctx, content).Errorw("Silicon Flow API请求失败", "error", err)
+		return reject, fmt.Errorf("%w: %v", ErrModeratorUnavailable, err)
 	}
 
 	//解析AI回答
-	if len(resp.Choices) == 0 {
-		logger.Log.Warnw("AI返回空内容,无法判断安全性", "content", content)
-		return true, fmt.Errorf("AI返回空内容,无法判断安全性")
-	}
 
 	respText := resp.Choices[0].Message.Content
+	reject.Raw = respText
+
+	safe, categories, confidence, ok := parseLLMAnswer(respText)
+	if !ok {
+		//无法判断，交给人工复核
+		contentLogger(ctx, content).Warnw("AI内容审核:无法判断愿望安全性,转人工复核", "AI回复", respText)
+		return reject, ErrAmbiguous
+	}
+	verdict := Verdict{
+		Violating:  !safe,
+		Provider:   m.Name(),
+		Categories: categories,
+		Confidence: confidence,
+		Raw:        respText,
+	}
+	if len(categories) > 0 {
+		verdict.Category = categories[0]
+	}
+	if verdict.Violating {
+		contentLogger(ctx, content).Infow("AI内容审核:不安全愿望被丢弃", "categories", categories, "confidence", confidence)
+	} else {
+		contentLogger(ctx, content).Infow("AI内容审核:安全愿望被接受", "confidence", confidence)
+	}
+	return verdict, nil
+}
+
+// errEmptyResponse 表示 API 返回了空的 choices
+var errEmptyResponse = errors.New("AI返回空内容,无法判断安全性")
 
-	//判断错误
-	respTextTrimmed := strings.TrimSpace(strings.ToLower(respText))
-	if respTextTrimmed == "false" {
-		logger.Log.Infow("AI内容审核:不安全愿望被丢弃", "content", content)
-		return true, nil
+// complete 发送请求，可重试的错误按指数退避（随机抖动）重试；调用方 ctx 结束时不再重试
+func (m *LLMModerator) complete(ctx context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := m.attempt(ctx, req)
+		if err == nil {
+			return resp, nil
+		}
+		if attempt >= m.maxRetries || !retryable(err) || ctx.Err() != nil {
+			return resp, err
+		}
+		delay := jitter(m.retryBackoff << attempt)
+		logger.FromContext(ctx).Warnw("Silicon Flow API请求失败，稍后重试", "attempt", attempt+1, "delay", delay, "error", err)
+		select {
+		case <-ctx.Done():
+			return resp, err
+		case <-time.After(delay):
+		}
 	}
-	//正确
-	if respTextTrimmed == "true" {
-		logger.Log.Infow("AI内容审核:安全愿望被接受", "content", content)
-		return false, nil
+}
+
+// attempt 发送一次请求，单次请求的超时由 timeout 控制
+func (m *LLMModerator) attempt(ctx context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	if m.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, m.timeout)
+		defer cancel()
+	}
+	resp, err := m.client.CreateChatCompletion(ctx, req)
+	if err == nil && len(resp.Choices) == 0 {
+		err = errEmptyResponse
+	}
+	// 每次请求 (含重试) 都计入调用次数与 token 消耗
+	metrics.ObserveLLMRequest(err, resp.Usage.PromptTokens, resp.Usage.CompletionTokens)
+	return resp, err
+}
+
+// retryable 判断请求错误是否值得重试：HTTP 429 / 5xx、网络错误、单次请求超时、空回答可以重试，
+// 其他 4xx（如 API Key 无效）重试也不会成功
+func retryable(err error) bool {
+	var apiErr *openai.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.HTTPStatusCode == 429 || apiErr.HTTPStatusCode >= 500
+	}
+	var reqErr *openai.RequestError
+	if errors.As(err, &reqErr) {
+		return reqErr.HTTPStatusCode == 429 || reqErr.HTTPStatusCode >= 500
+	}
+	return true
+}
+
+// jitter 返回 [0, d) 内的随机时长，避免大量请求同时重试
+func jitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
 	}
-	//无法判断
-	logger.Log.Warnw("AI内容审核:无法判断愿望安全性,默认丢弃", "content", content, "AI回复", respText)
-	return true, fmt.Errorf("AI无法判断内容安全性")
+	return rand.N(d)
 }
@@ -1,19 +1,21 @@
 package service
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
-	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/logger"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/mockllm"
 	"github.com/sashabaranov/go-openai"
 	"github.com/stretchr/testify/assert"
 )
 
-func TestCheckContent(t *testing.T) {
+func TestLLMModeratorCheck(t *testing.T) {
 	logger.InitLogger()
 
 	//设置一个模拟silicon flow api服务器
@@ -30,10 +32,11 @@ func TestCheckContent(t *testing.T) {
 	}))
 	defer mockServer.Close()
 
-	// 覆盖环境变量，使用模拟服务器的URL
-	os.Setenv("SILICONFLOW_API_KEY", "test-api-key")
-	// 将 SILICONFLOW_BASE_URL 指向模拟服务器的 v1 路径
-	os.Setenv("SILICONFLOW_BASE_URL", mockServer.URL+"/v1")
+	// 直接构造审核器，BaseURL 指向模拟服务器的 v1 路径
+	moderator := NewLLMModerator(LLMConfig{
+		APIKey:  "test-api-key",
+		BaseURL: mockServer.URL + "/v1",
+	})
 
 	testCases := []struct {
 		name              string
@@ -41,6 +44,7 @@ func TestCheckContent(t *testing.T) {
 		mockResponse      openai.ChatCompletionResponse
 		expectedViolating bool
 		expectedErrorMsg  string
+		expectedCategory  string
 	}{
 		{
 			name:              "内容为空",
@@ -54,7 +58,7 @@ func TestCheckContent(t *testing.T) {
 			inputContent:      strings.Repeat("a", 1001),
 			mockResponse:      openai.ChatCompletionResponse{},
 			expectedViolating: true,
-			expectedErrorMsg:  "内容长度不能超过1000个字符",
+			expectedErrorMsg:  "内容长度不能超过100个字符",
 		},
 		{
 			name:         "内容安全",
@@ -86,6 +90,50 @@ func TestCheckContent(t *testing.T) {
 			expectedViolating: true,
 			expectedErrorMsg:  "",
 		},
+		{
+			name:         "JSON 回答：命中类别与把握",
+			inputContent: "你就是个废物",
+			mockResponse: openai.ChatCompletionResponse{
+				Choices: []openai.ChatCompletionChoice{
+					{
+						Message: openai.ChatCompletionMessage{
+							Content: `{"safe": false, "categories": ["辱骂"], "confidence": 0.93}`,
+						},
+					},
+				},
+			},
+			expectedViolating: true,
+			expectedCategory:  "辱骂",
+		},
+		{
+			name:         "JSON 回答包在代码块中",
+			inputContent: "希望四六级一次过",
+			mockResponse: openai.ChatCompletionResponse{
+				Choices: []openai.ChatCompletionChoice{
+					{
+						Message: openai.ChatCompletionMessage{
+							Content: "```json\n{\"safe\": true, \"categories\": [], \"confidence\": 0.02}\n```",
+						},
+					},
+				},
+			},
+			expectedViolating: false,
+		},
+		{
+			name:         "回答无法解析时转人工复核",
+			inputContent: "火星文",
+			mockResponse: openai.ChatCompletionResponse{
+				Choices: []openai.ChatCompletionChoice{
+					{
+						Message: openai.ChatCompletionMessage{
+							Content: "我不确定",
+						},
+					},
+				},
+			},
+			expectedViolating: true,
+			expectedErrorMsg:  "AI无法判断内容安全性",
+		},
 		{
 			name:         "API返回无法判断",
 			inputContent: "火星文",
@@ -102,10 +150,11 @@ func TestCheckContent(t *testing.T) {
 			mockAPIResponse = tc.mockResponse
 
 			// 调用要测试的函数
-			isViolating, err := CheckContent(tc.inputContent)
+			verdict, err := moderator.Check(context.Background(), KindWish, tc.inputContent)
 
 			// 验证结果
-			assert.Equal(t, tc.expectedViolating, isViolating)
+			assert.Equal(t, tc.expectedViolating, verdict.Violating)
+			assert.Equal(t, tc.expectedCategory, verdict.Category)
 
 			if tc.expectedErrorMsg != "" {
 				// 期望返回错误
@@ -118,8 +167,123 @@ func TestCheckContent(t *testing.T) {
 			}
 		})
 	}
+}
+
+func TestLLMModeratorRetry(t *testing.T) {
+	logger.InitLogger()
+
+	newModerator := func(srv *mockllm.TestServer, cfg LLMConfig) *LLMModerator {
+		cfg.APIKey, cfg.BaseURL = "test", srv.BaseURL()
+		return NewLLMModerator(cfg)
+	}
+
+	t.Run("5xx 重试后成功", func(t *testing.T) {
+		srv := mockllm.NewTestServer(mockllm.Rule{Status: http.StatusServiceUnavailable, Times: 2})
+		defer srv.Close()
+		moderator := newModerator(srv, LLMConfig{MaxRetries: 2, RetryBackoff: time.Millisecond})
+
+		verdict, err := moderator.Check(context.Background(), KindWish, "希望期末考试顺利通过")
+		assert.NoError(t, err)
+		assert.False(t, verdict.Violating)
+		assert.Len(t, srv.Requests(), 3)
+	})
+
+	t.Run("超过重试次数后返回不可用", func(t *testing.T) {
+		srv := mockllm.NewTestServer(mockllm.Rule{Status: http.StatusInternalServerError})
+		defer srv.Close()
+		moderator := newModerator(srv, LLMConfig{MaxRetries: 2, RetryBackoff: time.Millisecond})
+
+		_, err := moderator.Check(context.Background(), KindWish, "希望期末考试顺利通过")
+		assert.ErrorIs(t, err, ErrModeratorUnavailable)
+		assert.Len(t, srv.Requests(), 3)
+	})
+
+	t.Run("4xx 不重试", func(t *testing.T) {
+		srv := mockllm.NewTestServer(mockllm.Rule{Status: http.StatusUnauthorized})
+		defer srv.Close()
+		moderator := newModerator(srv, LLMConfig{MaxRetries: 2, RetryBackoff: time.Millisecond})
+
+		_, err := moderator.Check(context.Background(), KindWish, "希望期末考试顺利通过")
+		assert.ErrorIs(t, err, ErrModeratorUnavailable)
+		assert.Len(t, srv.Requests(), 1)
+	})
+
+	t.Run("单次请求超时与空回答后重试", func(t *testing.T) {
+		srv := mockllm.NewTestServer(
+			mockllm.Rule{Latency: time.Second, Times: 1},
+			mockllm.Rule{Empty: true, Times: 1},
+		)
+		defer srv.Close()
+		moderator := newModerator(srv, LLMConfig{Timeout: 50 * time.Millisecond, MaxRetries: 2})
+
+		_, err := moderator.Check(context.Background(), KindWish, "希望期末考试顺利通过")
+		assert.NoError(t, err)
+		assert.Len(t, srv.Requests(), 3)
+	})
+
+	t.Run("调用方 ctx 结束后不再重试", func(t *testing.T) {
+		srv := mockllm.NewTestServer(mockllm.Rule{Status: http.StatusBadGateway})
+		defer srv.Close()
+		moderator := newModerator(srv, LLMConfig{MaxRetries: 5, RetryBackoff: time.Second})
 
-	// 清理：移除测试时设置的环境变量
-	os.Unsetenv("SILICONFLOW_BASE_URL")
-	os.Unsetenv("SILICONFLOW_API_KEY")
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		_, err := moderator.Check(ctx, KindWish, "希望期末考试顺利通过")
+		assert.ErrorIs(t, err, ErrModeratorUnavailable)
+		assert.Less(t, time.Since(start), time.Second)
+	})
+
+	t.Run("请求使用配置的模型与审核策略", func(t *testing.T) {
+		srv := mockllm.NewTestServer(mockllm.DefaultRules()...)
+		defer srv.Close()
+		moderator := newModerator(srv, LLMConfig{Model: "test-model"})
+
+		verdict, err := moderator.Check(context.Background(), KindReply, "想赚钱的加我微信")
+		assert.NoError(t, err)
+		assert.True(t, verdict.Violating)
+		assert.Equal(t, "广告", verdict.Category)
+		assert.InDelta(t, 0.9, verdict.Confidence, 1e-9)
+
+		_, err = moderator.Check(context.Background(), KindWish, "这句话模棱两可")
+		assert.ErrorIs(t, err, ErrAmbiguous)
+
+		requests := srv.Requests()
+		assert.Equal(t, "test-model", requests[0].Model)
+		assert.Equal(t, "[评论回复]: 想赚钱的加我微信", requests[0].Content)
+		assert.Contains(t, requests[0].System, PolicyFor(KindReply).Instruction)
+	})
+}
+
+func TestParseLLMAnswer(t *testing.T) {
+	testCases := []struct {
+		name           string
+		respText       string
+		wantOK         bool
+		wantSafe       bool
+		wantCategories []string
+		wantConfidence float64
+	}{
+		{name: "旧版 true", respText: " True\n", wantOK: true, wantSafe: true},
+		{name: "旧版 false", respText: "false", wantOK: true, wantSafe: false, wantConfidence: 1},
+		{name: "多个类别", respText: `{"safe":false,"categories":["色情"," 广告 ",""],"confidence":0.7}`, wantOK: true, wantCategories: []string{"色情", "广告"}, wantConfidence: 0.7},
+		{name: "JSON 前后有多余文字", respText: `结论如下：{"safe":true,"categories":[],"confidence":0.1} 以上`, wantOK: true, wantSafe: true, wantConfidence: 0.1},
+		{name: "缺少把握时按完全确定处理", respText: `{"safe":false,"categories":["暴力"]}`, wantOK: true, wantCategories: []string{"暴力"}, wantConfidence: 1},
+		{name: "把握越界时截断", respText: `{"safe":false,"categories":["暴力"],"confidence":3}`, wantOK: true, wantCategories: []string{"暴力"}, wantConfidence: 1},
+		{name: "缺少 safe 字段", respText: `{"categories":["暴力"],"confidence":0.9}`},
+		{name: "不是 JSON", respText: "这段内容看起来没问题"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			safe, categories, confidence, ok := parseLLMAnswer(tc.respText)
+			assert.Equal(t, tc.wantOK, ok)
+			if !tc.wantOK {
+				return
+			}
+			assert.Equal(t, tc.wantSafe, safe)
+			assert.Equal(t, tc.wantCategories, categories)
+			assert.InDelta(t, tc.wantConfidence, confidence, 1e-9)
+		})
+	}
 }
@@ -0,0 +1,219 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/config"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/logger"
+)
+
+// 熔断器状态
+const (
+	BreakerClosed   = "closed"    // 正常放行请求
+	BreakerOpen     = "open"      // 连续失败次数达到阈值，直接失败，不再调用上游
+	BreakerHalfOpen = "half_open" // 冷却时间已过，只放行一个探测请求，成功则恢复，失败则重新熔断
+)
+
+// BreakerState 是熔断器的状态快照，用于健康检查输出
+type BreakerState struct {
+	Name                string     `json:"name"`
+	State               string     `json:"state"`
+	ConsecutiveFailures int        `json:"consecutiveFailures"`
+	OpenedAt            *time.Time `json:"openedAt,omitempty"`
+	LastError           string     `json:"lastError,omitempty"`
+	Opens               int64      `json:"opens"` // 累计熔断次数
+}
+
+// CircuitBreaker 是按连续失败次数熔断的熔断器，可以被多个 goroutine 同时使用
+type CircuitBreaker struct {
+	name      string
+	threshold int           // 连续失败多少次后熔断
+	cooldown  time.Duration // 熔断多久后放行探测请求
+	now       func() time.Time
+
+	mu       sync.Mutex
+	state    string
+	failures int
+	openedAt time.Time
+	probing  bool // 半开状态下是否已有探测请求在进行
+	lastErr  string
+	opens    int64
+}
+
+// NewCircuitBreaker 创建熔断器
+func NewCircuitBreaker(name string, threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{name: name, threshold: threshold, cooldown: cooldown, now: time.Now, state: BreakerClosed}
+}
+
+// Allow 判断是否可以调用上游；返回 true 后调用方必须调用 Success、Failure 或 Release 之一
+func (b *CircuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case BreakerOpen:
+		if b.now().Sub(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = BreakerHalfOpen
+		b.probing = true
+		logger.Log.Infow("熔断器进入半开状态，放行探测请求", "breaker", b.name)
+		return true
+	case BreakerHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	}
+	return true
+}
+
+// Success 记录一次成功调用，熔断器恢复为关闭状态
+func (b *CircuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state != BreakerClosed {
+		logger.Log.Infow("熔断器恢复", "breaker", b.name)
+	}
+	b.state = BreakerClosed
+	b.failures = 0
+	b.probing = false
+}
+
+// Failure 记录一次失败调用，连续失败达到阈值或探测失败时熔断
+func (b *CircuitBreaker) Failure(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	b.lastErr = err.Error()
+	b.probing = false
+	if b.state == BreakerHalfOpen || (b.state == BreakerClosed && b.failures >= b.threshold) {
+		b.state = BreakerOpen
+		b.openedAt = b.now()
+		b.opens++
+		logger.Log.Errorw("熔断器打开", "breaker", b.name, "failures", b.failures, "cooldown", b.cooldown, "error", err)
+	}
+}
+
+// Release 结束一次不能说明上游好坏的调用（如调用方取消），只释放探测名额
+func (b *CircuitBreaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// State 返回熔断器的状态快照
+func (b *CircuitBreaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	s := BreakerState{
+		Name:                b.name,
+		State:               b.state,
+		ConsecutiveFailures: b.failures,
+		LastError:           b.lastErr,
+		Opens:               b.opens,
+	}
+	if b.state != BreakerClosed {
+		openedAt := b.openedAt
+		s.OpenedAt = &openedAt
+	}
+	return s
+}
+
+// BreakerModerator 给审核器加上熔断：上游连续不可用时直接返回 ErrModeratorUnavailable，
+// 不再让每个请求都等到超时，审核链会立即降级到下一个审核器
+type BreakerModerator struct {
+	next    Moderator
+	breaker *CircuitBreaker
+}
+
+// NewBreakerModerator 创建带熔断的审核器
+func NewBreakerModerator(next Moderator, breaker *CircuitBreaker) *BreakerModerator {
+	return &BreakerModerator{next: next, breaker: breaker}
+}
+
+func (m *BreakerModerator) Name() string { return m.next.Name() }
+
+func (m *BreakerModerator) Check(ctx context.Context, kind ContentKind, content string) (Verdict, error) {
+	if !m.breaker.Allow() {
+		return Verdict{Violating: true, Provider: m.Name()}, fmt.Errorf("%w: %s 熔断中", ErrModeratorUnavailable, m.Name())
+	}
+	verdict, err := m.next.Check(ctx, kind, content)
+	switch {
+	case err == nil || errors.Is(err, ErrAmbiguous):
+		// 无法判断也说明上游正常返回了
+		m.breaker.Success()
+	case errors.Is(err, ErrModeratorUnavailable) && !errors.Is(ctx.Err(), context.Canceled):
+		m.breaker.Failure(err)
+	default:
+		// 调用方取消、输入校验失败等，不能说明上游的好坏
+		m.breaker.Release()
+	}
+	return verdict, err
+}
+
+// breakers 是 NewModeratorFromConfig 创建的熔断器，供健康检查查看状态
+var breakers struct {
+	sync.Mutex
+	list []*CircuitBreaker
+}
+
+// BreakerStates 返回所有熔断器的状态
+func BreakerStates() []BreakerState {
+	breakers.Lock()
+	defer breakers.Unlock()
+	states := make([]BreakerState, 0, len(breakers.list))
+	for _, b := range breakers.list {
+		states = append(states, b.State())
+	}
+	return states
+}
+
+// newBreakerModeratorFromConfig 按配置给大模型审核器加上熔断
+// BreakerThreshold:  连续失败多少次后熔断，0 表示不熔断
+// BreakerCooldown:   熔断多久后放行探测请求
+func newBreakerModeratorFromConfig(next Moderator, cfg config.ModerationConfig) Moderator {
+	threshold, cooldown := cfg.BreakerThreshold, cfg.BreakerCooldown
+	if threshold <= 0 {
+		return next
+	}
+	breaker := NewCircuitBreaker(next.Name(), threshold, cooldown)
+
+	breakers.Lock()
+	breakers.list = append(breakers.list, breaker)
+	breakers.Unlock()
+	logger.Log.Infow("审核熔断已开启", "provider", next.Name(), "threshold", threshold, "cooldown", cooldown)
+	return NewBreakerModerator(next, breaker)
+}
+
+// ModerationHealthStatus 是内容审核链路的健康状况
+type ModerationHealthStatus struct {
+	Moderator string         `json:"moderator"` // 审核链组成
+	Breakers  []BreakerState `json:"breakers"`
+	Cache     CacheStats     `json:"cache"`
+}
+
+// moderatorName 是 NewModeratorFromConfig 组装出的审核链名称
+var moderatorName struct {
+	sync.Mutex
+	name string
+}
+
+// ModerationHealth 返回内容审核链路的健康状况；有熔断器未恢复（打开或半开）时 degraded 为 true
+func ModerationHealth() (status ModerationHealthStatus, degraded bool) {
+	moderatorName.Lock()
+	status.Moderator = moderatorName.name
+	moderatorName.Unlock()
+	status.Breakers = BreakerStates()
+	status.Cache = ModerationCacheStats()
+	for _, b := range status.Breakers {
+		if b.State != BreakerClosed {
+			degraded = true
+		}
+	}
+	return status, degraded
+}
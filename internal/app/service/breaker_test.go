@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	logger.InitLogger()
+	now := time.Now()
+	b := NewCircuitBreaker("llm", 2, time.Minute)
+	b.now = func() time.Time { return now }
+	errDown := errors.New("503")
+
+	// 连续失败达到阈值后熔断，成功会清零失败计数
+	assert.True(t, b.Allow())
+	b.Failure(errDown)
+	assert.True(t, b.Allow())
+	b.Success()
+	assert.True(t, b.Allow())
+	b.Failure(errDown)
+	assert.Equal(t, BreakerClosed, b.State().State)
+	assert.True(t, b.Allow())
+	b.Failure(errDown)
+	assert.Equal(t, BreakerOpen, b.State().State)
+	assert.False(t, b.Allow())
+
+	// 冷却后只放行一个探测请求，探测失败重新熔断
+	now = now.Add(time.Minute)
+	assert.True(t, b.Allow())
+	assert.False(t, b.Allow())
+	assert.Equal(t, BreakerHalfOpen, b.State().State)
+	b.Failure(errDown)
+	assert.Equal(t, BreakerOpen, b.State().State)
+	assert.Equal(t, int64(2), b.State().Opens)
+
+	// 探测成功后恢复
+	now = now.Add(time.Minute)
+	assert.True(t, b.Allow())
+	b.Success()
+	state := b.State()
+	assert.Equal(t, BreakerClosed, state.State)
+	assert.Equal(t, 0, state.ConsecutiveFailures)
+	assert.Nil(t, state.OpenedAt)
+	assert.Equal(t, "503", state.LastError)
+}
+
+func TestBreakerModerator(t *testing.T) {
+	logger.InitLogger()
+
+	t.Run("熔断后不再调用上游，直接降级", func(t *testing.T) {
+		down := &stubModerator{name: "llm", err: fmt.Errorf("%w: 503", ErrModeratorUnavailable)}
+		local := &stubModerator{name: "keyword"}
+		chain := NewChainModerator(0, NewBreakerModerator(down, NewCircuitBreaker("llm", 2, time.Minute)), local)
+
+		for i := 0; i < 5; i++ {
+			verdict, err := chain.Check(context.Background(), KindWish, "希望明天不下雨")
+			assert.NoError(t, err)
+			assert.Equal(t, "keyword", verdict.Provider)
+		}
+		assert.Equal(t, 2, down.calls)
+		assert.Equal(t, 5, local.calls)
+	})
+
+	t.Run("无法判断与调用方取消不计为失败", func(t *testing.T) {
+		breaker := NewCircuitBreaker("llm", 1, time.Minute)
+		unsure := &stubModerator{name: "llm", err: ErrAmbiguous}
+		_, err := NewBreakerModerator(unsure, breaker).Check(context.Background(), KindWish, "火星文")
+		assert.ErrorIs(t, err, ErrAmbiguous)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		slow := &stubModerator{name: "llm", delay: time.Second}
+		_, err = NewBreakerModerator(slow, breaker).Check(ctx, KindWish, "你好")
+		assert.Error(t, err)
+		assert.Equal(t, BreakerClosed, breaker.State().State)
+	})
+}
@@ -0,0 +1,288 @@
+package service
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/model"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/config"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/logger"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PromptVersion 标识当前的大模型审核提示词，参与缓存 key 的计算，提示词修改后旧的缓存结论自动失效
+var PromptVersion = func() string {
+	sum := sha256.Sum256([]byte(systemPrompt))
+	return hex.EncodeToString(sum[:4])
+}()
+
+// CacheStats 是审核结论缓存的命中统计，Hits 即节省下来的审核调用次数
+type CacheStats struct {
+	Enabled   bool    `json:"enabled"`
+	Size      int     `json:"size"`
+	Capacity  int     `json:"capacity"`
+	Hits      int64   `json:"hits"`
+	StoreHits int64   `json:"storeHits"` // Hits 中由持久化缓存命中的次数
+	Misses    int64   `json:"misses"`
+	HitRate   float64 `json:"hitRate"`
+}
+
+// VerdictStore 是审核结论的持久化存储，让缓存在服务重启后仍然有效
+type VerdictStore interface {
+	Load(key string) (verdict Verdict, expiresAt time.Time, ok bool)
+	Save(key string, verdict Verdict, expiresAt time.Time)
+}
+
+type cacheEntry struct {
+	key       string
+	verdict   Verdict
+	expiresAt time.Time
+}
+
+// CachedModerator 在审核器前加一层 LRU + TTL 缓存，相同内容（归一化后）直接复用之前的结论
+// 只缓存明确的结论；审核器出错（不可用、无法判断等）时不缓存，下次重新审核
+type CachedModerator struct {
+	next     Moderator
+	capacity int
+	ttl      time.Duration
+	store    VerdictStore // 为 nil 时只缓存在内存中
+
+	mu    sync.Mutex
+	ll    *list.List // 队头为最近使用
+	items map[string]*list.Element
+
+	hits, storeHits, misses atomic.Int64
+}
+
+// NewCachedModerator 创建带缓存的审核器，capacity 为内存中最多缓存的条数
+func NewCachedModerator(next Moderator, capacity int, ttl time.Duration, store VerdictStore) *CachedModerator {
+	return &CachedModerator{
+		next:     next,
+		capacity: capacity,
+		ttl:      ttl,
+		store:    store,
+		ll:       list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+func (m *CachedModerator) Name() string { return m.next.Name() }
+
+func (m *CachedModerator) Check(ctx context.Context, kind ContentKind, content string) (Verdict, error) {
+	key := cacheKey(kind, content)
+	if verdict, ok := m.get(key); ok {
+		m.hits.Add(1)
+		return cachedVerdict(verdict), nil
+	}
+	if m.store != nil {
+		if verdict, expiresAt, ok := m.store.Load(key); ok {
+			m.hits.Add(1)
+			m.storeHits.Add(1)
+			m.put(key, verdict, expiresAt)
+			return cachedVerdict(verdict), nil
+		}
+	}
+	m.misses.Add(1)
+
+	verdict, err := m.next.Check(ctx, kind, content)
+	if err != nil {
+		return verdict, err
+	}
+	expiresAt := time.Now().Add(m.ttl)
+	m.put(key, verdict, expiresAt)
+	if m.store != nil {
+		m.store.Save(key, verdict, expiresAt)
+	}
+	return verdict, nil
+}
+
+// Stats 返回缓存命中统计
+func (m *CachedModerator) Stats() CacheStats {
+	m.mu.Lock()
+	size := m.ll.Len()
+	m.mu.Unlock()
+	stats := CacheStats{
+		Enabled:   true,
+		Size:      size,
+		Capacity:  m.capacity,
+		Hits:      m.hits.Load(),
+		StoreHits: m.storeHits.Load(),
+		Misses:    m.misses.Load(),
+	}
+	if total := stats.Hits + stats.Misses; total > 0 {
+		stats.HitRate = float64(stats.Hits) / float64(total)
+	}
+	return stats
+}
+
+func (m *CachedModerator) get(key string) (Verdict, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	el, ok := m.items[key]
+	if !ok {
+		return Verdict{}, false
+	}
+	entry := el.Value.(*cacheEntry)
+	if time.Now().After(entry.expiresAt) {
+		m.ll.Remove(el)
+		delete(m.items, key)
+		return Verdict{}, false
+	}
+	m.ll.MoveToFront(el)
+	return entry.verdict, true
+}
+
+func (m *CachedModerator) put(key string, verdict Verdict, expiresAt time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if el, ok := m.items[key]; ok {
+		el.Value = &cacheEntry{key: key, verdict: verdict, expiresAt: expiresAt}
+		m.ll.MoveToFront(el)
+		return
+	}
+	m.items[key] = m.ll.PushFront(&cacheEntry{key: key, verdict: verdict, expiresAt: expiresAt})
+	for m.ll.Len() > m.capacity {
+		oldest := m.ll.Back()
+		m.ll.Remove(oldest)
+		delete(m.items, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// cachedVerdict 标记结论来自缓存，便于在审核记录中区分
+func cachedVerdict(verdict Verdict) Verdict {
+	verdict.Provider += "(cache)"
+	return verdict
+}
+
+// cacheKey 计算缓存 key：提示词版本 + 内容类型的提示词 + 归一化后的内容
+// 同一段内容作为昵称和作为评论时审核标准不同，不能共用结论
+func cacheKey(kind ContentKind, content string) string {
+	policy := PolicyFor(kind)
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		PromptVersion, string(kind), policy.Template, policy.Instruction, normalizeForCache(content),
+	}, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeForCache 去掉首尾空白、合并连续空白、全角转半角并转小写，
+// 让 "希望期末考试顺利通过!" 与 "希望期末考试顺利通过！ " 共用一条缓存
+func normalizeForCache(content string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range strings.TrimSpace(content) {
+		switch {
+		case r == 0x3000 || unicode.IsSpace(r):
+			space = true
+			continue
+		case r >= 0xFF01 && r <= 0xFF5E:
+			r -= 0xFEE0 // 全角 ASCII -> 半角
+		}
+		if space {
+			b.WriteByte(' ')
+			space = false
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// DBVerdictStore 把审核结论缓存保存在 moderation_cache 表中
+type DBVerdictStore struct {
+	db *gorm.DB
+}
+
+// NewDBVerdictStore 创建基于数据库的结论存储
+func NewDBVerdictStore(db *gorm.DB) *DBVerdictStore {
+	return &DBVerdictStore{db: db}
+}
+
+func (s *DBVerdictStore) Load(key string) (Verdict, time.Time, bool) {
+	var entry model.ModerationCacheEntry
+	err := s.db.Where("cache_key = ? AND expires_at > ?", key, time.Now()).First(&entry).Error
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.Log.Errorw("读取审核缓存失败", "error", err)
+		}
+		return Verdict{}, time.Time{}, false
+	}
+	verdict := Verdict{
+		Violating:  entry.Violating,
+		Provider:   entry.Provider,
+		Category:   entry.Category,
+		Confidence: entry.Confidence,
+		Raw:        entry.Raw,
+	}
+	if entry.Categories != "" {
+		verdict.Categories = strings.Split(entry.Categories, ",")
+	}
+	return verdict, entry.ExpiresAt, true
+}
+
+func (s *DBVerdictStore) Save(key string, verdict Verdict, expiresAt time.Time) {
+	entry := model.ModerationCacheEntry{
+		CacheKey:   key,
+		Violating:  verdict.Violating,
+		Provider:   verdict.Provider,
+		Category:   verdict.Category,
+		Categories: strings.Join(verdict.Categories, ","),
+		Confidence: verdict.Confidence,
+		Raw:        verdict.Raw,
+		ExpiresAt:  expiresAt,
+	}
+	if err := s.db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&entry).Error; err != nil {
+		logger.Log.Errorw("写入审核缓存失败", "error", err)
+	}
+}
+
+// PurgeExpired 删除已过期的缓存结论
+func (s *DBVerdictStore) PurgeExpired() (int64, error) {
+	res := s.db.Where("expires_at <= ?", time.Now()).Delete(&model.ModerationCacheEntry{})
+	return res.RowsAffected, res.Error
+}
+
+// moderationCache 是 NewModeratorFromConfig 创建的大模型结论缓存，供管理接口查看命中统计
+var moderationCache atomic.Pointer[CachedModerator]
+
+// ModerationCacheStats 返回大模型结论缓存的命中统计；未开启缓存时 Enabled 为 false
+func ModerationCacheStats() CacheStats {
+	if cache := moderationCache.Load(); cache != nil {
+		return cache.Stats()
+	}
+	return CacheStats{}
+}
+
+// newCachedModeratorFromConfig 按配置给大模型审核器加上结论缓存
+// CacheSize:     内存中最多缓存的结论条数，0 表示不缓存
+// CacheTTL:      缓存有效期
+// CachePersist:  是否把结论写入数据库（moderation_cache 表），服务重启后仍可复用
+func newCachedModeratorFromConfig(next Moderator, db *gorm.DB, cfg config.ModerationConfig) Moderator {
+	capacity, ttl := cfg.CacheSize, cfg.CacheTTL
+	if capacity == 0 {
+		return next
+	}
+
+	var store VerdictStore
+	if cfg.CachePersist && db != nil {
+		dbStore := NewDBVerdictStore(db)
+		if n, err := dbStore.PurgeExpired(); err != nil {
+			logger.Log.Errorw("清理过期审核缓存失败", "error", err)
+		} else if n > 0 {
+			logger.Log.Infow("已清理过期审核缓存", "count", n)
+		}
+		store = dbStore
+	}
+
+	cache := NewCachedModerator(next, capacity, ttl, store)
+	moderationCache.Store(cache)
+	logger.Log.Infow("审核结论缓存已开启", "capacity", capacity, "ttl", ttl, "persist", store != nil, "promptVersion", PromptVersion)
+	return cache
+}
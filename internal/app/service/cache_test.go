@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryVerdictStore 是测试用的持久化存储，模拟服务重启后仍保留的结论
+type memoryVerdictStore struct {
+	entries map[string]cacheEntry
+}
+
+func (s *memoryVerdictStore) Load(key string) (Verdict, time.Time, bool) {
+	entry, ok := s.entries[key]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return Verdict{}, time.Time{}, false
+	}
+	return entry.verdict, entry.expiresAt, true
+}
+
+func (s *memoryVerdictStore) Save(key string, verdict Verdict, expiresAt time.Time) {
+	s.entries[key] = cacheEntry{key: key, verdict: verdict, expiresAt: expiresAt}
+}
+
+func TestCachedModerator(t *testing.T) {
+	logger.InitLogger()
+	ctx := context.Background()
+
+	t.Run("归一化后相同的内容命中缓存", func(t *testing.T) {
+		llm := &stubModerator{name: "llm", verdict: Verdict{Violating: false, Confidence: 0.1}}
+		cache := NewCachedModerator(llm, 10, time.Minute, nil)
+
+		_, err := cache.Check(ctx, KindWish, "希望期末考试顺利通过!")
+		assert.NoError(t, err)
+		verdict, err := cache.Check(ctx, KindWish, "  希望期末考试顺利通过！ ")
+		assert.NoError(t, err)
+		assert.False(t, verdict.Violating)
+		assert.Equal(t, "llm(cache)", verdict.Provider)
+		assert.Equal(t, 1, llm.calls)
+
+		stats := cache.Stats()
+		assert.Equal(t, int64(1), stats.Hits)
+		assert.Equal(t, int64(1), stats.Misses)
+		assert.Equal(t, 1, stats.Size)
+		assert.InDelta(t, 0.5, stats.HitRate, 1e-9)
+	})
+
+	t.Run("审核出错时不缓存", func(t *testing.T) {
+		llm := &stubModerator{name: "llm", verdict: Verdict{Violating: true}, err: ErrAmbiguous}
+		cache := NewCachedModerator(llm, 10, time.Minute, nil)
+
+		for i := 0; i < 2; i++ {
+			_, err := cache.Check(ctx, KindWish, "火星文")
+			assert.ErrorIs(t, err, ErrAmbiguous)
+		}
+		assert.Equal(t, 2, llm.calls)
+		assert.Equal(t, 0, cache.Stats().Size)
+	})
+
+	t.Run("超过容量时淘汰最久未使用的结论", func(t *testing.T) {
+		llm := &stubModerator{name: "llm"}
+		cache := NewCachedModerator(llm, 2, time.Minute, nil)
+
+		cache.Check(ctx, KindWish, "a")
+		cache.Check(ctx, KindWish, "b")
+		cache.Check(ctx, KindWish, "a") // a 变为最近使用
+		cache.Check(ctx, KindWish, "c") // 淘汰 b
+		assert.Equal(t, 3, llm.calls)
+
+		cache.Check(ctx, KindWish, "a")
+		assert.Equal(t, 3, llm.calls)
+		cache.Check(ctx, KindWish, "b")
+		assert.Equal(t, 4, llm.calls)
+		assert.Equal(t, 2, cache.Stats().Size)
+	})
+
+	t.Run("过期后重新审核", func(t *testing.T) {
+		llm := &stubModerator{name: "llm"}
+		cache := NewCachedModerator(llm, 10, 20*time.Millisecond, nil)
+
+		cache.Check(ctx, KindWish, "好想早点回家")
+		time.Sleep(30 * time.Millisecond)
+		cache.Check(ctx, KindWish, "好想早点回家")
+		assert.Equal(t, 2, llm.calls)
+	})
+
+	t.Run("内存未命中时从持久化存储读取", func(t *testing.T) {
+		store := &memoryVerdictStore{entries: map[string]cacheEntry{}}
+		llm := &stubModerator{name: "llm", verdict: Verdict{Violating: true, Category: "辱骂", Categories: []string{"辱骂"}, Confidence: 0.9}}
+		NewCachedModerator(llm, 10, time.Minute, store).Check(ctx, KindWish, "你就是个废物")
+		assert.Len(t, store.entries, 1)
+
+		// 模拟重启：新的缓存实例，内存为空
+		restarted := NewCachedModerator(llm, 10, time.Minute, store)
+		verdict, err := restarted.Check(ctx, KindWish, "你就是个废物")
+		assert.NoError(t, err)
+		assert.True(t, verdict.Violating)
+		assert.Equal(t, []string{"辱骂"}, verdict.Categories)
+		assert.Equal(t, 1, llm.calls)
+		assert.Equal(t, int64(1), restarted.Stats().StoreHits)
+	})
+}
+
+func TestCacheKey(t *testing.T) {
+	testCases := []struct {
+		a, b string
+		same bool
+	}{
+		{a: "希望期末考试顺利通过!", b: "希望期末考试顺利通过！", same: true},
+		{a: "Hello  World", b: " hello world ", same: true},
+		{a: "ＡＢＣ", b: "abc", same: true},
+		{a: "我想去北京", b: "我想去南京", same: false},
+		{a: "hello world", b: "helloworld", same: false},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("%s|%s", tc.a, tc.b), func(t *testing.T) {
+			assert.Equal(t, tc.same, cacheKey(KindWish, tc.a) == cacheKey(KindWish, tc.b))
+		})
+	}
+
+	t.Run("不同内容类型不共用结论", func(t *testing.T) {
+		assert.NotEqual(t, cacheKey(KindNickname, "小明"), cacheKey(KindComment, "小明"))
+	})
+}
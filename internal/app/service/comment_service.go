@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/model"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/repository"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/logger"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/metrics"
+)
+
+// CommentService 负责评论、回复的发布与删除规则，以及愿望评论数的维护
+// （只有审核通过的评论计入 comment_count）
+type CommentService struct {
+	store repository.Store
+}
+
+// NewCommentService 创建 CommentService
+func NewCommentService(store repository.Store) *CommentService {
+	return &CommentService{store: store}
+}
+
+// CreateCommentInput 发布评论或回复的参数；ParentID 非 nil 时为回复。
+// 内容应当已经过审核，Status 为内容的审核状态
+type CreateCommentInput struct {
+	WishID   uint
+	ParentID *uint
+	UserID   uint
+	Content  string
+	Status   string
+}
+
+// CheckCommentable 检查 userID 能否评论该愿望：
+// 愿望不存在或对其不可见时返回 ErrWishNotFound，私密愿望只有作者本人可以评论
+func (s *CommentService) CheckCommentable(ctx context.Context, userID, wishID uint) error {
+	_, err := commentableWish(ctx, s.store, userID, wishID)
+	return err
+}
+
+func commentableWish(ctx context.Context, store repository.Store, userID, wishID uint) (*model.Wish, error) {
+	wish, err := store.Wishes().FindByID(ctx, wishID)
+	if err != nil {
+		return nil, notFoundAs(err, ErrWishNotFound)
+	}
+	if !WishVisibleTo(wish, userID) {
+		return nil, ErrWishNotFound
+	}
+	if !wish.IsPublic && wish.UserID != userID {
+		return nil, ErrCommentNotAllowed
+	}
+	return wish, nil
+}
+
+// Create 发布评论或回复，返回带评论者信息的评论
+func (s *CommentService) Create(ctx context.Context, in CreateCommentInput) (*model.Comment, error) {
+	comment := &model.Comment{
+		WishID:           in.WishID,
+		ParentID:         in.ParentID,
+		UserID:           in.UserID,
+		Content:          in.Content,
+		ModerationStatus: in.Status,
+	}
+	err := s.store.Transaction(ctx, func(tx repository.Store) error {
+		if _, err := commentableWish(ctx, tx, in.UserID, in.WishID); err != nil {
+			return err
+		}
+		if in.ParentID != nil {
+			parent, err := tx.Comments().FindByID(ctx, *in.ParentID)
+			if err != nil {
+				return notFoundAs(err, ErrCommentNotFound)
+			}
+			if parent.WishID != in.WishID {
+				return ErrCommentNotFound
+			}
+		}
+		if err := tx.Comments().Create(ctx, comment); err != nil {
+			return err
+		}
+		if in.Status != model.ModerationApproved {
+			return nil
+		}
+		return tx.Wishes().AddCommentCount(ctx, in.WishID, 1)
+	})
+	if err != nil {
+		return nil, err
+	}
+	metrics.CommentPosted(in.ParentID != nil)
+
+	// 重新查询以带上评论者信息，失败不影响发布结果
+	if reloaded, err := s.store.Comments().FindByID(ctx, comment.ID); err != nil {
+		logger.FromContext(ctx).Warnw("重新查询评论并预加载用户失败", "commentID", comment.ID, "error", err)
+	} else {
+		comment = reloaded
+	}
+	return comment, nil
+}
+
+// Delete 删除评论：评论作者、愿望作者或管理员可以删除
+func (s *CommentService) Delete(ctx context.Context, userID, commentID uint) error {
+	comment, err := s.store.Comments().FindByID(ctx, commentID)
+	if err != nil {
+		return notFoundAs(err, ErrCommentNotFound)
+	}
+	if err := s.checkDeletable(ctx, userID, comment); err != nil {
+		return err
+	}
+	// 读取之后后台审核或管理员可能修改了审核状态，按实际删除时的状态决定是否减少评论数：
+	// 先尝试删除审核通过的评论，再尝试删除其他状态的评论；两次之间状态又被修改时重试
+	return s.store.Transaction(ctx, func(tx repository.Store) error {
+		for range 3 {
+			deleted, err := tx.Comments().Delete(ctx, comment.ID, true)
+			if err != nil {
+				return err
+			}
+			if deleted {
+				return tx.Wishes().AddCommentCount(ctx, comment.WishID, -1)
+			}
+			if deleted, err = tx.Comments().Delete(ctx, comment.ID, false); err != nil || deleted {
+				return err
+			}
+		}
+		// 评论已被并发删除
+		return ErrCommentNotFound
+	})
+}
+
+func (s *CommentService) checkDeletable(ctx context.Context, userID uint, comment *model.Comment) error {
+	if comment.UserID == userID {
+		return nil
+	}
+	wish, err := s.store.Wishes().FindByID(ctx, comment.WishID)
+	if err != nil {
+		// 评论所属的愿望不存在属于数据不一致，不是调用方的问题
+		return err
+	}
+	if wish.UserID == userID {
+		return nil
+	}
+	user, err := s.store.Users().FindByID(ctx, userID)
+	if err != nil {
+		return notFoundAs(err, ErrUserNotFound)
+	}
+	if isAdmin(user) {
+		return nil
+	}
+	return ErrForbidden
+}
+
+// ListByWish 愿望下审核通过的评论，按发布时间正序
+func (s *CommentService) ListByWish(ctx context.Context, wishID uint, page repository.Page) ([]model.Comment, int64, error) {
+	if _, err := s.store.Wishes().FindByID(ctx, wishID); err != nil {
+		return nil, 0, notFoundAs(err, ErrWishNotFound)
+	}
+	return s.store.Comments().ListApprovedByWish(ctx, wishID, page)
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/model"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/repository"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// seedUsers 创建作者、路人、管理员三个用户
+func seedUsers(t *testing.T, store repository.Store) (author, other, admin *model.User) {
+	t.Helper()
+	ctx := context.Background()
+	author = &model.User{Username: "2024000001", Nickname: "作者"}
+	other = &model.User{Username: "2024000002", Nickname: "路人"}
+	admin = &model.User{Username: "2024000003", Nickname: "管理员", Role: "admin"}
+	for _, u := range []*model.User{author, other, admin} {
+		require.NoError(t, store.Users().Create(ctx, u))
+	}
+	return author, other, admin
+}
+
+func seedWish(t *testing.T, store repository.Store, userID uint, public bool, status string) *model.Wish {
+	t.Helper()
+	wish := &model.Wish{UserID: userID, Content: "愿望", IsPublic: public, ModerationStatus: status}
+	require.NoError(t, store.Wishes().Create(context.Background(), wish, nil))
+	return wish
+}
+
+func TestCommentService(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("评论规则", func(t *testing.T) {
+		store := repository.NewMemoryStore()
+		author, other, _ := seedUsers(t, store)
+		public := seedWish(t, store, author.ID, true, model.ModerationApproved)
+		private := seedWish(t, store, author.ID, false, model.ModerationApproved)
+		pending := seedWish(t, store, author.ID, true, model.ModerationPending)
+		svc := NewCommentService(store)
+
+		assert.NoError(t, svc.CheckCommentable(ctx, other.ID, public.ID))
+		assert.ErrorIs(t, svc.CheckCommentable(ctx, other.ID, private.ID), ErrCommentNotAllowed)
+		assert.NoError(t, svc.CheckCommentable(ctx, author.ID, private.ID))
+		assert.ErrorIs(t, svc.CheckCommentable(ctx, other.ID, pending.ID), ErrWishNotFound)
+		assert.ErrorIs(t, svc.CheckCommentable(ctx, other.ID, 9999), ErrWishNotFound)
+
+		// Create 在事务中再次校验，不依赖调用方先调用 CheckCommentable
+		_, err := svc.Create(ctx, CreateCommentInput{WishID: private.ID, UserID: other.ID, Content: "hi", Status: model.ModerationApproved})
+		assert.ErrorIs(t, err, ErrCommentNotAllowed)
+	})
+
+	t.Run("只有审核通过的评论计入评论数", func(t *testing.T) {
+		store := repository.NewMemoryStore()
+		author, other, _ := seedUsers(t, store)
+		wish := seedWish(t, store, author.ID, true, model.ModerationApproved)
+		svc := NewCommentService(store)
+
+		approved, err := svc.Create(ctx, CreateCommentInput{WishID: wish.ID, UserID: other.ID, Content: "加油", Status: model.ModerationApproved})
+		require.NoError(t, err)
+		require.NotNil(t, approved.User)
+		assert.Equal(t, "路人", approved.User.Nickname)
+		pending, err := svc.Create(ctx, CreateCommentInput{WishID: wish.ID, UserID: other.ID, Content: "待审", Status: model.ModerationPending})
+		require.NoError(t, err)
+
+		got, _ := store.Wishes().FindByID(ctx, wish.ID)
+		assert.Equal(t, 1, got.CommentCount)
+
+		require.NoError(t, svc.Delete(ctx, other.ID, pending.ID))
+		got, _ = store.Wishes().FindByID(ctx, wish.ID)
+		assert.Equal(t, 1, got.CommentCount)
+		require.NoError(t, svc.Delete(ctx, other.ID, approved.ID))
+		got, _ = store.Wishes().FindByID(ctx, wish.ID)
+		assert.Equal(t, 0, got.CommentCount)
+	})
+
+	t.Run("回复的父评论必须属于同一愿望", func(t *testing.T) {
+		store := repository.NewMemoryStore()
+		author, other, _ := seedUsers(t, store)
+		wish := seedWish(t, store, author.ID, true, model.ModerationApproved)
+		another := seedWish(t, store, author.ID, true, model.ModerationApproved)
+		svc := NewCommentService(store)
+
+		parent, err := svc.Create(ctx, CreateCommentInput{WishID: wish.ID, UserID: other.ID, Content: "楼主", Status: model.ModerationApproved})
+		require.NoError(t, err)
+
+		_, err = svc.Create(ctx, CreateCommentInput{WishID: wish.ID, ParentID: &parent.ID, UserID: author.ID, Content: "谢谢", Status: model.ModerationApproved})
+		assert.NoError(t, err)
+		_, err = svc.Create(ctx, CreateCommentInput{WishID: another.ID, ParentID: &parent.ID, UserID: author.ID, Content: "串楼", Status: model.ModerationApproved})
+		assert.ErrorIs(t, err, ErrCommentNotFound)
+		missing := uint(9999)
+		_, err = svc.Create(ctx, CreateCommentInput{WishID: wish.ID, ParentID: &missing, UserID: author.ID, Content: "谢谢", Status: model.ModerationApproved})
+		assert.ErrorIs(t, err, ErrCommentNotFound)
+	})
+
+	t.Run("删除权限", func(t *testing.T) {
+		store := repository.NewMemoryStore()
+		author, other, admin := seedUsers(t, store)
+		stranger := &model.User{Username: "2024000004"}
+		require.NoError(t, store.Users().Create(ctx, stranger))
+		wish := seedWish(t, store, author.ID, true, model.ModerationApproved)
+		svc := NewCommentService(store)
+		comment := func() uint {
+			cm, err := svc.Create(ctx, CreateCommentInput{WishID: wish.ID, UserID: other.ID, Content: "评论", Status: model.ModerationApproved})
+			require.NoError(t, err)
+			return cm.ID
+		}
+
+		assert.ErrorIs(t, svc.Delete(ctx, stranger.ID, comment()), ErrForbidden)
+		assert.NoError(t, svc.Delete(ctx, other.ID, comment()), "评论作者")
+		assert.NoError(t, svc.Delete(ctx, author.ID, comment()), "愿望作者")
+		assert.NoError(t, svc.Delete(ctx, admin.ID, comment()), "管理员")
+		assert.ErrorIs(t, svc.Delete(ctx, admin.ID, 9999), ErrCommentNotFound)
+	})
+}
+
+// interleavedStore 在开启事务前执行 before，模拟读取评论之后、删除之前后台审核或管理员修改了审核状态
+type interleavedStore struct {
+	repository.Store
+	before func()
+}
+
+func (s *interleavedStore) Transaction(ctx context.Context, fn func(tx repository.Store) error) error {
+	if s.before != nil {
+		s.before()
+		s.before = nil
+	}
+	return s.Store.Transaction(ctx, fn)
+}
+
+func TestCommentDeleteRace(t *testing.T) {
+	logger.InitLogger()
+	ctx := context.Background()
+	db, wish := newWorkerTestDB(t)
+	store := &interleavedStore{Store: repository.NewGormStore(db)}
+	svc := NewCommentService(store)
+
+	t.Run("删除前审核通过", func(t *testing.T) {
+		task := createPendingComment(t, db, wish, "加油")
+		store.before = func() {
+			require.NoError(t, ApplyModerationResult(db, ModerationResult{Target: TargetComment, ID: task.ID, Status: model.ModerationApproved}, ""))
+		}
+		require.NoError(t, svc.Delete(ctx, wish.UserID, task.ID))
+		assert.Equal(t, 0, commentCount(t, db, wish.ID), "审核通过时增加的评论数随删除撤销")
+	})
+
+	t.Run("删除前被管理员拒绝", func(t *testing.T) {
+		task := createPendingComment(t, db, wish, "加油")
+		require.NoError(t, ApplyModerationResult(db, ModerationResult{Target: TargetComment, ID: task.ID, Status: model.ModerationApproved}, ""))
+		require.Equal(t, 1, commentCount(t, db, wish.ID))
+		store.before = func() {
+			record := model.ModerationRecord{SubjectType: string(TargetComment), SubjectID: task.ID}
+			require.NoError(t, applyReview(db, record, model.ModerationRejected, ""))
+		}
+		require.NoError(t, svc.Delete(ctx, wish.UserID, task.ID))
+		assert.Equal(t, 0, commentCount(t, db, wish.ID), "拒绝时已经减过，删除时不再重复减少")
+	})
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/model"
+	"gorm.io/gorm"
+)
+
+// reconcileBatchSize 是校对计数时每批检查的愿望数
+const reconcileBatchSize = 500
+
+// 愿望的实际点赞数与评论数 (只统计审核通过的评论，与发布、删除评论时的计数规则一致)
+const (
+	actualLikesSQL    = "(SELECT COUNT(*) FROM likes WHERE likes.wish_id = wishes.id AND likes.deleted_at IS NULL)"
+	actualCommentsSQL = "(SELECT COUNT(*) FROM comments WHERE comments.wish_id = wishes.id AND comments.deleted_at IS NULL AND comments.moderation_status = '" + model.ModerationApproved + "')"
+)
+
+// CounterMismatch 是一条冗余计数与实际不一致的愿望
+type CounterMismatch struct {
+	WishID         uint
+	LikeCount      int // 愿望上记录的点赞数
+	ActualLikes    int
+	CommentCount   int // 愿望上记录的评论数
+	ActualComments int
+}
+
+// ReconcileCounters 重新统计愿望的点赞数与评论数，返回不一致的愿望；fix 为 true 时写回实际值。
+// 按 ID 分批检查，不持有长事务；写回时重新统计，不会覆盖检查之后新增的点赞和评论
+func ReconcileCounters(ctx context.Context, db *gorm.DB, fix bool) ([]CounterMismatch, error) {
+	var mismatches []CounterMismatch
+	var lastID uint
+	for {
+		var batch []CounterMismatch
+		err := db.WithContext(ctx).Model(&model.Wish{}).
+			Select("id AS wish_id, like_count, "+actualLikesSQL+" AS actual_likes, comment_count, "+actualCommentsSQL+" AS actual_comments").
+			Where("id > ?", lastID).
+			Order("id").
+			Limit(reconcileBatchSize).
+			Scan(&batch).Error
+		if err != nil {
+			return mismatches, err
+		}
+		if len(batch) == 0 {
+			return mismatches, nil
+		}
+		lastID = batch[len(batch)-1].WishID
+
+		for _, m := range batch {
+			if m.LikeCount == m.ActualLikes && m.CommentCount == m.ActualComments {
+				continue
+			}
+			mismatches = append(mismatches, m)
+			if !fix {
+				continue
+			}
+			err := db.WithContext(ctx).Model(&model.Wish{}).Where("id = ?", m.WishID).UpdateColumns(map[string]interface{}{
+				"like_count":    gorm.Expr(actualLikesSQL),
+				"comment_count": gorm.Expr(actualCommentsSQL),
+			}).Error
+			if err != nil {
+				return mismatches, err
+			}
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/model"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/database"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/logger"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/migrate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReconcileCounters(t *testing.T) {
+	logger.InitLogger()
+	ctx := context.Background()
+	db, err := database.Open(database.DriverSQLite, "file:reconcile_test?mode=memory&cache=shared")
+	require.NoError(t, err)
+	m, err := migrate.New(db)
+	require.NoError(t, err)
+	_, err = m.Up(ctx)
+	require.NoError(t, err)
+
+	users := []model.User{{Username: "2024000001", Password: "x"}, {Username: "2024000002", Password: "x"}}
+	require.NoError(t, db.Create(&users).Error)
+	// 第一条愿望的计数正确，第二条的计数被改乱
+	wishes := []model.Wish{
+		{UserID: users[0].ID, Content: "a", LikeCount: 1, CommentCount: 1},
+		{UserID: users[0].ID, Content: "b", LikeCount: 7, CommentCount: 0},
+	}
+	require.NoError(t, db.Create(&wishes).Error)
+	require.NoError(t, db.Create(&model.Like{WishID: wishes[0].ID, UserID: users[1].ID}).Error)
+	require.NoError(t, db.Create(&model.Comment{WishID: wishes[0].ID, UserID: users[1].ID, Content: "c", ModerationStatus: model.ModerationApproved}).Error)
+	require.NoError(t, db.Create(&[]model.Like{{WishID: wishes[1].ID, UserID: users[0].ID}, {WishID: wishes[1].ID, UserID: users[1].ID}}).Error)
+	require.NoError(t, db.Create(&model.Comment{WishID: wishes[1].ID, UserID: users[1].ID, Content: "c", ModerationStatus: model.ModerationApproved}).Error)
+	// 未通过审核和已删除的评论不计数
+	require.NoError(t, db.Create(&model.Comment{WishID: wishes[1].ID, UserID: users[1].ID, Content: "c", ModerationStatus: model.ModerationRejected}).Error)
+	deleted := model.Comment{WishID: wishes[1].ID, UserID: users[1].ID, Content: "c", ModerationStatus: model.ModerationApproved}
+	require.NoError(t, db.Create(&deleted).Error)
+	require.NoError(t, db.Delete(&deleted).Error)
+
+	want := []CounterMismatch{{WishID: wishes[1].ID, LikeCount: 7, ActualLikes: 2, CommentCount: 0, ActualComments: 1}}
+	got, err := ReconcileCounters(ctx, db, false)
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+	var unchanged model.Wish
+	require.NoError(t, db.First(&unchanged, wishes[1].ID).Error)
+	assert.Equal(t, 7, unchanged.LikeCount, "不带 fix 时只报告不修改")
+
+	got, err = ReconcileCounters(ctx, db, true)
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+	var fixed model.Wish
+	require.NoError(t, db.First(&fixed, wishes[1].ID).Error)
+	assert.Equal(t, 2, fixed.LikeCount)
+	assert.Equal(t, 1, fixed.CommentCount)
+
+	got, err = ReconcileCounters(ctx, db, false)
+	require.NoError(t, err)
+	assert.Empty(t, got)
+}
@@ -0,0 +1,37 @@
+package service
+
+import "errors"
+
+// 业务规则错误：由 WishService / CommentService / LikeService / UserService 返回，
+// 传输层（HTTP handler、命令行、后台任务）据此决定如何响应，而不必关心数据库细节
+var (
+	ErrUserNotFound    = errors.New("用户不存在")
+	ErrWishNotFound    = errors.New("愿望不存在")
+	ErrCommentNotFound = errors.New("评论不存在")
+
+	// ErrForbidden 表示当前用户无权执行该操作（如删除他人的愿望或评论）
+	ErrForbidden = errors.New("没有权限执行该操作")
+	// ErrCommentNotAllowed 表示不能评论该愿望（私密愿望只有作者本人可以评论）
+	ErrCommentNotAllowed = errors.New("不允许评论该愿望")
+
+	ErrInvalidUsername    = errors.New("输入错误，请输入十位学号")
+	ErrUsernameTaken      = errors.New("该学号已被注册")
+	ErrInvalidCredentials = errors.New("用户名或密码错误")
+	ErrInvalidRole        = errors.New("角色只能是 user / admin / bot")
+	ErrEmptyPassword      = errors.New("密码不能为空")
+	// ErrWeakPassword 表示密码不符合强度要求，返回时会附上具体原因
+	ErrWeakPassword = errors.New("密码强度不足")
+	// ErrWrongPassword 表示修改密码时提供的原密码错误
+	ErrWrongPassword = errors.New("原密码错误")
+	// ErrInvalidResetCode 表示重置码不存在、已使用、已过期或不属于该用户
+	ErrInvalidResetCode = errors.New("重置码无效或已过期")
+	// ErrResetUnavailable 表示未配置重置码通知渠道，只能由管理员签发重置码
+	ErrResetUnavailable = errors.New("未开通自助找回密码，请联系管理员")
+
+	// ErrInvalidRefreshToken 表示刷新令牌不存在、已过期或所属会话已退出登录
+	ErrInvalidRefreshToken = errors.New("刷新令牌无效或已过期，请重新登录")
+	// ErrRefreshTokenReused 表示已换发过的刷新令牌被再次使用，令牌可能已泄露，整个登录会话已被吊销
+	ErrRefreshTokenReused = errors.New("刷新令牌已被使用过，请重新登录")
+	// ErrSessionNotFound 表示登录会话不存在、已过期或不属于当前用户
+	ErrSessionNotFound = errors.New("登录会话不存在")
+)
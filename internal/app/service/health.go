@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/buildinfo"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/migrate"
+	"gorm.io/gorm"
+)
+
+// 就绪检查的结果，整体状态取各项中最差的一项
+const (
+	HealthOK       = "ok"       // 正常
+	HealthDegraded = "degraded" // 可以继续处理请求，但有需要关注的问题 (如大模型审核熔断，由关键词审核兜底)
+	HealthFail     = "fail"     // 无法处理请求，应停止向该实例转发流量
+)
+
+// healthRank 用于比较检查结果的严重程度
+var healthRank = map[string]int{HealthOK: 0, HealthDegraded: 1, HealthFail: 2}
+
+// HealthCheck 是一项依赖的检查结果
+type HealthCheck struct {
+	Name      string      `json:"name"`
+	Status    string      `json:"status"`
+	LatencyMs float64     `json:"latencyMs"`
+	Error     string      `json:"error,omitempty"`
+	Detail    interface{} `json:"detail,omitempty"`
+}
+
+// ReadinessReport 是一次就绪检查的结果
+type ReadinessReport struct {
+	Status    string         `json:"status"`
+	CheckedAt time.Time      `json:"checkedAt"`
+	Checks    []HealthCheck  `json:"checks"`
+	Build     buildinfo.Info `json:"build"`
+}
+
+// Ready 表示实例可以接收流量 (没有失败的检查项)
+func (r ReadinessReport) Ready() bool { return r.Status != HealthFail }
+
+// MigrationHealth 是数据库表结构版本与程序版本的对比
+type MigrationHealth struct {
+	Version uint `json:"version"` // 数据库当前版本
+	Latest  uint `json:"latest"`  // 程序最新版本
+}
+
+// HealthChecker 检查服务依赖的数据库、表结构版本与内容审核链路
+type HealthChecker struct {
+	db *gorm.DB
+}
+
+// NewHealthChecker 创建就绪检查器
+func NewHealthChecker(db *gorm.DB) *HealthChecker {
+	return &HealthChecker{db: db}
+}
+
+// CheckReadiness 依次检查各项依赖，ctx 的期限决定单次检查的最长耗时
+func (h *HealthChecker) CheckReadiness(ctx context.Context) ReadinessReport {
+	report := ReadinessReport{Status: HealthOK, CheckedAt: time.Now(), Build: buildinfo.Get()}
+	for _, check := range []struct {
+		name string
+		run  func(ctx context.Context) (status string, detail interface{}, err error)
+	}{
+		{"database", h.checkDatabase},
+		{"migrations", h.checkMigrations},
+		{"moderation", checkModeration},
+	} {
+		start := time.Now()
+		status, detail, err := check.run(ctx)
+		result := HealthCheck{
+			Name:      check.name,
+			Status:    status,
+			LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+			Detail:    detail,
+		}
+		if err != nil {
+			result.Error = err.Error()
+		}
+		if healthRank[status] > healthRank[report.Status] {
+			report.Status = status
+		}
+		report.Checks = append(report.Checks, result)
+	}
+	return report
+}
+
+// checkDatabase 检查数据库连接是否可用；同时返回连接池的使用情况
+func (h *HealthChecker) checkDatabase(ctx context.Context) (string, interface{}, error) {
+	sqlDB, err := h.db.DB()
+	if err != nil {
+		return HealthFail, nil, err
+	}
+	if err := sqlDB.PingContext(ctx); err != nil {
+		return HealthFail, nil, err
+	}
+	stats := sqlDB.Stats()
+	return HealthOK, map[string]int{"open": stats.OpenConnections, "inUse": stats.InUse, "idle": stats.Idle}, nil
+}
+
+// checkMigrations 对比数据库与程序的表结构版本。数据库版本落后时程序可能访问不存在的列，视为失败；
+// 数据库版本较新时通常是滚动发布中新版本已执行迁移，旧实例仍可继续服务，视为降级
+func (h *HealthChecker) checkMigrations(ctx context.Context) (string, interface{}, error) {
+	m, err := migrate.New(h.db)
+	if err != nil {
+		return HealthFail, nil, err
+	}
+	version, err := m.Version(ctx)
+	if err != nil {
+		return HealthFail, nil, err
+	}
+	detail := MigrationHealth{Version: version, Latest: m.Latest()}
+	switch {
+	case version < detail.Latest:
+		return HealthFail, detail, fmt.Errorf("数据库版本 %d 落后于程序版本 %d，请执行 migrate up", version, detail.Latest)
+	case version > detail.Latest:
+		return HealthDegraded, detail, fmt.Errorf("数据库版本 %d 比程序版本 %d 新", version, detail.Latest)
+	}
+	return HealthOK, detail, nil
+}
+
+// checkModeration 查看审核链路的状态。大模型熔断时由后续审核器兜底，不影响接收流量，视为降级
+func checkModeration(ctx context.Context) (string, interface{}, error) {
+	status, degraded := ModerationHealth()
+	if degraded {
+		return HealthDegraded, status, fmt.Errorf("审核熔断器未恢复")
+	}
+	return HealthOK, status, nil
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/database"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/logger"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/migrate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckReadiness(t *testing.T) {
+	logger.InitLogger()
+	ctx := context.Background()
+	db, err := database.Open(database.DriverSQLite, "file:health_test?mode=memory&cache=shared")
+	require.NoError(t, err)
+	h := NewHealthChecker(db)
+
+	checks := func(r ReadinessReport) map[string]HealthCheck {
+		byName := make(map[string]HealthCheck)
+		for _, c := range r.Checks {
+			byName[c.Name] = c
+		}
+		return byName
+	}
+
+	// 未迁移的数据库：连接正常，但表结构版本落后
+	report := h.CheckReadiness(ctx)
+	assert.False(t, report.Ready())
+	assert.Equal(t, HealthOK, checks(report)["database"].Status)
+	assert.Equal(t, HealthFail, checks(report)["migrations"].Status)
+	assert.Contains(t, checks(report)["migrations"].Error, "migrate up")
+
+	m, err := migrate.New(db)
+	require.NoError(t, err)
+	_, err = m.Up(ctx)
+	require.NoError(t, err)
+	report = h.CheckReadiness(ctx)
+	assert.True(t, report.Ready())
+	assert.Equal(t, MigrationHealth{Version: m.Latest(), Latest: m.Latest()}, checks(report)["migrations"].Detail)
+	assert.NotEmpty(t, report.Build.GoVersion)
+
+	// 连接池关闭后数据库检查失败
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	require.NoError(t, sqlDB.Close())
+	report = h.CheckReadiness(ctx)
+	assert.Equal(t, HealthFail, report.Status)
+	assert.Equal(t, HealthFail, checks(report)["database"].Status)
+	assert.NotEmpty(t, checks(report)["database"].Error)
+}
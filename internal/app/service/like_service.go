@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/model"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/repository"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/logger"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/metrics"
+)
+
+// LikeService 负责点赞状态与愿望点赞数的维护
+type LikeService struct {
+	store repository.Store
+}
+
+// NewLikeService 创建 LikeService
+func NewLikeService(store repository.Store) *LikeService {
+	return &LikeService{store: store}
+}
+
+// LikeResult 是点赞切换后的状态
+type LikeResult struct {
+	Liked     bool
+	LikeCount int
+}
+
+// Toggle 切换 userID 对愿望的点赞状态：已点赞则取消，否则点赞。
+// 并发点赞触发唯一索引冲突时，视为已经点赞，返回最新的点赞数
+func (s *LikeService) Toggle(ctx context.Context, userID, wishID uint) (LikeResult, error) {
+	var result LikeResult
+	err := s.store.Transaction(ctx, func(tx repository.Store) error {
+		// 加行锁，防止并发修改点赞数
+		wish, err := tx.Wishes().LockByID(ctx, wishID)
+		if err != nil {
+			return notFoundAs(err, ErrWishNotFound)
+		}
+		if !WishVisibleTo(wish, userID) {
+			// 未通过审核的愿望对他人等同于不存在
+			return ErrWishNotFound
+		}
+
+		liked, err := tx.Likes().Exists(ctx, wishID, userID)
+		if err != nil {
+			return err
+		}
+		delta := 1
+		if liked {
+			err = tx.Likes().Delete(ctx, wishID, userID)
+			delta = -1
+		} else {
+			err = tx.Likes().Create(ctx, &model.Like{WishID: wishID, UserID: userID})
+		}
+		if err != nil {
+			return err
+		}
+		result.Liked = !liked
+		result.LikeCount, err = tx.Wishes().AddLikeCount(ctx, wishID, delta)
+		return err
+	})
+	if errors.Is(err, repository.ErrDuplicate) {
+		logger.FromContext(ctx).Warnw("点赞并发冲突，返回已点赞状态", "wishID", wishID, "userID", userID)
+		wish, findErr := s.store.Wishes().FindByID(ctx, wishID)
+		if findErr != nil {
+			return LikeResult{}, findErr
+		}
+		return LikeResult{Liked: true, LikeCount: wish.LikeCount}, nil
+	}
+	if err == nil {
+		metrics.LikeToggled(result.Liked)
+	}
+	return result, err
+}
+
+// Liked 查询 userID 是否点赞了该愿望
+func (s *LikeService) Liked(ctx context.Context, userID, wishID uint) (bool, error) {
+	return s.store.Likes().Exists(ctx, wishID, userID)
+}
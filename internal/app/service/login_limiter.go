@@ -0,0 +1,410 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/model"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/config"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/logger"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/metrics"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 限流对象的 key 前缀
+const (
+	accountKeyPrefix = "account:"
+	ipKeyPrefix      = "ip:"
+	userKeyPrefix    = "user:" // 已登录用户修改密码时按用户 ID 统计
+)
+
+// lockoutMemory 是锁定级别的保留时长：上一次锁定结束后这么久没有再被锁定，下一次锁定重新从 BaseLockout 开始
+const lockoutMemory = 24 * time.Hour
+
+// LoginLock 是一个学号或 IP 的锁定状态
+type LoginLock struct {
+	Key         string    // 限流对象，如 "account:2024000001"、"ip:10.0.0.1"
+	Lockouts    int       // 连续锁定的次数
+	LockedUntil time.Time // 锁定截止时间
+}
+
+// ThrottleStore 保存登录失败记录和锁定状态
+type ThrottleStore interface {
+	// LockedUntil 返回 keys 中最晚的锁定截止时间，都未锁定时返回零值
+	LockedUntil(ctx context.Context, keys []string, now time.Time) (time.Time, error)
+	// AddFailure 记录 key 的一次失败，返回 (now-window, now] 内的失败次数
+	AddFailure(ctx context.Context, key string, now time.Time, window time.Duration) (int, error)
+	// Lock 锁定 key 并清空它的失败记录，lockout 根据本次是第几次连续锁定给出锁定时长
+	Lock(ctx context.Context, key string, now time.Time, lockout func(lockouts int) time.Duration) (LoginLock, error)
+	// Reset 清除 key 的失败记录和锁定状态
+	Reset(ctx context.Context, key string) error
+	// ListLocked 列出 key 以 prefix 开头、当前仍被锁定的对象，截止时间晚的在前
+	ListLocked(ctx context.Context, prefix string, now time.Time) ([]LoginLock, error)
+}
+
+// LoginLimitPolicy 是一类限流对象 (学号或 IP) 的失败次数限制
+type LoginLimitPolicy struct {
+	MaxFailures int           // 滑动窗口内允许的失败次数，达到后锁定；0 表示不限制
+	BaseLockout time.Duration // 第一次锁定的时长，之后每次翻倍
+	MaxLockout  time.Duration // 锁定时长的上限
+}
+
+// lockout 返回第 n 次连续锁定的时长：BaseLockout × 2^(n-1)，不超过 MaxLockout
+func (p LoginLimitPolicy) lockout(n int) time.Duration {
+	d := p.BaseLockout
+	for i := 1; i < n && d < p.MaxLockout; i++ {
+		d *= 2
+	}
+	return min(d, p.MaxLockout)
+}
+
+// LoginLimiter 限制登录失败的次数：按学号和客户端 IP 分别统计滑动窗口内的失败次数，达到上限后锁定，
+// 锁定期间的登录请求不再校验密码；锁定到期后再次达到上限时锁定时长翻倍。
+// 按学号限制防止针对某个账号猜密码，按 IP 限制防止用同一个密码尝试大量学号。
+// 使用重置码重置密码与登录共用学号和 IP 的计数；修改密码 (需要原密码) 按用户 ID 计数，防止用盗取的访问令牌猜原密码
+type LoginLimiter struct {
+	store   ThrottleStore
+	window  time.Duration
+	account LoginLimitPolicy
+	ip      LoginLimitPolicy
+	now     func() time.Time
+}
+
+// NewLoginLimiter 创建 LoginLimiter，window 为统计失败次数的滑动窗口
+func NewLoginLimiter(store ThrottleStore, window time.Duration, account, ip LoginLimitPolicy) *LoginLimiter {
+	return &LoginLimiter{store: store, window: window, account: account, ip: ip, now: time.Now}
+}
+
+// NewThrottleStoreFromConfig 按 cfg.Store 创建 ThrottleStore；多个实例部署时应使用 database，让各实例共享计数
+func NewThrottleStoreFromConfig(db *gorm.DB, cfg config.LoginLimitConfig) ThrottleStore {
+	if cfg.Store == "database" {
+		return NewDBThrottleStore(db)
+	}
+	return NewMemoryThrottleStore()
+}
+
+// NewLoginLimiterFromConfig 按配置创建使用 store 的 LoginLimiter
+func NewLoginLimiterFromConfig(store ThrottleStore, cfg config.LoginLimitConfig) *LoginLimiter {
+	account := LoginLimitPolicy{MaxFailures: cfg.AccountMaxFailures, BaseLockout: cfg.BaseLockout, MaxLockout: cfg.MaxLockout}
+	ip := LoginLimitPolicy{MaxFailures: cfg.IPMaxFailures, BaseLockout: cfg.BaseLockout, MaxLockout: cfg.MaxLockout}
+	return NewLoginLimiter(store, cfg.Window, account, ip)
+}
+
+// limitTarget 是一次失败计入的限流对象
+type limitTarget struct {
+	key    string
+	scope  string // 指标的 scope 标签
+	policy LoginLimitPolicy
+}
+
+// Check 在校验密码 (或重置码) 前调用，返回需要等待的时长，0 表示可以尝试
+func (l *LoginLimiter) Check(ctx context.Context, username, ip string) (time.Duration, error) {
+	return l.check(ctx, l.targets(username, ip))
+}
+
+// Fail 记录一次登录失败 (学号未注册也计入，避免泄露学号是否存在)，达到上限时锁定，返回需要等待的时长
+func (l *LoginLimiter) Fail(ctx context.Context, username, ip string) (time.Duration, error) {
+	return l.fail(ctx, l.targets(username, ip))
+}
+
+// CheckUser 在修改密码校验原密码前调用，返回需要等待的时长
+func (l *LoginLimiter) CheckUser(ctx context.Context, userID uint) (time.Duration, error) {
+	return l.check(ctx, []limitTarget{l.userTarget(userID)})
+}
+
+// FailUser 记录一次修改密码时原密码错误，达到上限时锁定，返回需要等待的时长
+func (l *LoginLimiter) FailUser(ctx context.Context, userID uint) (time.Duration, error) {
+	return l.fail(ctx, []limitTarget{l.userTarget(userID)})
+}
+
+// SucceedUser 在修改密码成功后清除该用户的失败记录
+func (l *LoginLimiter) SucceedUser(ctx context.Context, userID uint) error {
+	return l.store.Reset(ctx, l.userTarget(userID).key)
+}
+
+func (l *LoginLimiter) check(ctx context.Context, targets []limitTarget) (time.Duration, error) {
+	var keys []string
+	for _, t := range targets {
+		if t.key != "" {
+			keys = append(keys, t.key)
+		}
+	}
+	if len(keys) == 0 {
+		return 0, nil
+	}
+	now := l.now()
+	until, err := l.store.LockedUntil(ctx, keys, now)
+	if err != nil || until.IsZero() {
+		return 0, err
+	}
+	return until.Sub(now), nil
+}
+
+func (l *LoginLimiter) fail(ctx context.Context, targets []limitTarget) (time.Duration, error) {
+	now := l.now()
+	var wait time.Duration
+	for _, t := range targets {
+		if t.key == "" || t.policy.MaxFailures <= 0 {
+			continue
+		}
+		failures, err := l.store.AddFailure(ctx, t.key, now, l.window)
+		if err != nil {
+			return wait, err
+		}
+		if failures < t.policy.MaxFailures {
+			continue
+		}
+		lock, err := l.store.Lock(ctx, t.key, now, t.policy.lockout)
+		if err != nil {
+			return wait, err
+		}
+		metrics.LoginLocked(t.scope)
+		logger.FromContext(ctx).Warnw("登录失败次数过多，已暂时锁定", "key", t.key, "lockouts", lock.Lockouts, "lockedUntil", lock.LockedUntil)
+		wait = max(wait, lock.LockedUntil.Sub(now))
+	}
+	return wait, nil
+}
+
+// Succeed 在登录成功后清除该学号的失败记录和锁定级别；IP 的记录保留，同一 IP 可能在尝试其他学号
+func (l *LoginLimiter) Succeed(ctx context.Context, username string) error {
+	if key := l.accountKey(username); key != "" {
+		return l.store.Reset(ctx, key)
+	}
+	return nil
+}
+
+// LockedAccounts 列出当前被锁定的学号
+func (l *LoginLimiter) LockedAccounts(ctx context.Context) ([]LoginLock, error) {
+	return l.store.ListLocked(ctx, accountKeyPrefix, l.now())
+}
+
+// LockedIPs 列出当前被锁定的 IP
+func (l *LoginLimiter) LockedIPs(ctx context.Context) ([]LoginLock, error) {
+	return l.store.ListLocked(ctx, ipKeyPrefix, l.now())
+}
+
+// LockedUsers 列出修改密码时原密码错误次数过多而被锁定的用户 (Subject 为用户 ID)
+func (l *LoginLimiter) LockedUsers(ctx context.Context) ([]LoginLock, error) {
+	return l.store.ListLocked(ctx, userKeyPrefix, l.now())
+}
+
+// Unlock 解除学号的锁定并清除其失败记录 (管理员核实身份后使用)
+func (l *LoginLimiter) Unlock(ctx context.Context, username string) error {
+	key := l.accountKey(username)
+	if key == "" {
+		return ErrInvalidUsername
+	}
+	return l.store.Reset(ctx, key)
+}
+
+func (l *LoginLimiter) targets(username, ip string) []limitTarget {
+	return []limitTarget{
+		{l.accountKey(username), "account", l.account},
+		{l.ipKey(ip), "ip", l.ip},
+	}
+}
+
+// userTarget 按用户 ID 限制，使用与学号相同的次数和锁定时长
+func (l *LoginLimiter) userTarget(userID uint) limitTarget {
+	return limitTarget{userKeyPrefix + strconv.FormatUint(uint64(userID), 10), "user", l.account}
+}
+
+// accountKey 返回学号的限流 key；不是十位学号时不可能登录成功，只按 IP 限制，也避免任意长的输入写进存储
+func (l *LoginLimiter) accountKey(username string) string {
+	if !studentIDPattern.MatchString(username) {
+		return ""
+	}
+	return accountKeyPrefix + username
+}
+
+func (l *LoginLimiter) ipKey(ip string) string {
+	if ip == "" {
+		return ""
+	}
+	return ipKeyPrefix + ip
+}
+
+// Subject 去掉 key 的前缀，返回学号或 IP
+func (lock LoginLock) Subject() string {
+	_, subject, _ := strings.Cut(lock.Key, ":")
+	return subject
+}
+
+// MemoryThrottleStore 把失败记录和锁定状态保存在内存中，只适用于单实例部署，重启后清空
+type MemoryThrottleStore struct {
+	mu        sync.Mutex
+	failures  map[string][]time.Time
+	lockouts  map[string]LoginLock
+	lastSweep time.Time
+}
+
+// NewMemoryThrottleStore 创建内存存储
+func NewMemoryThrottleStore() *MemoryThrottleStore {
+	return &MemoryThrottleStore{failures: make(map[string][]time.Time), lockouts: make(map[string]LoginLock)}
+}
+
+func (s *MemoryThrottleStore) LockedUntil(_ context.Context, keys []string, now time.Time) (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var until time.Time
+	for _, key := range keys {
+		if lock, ok := s.lockouts[key]; ok && lock.LockedUntil.After(now) && lock.LockedUntil.After(until) {
+			until = lock.LockedUntil
+		}
+	}
+	return until, nil
+}
+
+func (s *MemoryThrottleStore) AddFailure(_ context.Context, key string, now time.Time, window time.Duration) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	since := now.Add(-window)
+	// 每个窗口清理一次不再出现的 key，避免内存无限增长
+	if now.Sub(s.lastSweep) >= window {
+		s.lastSweep = now
+		for k, times := range s.failures {
+			if !times[len(times)-1].After(since) {
+				delete(s.failures, k)
+			}
+		}
+		for k, lock := range s.lockouts {
+			if lock.LockedUntil.Before(now.Add(-lockoutMemory)) {
+				delete(s.lockouts, k)
+			}
+		}
+	}
+	times := slices.DeleteFunc(s.failures[key], func(t time.Time) bool { return !t.After(since) })
+	times = append(times, now)
+	s.failures[key] = times
+	return len(times), nil
+}
+
+func (s *MemoryThrottleStore) Lock(_ context.Context, key string, now time.Time, lockout func(int) time.Duration) (LoginLock, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	lockouts := 1
+	if prev, ok := s.lockouts[key]; ok && prev.LockedUntil.After(now.Add(-lockoutMemory)) {
+		lockouts = prev.Lockouts + 1
+	}
+	lock := LoginLock{Key: key, Lockouts: lockouts, LockedUntil: now.Add(lockout(lockouts))}
+	s.lockouts[key] = lock
+	delete(s.failures, key)
+	return lock, nil
+}
+
+func (s *MemoryThrottleStore) Reset(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.failures, key)
+	delete(s.lockouts, key)
+	return nil
+}
+
+func (s *MemoryThrottleStore) ListLocked(_ context.Context, prefix string, now time.Time) ([]LoginLock, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var locks []LoginLock
+	for key, lock := range s.lockouts {
+		if strings.HasPrefix(key, prefix) && lock.LockedUntil.After(now) {
+			locks = append(locks, lock)
+		}
+	}
+	slices.SortFunc(locks, func(a, b LoginLock) int { return b.LockedUntil.Compare(a.LockedUntil) })
+	return locks, nil
+}
+
+// DBThrottleStore 把失败记录和锁定状态保存在数据库中 (login_failures / login_lockouts 表)，多个实例共享。
+// 并发的失败请求可能让锁定级别多加一次，对限流来说可以接受，不使用行锁
+type DBThrottleStore struct {
+	db *gorm.DB
+}
+
+// NewDBThrottleStore 创建基于数据库的存储
+func NewDBThrottleStore(db *gorm.DB) *DBThrottleStore {
+	return &DBThrottleStore{db: db}
+}
+
+func (s *DBThrottleStore) LockedUntil(ctx context.Context, keys []string, now time.Time) (time.Time, error) {
+	if len(keys) == 0 {
+		return time.Time{}, nil
+	}
+	var lock model.LoginLockout
+	err := s.db.WithContext(ctx).Where("throttle_key IN ? AND locked_until > ?", keys, now).
+		Order("locked_until DESC").First(&lock).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return lock.LockedUntil, nil
+}
+
+func (s *DBThrottleStore) AddFailure(ctx context.Context, key string, now time.Time, window time.Duration) (int, error) {
+	db := s.db.WithContext(ctx)
+	since := now.Add(-window)
+	// 顺便清理所有已经滑出窗口的记录，避免表无限增长
+	if err := db.Where("created_at <= ?", since).Delete(&model.LoginFailure{}).Error; err != nil {
+		return 0, err
+	}
+	if err := db.Create(&model.LoginFailure{ThrottleKey: key, CreatedAt: now}).Error; err != nil {
+		return 0, err
+	}
+	var n int64
+	err := db.Model(&model.LoginFailure{}).Where("throttle_key = ? AND created_at > ?", key, since).Count(&n).Error
+	return int(n), err
+}
+
+func (s *DBThrottleStore) Lock(ctx context.Context, key string, now time.Time, lockout func(int) time.Duration) (LoginLock, error) {
+	var lock LoginLock
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("locked_until < ?", now.Add(-lockoutMemory)).Delete(&model.LoginLockout{}).Error; err != nil {
+			return err
+		}
+		var prev model.LoginLockout
+		lockouts := 1
+		err := tx.Where("throttle_key = ?", key).First(&prev).Error
+		switch {
+		case err == nil:
+			lockouts = prev.Lockouts + 1
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return err
+		}
+		row := model.LoginLockout{ThrottleKey: key, Lockouts: lockouts, LockedUntil: now.Add(lockout(lockouts))}
+		if err := tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&row).Error; err != nil {
+			return err
+		}
+		lock = LoginLock{Key: key, Lockouts: row.Lockouts, LockedUntil: row.LockedUntil}
+		return tx.Where("throttle_key = ?", key).Delete(&model.LoginFailure{}).Error
+	})
+	return lock, err
+}
+
+func (s *DBThrottleStore) Reset(ctx context.Context, key string) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("throttle_key = ?", key).Delete(&model.LoginFailure{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("throttle_key = ?", key).Delete(&model.LoginLockout{}).Error
+	})
+}
+
+func (s *DBThrottleStore) ListLocked(ctx context.Context, prefix string, now time.Time) ([]LoginLock, error) {
+	var rows []model.LoginLockout
+	err := s.db.WithContext(ctx).Where("throttle_key LIKE ? AND locked_until > ?", prefix+"%", now).
+		Order("locked_until DESC").Limit(500).Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	locks := make([]LoginLock, 0, len(rows))
+	for _, row := range rows {
+		locks = append(locks, LoginLock{Key: row.ThrottleKey, Lockouts: row.Lockouts, LockedUntil: row.LockedUntil})
+	}
+	return locks, nil
+}
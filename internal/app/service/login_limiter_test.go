@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/database"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/logger"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/migrate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoginLimitPolicyLockout(t *testing.T) {
+	p := LoginLimitPolicy{BaseLockout: time.Minute, MaxLockout: 10 * time.Minute}
+	assert.Equal(t, time.Minute, p.lockout(1))
+	assert.Equal(t, 2*time.Minute, p.lockout(2))
+	assert.Equal(t, 8*time.Minute, p.lockout(4))
+	assert.Equal(t, 10*time.Minute, p.lockout(5), "不超过上限")
+	assert.Equal(t, 10*time.Minute, p.lockout(100))
+}
+
+func TestLoginLimiter(t *testing.T) {
+	logger.InitLogger()
+	db, err := database.Open(database.DriverSQLite, "file:login_limiter_test?mode=memory&cache=shared")
+	require.NoError(t, err)
+	m, err := migrate.New(db)
+	require.NoError(t, err)
+	_, err = m.Up(context.Background())
+	require.NoError(t, err)
+
+	for name, store := range map[string]ThrottleStore{
+		"memory":   NewMemoryThrottleStore(),
+		"database": NewDBThrottleStore(db),
+	} {
+		t.Run(name, func(t *testing.T) {
+			testLoginLimiter(t, store)
+		})
+	}
+}
+
+func testLoginLimiter(t *testing.T, store ThrottleStore) {
+	ctx := context.Background()
+	clock := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	l := NewLoginLimiter(store, 10*time.Minute,
+		LoginLimitPolicy{MaxFailures: 3, BaseLockout: time.Minute, MaxLockout: 4 * time.Minute},
+		LoginLimitPolicy{MaxFailures: 5, BaseLockout: time.Minute, MaxLockout: 4 * time.Minute})
+	l.now = func() time.Time { return clock }
+	fail := func(username, ip string) time.Duration {
+		t.Helper()
+		wait, err := l.Fail(ctx, username, ip)
+		require.NoError(t, err)
+		return wait
+	}
+	check := func(username, ip string) time.Duration {
+		t.Helper()
+		wait, err := l.Check(ctx, username, ip)
+		require.NoError(t, err)
+		return wait
+	}
+
+	t.Run("滑动窗口外的失败不计入", func(t *testing.T) {
+		assert.Zero(t, fail("2024000001", "10.0.0.1"))
+		clock = clock.Add(11 * time.Minute)
+		assert.Zero(t, fail("2024000001", "10.0.0.2"))
+		assert.Zero(t, fail("2024000001", "10.0.0.3"))
+		assert.Zero(t, check("2024000001", "10.0.0.1"))
+	})
+
+	t.Run("学号失败次数达到上限后锁定，锁定时长逐次翻倍", func(t *testing.T) {
+		assert.Equal(t, time.Minute, fail("2024000001", "10.0.0.4"))
+		assert.Equal(t, time.Minute, check("2024000001", "10.0.0.9"), "换 IP 也被锁定")
+		assert.Zero(t, check("2024000002", "10.0.0.9"), "不影响其他学号")
+
+		locks, err := l.LockedAccounts(ctx)
+		require.NoError(t, err)
+		require.Len(t, locks, 1)
+		assert.Equal(t, "2024000001", locks[0].Subject())
+		assert.Equal(t, 1, locks[0].Lockouts)
+
+		clock = clock.Add(time.Minute)
+		assert.Zero(t, check("2024000001", "10.0.0.9"), "锁定到期")
+		for range 2 {
+			assert.Zero(t, fail("2024000001", "10.0.0.5"))
+		}
+		assert.Equal(t, 2*time.Minute, fail("2024000001", "10.0.0.6"))
+
+		clock = clock.Add(2 * time.Minute)
+		for range 2 {
+			fail("2024000001", "10.0.0.7")
+		}
+		assert.Equal(t, 4*time.Minute, fail("2024000001", "10.0.0.8"))
+		clock = clock.Add(4 * time.Minute)
+		for range 2 {
+			fail("2024000001", "10.0.0.7")
+		}
+		assert.Equal(t, 4*time.Minute, fail("2024000001", "10.0.0.8"), "不超过上限")
+	})
+
+	t.Run("登录成功和管理员解锁清除锁定级别", func(t *testing.T) {
+		require.NoError(t, l.Unlock(ctx, "2024000001"))
+		assert.Zero(t, check("2024000001", "10.0.0.20"))
+		for range 2 {
+			fail("2024000001", "10.0.0.20")
+		}
+		require.NoError(t, l.Succeed(ctx, "2024000001"))
+		assert.Zero(t, fail("2024000001", "10.0.0.20"), "登录成功后失败次数清零")
+		fail("2024000001", "10.0.0.20")
+		assert.Equal(t, time.Minute, fail("2024000001", "10.0.0.20"), "重新从 BaseLockout 开始")
+		assert.ErrorIs(t, l.Unlock(ctx, "admin"), ErrInvalidUsername)
+	})
+
+	t.Run("同一 IP 尝试大量学号", func(t *testing.T) {
+		clock = clock.Add(time.Hour)
+		for i, username := range []string{"2024000011", "2024000012", "2024000013", "2024000014"} {
+			assert.Zero(t, fail(username, "10.0.1.1"), i)
+		}
+		assert.Equal(t, time.Minute, fail("2024000015", "10.0.1.1"))
+		assert.Equal(t, time.Minute, check("2024000016", "10.0.1.1"))
+		assert.Zero(t, check("2024000016", "10.0.1.2"))
+
+		ips, err := l.LockedIPs(ctx)
+		require.NoError(t, err)
+		require.Len(t, ips, 1)
+		assert.Equal(t, "10.0.1.1", ips[0].Subject())
+		require.NoError(t, l.Succeed(ctx, "2024000016"))
+		assert.Equal(t, time.Minute, check("2024000016", "10.0.1.1"), "登录成功不解除 IP 锁定")
+	})
+
+	t.Run("修改密码按用户 ID 统计", func(t *testing.T) {
+		clock = clock.Add(time.Hour)
+		failUser := func(userID uint) time.Duration {
+			t.Helper()
+			wait, err := l.FailUser(ctx, userID)
+			require.NoError(t, err)
+			return wait
+		}
+		for range 2 {
+			assert.Zero(t, failUser(7))
+		}
+		assert.Equal(t, time.Minute, failUser(7))
+		wait, err := l.CheckUser(ctx, 7)
+		require.NoError(t, err)
+		assert.Equal(t, time.Minute, wait)
+		wait, err = l.CheckUser(ctx, 8)
+		require.NoError(t, err)
+		assert.Zero(t, wait, "不影响其他用户")
+		assert.Zero(t, check("2024000007", "10.0.3.1"), "不影响登录")
+
+		users, err := l.LockedUsers(ctx)
+		require.NoError(t, err)
+		require.Len(t, users, 1)
+		assert.Equal(t, "7", users[0].Subject())
+
+		require.NoError(t, l.SucceedUser(ctx, 7))
+		wait, err = l.CheckUser(ctx, 7)
+		require.NoError(t, err)
+		assert.Zero(t, wait)
+	})
+
+	t.Run("非学号只按 IP 统计", func(t *testing.T) {
+		clock = clock.Add(time.Hour)
+		for range 4 {
+			assert.Zero(t, fail("not-a-student-id", "10.0.2.1"))
+		}
+		locks, err := l.LockedAccounts(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, locks)
+	})
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/model"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/logger"
+	"gorm.io/gorm"
+)
+
+// ErrAlreadyReviewed 表示审核记录已经完成过人工复核
+var ErrAlreadyReviewed = errors.New("该记录已完成人工复核")
+
+// contentHash 返回内容的 SHA-256 (十六进制)
+func contentHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// NewModerationRecord 根据一次审核的结果构造审核记录
+// status 为自动审核结论（approved / rejected / needs_review），reason 为拒绝或转人工的原因
+func NewModerationRecord(target ModerationTarget, subjectID, userID uint, content string, verdict Verdict, status, reason string, latency time.Duration) *model.ModerationRecord {
+	return &model.ModerationRecord{
+		SubjectType: string(target),
+		SubjectID:   subjectID,
+		UserID:      userID,
+		ContentHash: contentHash(content),
+		Content:     content,
+		Provider:    verdict.Provider,
+		RawAnswer:   verdict.Raw,
+		Verdict:     status,
+		Category:    verdict.Category,
+		Confidence:  verdict.Confidence,
+		Reason:      reason,
+		LatencyMs:   latency.Milliseconds(),
+	}
+}
+
+// SaveModerationRecord 写入审核记录；审计日志写入失败不应影响主流程，只记录日志
+func SaveModerationRecord(db *gorm.DB, record *model.ModerationRecord) {
+	if err := db.Create(record).Error; err != nil {
+		logger.Log.Errorw("写入审核记录失败", "subjectType", record.SubjectType, "subjectID", record.SubjectID, "error", err)
+	}
+}
+
+// ReviewModerationRecord 管理员人工复核一条审核记录，并把结论同步到被审核的内容上：
+//   - 愿望/评论：更新 moderation_status，评论的通过/驳回会同步调整愿望评论数
+//   - 昵称：通过时把用户昵称改为记录中的昵称，驳回时重置为学号
+//   - 简介：通过时把用户简介改为记录中的简介，驳回时若当前简介就是该内容则清空
+//   - 标签：通过时把标签加到愿望上，驳回时从愿望上移除
+//
+// 被审核内容已被删除时只更新记录本身
+func ReviewModerationRecord(db *gorm.DB, recordID, reviewerID uint, approve bool, note string) (*model.ModerationRecord, error) {
+	status := model.ModerationRejected
+	if approve {
+		status = model.ModerationApproved
+	}
+	var record model.ModerationRecord
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&record, recordID).Error; err != nil {
+			return err
+		}
+		if record.ReviewStatus != "" {
+			return ErrAlreadyReviewed
+		}
+		if record.SubjectID != 0 {
+			if err := applyReview(tx, record, status, note); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+		}
+		now := time.Now()
+		record.ReviewStatus = status
+		record.ReviewerID = &reviewerID
+		record.ReviewNote = note
+		record.ReviewedAt = &now
+		return tx.Save(&record).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	logger.Log.Infow("人工复核完成", "recordID", record.ID, "subjectType", record.SubjectType, "subjectID", record.SubjectID, "status", status, "reviewerID", reviewerID)
+	return &record, nil
+}
+
+func applyReview(tx *gorm.DB, record model.ModerationRecord, status, note string) error {
+	reason := note
+	if status == model.ModerationRejected && reason == "" {
+		reason = "人工复核未通过"
+	}
+	switch ModerationTarget(record.SubjectType) {
+	case TargetWish:
+		var wish model.Wish
+		if err := tx.First(&wish, record.SubjectID).Error; err != nil {
+			return err
+		}
+		return tx.Model(&wish).Updates(map[string]interface{}{
+			"moderation_status": status,
+			"moderation_reason": reason,
+		}).Error
+	case TargetComment:
+		var comment model.Comment
+		if err := tx.First(&comment, record.SubjectID).Error; err != nil {
+			return err
+		}
+		wasApproved := comment.ModerationStatus == model.ModerationApproved
+		if err := tx.Model(&comment).Updates(map[string]interface{}{
+			"moderation_status": status,
+			"moderation_reason": reason,
+		}).Error; err != nil {
+			return err
+		}
+		// 评论数只统计审核通过的评论
+		switch {
+		case !wasApproved && status == model.ModerationApproved:
+			return tx.Model(&model.Wish{}).Where("id = ?", comment.WishID).
+				UpdateColumn("comment_count", gorm.Expr("comment_count + ?", 1)).Error
+		case wasApproved && status == model.ModerationRejected:
+			return tx.Model(&model.Wish{}).Where("id = ? AND comment_count > 0", comment.WishID).
+				UpdateColumn("comment_count", gorm.Expr("comment_count - ?", 1)).Error
+		}
+		return nil
+	case TargetNickname:
+		var user model.User
+		if err := tx.First(&user, record.SubjectID).Error; err != nil {
+			return err
+		}
+		nickname := user.Username
+		if status == model.ModerationApproved {
+			nickname = record.Content
+		}
+		if err := tx.Model(&user).Update("nickname", nickname).Error; err != nil {
+			return err
+		}
+		// 同步愿望中的冗余昵称
+		return tx.Model(&model.Wish{}).Where("user_id = ?", user.ID).Update("user_nickname", nickname).Error
+	case TargetBio:
+		var user model.User
+		if err := tx.First(&user, record.SubjectID).Error; err != nil {
+			return err
+		}
+		if status == model.ModerationApproved {
+			return tx.Model(&user).Update("bio", record.Content).Error
+		}
+		if user.Bio != nil && *user.Bio == record.Content {
+			return tx.Model(&user).Update("bio", nil).Error
+		}
+		return nil
+	case TargetTag:
+		var wish model.Wish
+		if err := tx.First(&wish, record.SubjectID).Error; err != nil {
+			return err
+		}
+		if status == model.ModerationRejected {
+			return tx.Where("wish_id = ? AND tag_name = ?", wish.ID, record.Content).Delete(&model.WishTag{}).Error
+		}
+		var count int64
+		if err := tx.Model(&model.WishTag{}).Where("wish_id = ? AND tag_name = ?", wish.ID, record.Content).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return nil
+		}
+		return tx.Create(&model.WishTag{WishID: wish.ID, TagName: record.Content}).Error
+	}
+	return fmt.Errorf("未知的审核对象 %q", record.SubjectType)
+}
@@ -0,0 +1,421 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/model"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/config"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/logger"
+	"gorm.io/gorm"
+)
+
+// ModerationTarget 表示待审核内容的类型
+type ModerationTarget string
+
+const (
+	TargetWish     ModerationTarget = "wish"
+	TargetComment  ModerationTarget = "comment"
+	TargetNickname ModerationTarget = "nickname" // 昵称、简介、标签只做同步审核，不进入异步队列
+	TargetBio      ModerationTarget = "bio"
+	TargetTag      ModerationTarget = "tag" // 审核记录的 SubjectID 为所属愿望
+)
+
+// ModerationTask 是一条待审核内容
+type ModerationTask struct {
+	Target ModerationTarget
+	ID     uint
+}
+
+// ModerationQueue 是 handler 依赖的异步审核入口
+// 为 nil 时 handler 退回同步审核
+type ModerationQueue interface {
+	Enqueue(task ModerationTask)
+}
+
+// ModerationResult 是一条内容的最终审核结论，用于通知作者
+type ModerationResult struct {
+	Target ModerationTarget `json:"target"`
+	ID     uint             `json:"id"`
+	UserID uint             `json:"userId"`
+	Status string           `json:"status"`
+	Reason string           `json:"reason,omitempty"`
+}
+
+// ModerationNotifier 在内容得到最终结论后通知作者
+type ModerationNotifier interface {
+	Notify(ctx context.Context, result ModerationResult) error
+}
+
+// WebhookNotifier 把审核结论以 JSON POST 到指定地址（如消息推送服务）
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookNotifier 创建 webhook 通知器
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{url: url, client: &http.Client{Timeout: 5 * time.Second}}
+}
+
+func (n *WebhookNotifier) Notify(ctx context.Context, result ModerationResult) error {
+	body, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook 返回状态码 %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// ModerationWorkerConfig 是后台审核协程池的配置
+type ModerationWorkerConfig struct {
+	Workers       int           // 并发审核协程数
+	QueueSize     int           // 队列容量，队列满时内容保持 pending，由定期扫描补偿
+	MaxRetries    int           // 审核服务不可用时的最大重试次数，超过后转人工复核
+	RetryBackoff  time.Duration // 首次重试的等待时间，之后每次翻倍
+	SweepInterval time.Duration // 扫描遗留 pending 内容的间隔
+}
+
+// ModerationWorker 是后台审核协程池：从队列取出 pending 内容，调用审核器，把结论写回数据库
+type ModerationWorker struct {
+	db        *gorm.DB
+	moderator Moderator
+	notifier  ModerationNotifier
+	cfg       ModerationWorkerConfig
+
+	tasks  chan ModerationTask
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu       sync.Mutex
+	inflight map[ModerationTask]int // 正在处理或等待重试的任务 -> 已重试次数
+}
+
+// NewModerationWorker 创建后台审核协程池，notifier 可以为 nil
+func NewModerationWorker(db *gorm.DB, moderator Moderator, notifier ModerationNotifier, cfg ModerationWorkerConfig) *ModerationWorker {
+	if cfg.Workers <= 0 {
+		cfg.Workers = 4
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = 256
+	}
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = 2 * time.Second
+	}
+	if cfg.SweepInterval <= 0 {
+		cfg.SweepInterval = time.Minute
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &ModerationWorker{
+		db:        db,
+		moderator: moderator,
+		notifier:  notifier,
+		cfg:       cfg,
+		tasks:     make(chan ModerationTask, cfg.QueueSize),
+		ctx:       ctx,
+		cancel:    cancel,
+		inflight:  make(map[ModerationTask]int),
+	}
+}
+
+// Start 启动审核协程，并把数据库中遗留的 pending 内容（如上次进程退出时未处理完的）重新入队
+func (w *ModerationWorker) Start() {
+	for i := 0; i < w.cfg.Workers; i++ {
+		w.wg.Add(1)
+		go w.run()
+	}
+	w.wg.Add(1)
+	go w.sweep()
+}
+
+// Stop 停止接收新任务并等待正在处理的任务结束
+// 尚未处理的内容保持 pending，下次启动时会被重新入队
+func (w *ModerationWorker) Stop() {
+	w.cancel()
+	w.wg.Wait()
+}
+
+// Enqueue 把内容加入审核队列，不会阻塞请求；队列已满时交给定期扫描补偿
+func (w *ModerationWorker) Enqueue(task ModerationTask) {
+	w.mu.Lock()
+	if _, ok := w.inflight[task]; ok {
+		w.mu.Unlock()
+		return
+	}
+	w.inflight[task] = 0
+	w.mu.Unlock()
+
+	select {
+	case w.tasks <- task:
+	default:
+		w.done(task)
+		logger.FromContext(w.ctx).Warnw("审核队列已满，内容保持 pending 等待扫描补偿", "target", task.Target, "id", task.ID)
+	}
+}
+
+func (w *ModerationWorker) run() {
+	defer w.wg.Done()
+	for {
+		select {
+		case <-w.ctx.Done():
+			return
+		case task := <-w.tasks:
+			w.process(task)
+		}
+	}
+}
+
+// sweep 启动时及之后每隔 SweepInterval 把数据库里的 pending 内容重新入队
+func (w *ModerationWorker) sweep() {
+	defer w.wg.Done()
+	w.enqueuePending()
+	ticker := time.NewTicker(w.cfg.SweepInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.ctx.Done():
+			return
+		case <-ticker.C:
+			w.enqueuePending()
+		}
+	}
+}
+
+func (w *ModerationWorker) enqueuePending() {
+	log := logger.FromContext(w.ctx)
+	var wishIDs, commentIDs []uint
+	if err := w.db.Model(&model.Wish{}).Where("moderation_status = ?", model.ModerationPending).Pluck("id", &wishIDs).Error; err != nil {
+		log.Errorw("扫描待审核愿望失败", "error", err)
+	}
+	if err := w.db.Model(&model.Comment{}).Where("moderation_status = ?", model.ModerationPending).Pluck("id", &commentIDs).Error; err != nil {
+		log.Errorw("扫描待审核评论失败", "error", err)
+	}
+	if len(wishIDs)+len(commentIDs) > 0 {
+		log.Infow("重新入队待审核内容", "wishes", len(wishIDs), "comments", len(commentIDs))
+	}
+	for _, id := range wishIDs {
+		w.Enqueue(ModerationTask{Target: TargetWish, ID: id})
+	}
+	for _, id := range commentIDs {
+		w.Enqueue(ModerationTask{Target: TargetComment, ID: id})
+	}
+}
+
+func (w *ModerationWorker) done(task ModerationTask) {
+	w.mu.Lock()
+	delete(w.inflight, task)
+	w.mu.Unlock()
+}
+
+// taskContext 返回处理 task 时使用的 ctx，其中的日志记录器带有审核对象和 ID，
+// 审核器经 logger.FromContext 输出的日志也能对应到具体内容
+func (w *ModerationWorker) taskContext(task ModerationTask) context.Context {
+	return logger.WithContext(w.ctx, logger.FromContext(w.ctx).With("target", task.Target, "id", task.ID))
+}
+
+// process 审核一条内容；审核服务不可用时按指数退避重试，超过次数转人工复核
+func (w *ModerationWorker) process(task ModerationTask) {
+	ctx := w.taskContext(task)
+	kind, content, userID, err := w.load(task)
+	if err != nil {
+		if errors.Is(err, errNotPending) || errors.Is(err, gorm.ErrRecordNotFound) {
+			// 已被删除或已有结论（例如被管理员处理）
+			w.done(task)
+			return
+		}
+		logger.FromContext(ctx).Errorw("后台审核:读取内容失败", "error", err)
+		w.retry(ctx, task)
+		return
+	}
+
+	start := time.Now()
+	verdict, err := w.moderator.Check(ctx, kind, content)
+	latency := time.Since(start)
+	if err != nil && errors.Is(err, ErrModeratorUnavailable) {
+		if w.ctx.Err() != nil {
+			// 进程退出中，保持 pending 等待下次启动
+			w.done(task)
+			return
+		}
+		logger.FromContext(ctx).Warnw("后台审核:审核服务不可用", "error", err)
+		w.retry(ctx, task)
+		return
+	}
+
+	result := ModerationResult{Target: task.Target, ID: task.ID, UserID: userID}
+	switch {
+	case err != nil:
+		// AI 无法判断等明确的业务错误，交给人工复核
+		result.Status, result.Reason = model.ModerationNeedsReview, err.Error()
+	case verdict.Violating:
+		result.Status, result.Reason = model.ModerationRejected, rejectReason(verdict)
+	default:
+		result.Status = model.ModerationApproved
+	}
+	record := NewModerationRecord(task.Target, task.ID, userID, content, verdict, result.Status, result.Reason, latency)
+	w.finish(ctx, task, result, record, verdict.Masked)
+}
+
+// retry 按指数退避重新入队；超过最大次数后转人工复核
+func (w *ModerationWorker) retry(ctx context.Context, task ModerationTask) {
+	w.mu.Lock()
+	attempt := w.inflight[task]
+	w.inflight[task] = attempt + 1
+	w.mu.Unlock()
+
+	if attempt >= w.cfg.MaxRetries {
+		_, content, userID, err := w.load(task)
+		if err != nil {
+			w.done(task)
+			return
+		}
+		result := ModerationResult{
+			Target: task.Target, ID: task.ID, UserID: userID,
+			Status: model.ModerationNeedsReview, Reason: "审核服务暂不可用，已转人工复核",
+		}
+		record := NewModerationRecord(task.Target, task.ID, userID, content, Verdict{}, result.Status, result.Reason, 0)
+		w.finish(ctx, task, result, record, "")
+		return
+	}
+
+	delay := w.cfg.RetryBackoff << attempt
+	time.AfterFunc(delay, func() {
+		select {
+		case <-w.ctx.Done():
+			w.done(task)
+		case w.tasks <- task:
+		}
+	})
+}
+
+var errNotPending = errors.New("内容不是待审核状态")
+
+// load 读取待审核内容及其作者
+func (w *ModerationWorker) load(task ModerationTask) (ContentKind, string, uint, error) {
+	switch task.Target {
+	case TargetWish:
+		var wish model.Wish
+		if err := w.db.First(&wish, task.ID).Error; err != nil {
+			return "", "", 0, err
+		}
+		if wish.ModerationStatus != model.ModerationPending {
+			return "", "", 0, errNotPending
+		}
+		return KindWish, wish.Content, wish.UserID, nil
+	case TargetComment:
+		var comment model.Comment
+		if err := w.db.First(&comment, task.ID).Error; err != nil {
+			return "", "", 0, err
+		}
+		if comment.ModerationStatus != model.ModerationPending {
+			return "", "", 0, errNotPending
+		}
+		kind := KindComment
+		if comment.ParentID != nil {
+			kind = KindReply
+		}
+		return kind, comment.Content, comment.UserID, nil
+	}
+	return "", "", 0, fmt.Errorf("未知的审核对象 %q", task.Target)
+}
+
+// finish 把结论写回数据库、写入审核记录并通知作者
+func (w *ModerationWorker) finish(ctx context.Context, task ModerationTask, result ModerationResult, record *model.ModerationRecord, masked string) {
+	defer w.done(task)
+	if err := ApplyModerationResult(w.db, result, masked); err != nil {
+		logger.FromContext(ctx).Errorw("后台审核:保存审核结论失败", "error", err)
+		return
+	}
+	SaveModerationRecord(w.db, record)
+	logger.FromContext(ctx).Infow("后台审核完成", "status", result.Status, "reason", result.Reason)
+	if w.notifier != nil {
+		if err := w.notifier.Notify(ctx, result); err != nil {
+			logger.FromContext(ctx).Warnw("后台审核:通知作者失败", "error", err)
+		}
+	}
+}
+
+// ApplyModerationResult 把审核结论写回 pending 状态的内容
+// masked 非空时同时用打码后的内容替换原文；评论审核通过时愿望评论数 +1
+func ApplyModerationResult(db *gorm.DB, result ModerationResult, masked string) error {
+	updates := map[string]interface{}{
+		"moderation_status": result.Status,
+		"moderation_reason": result.Reason,
+	}
+	if masked != "" && result.Status == model.ModerationApproved {
+		updates["content"] = masked
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		switch result.Target {
+		case TargetWish:
+			return tx.Model(&model.Wish{}).
+				Where("id = ? AND moderation_status = ?", result.ID, model.ModerationPending).
+				Updates(updates).Error
+		case TargetComment:
+			var comment model.Comment
+			if err := tx.First(&comment, result.ID).Error; err != nil {
+				return err
+			}
+			res := tx.Model(&model.Comment{}).
+				Where("id = ? AND moderation_status = ?", result.ID, model.ModerationPending).
+				Updates(updates)
+			if res.Error != nil {
+				return res.Error
+			}
+			// 只有本次真正从 pending 变为 approved 时才计数，避免重复处理导致计数偏大
+			if res.RowsAffected == 1 && result.Status == model.ModerationApproved {
+				return tx.Model(&model.Wish{}).Where("id = ?", comment.WishID).
+					UpdateColumn("comment_count", gorm.Expr("comment_count + ?", 1)).Error
+			}
+			return nil
+		}
+		return fmt.Errorf("未知的审核对象 %q", result.Target)
+	})
+}
+
+// rejectReason 生成保存到数据库的拒绝原因
+func rejectReason(verdict Verdict) string {
+	if verdict.Category == "" {
+		return "内容未通过审核"
+	}
+	return "内容包含" + verdict.Category + "信息"
+}
+
+// NewModerationWorkerFromConfig 按配置创建后台审核协程池；未开启异步审核 (moderation.async) 时返回 nil
+// 未设置 WebhookURL 时不通知审核结论（前端可轮询审核状态接口）
+func NewModerationWorkerFromConfig(db *gorm.DB, moderator Moderator, cfg config.ModerationConfig) *ModerationWorker {
+	if !cfg.Async {
+		return nil
+	}
+	workerCfg := ModerationWorkerConfig{
+		Workers:      cfg.Workers,
+		QueueSize:    cfg.QueueSize,
+		MaxRetries:   cfg.MaxRetries,
+		RetryBackoff: cfg.RetryBackoff,
+	}
+	var notifier ModerationNotifier
+	if cfg.WebhookURL != "" {
+		notifier = NewWebhookNotifier(cfg.WebhookURL)
+	}
+	logger.Log.Infow("异步审核已开启", "workers", workerCfg.Workers, "queueSize", workerCfg.QueueSize, "maxRetries", workerCfg.MaxRetries, "webhook", notifier != nil)
+	return NewModerationWorker(db, moderator, notifier, workerCfg)
+}
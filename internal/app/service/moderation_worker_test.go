@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/model"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/database"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/logger"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/migrate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// newWorkerTestDB 打开一个迁移到最新版本的内存数据库，并创建一个用户和一条已通过审核的愿望
+func newWorkerTestDB(t *testing.T) (*gorm.DB, *model.Wish) {
+	t.Helper()
+	db, err := database.Open(database.DriverSQLite, "file:"+t.Name()+"?mode=memory&cache=shared")
+	require.NoError(t, err)
+	m, err := migrate.New(db)
+	require.NoError(t, err)
+	_, err = m.Up(context.Background())
+	require.NoError(t, err)
+
+	user := &model.User{Username: "2024000001", Password: "x", Nickname: "同学"}
+	require.NoError(t, db.Create(user).Error)
+	wish := &model.Wish{UserID: user.ID, Content: "希望明天不下雨", ModerationStatus: model.ModerationApproved}
+	require.NoError(t, db.Create(wish).Error)
+	return db, wish
+}
+
+func createPendingComment(t *testing.T, db *gorm.DB, wish *model.Wish, content string) ModerationTask {
+	t.Helper()
+	comment := &model.Comment{WishID: wish.ID, UserID: wish.UserID, Content: content, ModerationStatus: model.ModerationPending}
+	require.NoError(t, db.Create(comment).Error)
+	return ModerationTask{Target: TargetComment, ID: comment.ID}
+}
+
+func commentCount(t *testing.T, db *gorm.DB, wishID uint) int {
+	t.Helper()
+	var wish model.Wish
+	require.NoError(t, db.First(&wish, wishID).Error)
+	return wish.CommentCount
+}
+
+func loadComment(t *testing.T, db *gorm.DB, id uint) model.Comment {
+	t.Helper()
+	var comment model.Comment
+	require.NoError(t, db.First(&comment, id).Error)
+	return comment
+}
+
+func TestModerationWorkerProcess(t *testing.T) {
+	logger.InitLogger()
+	db, wish := newWorkerTestDB(t)
+	moderator := &stubModerator{name: "stub"}
+	w := NewModerationWorker(db, moderator, nil, ModerationWorkerConfig{MaxRetries: 2, RetryBackoff: time.Millisecond})
+	defer w.Stop()
+
+	t.Run("审核通过时评论数只增加一次", func(t *testing.T) {
+		task := createPendingComment(t, db, wish, "加油")
+		w.process(task)
+		assert.Equal(t, model.ModerationApproved, loadComment(t, db, task.ID).ModerationStatus)
+		assert.Equal(t, 1, commentCount(t, db, wish.ID))
+
+		// 重复处理 (例如扫描补偿与队列中的任务重复) 不再计数
+		w.process(task)
+		require.NoError(t, ApplyModerationResult(db, ModerationResult{Target: TargetComment, ID: task.ID, Status: model.ModerationApproved}, ""))
+		assert.Equal(t, 1, commentCount(t, db, wish.ID))
+		assert.Equal(t, 1, moderator.calls, "已有结论的内容不再调用审核器")
+	})
+
+	t.Run("拒绝时不计数", func(t *testing.T) {
+		moderator.verdict = Verdict{Violating: true, Category: "广告"}
+		defer func() { moderator.verdict = Verdict{} }()
+		task := createPendingComment(t, db, wish, "加微信")
+		w.process(task)
+		comment := loadComment(t, db, task.ID)
+		assert.Equal(t, model.ModerationRejected, comment.ModerationStatus)
+		assert.Equal(t, "内容包含广告信息", comment.ModerationReason)
+		assert.Equal(t, 1, commentCount(t, db, wish.ID))
+	})
+
+	t.Run("跳过已不是待审核状态的内容", func(t *testing.T) {
+		task := createPendingComment(t, db, wish, "管理员已处理")
+		require.NoError(t, db.Model(&model.Comment{}).Where("id = ?", task.ID).Update("moderation_status", model.ModerationRejected).Error)
+		calls := moderator.calls
+		w.Enqueue(task)
+		<-w.tasks
+		w.process(task)
+		assert.Equal(t, calls, moderator.calls)
+		assert.Equal(t, model.ModerationRejected, loadComment(t, db, task.ID).ModerationStatus)
+		assert.Equal(t, 1, commentCount(t, db, wish.ID))
+		assert.NotContains(t, w.inflight, task, "处理完后可以再次入队")
+
+		// 结论已经写入后再写入其他结论不生效
+		require.NoError(t, ApplyModerationResult(db, ModerationResult{Target: TargetComment, ID: task.ID, Status: model.ModerationApproved}, ""))
+		assert.Equal(t, model.ModerationRejected, loadComment(t, db, task.ID).ModerationStatus)
+		assert.Equal(t, 1, commentCount(t, db, wish.ID))
+	})
+
+	t.Run("审核服务不可用时重试，超过次数转人工复核", func(t *testing.T) {
+		moderator.err = fmt.Errorf("%w: 503", ErrModeratorUnavailable)
+		defer func() { moderator.err = nil }()
+		task := createPendingComment(t, db, wish, "希望考试顺利")
+		calls := moderator.calls
+		w.Enqueue(task)
+		for attempt := 0; attempt <= 2; attempt++ {
+			select {
+			case got := <-w.tasks:
+				require.Equal(t, task, got)
+			case <-time.After(time.Second):
+				t.Fatalf("第 %d 次重试没有重新入队", attempt)
+			}
+			assert.Equal(t, model.ModerationPending, loadComment(t, db, task.ID).ModerationStatus)
+			w.process(task)
+		}
+		assert.Equal(t, calls+3, moderator.calls, "首次审核加 MaxRetries 次重试")
+
+		comment := loadComment(t, db, task.ID)
+		assert.Equal(t, model.ModerationNeedsReview, comment.ModerationStatus)
+		assert.Equal(t, "审核服务暂不可用，已转人工复核", comment.ModerationReason)
+		assert.Equal(t, 1, commentCount(t, db, wish.ID), "转人工复核不计数")
+		var records int64
+		require.NoError(t, db.Model(&model.ModerationRecord{}).Where("subject_type = ? AND subject_id = ?", TargetComment, task.ID).Count(&records).Error)
+		assert.Equal(t, int64(1), records)
+		select {
+		case <-w.tasks:
+			t.Fatal("超过重试次数后不再入队")
+		case <-time.After(20 * time.Millisecond):
+		}
+	})
+
+	t.Run("愿望审核通过时保存打码后的内容", func(t *testing.T) {
+		pending := &model.Wish{UserID: wish.UserID, Content: "有事加微信", ModerationStatus: model.ModerationPending}
+		require.NoError(t, db.Create(pending).Error)
+		moderator.verdict = Verdict{Masked: "有事***"}
+		defer func() { moderator.verdict = Verdict{} }()
+		w.process(ModerationTask{Target: TargetWish, ID: pending.ID})
+		var got model.Wish
+		require.NoError(t, db.First(&got, pending.ID).Error)
+		assert.Equal(t, model.ModerationApproved, got.ModerationStatus)
+		assert.Equal(t, "有事***", got.Content)
+	})
+}
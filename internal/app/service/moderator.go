@@ -0,0 +1,307 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/config"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/logger"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/metrics"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/sensitive"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// ErrModeratorUnavailable 表示审核服务本身不可用（未配置、网络错误、超时、返回异常等）。
+// ChainModerator 遇到此类错误会降级到下一个审核器；其他错误（如内容为空/过长）直接返回给调用方。
+var ErrModeratorUnavailable = errors.New("审核服务暂不可用")
+
+// ErrAmbiguous 表示审核器无法给出明确结论（如大模型的回答无法解析，或违规把握介于复核阈值与拒绝阈值之间）。
+// 这类内容不直接拒绝，而是进入人工复核队列。
+var ErrAmbiguous = errors.New("AI无法判断内容安全性")
+
+// Verdict 是一次内容审核的结果
+type Verdict struct {
+	Violating bool   // true=违规，丢弃；false=安全，接受
+	Provider  string // 给出结论的审核器名称
+	Category  string // 命中的违规类别（审核器能给出时填写，如 "辱骂"）
+	Masked    string // 非空时表示打码后的内容，调用方应保存它而不是原文
+	Raw       string // 审核器的原始回答（如大模型的回复），写入审核记录便于复核
+
+	// 大模型给出的全部违规类别与违规把握（0~1），由 ThresholdModerator 按类别阈值换算成最终结论；
+	// 不给出把握的审核器（关键词、放行）Confidence 为 0
+	Categories []string
+	Confidence float64
+}
+
+// ContentToSave 返回审核通过后应当落库的内容：有打码结果时用打码结果，否则用原文
+func (v Verdict) ContentToSave(original string) string {
+	if v.Masked != "" {
+		return v.Masked
+	}
+	return original
+}
+
+// Moderator 是内容审核器的统一接口，handler 只依赖这个接口
+type Moderator interface {
+	// Name 返回审核器名称，用于日志与结果溯源
+	Name() string
+	// Check 按 kind 对应的审核策略审核一段内容
+	Check(ctx context.Context, kind ContentKind, content string) (Verdict, error)
+}
+
+// maxContentLength 是愿望、评论的默认长度上限，其他类型见 DefaultPolicies
+const maxContentLength = 100
+
+// contentLogger 返回 ctx 中的日志记录器，并附带内容的长度和 SHA-256。
+// 日志中不记录用户内容原文，需要时按哈希到审核记录 (moderation_records.content_hash) 中查找
+func contentLogger(ctx context.Context, content string) *zap.SugaredLogger {
+	return logger.FromContext(ctx).With("contentLength", len([]rune(content)), "contentHash", contentHash(content))
+}
+
+// ValidateContent 对所有审核器通用的输入校验（内容为空/超过 kind 的长度上限），异步审核模式下 handler 也会在落库前调用
+func ValidateContent(ctx context.Context, kind ContentKind, content string) error {
+	trimmedContent := strings.TrimSpace(content)
+	if trimmedContent == "" {
+		contentLogger(ctx, content).Warnw("内容审核失败:内容为空", "kind", kind)
+		return fmt.Errorf("内容不能为空")
+	}
+	maxLength := PolicyFor(kind).MaxLength
+	if len([]rune(trimmedContent)) > maxLength {
+		contentLogger(ctx, content).Warnw("内容审核失败:内容过长", "kind", kind, "maxLength", maxLength)
+		return fmt.Errorf("内容长度不能超过%d个字符", maxLength)
+	}
+	return nil
+}
+
+// AllowAllModerator 放行所有内容，仅用于本地开发或作为链路最后的兜底
+type AllowAllModerator struct{}
+
+func (AllowAllModerator) Name() string { return "allow" }
+
+func (m AllowAllModerator) Check(ctx context.Context, kind ContentKind, content string) (Verdict, error) {
+	if err := ValidateContent(ctx, kind, content); err != nil {
+		return Verdict{Violating: true, Provider: m.Name()}, err
+	}
+	return Verdict{Violating: false, Provider: m.Name()}, nil
+}
+
+// KeywordModerator 基于本地敏感词表（sensitive.Filter）的审核器，不依赖任何外部服务
+// 命中 reject 类别时拒绝并给出类别；只命中 mask 类别时放行并返回打码后的内容
+type KeywordModerator struct {
+	filter *sensitive.Filter
+}
+
+// NewKeywordModerator 创建关键词审核器
+func NewKeywordModerator(filter *sensitive.Filter) *KeywordModerator {
+	return &KeywordModerator{filter: filter}
+}
+
+func (m *KeywordModerator) Name() string { return "keyword" }
+
+func (m *KeywordModerator) Check(ctx context.Context, kind ContentKind, content string) (Verdict, error) {
+	if err := ValidateContent(ctx, kind, content); err != nil {
+		return Verdict{Violating: true, Provider: m.Name()}, err
+	}
+	res := m.filter.Check(content)
+	if hit, ok := res.Rejected(); ok {
+		contentLogger(ctx, content).Infow("关键词审核:命中违规词", "word", hit.Word, "category", hit.Category)
+		return Verdict{Violating: true, Provider: m.Name(), Category: hit.Category}, nil
+	}
+	if len(res.Hits) > 0 {
+		contentLogger(ctx, content).Infow("关键词审核:命中打码词，打码后放行", "hits", len(res.Hits))
+		return Verdict{Violating: false, Provider: m.Name(), Masked: res.Masked}, nil
+	}
+	return Verdict{Violating: false, Provider: m.Name()}, nil
+}
+
+// PrefilterModerator 先用本地审核器做第一道过滤，通过后再把（可能已打码的）内容交给 next，
+// 明显违规的内容不会再消耗一次 AI 调用
+type PrefilterModerator struct {
+	local Moderator
+	next  Moderator
+}
+
+// NewPrefilterModerator 创建带本地前置过滤的审核器
+func NewPrefilterModerator(local, next Moderator) *PrefilterModerator {
+	return &PrefilterModerator{local: local, next: next}
+}
+
+func (p *PrefilterModerator) Name() string { return p.local.Name() + "+" + p.next.Name() }
+
+func (p *PrefilterModerator) Check(ctx context.Context, kind ContentKind, content string) (Verdict, error) {
+	verdict, err := p.local.Check(ctx, kind, content)
+	if err != nil || verdict.Violating {
+		return verdict, err
+	}
+	nextVerdict, err := p.next.Check(ctx, kind, verdict.ContentToSave(content))
+	if nextVerdict.Masked == "" {
+		nextVerdict.Masked = verdict.Masked
+	}
+	return nextVerdict, err
+}
+
+// ChainModerator 按顺序组合多个审核器：
+// 第一个给出明确结论的审核器生效；若某个审核器返回 ErrModeratorUnavailable 或超时，则降级到下一个。
+// 全部不可用时按 kind 对应策略的 FailMode 处理：closed 返回错误，open 放行，review 返回 ErrAmbiguous 转人工复核。
+type ChainModerator struct {
+	moderators  []Moderator
+	stepTimeout time.Duration // 单个审核器的超时时间，<=0 表示不限制
+}
+
+// NewChainModerator 创建审核链
+func NewChainModerator(stepTimeout time.Duration, moderators ...Moderator) *ChainModerator {
+	return &ChainModerator{moderators: moderators, stepTimeout: stepTimeout}
+}
+
+func (c *ChainModerator) Name() string {
+	names := make([]string, 0, len(c.moderators))
+	for _, m := range c.moderators {
+		names = append(names, m.Name())
+	}
+	return "chain(" + strings.Join(names, ",") + ")"
+}
+
+func (c *ChainModerator) Check(ctx context.Context, kind ContentKind, content string) (Verdict, error) {
+	if err := ValidateContent(ctx, kind, content); err != nil {
+		return Verdict{Violating: true, Provider: c.Name()}, err
+	}
+	lastErr := fmt.Errorf("%w: 未配置任何审核器", ErrModeratorUnavailable)
+	for _, m := range c.moderators {
+		verdict, err := c.checkOne(ctx, m, kind, content)
+		if err == nil {
+			return verdict, nil
+		}
+		if !errors.Is(err, ErrModeratorUnavailable) {
+			// 明确的业务错误（如 AI 无法判断），不再降级，由调用方决定是否转人工复核
+			return verdict, err
+		}
+		logger.FromContext(ctx).Warnw("内容审核:审核器不可用，降级到下一个", "provider", m.Name(), "error", err)
+		lastErr = err
+		if ctx.Err() != nil {
+			// 请求本身已被取消，没有必要继续尝试
+			return Verdict{Violating: true, Provider: c.Name()}, lastErr
+		}
+	}
+
+	failMode := PolicyFor(kind).FailMode
+	if failClosed(ctx) {
+		failMode = FailClosed
+	}
+	switch failMode {
+	case FailOpen:
+		contentLogger(ctx, content).Warnw("内容审核:审核服务不可用，按策略放行", "kind", kind, "error", lastErr)
+		return Verdict{Violating: false, Provider: c.Name() + "(fail-open)"}, nil
+	case FailReview:
+		contentLogger(ctx, content).Warnw("内容审核:审核服务不可用，按策略转人工复核", "kind", kind, "error", lastErr)
+		return Verdict{Violating: true, Provider: c.Name()}, fmt.Errorf("%w: %v", ErrAmbiguous, lastErr)
+	}
+	return Verdict{Violating: true, Provider: c.Name()}, lastErr
+}
+
+type failClosedKey struct{}
+
+// WithFailClosed 让审核链在审核服务不可用时总是返回错误，忽略策略中的 FailMode；
+// 用于批量重新审核等不能把"审核服务故障"当成审核结论的场景
+func WithFailClosed(ctx context.Context) context.Context {
+	return context.WithValue(ctx, failClosedKey{}, true)
+}
+
+func failClosed(ctx context.Context) bool {
+	v, _ := ctx.Value(failClosedKey{}).(bool)
+	return v
+}
+
+func (c *ChainModerator) checkOne(ctx context.Context, m Moderator, kind ContentKind, content string) (Verdict, error) {
+	if c.stepTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.stepTimeout)
+		defer cancel()
+	}
+	verdict, err := m.Check(ctx, kind, content)
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) && !errors.Is(err, ErrModeratorUnavailable) {
+		err = fmt.Errorf("%w: %v", ErrModeratorUnavailable, err)
+	}
+	return verdict, err
+}
+
+// meteredModerator 记录审核器的结论与耗时 (Prometheus 指标)，缓存命中也计入大模型审核器
+type meteredModerator struct {
+	next Moderator
+}
+
+func (m meteredModerator) Name() string { return m.next.Name() }
+
+func (m meteredModerator) Check(ctx context.Context, kind ContentKind, content string) (Verdict, error) {
+	start := time.Now()
+	verdict, err := m.next.Check(ctx, kind, content)
+	result := "approved"
+	switch {
+	case errors.Is(err, ErrAmbiguous):
+		result = "ambiguous"
+	case errors.Is(err, ErrModeratorUnavailable):
+		result = "unavailable"
+	case err != nil:
+		result = "error"
+	case verdict.Violating:
+		result = "rejected"
+	}
+	metrics.ObserveModeration(m.next.Name(), result, time.Since(start))
+	return verdict, err
+}
+
+// NewModeratorFromConfig 按配置组装审核链，各配置项的含义见 config.ModerationConfig
+// 大模型审核器的超时与重试见 NewLLMModeratorFromConfig，熔断见 newBreakerModeratorFromConfig，
+// 结论缓存见 newCachedModeratorFromConfig；db 用于持久化缓存，可以为 nil
+func NewModeratorFromConfig(db *gorm.DB, cfg config.ModerationConfig) Moderator {
+	LoadPolicies(cfg.PolicyFile)
+	filter := newSensitiveFilter(cfg.SensitiveWordsFile, cfg.SensitiveWordsReload)
+
+	var moderators []Moderator
+	for _, p := range cfg.Providers {
+		switch p {
+		case "llm":
+			llm := NewLLMModeratorFromConfig(cfg.LLM)
+			moderators = append(moderators, meteredModerator{newCachedModeratorFromConfig(newBreakerModeratorFromConfig(llm, cfg), db, cfg)})
+		case "keyword":
+			moderators = append(moderators, meteredModerator{NewKeywordModerator(filter)})
+		case "allow":
+			moderators = append(moderators, meteredModerator{AllowAllModerator{}})
+		default:
+			logger.Log.Warnw("未知的审核器，已忽略", "provider", p)
+		}
+	}
+
+	var moderator Moderator = NewChainModerator(cfg.Timeout, moderators...)
+	if cfg.Prefilter {
+		moderator = NewPrefilterModerator(meteredModerator{NewKeywordModerator(filter)}, moderator)
+	}
+	moderatorName.Lock()
+	moderatorName.name = moderator.Name()
+	moderatorName.Unlock()
+	logger.Log.Infow("内容审核链初始化完成", "moderator", moderator.Name(), "stepTimeout", cfg.Timeout)
+	return moderator
+}
+
+// newSensitiveFilter 加载敏感词表；path 为空时使用内置词表，文件加载失败时同样退回内置词表，保证服务可以启动。
+// interval 为热更新的检查间隔，0 表示不热更新
+func newSensitiveFilter(path string, interval time.Duration) *sensitive.Filter {
+	if path == "" {
+		return sensitive.Default()
+	}
+	filter, err := sensitive.LoadFile(path)
+	if err != nil {
+		logger.Log.Errorw("加载敏感词表失败，使用内置词表", "path", path, "error", err)
+		return sensitive.Default()
+	}
+
+	if interval > 0 {
+		// 监听随进程存活，无需停止
+		filter.Watch(interval)
+	}
+	logger.Log.Infow("敏感词表加载成功", "path", path, "words", filter.Len(), "reloadInterval", interval)
+	return filter
+}
@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/logger"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/sensitive"
+	"github.com/stretchr/testify/assert"
+)
+
+// stubModerator 是可编程的审核器，用于验证审核链的降级逻辑
+type stubModerator struct {
+	name    string
+	verdict Verdict
+	err     error
+	delay   time.Duration
+	calls   int
+}
+
+func (s *stubModerator) Name() string { return s.name }
+
+func (s *stubModerator) Check(ctx context.Context, kind ContentKind, content string) (Verdict, error) {
+	s.calls++
+	if s.delay > 0 {
+		select {
+		case <-time.After(s.delay):
+		case <-ctx.Done():
+			return Verdict{Violating: true, Provider: s.name}, ctx.Err()
+		}
+	}
+	s.verdict.Provider = s.name
+	return s.verdict, s.err
+}
+
+func TestChainModerator(t *testing.T) {
+	logger.InitLogger()
+
+	t.Run("上游不可用时降级到下一个审核器", func(t *testing.T) {
+		down := &stubModerator{name: "llm", err: fmt.Errorf("%w: connection refused", ErrModeratorUnavailable)}
+		local := &stubModerator{name: "keyword", verdict: Verdict{Violating: false}}
+
+		verdict, err := NewChainModerator(0, down, local).Check(context.Background(), KindWish, "希望期末考试顺利通过!")
+		assert.NoError(t, err)
+		assert.False(t, verdict.Violating)
+		assert.Equal(t, "keyword", verdict.Provider)
+		assert.Equal(t, 1, down.calls)
+		assert.Equal(t, 1, local.calls)
+	})
+
+	t.Run("上游超时时降级到下一个审核器", func(t *testing.T) {
+		slow := &stubModerator{name: "llm", delay: time.Second}
+		local := &stubModerator{name: "keyword", verdict: Verdict{Violating: true}}
+
+		verdict, err := NewChainModerator(20*time.Millisecond, slow, local).Check(context.Background(), KindWish, "好想早点回家")
+		assert.NoError(t, err)
+		assert.True(t, verdict.Violating)
+		assert.Equal(t, "keyword", verdict.Provider)
+	})
+
+	t.Run("明确的业务错误不降级", func(t *testing.T) {
+		unsure := &stubModerator{name: "llm", verdict: Verdict{Violating: true}, err: ErrAmbiguous}
+		local := &stubModerator{name: "keyword"}
+
+		_, err := NewChainModerator(0, unsure, local).Check(context.Background(), KindWish, "火星文")
+		assert.EqualError(t, err, "AI无法判断内容安全性")
+		assert.Equal(t, 0, local.calls)
+	})
+
+	t.Run("全部不可用时返回最后一个错误", func(t *testing.T) {
+		down := &stubModerator{name: "llm", err: fmt.Errorf("%w: 503", ErrModeratorUnavailable)}
+
+		verdict, err := NewChainModerator(0, down).Check(context.Background(), KindWish, "你好")
+		assert.ErrorIs(t, err, ErrModeratorUnavailable)
+		assert.True(t, verdict.Violating)
+	})
+
+	t.Run("全部不可用时按内容类型的策略放行或转人工复核", func(t *testing.T) {
+		defer SetPolicies(DefaultPolicies())
+		policies := DefaultPolicies()
+		review := policies[KindComment]
+		review.FailMode = FailReview
+		policies[KindComment] = review
+		SetPolicies(policies)
+		down := &stubModerator{name: "llm", err: fmt.Errorf("%w: 熔断中", ErrModeratorUnavailable)}
+		chain := NewChainModerator(0, down)
+
+		verdict, err := chain.Check(context.Background(), KindNickname, "小明")
+		assert.NoError(t, err)
+		assert.False(t, verdict.Violating)
+		assert.Equal(t, "chain(llm)(fail-open)", verdict.Provider)
+
+		_, err = chain.Check(context.Background(), KindComment, "加油")
+		assert.ErrorIs(t, err, ErrAmbiguous)
+
+		// 批量重新审核要求审核服务不可用时总是返回错误
+		_, err = chain.Check(WithFailClosed(context.Background()), KindNickname, "小明")
+		assert.ErrorIs(t, err, ErrModeratorUnavailable)
+	})
+
+	t.Run("输入校验在链路入口完成", func(t *testing.T) {
+		local := &stubModerator{name: "keyword"}
+
+		_, err := NewChainModerator(0, local).Check(context.Background(), KindWish, "  ")
+		assert.EqualError(t, err, "内容不能为空")
+		assert.Equal(t, 0, local.calls)
+	})
+}
+
+func TestKeywordModerator(t *testing.T) {
+	logger.InitLogger()
+	m := NewKeywordModerator(sensitive.New([]sensitive.Word{
+		{Text: "代开发票", Category: "广告", Action: sensitive.ActionReject},
+		{Text: "加微信", Category: "联系方式", Action: sensitive.ActionMask},
+	}))
+
+	verdict, err := m.Check(context.Background(), KindWish, "代开发票找我")
+	assert.NoError(t, err)
+	assert.True(t, verdict.Violating)
+	assert.Equal(t, "广告", verdict.Category)
+
+	verdict, err = m.Check(context.Background(), KindWish, "有事加微信")
+	assert.NoError(t, err)
+	assert.False(t, verdict.Violating)
+	assert.Equal(t, "有事***", verdict.ContentToSave("有事加微信"))
+
+	verdict, err = m.Check(context.Background(), KindWish, "希望世界和平呀")
+	assert.NoError(t, err)
+	assert.False(t, verdict.Violating)
+	assert.Equal(t, "希望世界和平呀", verdict.ContentToSave("希望世界和平呀"))
+}
+
+func TestPrefilterModerator(t *testing.T) {
+	logger.InitLogger()
+	local := NewKeywordModerator(sensitive.New([]sensitive.Word{
+		{Text: "傻逼", Category: "辱骂", Action: sensitive.ActionReject},
+		{Text: "加微信", Category: "联系方式", Action: sensitive.ActionMask},
+	}))
+
+	t.Run("本地命中时不再调用下游", func(t *testing.T) {
+		llm := &stubModerator{name: "llm"}
+		verdict, err := NewPrefilterModerator(local, llm).Check(context.Background(), KindWish, "你是傻逼")
+		assert.NoError(t, err)
+		assert.True(t, verdict.Violating)
+		assert.Equal(t, "辱骂", verdict.Category)
+		assert.Equal(t, 0, llm.calls)
+	})
+
+	t.Run("打码后的内容交给下游并保留打码结果", func(t *testing.T) {
+		llm := &recordingModerator{}
+		verdict, err := NewPrefilterModerator(local, llm).Check(context.Background(), KindWish, "有事加微信")
+		assert.NoError(t, err)
+		assert.False(t, verdict.Violating)
+		assert.Equal(t, "有事***", llm.lastContent)
+		assert.Equal(t, "有事***", verdict.ContentToSave("有事加微信"))
+	})
+}
+
+// recordingModerator 记录最后一次收到的内容并放行
+type recordingModerator struct {
+	lastContent string
+}
+
+func (r *recordingModerator) Name() string { return "recording" }
+
+func (r *recordingModerator) Check(ctx context.Context, kind ContentKind, content string) (Verdict, error) {
+	r.lastContent = content
+	return Verdict{Provider: r.Name()}, nil
+}
@@ -0,0 +1,429 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/model"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/repository"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/config"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/logger"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// minPasswordLen 是密码的最短长度 (字节)
+	minPasswordLen = 8
+	// maxPasswordLen 是密码的最大长度 (字节)：bcrypt 只使用前 72 个字节，更长的部分不起作用
+	maxPasswordLen = 72
+	// resetCodeLen 是重置码的长度 (不含分隔符)，base32 字符，约 60 位随机数
+	resetCodeLen = 12
+	// resetSendTimeout 是后台签发并发送一个自助申请的重置码的超时时间
+	resetSendTimeout = 30 * time.Second
+)
+
+// 自助申请重置码的限流 key 前缀，与登录限流的 key 不重叠，可以保存在同一个 ThrottleStore 中
+const (
+	resetAccountKeyPrefix = "reset-account:"
+	resetIPKeyPrefix      = "reset-ip:"
+)
+
+// 重置码的签发渠道
+const (
+	ResetChannelAdmin   = "admin"   // 管理员签发后告知用户
+	ResetChannelWebhook = "webhook" // 用户自助申请，通过 webhook 发送
+)
+
+// commonPasswords 是常见的弱密码 (小写)，即使满足长度和字符种类要求也拒绝
+var commonPasswords = map[string]bool{
+	"password1": true, "password123": true, "passw0rd": true, "p@ssw0rd": true, "p@ssword": true,
+	"qwerty123": true, "qwertyuiop1": true, "1qaz2wsx": true, "1q2w3e4r": true, "1q2w3e4r5t": true,
+	"abc12345": true, "abcd1234": true, "a1234567": true, "a12345678": true, "aa123456": true,
+	"iloveyou1": true, "admin123": true, "admin@123": true, "welcome1": true, "zxcvbnm1": true,
+	"ncu123456": true, "ncuhome123": true,
+}
+
+// ValidatePassword 校验密码强度：长度 8-72 个字节，至少包含字母、数字、符号中的两类，
+// 不包含学号，且不是常见的弱密码。注册、修改密码和重置密码时调用
+func ValidatePassword(username, password string) error {
+	switch {
+	case password == "":
+		return ErrEmptyPassword
+	case len(password) < minPasswordLen:
+		return fmt.Errorf("%w：至少需要 %d 个字符", ErrWeakPassword, minPasswordLen)
+	case len(password) > maxPasswordLen:
+		return fmt.Errorf("%w：不能超过 %d 个字节", ErrWeakPassword, maxPasswordLen)
+	}
+
+	var letter, digit, other bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLetter(r):
+			letter = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			other = true
+		}
+	}
+	classes := 0
+	for _, ok := range []bool{letter, digit, other} {
+		if ok {
+			classes++
+		}
+	}
+	if classes < 2 {
+		return fmt.Errorf("%w：需要包含字母、数字、符号中的至少两类", ErrWeakPassword)
+	}
+	if username != "" && strings.Contains(password, username) {
+		return fmt.Errorf("%w：不能包含学号", ErrWeakPassword)
+	}
+	if commonPasswords[strings.ToLower(password)] {
+		return fmt.Errorf("%w：密码过于常见", ErrWeakPassword)
+	}
+	return nil
+}
+
+// ResetNotifier 把用户自助申请的重置码发送给用户本人
+type ResetNotifier interface {
+	SendResetCode(ctx context.Context, user *model.User, code string, expiresAt time.Time) error
+}
+
+// ResetCodeMessage 是 ResetWebhookNotifier 发送的请求体
+type ResetCodeMessage struct {
+	UserID    uint      `json:"userId"`
+	Username  string    `json:"username"` // 学号，推送服务据此找到用户
+	Code      string    `json:"code"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// ResetWebhookNotifier 把重置码以 JSON POST 到指定地址 (如校园消息推送服务)，由它转发给用户
+type ResetWebhookNotifier struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+// NewResetWebhookNotifier 创建重置码 webhook 通知器，token 不为空时带 Authorization: Bearer <token>
+func NewResetWebhookNotifier(url, token string) *ResetWebhookNotifier {
+	return &ResetWebhookNotifier{url: url, token: token, client: &http.Client{Timeout: 5 * time.Second}}
+}
+
+func (n *ResetWebhookNotifier) SendResetCode(ctx context.Context, user *model.User, code string, expiresAt time.Time) error {
+	body, err := json.Marshal(ResetCodeMessage{UserID: user.ID, Username: user.Username, Code: code, ExpiresAt: expiresAt})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if n.token != "" {
+		req.Header.Set("Authorization", "Bearer "+n.token)
+	}
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook 返回状态码 %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// ResetRequestLimit 限制自助申请重置码的频率：窗口内同一学号、同一 IP 的申请次数达到上限后，
+// 直到窗口结束前的申请都被拒绝，防止冒名刷爆用户的消息推送
+type ResetRequestLimit struct {
+	Store      ThrottleStore // 为 nil 时不限制
+	Window     time.Duration
+	PerAccount int // 0 表示不限制
+	PerIP      int // 0 表示不限制
+}
+
+// PasswordService 负责修改密码和找回密码。
+// 找回密码使用一次性的重置码：由管理员签发后告知用户，或者由用户自助申请、通过 ResetNotifier 发送；
+// 重置码只保存哈希，有效期内只能使用一次，同一渠道签发新的重置码后旧的作废。重置密码后用户的全部登录会话被吊销
+type PasswordService struct {
+	store    repository.Store
+	notifier ResetNotifier // 为 nil 时不开放自助找回密码
+	codeTTL  time.Duration
+	limit    ResetRequestLimit
+	pending  sync.WaitGroup // 后台签发、发送中的自助申请
+	now      func() time.Time
+}
+
+// NewPasswordService 创建 PasswordService，codeTTL 为重置码的有效期
+func NewPasswordService(store repository.Store, notifier ResetNotifier, codeTTL time.Duration) *PasswordService {
+	return &PasswordService{store: store, notifier: notifier, codeTTL: codeTTL, now: time.Now}
+}
+
+// NewPasswordServiceFromConfig 按配置创建 PasswordService；未设置 WebhookURL 时只能由管理员签发重置码。
+// 自助申请的次数记录在 throttle 中 (按 LOGIN_LIMIT_STORE 创建，与登录限流使用同一种存储)
+func NewPasswordServiceFromConfig(store repository.Store, cfg config.PasswordResetConfig, throttle ThrottleStore) *PasswordService {
+	var notifier ResetNotifier
+	if cfg.WebhookURL != "" {
+		notifier = NewResetWebhookNotifier(cfg.WebhookURL, cfg.WebhookToken)
+	}
+	s := NewPasswordService(store, notifier, cfg.CodeTTL)
+	s.limit = ResetRequestLimit{Store: throttle, Window: cfg.RequestWindow, PerAccount: cfg.AccountRequestLimit, PerIP: cfg.IPRequestLimit}
+	return s
+}
+
+// SelfServiceEnabled 表示是否开放用户自助申请重置码
+func (s *PasswordService) SelfServiceEnabled() bool {
+	return s.notifier != nil
+}
+
+// ChangePassword 校验原密码后修改密码，并结束该用户除 keepJTI (当前会话) 以外的全部登录会话，
+// 未使用的重置码随之作废
+func (s *PasswordService) ChangePassword(ctx context.Context, userID uint, keepJTI, oldPassword, newPassword string) error {
+	user, err := s.store.Users().FindByID(ctx, userID)
+	if err != nil {
+		return notFoundAs(err, ErrUserNotFound)
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(oldPassword)); err != nil {
+		return ErrWrongPassword
+	}
+	if newPassword == oldPassword {
+		return fmt.Errorf("%w：新密码不能与原密码相同", ErrWeakPassword)
+	}
+	if err := ValidatePassword(user.Username, newPassword); err != nil {
+		return err
+	}
+	if user.Password, err = hashPassword(newPassword); err != nil {
+		return err
+	}
+
+	now := s.now()
+	var ended int64
+	err = s.store.Transaction(ctx, func(tx repository.Store) error {
+		if err := tx.Users().Save(ctx, user); err != nil {
+			return err
+		}
+		if _, err := tx.PasswordResets().InvalidateByUser(ctx, user.ID, now); err != nil {
+			return err
+		}
+		ended, err = revokeSessions(ctx, tx, user.ID, keepJTI, now)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	logger.FromContext(ctx).Infow("用户修改了密码", "userID", user.ID, "endedSessions", ended)
+	return nil
+}
+
+// IssueResetCode 为用户签发重置码，返回明文重置码及其过期时间；该用户之前通过同一渠道签发、未使用的重置码作废，
+// 这样任何人都能触发的自助申请不会让管理员签发的重置码失效。issuedBy 为签发的管理员，用户自助申请时为 nil
+func (s *PasswordService) IssueResetCode(ctx context.Context, userID uint, issuedBy *uint, channel string) (string, time.Time, error) {
+	if _, err := s.store.Users().FindByID(ctx, userID); err != nil {
+		return "", time.Time{}, notFoundAs(err, ErrUserNotFound)
+	}
+	now := s.now()
+	// 顺便清理该用户已过期的重置码，避免表无限增长
+	if err := s.store.PasswordResets().DeleteExpired(ctx, userID, now); err != nil {
+		logger.FromContext(ctx).Warnw("清理过期的重置码失败", "userID", userID, "error", err)
+	}
+
+	code := newResetCode()
+	reset := &model.PasswordReset{
+		UserID:    userID,
+		CodeHash:  hashToken(normalizeResetCode(code)),
+		IssuedBy:  issuedBy,
+		Channel:   channel,
+		ExpiresAt: now.Add(s.codeTTL),
+	}
+	err := s.store.Transaction(ctx, func(tx repository.Store) error {
+		if _, err := tx.PasswordResets().InvalidateByUserChannel(ctx, userID, channel, now); err != nil {
+			return err
+		}
+		return tx.PasswordResets().Create(ctx, reset)
+	})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	logger.FromContext(ctx).Infow("已签发重置码", "userID", userID, "issuedBy", issuedBy, "channel", channel, "expiresAt", reset.ExpiresAt)
+	return code, reset.ExpiresAt, nil
+}
+
+// RequestReset 处理用户自助找回密码：在后台签发重置码并通过 notifier 发送。
+// 为了不泄露学号是否已注册，无论学号是否存在都立即返回，查询、签发和发送的结果只记录日志；
+// 同一学号或 IP 申请过于频繁时返回需要等待的时长。未开放自助找回时返回 ErrResetUnavailable
+func (s *PasswordService) RequestReset(ctx context.Context, username, ip string) (time.Duration, error) {
+	if s.notifier == nil {
+		return 0, ErrResetUnavailable
+	}
+	if wait := s.throttleRequest(ctx, username, ip); wait > 0 {
+		return wait, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), resetSendTimeout)
+	s.pending.Go(func() {
+		defer cancel()
+		s.sendResetCode(ctx, username)
+	})
+	return 0, nil
+}
+
+// Wait 等待后台签发、发送中的自助申请全部完成，停止服务时在关闭数据库之前调用
+func (s *PasswordService) Wait() {
+	s.pending.Wait()
+}
+
+func (s *PasswordService) sendResetCode(ctx context.Context, username string) {
+	log := logger.FromContext(ctx)
+	user, err := s.store.Users().FindByUsername(ctx, username)
+	if errors.Is(err, repository.ErrNotFound) {
+		log.Infow("申请找回密码的学号未注册", "username", username)
+		return
+	}
+	if err != nil {
+		log.Errorw("查询申请找回密码的用户失败", "username", username, "error", err)
+		return
+	}
+	code, expiresAt, err := s.IssueResetCode(ctx, user.ID, nil, ResetChannelWebhook)
+	if err != nil {
+		log.Errorw("签发重置码失败", "userID", user.ID, "error", err)
+		return
+	}
+	if err := s.notifier.SendResetCode(ctx, user, code, expiresAt); err != nil {
+		log.Errorw("发送重置码失败", "userID", user.ID, "error", err)
+	}
+}
+
+// throttleRequest 记录一次自助申请，返回需要等待的时长；达到上限的这次申请仍然受理，之后到窗口结束前的申请被拒绝。
+// 学号未注册也计入，避免泄露学号是否存在；限流存储出错时只记录日志，不影响申请
+func (s *PasswordService) throttleRequest(ctx context.Context, username, ip string) time.Duration {
+	l := s.limit
+	if l.Store == nil {
+		return 0
+	}
+	type target struct {
+		key   string
+		limit int
+	}
+	var targets []target
+	var keys []string
+	if l.PerAccount > 0 && studentIDPattern.MatchString(username) {
+		targets = append(targets, target{resetAccountKeyPrefix + username, l.PerAccount})
+	}
+	if l.PerIP > 0 && ip != "" {
+		targets = append(targets, target{resetIPKeyPrefix + ip, l.PerIP})
+	}
+	for _, t := range targets {
+		keys = append(keys, t.key)
+	}
+	if len(keys) == 0 {
+		return 0
+	}
+
+	log := logger.FromContext(ctx)
+	now := s.now()
+	until, err := l.Store.LockedUntil(ctx, keys, now)
+	if err != nil {
+		log.Errorw("查询找回密码申请限制失败", "error", err)
+		return 0
+	}
+	if !until.IsZero() {
+		return until.Sub(now)
+	}
+	for _, t := range targets {
+		requests, err := l.Store.AddFailure(ctx, t.key, now, l.Window)
+		if err != nil {
+			log.Errorw("记录找回密码申请次数失败", "key", t.key, "error", err)
+			continue
+		}
+		if requests < t.limit {
+			continue
+		}
+		lock, err := l.Store.Lock(ctx, t.key, now, func(int) time.Duration { return l.Window })
+		if err != nil {
+			log.Errorw("限制找回密码申请失败", "key", t.key, "error", err)
+			continue
+		}
+		log.Warnw("找回密码申请过于频繁，已暂时限制", "key", t.key, "lockedUntil", lock.LockedUntil)
+	}
+	return 0
+}
+
+// ResetWithCode 使用重置码重置密码：重置码作废，用户的全部登录会话被吊销，需要用新密码重新登录
+func (s *PasswordService) ResetWithCode(ctx context.Context, username, code, newPassword string) error {
+	user, err := s.store.Users().FindByUsername(ctx, username)
+	if err != nil {
+		return notFoundAs(err, ErrInvalidResetCode)
+	}
+	now := s.now()
+	reset, err := s.store.PasswordResets().FindByHash(ctx, hashToken(normalizeResetCode(code)))
+	if err != nil {
+		return notFoundAs(err, ErrInvalidResetCode)
+	}
+	if reset.UserID != user.ID || reset.UsedAt != nil || !reset.ExpiresAt.After(now) {
+		return ErrInvalidResetCode
+	}
+	// 先校验新密码，密码不合格时重置码仍然可用
+	if err := ValidatePassword(user.Username, newPassword); err != nil {
+		return err
+	}
+	if user.Password, err = hashPassword(newPassword); err != nil {
+		return err
+	}
+
+	var ended int64
+	err = s.store.Transaction(ctx, func(tx repository.Store) error {
+		used, err := tx.PasswordResets().MarkUsed(ctx, reset.ID, now)
+		if err != nil {
+			return err
+		}
+		if !used {
+			// 另一个请求已经用这个重置码重置过
+			return ErrInvalidResetCode
+		}
+		if _, err := tx.PasswordResets().InvalidateByUser(ctx, user.ID, now); err != nil {
+			return err
+		}
+		if err := tx.Users().Save(ctx, user); err != nil {
+			return err
+		}
+		ended, err = revokeSessions(ctx, tx, user.ID, "", now)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	logger.FromContext(ctx).Infow("用户通过重置码重置了密码", "userID", user.ID, "channel", reset.Channel, "endedSessions", ended)
+	return nil
+}
+
+// newResetCode 生成重置码，每 4 个字符用 "-" 分隔，便于口头或手动转告
+func newResetCode() string {
+	raw := rand.Text()[:resetCodeLen]
+	var b strings.Builder
+	for i := 0; i < len(raw); i += 4 {
+		if i > 0 {
+			b.WriteByte('-')
+		}
+		b.WriteString(raw[i : i+4])
+	}
+	return b.String()
+}
+
+// normalizeResetCode 去掉用户输入的重置码中的分隔符和空白并转为大写
+func normalizeResetCode(code string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || unicode.IsSpace(r) {
+			return -1
+		}
+		return unicode.ToUpper(r)
+	}, code)
+}
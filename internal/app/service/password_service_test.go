@@ -0,0 +1,216 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/model"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/repository"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/logger"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidatePassword(t *testing.T) {
+	for password, ok := range map[string]bool{
+		"snow-wish-42":            true,
+		"雪落藏愿2024":                true,
+		"correct horse battery":   true,
+		"short1":                  false, // 太短
+		"onlyletters":             false, // 只有一类字符
+		"1234567890":              false,
+		"my2024000001pw":          false, // 包含学号
+		"Password1":               false, // 常见密码
+		strings.Repeat("ab1", 30): false, // 超过 72 字节
+	} {
+		err := ValidatePassword("2024000001", password)
+		if ok {
+			assert.NoError(t, err, password)
+		} else {
+			assert.ErrorIs(t, err, ErrWeakPassword, password)
+		}
+	}
+	assert.ErrorIs(t, ValidatePassword("2024000001", ""), ErrEmptyPassword)
+}
+
+// recordingNotifier 记录发送的重置码
+type recordingNotifier struct{ codes map[string]string }
+
+func (n *recordingNotifier) SendResetCode(_ context.Context, user *model.User, code string, _ time.Time) error {
+	n.codes[user.Username] = code
+	return nil
+}
+
+func TestPasswordService(t *testing.T) {
+	logger.InitLogger()
+	ctx := context.Background()
+	store := repository.NewMemoryStore()
+	users := NewUserService(store)
+	tokens := NewTokenService(store, util.NewJWT("my_test_secret_key", 15*time.Minute), time.Hour)
+	notifier := &recordingNotifier{codes: map[string]string{}}
+	svc := NewPasswordService(store, notifier, 30*time.Minute)
+
+	user, err := users.Register(ctx, "2024000001", "snow-wish-42", "同学")
+	require.NoError(t, err)
+	admin, err := users.CreateUser(ctx, "2024000002", "admin-pass-99", "管理员", model.RoleAdmin)
+	require.NoError(t, err)
+	_, err = users.Register(ctx, "2024000003", "weak", "同学")
+	assert.ErrorIs(t, err, ErrWeakPassword, "注册时校验密码强度")
+
+	activeSessions := func() int {
+		sessions, err := tokens.Sessions(ctx, user.ID)
+		require.NoError(t, err)
+		return len(sessions)
+	}
+
+	t.Run("修改密码", func(t *testing.T) {
+		current, err := tokens.Issue(ctx, user.ID, ClientInfo{})
+		require.NoError(t, err)
+		_, err = tokens.Issue(ctx, user.ID, ClientInfo{})
+		require.NoError(t, err)
+
+		assert.ErrorIs(t, svc.ChangePassword(ctx, user.ID, current.SessionID, "wrong-pass-1", "new-wish-43"), ErrWrongPassword)
+		assert.ErrorIs(t, svc.ChangePassword(ctx, user.ID, current.SessionID, "snow-wish-42", "snow-wish-42"), ErrWeakPassword)
+		assert.ErrorIs(t, svc.ChangePassword(ctx, user.ID, current.SessionID, "snow-wish-42", "weak"), ErrWeakPassword)
+		assert.Equal(t, 2, activeSessions(), "修改失败时不结束会话")
+
+		require.NoError(t, svc.ChangePassword(ctx, user.ID, current.SessionID, "snow-wish-42", "new-wish-43"))
+		_, err = users.Authenticate(ctx, "2024000001", "new-wish-43")
+		assert.NoError(t, err)
+		sessions, err := tokens.Sessions(ctx, user.ID)
+		require.NoError(t, err)
+		require.Len(t, sessions, 1, "其他设备被退出登录")
+		assert.Equal(t, current.SessionID, sessions[0].JTI, "保留当前会话")
+	})
+
+	t.Run("管理员签发重置码", func(t *testing.T) {
+		_, err := tokens.Issue(ctx, user.ID, ClientInfo{})
+		require.NoError(t, err)
+		first, _, err := svc.IssueResetCode(ctx, user.ID, &admin.ID, ResetChannelAdmin)
+		require.NoError(t, err)
+		code, expiresAt, err := svc.IssueResetCode(ctx, user.ID, &admin.ID, ResetChannelAdmin)
+		require.NoError(t, err)
+		assert.Regexp(t, `^[A-Z2-7]{4}-[A-Z2-7]{4}-[A-Z2-7]{4}$`, code)
+		assert.WithinDuration(t, time.Now().Add(30*time.Minute), expiresAt, time.Minute)
+
+		saved, err := store.PasswordResets().FindByHash(ctx, hashToken(normalizeResetCode(code)))
+		require.NoError(t, err)
+		assert.NotContains(t, saved.CodeHash, normalizeResetCode(code), "只保存重置码的哈希")
+		assert.Equal(t, &admin.ID, saved.IssuedBy)
+
+		assert.ErrorIs(t, svc.ResetWithCode(ctx, "2024000001", first, "reset-wish-44"), ErrInvalidResetCode, "签发新的重置码后旧的作废")
+		assert.ErrorIs(t, svc.ResetWithCode(ctx, "2024000002", code, "reset-wish-44"), ErrInvalidResetCode, "重置码属于其他用户")
+		assert.ErrorIs(t, svc.ResetWithCode(ctx, "2024000001", code, "weak"), ErrWeakPassword)
+
+		// 大小写、分隔符不影响
+		require.NoError(t, svc.ResetWithCode(ctx, "2024000001", " "+strings.ToLower(strings.ReplaceAll(code, "-", ""))+" ", "reset-wish-44"))
+		_, err = users.Authenticate(ctx, "2024000001", "reset-wish-44")
+		assert.NoError(t, err)
+		assert.Zero(t, activeSessions(), "重置密码后全部会话被吊销")
+		assert.ErrorIs(t, svc.ResetWithCode(ctx, "2024000001", code, "again-wish-45"), ErrInvalidResetCode, "重置码只能使用一次")
+
+		_, _, err = svc.IssueResetCode(ctx, 9999, &admin.ID, ResetChannelAdmin)
+		assert.ErrorIs(t, err, ErrUserNotFound)
+	})
+
+	t.Run("重置码过期", func(t *testing.T) {
+		code, _, err := svc.IssueResetCode(ctx, user.ID, nil, ResetChannelAdmin)
+		require.NoError(t, err)
+		svc.now = func() time.Time { return time.Now().Add(31 * time.Minute) }
+		defer func() { svc.now = time.Now }()
+		assert.ErrorIs(t, svc.ResetWithCode(ctx, "2024000001", code, "later-wish-46"), ErrInvalidResetCode)
+	})
+
+	t.Run("自助找回密码", func(t *testing.T) {
+		wait, err := svc.RequestReset(ctx, "2024000001", "10.0.0.1")
+		require.NoError(t, err)
+		assert.Zero(t, wait)
+		svc.Wait()
+		code := notifier.codes["2024000001"]
+		require.NotEmpty(t, code)
+		require.NoError(t, svc.ResetWithCode(ctx, "2024000001", code, "self-wish-47"))
+
+		// 自助申请只作废之前自助申请的重置码，不能被用来作废管理员签发的重置码
+		adminCode, _, err := svc.IssueResetCode(ctx, user.ID, &admin.ID, ResetChannelAdmin)
+		require.NoError(t, err)
+		_, err = svc.RequestReset(ctx, "2024000001", "10.0.0.1")
+		require.NoError(t, err)
+		svc.Wait()
+		require.NoError(t, svc.ResetWithCode(ctx, "2024000001", adminCode, "admin-wish-49"))
+
+		// 未注册的学号同样返回成功，不泄露是否已注册
+		_, err = svc.RequestReset(ctx, "2024000009", "10.0.0.1")
+		assert.NoError(t, err)
+		svc.Wait()
+		assert.NotContains(t, notifier.codes, "2024000009")
+
+		_, err = NewPasswordService(store, nil, time.Minute).RequestReset(ctx, "2024000001", "10.0.0.1")
+		assert.ErrorIs(t, err, ErrResetUnavailable)
+	})
+
+	t.Run("限制自助申请的频率", func(t *testing.T) {
+		clock := time.Now()
+		limited := NewPasswordService(store, notifier, 30*time.Minute)
+		limited.limit = ResetRequestLimit{Store: NewMemoryThrottleStore(), Window: time.Hour, PerAccount: 2, PerIP: 3}
+		limited.now = func() time.Time { return clock }
+		request := func(username, ip string) time.Duration {
+			t.Helper()
+			wait, err := limited.RequestReset(ctx, username, ip)
+			require.NoError(t, err)
+			limited.Wait()
+			return wait
+		}
+
+		assert.Zero(t, request("2024000001", "10.0.0.1"))
+		assert.Zero(t, request("2024000001", "10.0.0.2"), "达到上限的这次仍然受理")
+		assert.Equal(t, time.Hour, request("2024000001", "10.0.0.3"), "换 IP 也被限制")
+		assert.Zero(t, request("2024000009", "10.0.0.1"), "不影响其他学号")
+		assert.Zero(t, request("2024000010", "10.0.0.1"), "未注册的学号同样计数")
+		assert.Equal(t, time.Hour, request("2024000011", "10.0.0.1"), "同一 IP 申请了 3 次")
+		assert.Zero(t, request("2024000011", "10.0.0.2"))
+
+		clock = clock.Add(time.Hour)
+		assert.Zero(t, request("2024000001", "10.0.0.4"), "窗口结束后恢复")
+	})
+
+	t.Run("命令行重置密码吊销全部会话", func(t *testing.T) {
+		_, err := tokens.Issue(ctx, user.ID, ClientInfo{})
+		require.NoError(t, err)
+		_, err = users.ResetPassword(ctx, "2024000001", "weak")
+		assert.ErrorIs(t, err, ErrWeakPassword)
+		assert.Equal(t, 1, activeSessions())
+		_, err = users.ResetPassword(ctx, "2024000001", "admin-reset-48")
+		require.NoError(t, err)
+		assert.Zero(t, activeSessions())
+	})
+}
+
+func TestResetWebhookNotifier(t *testing.T) {
+	var got ResetCodeMessage
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+	}))
+	defer server.Close()
+
+	expiresAt := time.Now().Add(time.Hour).Truncate(time.Second)
+	user := &model.User{ID: 7, Username: "2024000001"}
+	require.NoError(t, NewResetWebhookNotifier(server.URL, "push-token").SendResetCode(context.Background(), user, "ABCD-EFGH-IJKL", expiresAt))
+	assert.Equal(t, "Bearer push-token", auth)
+	assert.Equal(t, "2024000001", got.Username)
+	assert.Equal(t, "ABCD-EFGH-IJKL", got.Code)
+	assert.True(t, expiresAt.Equal(got.ExpiresAt))
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer failing.Close()
+	assert.Error(t, NewResetWebhookNotifier(failing.URL, "").SendResetCode(context.Background(), user, "code", expiresAt))
+}
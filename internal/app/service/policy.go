@@ -0,0 +1,205 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/logger"
+)
+
+// ContentKind 是被审核内容的类型，不同类型使用不同的审核策略（长度上限、提示词、严格程度）
+type ContentKind string
+
+const (
+	KindNickname ContentKind = "nickname"
+	KindBio      ContentKind = "bio"
+	KindWish     ContentKind = "wish"
+	KindComment  ContentKind = "comment"
+	KindReply    ContentKind = "reply"
+	KindTag      ContentKind = "tag"
+)
+
+// Target 返回该类内容在审核记录中的对象类型（回复也是评论）
+func (k ContentKind) Target() ModerationTarget {
+	switch k {
+	case KindNickname:
+		return TargetNickname
+	case KindBio:
+		return TargetBio
+	case KindComment, KindReply:
+		return TargetComment
+	case KindTag:
+		return TargetTag
+	}
+	return TargetWish
+}
+
+// 审核严格程度：strict 把类别阈值整体调低 0.1（更容易拒绝），lenient 整体调高 0.1
+const (
+	StrictnessLenient = "lenient"
+	StrictnessNormal  = "normal"
+	StrictnessStrict  = "strict"
+)
+
+const strictnessStep = 0.1
+
+// 审核服务全部不可用（超时、熔断等）时的处理方式
+const (
+	FailClosed = "closed" // 拒绝内容，提示用户稍后再试
+	FailOpen   = "open"   // 放行内容
+	FailReview = "review" // 暂时隐藏，进入人工复核队列
+)
+
+// Policy 是某类内容的审核策略
+type Policy struct {
+	Name        string `json:"name"`        // 内容名称，用于拒绝提示（如 "昵称包含辱骂信息，请修改"）
+	MaxLength   int    `json:"maxLength"`   // 最大字符数
+	Template    string `json:"template"`    // 发给大模型的用户消息模板，{content} 会被替换为内容
+	Instruction string `json:"instruction"` // 追加在系统提示词后的额外要求，可以为空
+	Strictness  string `json:"strictness"`  // lenient / normal / strict
+	Moderated   bool   `json:"moderated"`   // 为 false 时只做长度校验，不调用审核器
+	FailMode    string `json:"failMode"`    // 审核服务不可用时的处理方式：closed / open / review
+}
+
+// render 生成发给大模型的用户消息
+func (p Policy) render(content string) string {
+	return strings.ReplaceAll(p.Template, "{content}", content)
+}
+
+// adjust 按严格程度调整类别阈值
+func (p Policy) adjust(t Threshold) Threshold {
+	var delta float64
+	switch p.Strictness {
+	case StrictnessStrict:
+		delta = -strictnessStep
+	case StrictnessLenient:
+		delta = strictnessStep
+	default:
+		return t
+	}
+	return Threshold{
+		Reject: min(max(t.Reject+delta, 0), 1),
+		Review: min(max(t.Review+delta, 0), 1),
+	}
+}
+
+func (p Policy) validate(kind ContentKind) error {
+	if p.MaxLength <= 0 {
+		return fmt.Errorf("%s: maxLength 必须大于 0", kind)
+	}
+	if !strings.Contains(p.Template, "{content}") {
+		return fmt.Errorf("%s: template 必须包含 {content}", kind)
+	}
+	switch p.Strictness {
+	case StrictnessLenient, StrictnessNormal, StrictnessStrict:
+	default:
+		return fmt.Errorf("%s: 未知的 strictness %q", kind, p.Strictness)
+	}
+	switch p.FailMode {
+	case FailClosed, FailOpen, FailReview:
+	default:
+		return fmt.Errorf("%s: 未知的 failMode %q", kind, p.FailMode)
+	}
+	return nil
+}
+
+// DefaultPolicies 返回内置的审核策略
+func DefaultPolicies() map[ContentKind]Policy {
+	return map[ContentKind]Policy{
+		KindNickname: {
+			Name: "昵称", MaxLength: 20, Template: "[用户昵称]: {content}",
+			Instruction: "这是用户昵称，审核要求可以放松一些，无意义的字符组合直接放行。",
+			Strictness:  StrictnessLenient, Moderated: true, FailMode: FailOpen,
+		},
+		KindBio: {
+			Name: "个人简介", MaxLength: 200, Template: "[个人简介]: {content}",
+			Strictness: StrictnessNormal, Moderated: true, FailMode: FailClosed,
+		},
+		KindWish: {
+			Name: "内容", MaxLength: maxContentLength, Template: "[用户愿望]: {content}",
+			Strictness: StrictnessNormal, Moderated: true, FailMode: FailClosed,
+		},
+		KindComment: {
+			Name: "内容", MaxLength: maxContentLength, Template: "[愿望评论]: {content}",
+			Strictness: StrictnessNormal, Moderated: true, FailMode: FailClosed,
+		},
+		KindReply: {
+			Name: "内容", MaxLength: maxContentLength, Template: "[评论回复]: {content}",
+			Instruction: "这是对其他用户评论的回复，请特别注意针对他人的辱骂和人身攻击。",
+			Strictness:  StrictnessStrict, Moderated: true, FailMode: FailClosed,
+		},
+		KindTag: {
+			Name: "标签", MaxLength: 20, Template: "[愿望标签]: {content}",
+			Instruction: "这是愿望的分类标签，通常只有几个字。",
+			Strictness:  StrictnessNormal, Moderated: true, FailMode: FailOpen,
+		},
+	}
+}
+
+// policies 是当前生效的审核策略，启动时由 LoadPolicies 设置
+var policies atomic.Pointer[map[ContentKind]Policy]
+
+func init() {
+	defaults := DefaultPolicies()
+	policies.Store(&defaults)
+}
+
+// PolicyFor 返回某类内容当前生效的审核策略；未知类型按愿望处理
+func PolicyFor(kind ContentKind) Policy {
+	all := *policies.Load()
+	if p, ok := all[kind]; ok {
+		return p
+	}
+	return all[KindWish]
+}
+
+// SetPolicies 替换当前生效的审核策略
+func SetPolicies(p map[ContentKind]Policy) {
+	policies.Store(&p)
+}
+
+// LoadPolicyFile 读取 JSON 格式的策略文件，文件中的字段覆盖内置策略，未出现的类型与字段保持默认值：
+//
+//	{ "nickname": { "maxLength": 16 }, "tag": { "moderated": false }, "wish": { "failMode": "review" } }
+func LoadPolicyFile(path string) (map[ContentKind]Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var overrides map[ContentKind]json.RawMessage
+	if err := json.Unmarshal(data, &overrides); err != nil {
+		return nil, fmt.Errorf("解析审核策略文件失败: %w", err)
+	}
+	result := DefaultPolicies()
+	for kind, raw := range overrides {
+		p, ok := result[kind]
+		if !ok {
+			return nil, fmt.Errorf("未知的内容类型 %q", kind)
+		}
+		if err := json.Unmarshal(raw, &p); err != nil {
+			return nil, fmt.Errorf("解析 %s 的审核策略失败: %w", kind, err)
+		}
+		if err := p.validate(kind); err != nil {
+			return nil, err
+		}
+		result[kind] = p
+	}
+	return result, nil
+}
+
+// LoadPolicies 从审核策略文件 (配置项 moderation.policyFile) 加载审核策略；未设置或加载失败时使用内置策略
+func LoadPolicies(path string) {
+	if path == "" {
+		return
+	}
+	p, err := LoadPolicyFile(path)
+	if err != nil {
+		logger.Log.Errorw("加载审核策略文件失败，使用内置策略", "path", path, "error", err)
+		return
+	}
+	SetPolicies(p)
+	logger.Log.Infow("审核策略加载成功", "path", path)
+}
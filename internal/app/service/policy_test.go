@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLoadPolicyFile(t *testing.T) {
+	write := func(t *testing.T, content string) string {
+		path := filepath.Join(t.TempDir(), "policy.json")
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+		return path
+	}
+
+	t.Run("文件中的字段覆盖内置策略", func(t *testing.T) {
+		p, err := LoadPolicyFile(write(t, `{"nickname": {"maxLength": 16}, "tag": {"moderated": false}}`))
+		assert.NoError(t, err)
+		assert.Equal(t, 16, p[KindNickname].MaxLength)
+		assert.Equal(t, StrictnessLenient, p[KindNickname].Strictness)
+		assert.False(t, p[KindTag].Moderated)
+		assert.Equal(t, DefaultPolicies()[KindWish], p[KindWish])
+	})
+
+	testCases := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{name: "未知的内容类型", content: `{"avatar": {"maxLength": 10}}`, wantErr: `未知的内容类型 "avatar"`},
+		{name: "长度上限无效", content: `{"wish": {"maxLength": 0}}`, wantErr: "maxLength 必须大于 0"},
+		{name: "模板缺少占位符", content: `{"comment": {"template": "评论"}}`, wantErr: "template 必须包含 {content}"},
+		{name: "未知的严格程度", content: `{"reply": {"strictness": "harsh"}}`, wantErr: `未知的 strictness "harsh"`},
+		{name: "未知的故障处理方式", content: `{"wish": {"failMode": "ignore"}}`, wantErr: `未知的 failMode "ignore"`},
+		{name: "JSON 格式错误", content: `{`, wantErr: "解析审核策略文件失败"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := LoadPolicyFile(write(t, tc.content))
+			assert.ErrorContains(t, err, tc.wantErr)
+		})
+	}
+}
+
+func TestPolicyAdjust(t *testing.T) {
+	base := Threshold{Reject: 0.8, Review: 0.05}
+
+	assert.Equal(t, base, Policy{Strictness: StrictnessNormal}.adjust(base))
+
+	strict := Policy{Strictness: StrictnessStrict}.adjust(base)
+	assert.InDelta(t, 0.7, strict.Reject, 1e-9)
+	assert.Equal(t, 0.0, strict.Review)
+
+	lenient := Policy{Strictness: StrictnessLenient}.adjust(Threshold{Reject: 0.95, Review: 0.5})
+	assert.Equal(t, 1.0, lenient.Reject)
+	assert.InDelta(t, 0.6, lenient.Review, 1e-9)
+}
+
+func TestValidateContentByKind(t *testing.T) {
+	assert.NoError(t, ValidateContent(context.Background(), KindWish, strings.Repeat("愿", 100)))
+	assert.EqualError(t, ValidateContent(context.Background(), KindNickname, strings.Repeat("名", 21)), "内容长度不能超过20个字符")
+	assert.NoError(t, ValidateContent(context.Background(), KindBio, strings.Repeat("介", 200)))
+	assert.EqualError(t, ValidateContent(context.Background(), KindTag, "   "), "内容不能为空")
+}
+
+func TestValidateContentLogsWithoutContent(t *testing.T) {
+	// 使用请求级日志记录器，只记录内容的长度和哈希
+	core, logs := observer.New(zap.DebugLevel)
+	ctx := logger.WithContext(context.Background(), zap.New(core).Sugar().With("requestID", "req-1"))
+	content := strings.Repeat("名", 21)
+	assert.Error(t, ValidateContent(ctx, KindNickname, content))
+
+	entries := logs.All()
+	if assert.Len(t, entries, 1) {
+		fields := entries[0].ContextMap()
+		assert.Equal(t, "req-1", fields["requestID"])
+		assert.EqualValues(t, 21, fields["contentLength"])
+		assert.Equal(t, contentHash(content), fields["contentHash"])
+		assert.NotContains(t, fields, "content")
+	}
+}
+
+func TestThresholdModeratorStrictness(t *testing.T) {
+	logger.InitLogger()
+
+	// 默认阈值为 拒绝 0.8 / 复核 0.5：回复（strict）为 0.7 / 0.4，昵称（lenient）为 0.9 / 0.6
+	next := &stubModerator{name: "llm", verdict: Verdict{Violating: true, Categories: []string{"辱骂"}, Confidence: 0.55}}
+	m := NewThresholdModerator(next, StaticThresholds{})
+
+	_, err := m.Check(context.Background(), KindWish, "你真笨")
+	assert.ErrorIs(t, err, ErrAmbiguous)
+
+	next.verdict.Confidence = 0.75
+	verdict, err := m.Check(context.Background(), KindReply, "你真笨")
+	assert.NoError(t, err)
+	assert.True(t, verdict.Violating)
+
+	next.verdict.Confidence = 0.55
+	verdict, err = m.Check(context.Background(), KindNickname, "你真笨")
+	assert.NoError(t, err)
+	assert.False(t, verdict.Violating)
+}
@@ -0,0 +1,467 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/model"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/lifecycle"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/logger"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrRemoderationRunning 表示已有重新审核任务正在执行，同一时间只允许一个任务
+	ErrRemoderationRunning = errors.New("已有重新审核任务正在执行")
+	// ErrRemoderationNotResumable 表示任务已完成或已取消，不能继续
+	ErrRemoderationNotResumable = errors.New("任务已结束，不能继续")
+	// ErrInvalidRemoderation 表示重新审核任务的参数不合法
+	ErrInvalidRemoderation = errors.New("重新审核参数无效")
+)
+
+// remoderationStaleAfter 是 running 任务的心跳超时：任务每审核一条内容都会更新 updated_at，
+// 超过这个时间没有更新说明执行任务的进程已经退出，任务可以被继续
+const remoderationStaleAfter = 5 * time.Minute
+
+// RemoderationTargets 是可以重新审核的内容类型
+var RemoderationTargets = []ModerationTarget{TargetWish, TargetComment}
+
+// RemoderationOptions 是创建重新审核任务的参数
+type RemoderationOptions struct {
+	Targets   []ModerationTarget // 为空时审核愿望和评论
+	BatchSize int                // 每批读取的条数，默认 100
+	Rate      float64            // 每秒最多审核的条数，默认 5
+	DryRun    bool               // 只生成报告，不修改内容
+	StartedBy uint
+}
+
+// ParseRemoderationTargets 解析逗号分隔的内容类型（如 "wish,comment"）
+func ParseRemoderationTargets(s string) ([]ModerationTarget, error) {
+	var targets []ModerationTarget
+	for _, part := range strings.Split(s, ",") {
+		target := ModerationTarget(strings.TrimSpace(part))
+		if target == "" {
+			continue
+		}
+		if !slices.Contains(RemoderationTargets, target) {
+			return nil, fmt.Errorf("%w: 未知的内容类型 %q", ErrInvalidRemoderation, target)
+		}
+		if !slices.Contains(targets, target) {
+			targets = append(targets, target)
+		}
+	}
+	return targets, nil
+}
+
+// CreateRemoderationJob 校验参数并创建一个 running 状态的重新审核任务，之后由 Remoderator.Run 执行
+func CreateRemoderationJob(db *gorm.DB, opts RemoderationOptions) (*model.RemoderationJob, error) {
+	if len(opts.Targets) == 0 {
+		opts.Targets = RemoderationTargets
+	}
+	for _, target := range opts.Targets {
+		if !slices.Contains(RemoderationTargets, target) {
+			return nil, fmt.Errorf("%w: 未知的内容类型 %q", ErrInvalidRemoderation, target)
+		}
+	}
+	if opts.BatchSize == 0 {
+		opts.BatchSize = 100
+	}
+	if opts.Rate == 0 {
+		opts.Rate = 5
+	}
+	if opts.BatchSize < 1 || opts.BatchSize > 1000 {
+		return nil, fmt.Errorf("%w: batchSize 需在 1~1000 之间", ErrInvalidRemoderation)
+	}
+	// 速率下限保证心跳间隔远小于 remoderationStaleAfter
+	if opts.Rate < 0.1 || opts.Rate > 100 {
+		return nil, fmt.Errorf("%w: rate 需在 0.1~100 之间", ErrInvalidRemoderation)
+	}
+
+	targets := make([]string, len(opts.Targets))
+	for i, target := range opts.Targets {
+		targets[i] = string(target)
+	}
+	job := model.RemoderationJob{
+		Status:        model.RemoderationRunning,
+		Targets:       strings.Join(targets, ","),
+		DryRun:        opts.DryRun,
+		BatchSize:     opts.BatchSize,
+		Rate:          opts.Rate,
+		PromptVersion: PromptVersion,
+		StartedBy:     opts.StartedBy,
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := checkNoRunningJob(tx); err != nil {
+			return err
+		}
+		return tx.Create(&job).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	logger.Log.Infow("创建重新审核任务", "jobID", job.ID, "targets", job.Targets, "dryRun", job.DryRun, "rate", job.Rate, "startedBy", job.StartedBy)
+	return &job, nil
+}
+
+// ResumeRemoderationJob 把暂停（或心跳超时）的任务重新置为 running，之后由 Remoderator.Run 从检查点继续
+func ResumeRemoderationJob(db *gorm.DB, jobID uint) (*model.RemoderationJob, error) {
+	var job model.RemoderationJob
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&job, jobID).Error; err != nil {
+			return err
+		}
+		if job.Status == model.RemoderationCompleted || job.Status == model.RemoderationCancelled {
+			return ErrRemoderationNotResumable
+		}
+		if err := checkNoRunningJob(tx); err != nil {
+			return err
+		}
+		job.Status, job.Error = model.RemoderationRunning, ""
+		return tx.Model(&job).Updates(map[string]interface{}{"status": job.Status, "error": ""}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	logger.Log.Infow("继续重新审核任务", "jobID", job.ID, "lastWishID", job.LastWishID, "lastCommentID", job.LastCommentID)
+	return &job, nil
+}
+
+// CancelRemoderationJob 取消未结束的任务；正在执行的任务会在审核完当前这条内容后停止
+func CancelRemoderationJob(db *gorm.DB, jobID uint) (*model.RemoderationJob, error) {
+	var job model.RemoderationJob
+	if err := db.First(&job, jobID).Error; err != nil {
+		return nil, err
+	}
+	if job.Status == model.RemoderationCompleted || job.Status == model.RemoderationCancelled {
+		return nil, ErrRemoderationNotResumable
+	}
+	now := time.Now()
+	res := db.Model(&job).Where("status = ?", job.Status).
+		Updates(map[string]interface{}{"status": model.RemoderationCancelled, "finished_at": now})
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		// 状态在读取之后变了（刚好执行完），按已结束处理
+		return nil, ErrRemoderationNotResumable
+	}
+	job.Status, job.FinishedAt = model.RemoderationCancelled, &now
+	logger.Log.Infow("取消重新审核任务", "jobID", job.ID)
+	return &job, nil
+}
+
+// checkNoRunningJob 检查是否有心跳未超时的 running 任务
+func checkNoRunningJob(tx *gorm.DB) error {
+	var count int64
+	err := tx.Model(&model.RemoderationJob{}).
+		Where("status = ? AND updated_at > ?", model.RemoderationRunning, time.Now().Add(-remoderationStaleAfter)).
+		Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrRemoderationRunning
+	}
+	return nil
+}
+
+// remoderationItem 是一条待重新审核的存量内容
+type remoderationItem struct {
+	target  ModerationTarget
+	kind    ContentKind
+	id      uint
+	userID  uint
+	wishID  uint // 评论所属的愿望
+	content string
+}
+
+// Remoderator 用当前的审核器重新审核已通过的存量愿望和评论：
+// 现在判定违规的内容改为 rejected（对其他人隐藏），无法判断的改为 needs_review（进入人工复核队列）
+//
+// 为了能在线上数据库直接运行：
+//   - 按 ID 分批扫描、按 Rate 限速，不持有长事务；
+//   - 写回结论时要求内容仍是审核时读到的原文且仍为 approved，期间被修改、删除或被管理员处理过的内容跳过；
+//   - 每审核一条内容就保存检查点，进程退出或审核服务不可用时任务暂停，可以从检查点继续
+type Remoderator struct {
+	db        *gorm.DB
+	moderator Moderator
+}
+
+// NewRemoderator 创建重新审核执行器
+func NewRemoderator(db *gorm.DB, moderator Moderator) *Remoderator {
+	return &Remoderator{db: db, moderator: moderator}
+}
+
+// backgroundRemoderations 跟踪管理接口在后台启动的重新审核任务，服务退出时中断它们
+var backgroundRemoderations = func() *backgroundJobs {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &backgroundJobs{ctx: ctx, cancel: cancel}
+}()
+
+type backgroundJobs struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// RunRemoderationInBackground 在后台执行重新审核任务，服务退出时任务会暂停并保存检查点
+func RunRemoderationInBackground(db *gorm.DB, moderator Moderator, jobID uint) {
+	b := backgroundRemoderations
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		if _, err := NewRemoderator(db, moderator).Run(b.ctx, jobID); err != nil {
+			logger.Log.Errorw("重新审核任务执行失败", "jobID", jobID, "error", err)
+		}
+	}()
+}
+
+// StopBackgroundRemoderations 中断后台的重新审核任务并等待它们保存检查点，
+// 被中断的任务状态为 paused，之后可以从检查点继续
+func StopBackgroundRemoderations(ctx context.Context) error {
+	backgroundRemoderations.cancel()
+	return lifecycle.Wait(ctx, backgroundRemoderations.wg.Wait)
+}
+
+// Run 执行任务直到完成、被取消、ctx 结束或审核服务不可用，返回任务的最新状态
+func (r *Remoderator) Run(ctx context.Context, jobID uint) (*model.RemoderationJob, error) {
+	var job model.RemoderationJob
+	if err := r.db.First(&job, jobID).Error; err != nil {
+		return nil, err
+	}
+	if job.Status != model.RemoderationRunning {
+		return &job, fmt.Errorf("任务状态为 %s，不能执行", job.Status)
+	}
+	logger.Log.Infow("重新审核任务开始执行", "jobID", job.ID, "targets", job.Targets, "dryRun", job.DryRun)
+
+	ticker := time.NewTicker(time.Duration(float64(time.Second) / job.Rate))
+	defer ticker.Stop()
+
+	for _, target := range strings.Split(job.Targets, ",") {
+		for {
+			items, err := r.nextBatch(&job, ModerationTarget(target))
+			if err != nil {
+				return r.pause(&job, "读取内容失败: "+err.Error())
+			}
+			if len(items) == 0 {
+				break
+			}
+			for _, item := range items {
+				select {
+				case <-ctx.Done():
+					return r.pause(&job, "进程中断")
+				case <-ticker.C:
+				}
+
+				if err := r.process(ctx, &job, item); err != nil {
+					if ctx.Err() != nil {
+						return r.pause(&job, "进程中断")
+					}
+					return r.pause(&job, err.Error())
+				}
+				stillRunning, err := r.checkpoint(&job)
+				if err != nil {
+					return r.pause(&job, "保存检查点失败: "+err.Error())
+				}
+				if !stillRunning {
+					logger.Log.Infow("重新审核任务已被取消", "jobID", job.ID, "scanned", job.Scanned)
+					r.db.First(&job, job.ID)
+					return &job, nil
+				}
+			}
+		}
+	}
+
+	now := time.Now()
+	job.Status, job.FinishedAt = model.RemoderationCompleted, &now
+	if err := r.db.Model(&job).Where("status = ?", model.RemoderationRunning).
+		Updates(map[string]interface{}{"status": job.Status, "finished_at": now}).Error; err != nil {
+		return &job, err
+	}
+	logger.Log.Infow("重新审核任务完成", "jobID", job.ID, "scanned", job.Scanned, "hidden", job.Hidden, "flagged", job.Flagged, "skipped", job.Skipped)
+	return &job, nil
+}
+
+// nextBatch 读取检查点之后的下一批 approved 内容
+func (r *Remoderator) nextBatch(job *model.RemoderationJob, target ModerationTarget) ([]remoderationItem, error) {
+	var items []remoderationItem
+	switch target {
+	case TargetWish:
+		var wishes []model.Wish
+		if err := r.db.Where("id > ? AND moderation_status = ?", job.LastWishID, model.ModerationApproved).
+			Order("id").Limit(job.BatchSize).Find(&wishes).Error; err != nil {
+			return nil, err
+		}
+		for _, w := range wishes {
+			items = append(items, remoderationItem{target: TargetWish, kind: KindWish, id: w.ID, userID: w.UserID, wishID: w.ID, content: w.Content})
+		}
+	case TargetComment:
+		var comments []model.Comment
+		if err := r.db.Where("id > ? AND moderation_status = ?", job.LastCommentID, model.ModerationApproved).
+			Order("id").Limit(job.BatchSize).Find(&comments).Error; err != nil {
+			return nil, err
+		}
+		for _, c := range comments {
+			kind := KindComment
+			if c.ParentID != nil {
+				kind = KindReply
+			}
+			items = append(items, remoderationItem{target: TargetComment, kind: kind, id: c.ID, userID: c.UserID, wishID: c.WishID, content: c.Content})
+		}
+	default:
+		return nil, fmt.Errorf("未知的内容类型 %q", target)
+	}
+	return items, nil
+}
+
+// process 重新审核一条内容并推进检查点；只有审核服务不可用（需要暂停任务）时返回错误
+func (r *Remoderator) process(ctx context.Context, job *model.RemoderationJob, item remoderationItem) error {
+	start := time.Now()
+	// 审核服务不可用时暂停任务，不能按策略放行或转人工复核
+	verdict, err := r.moderator.Check(WithFailClosed(ctx), item.kind, item.content)
+	latency := time.Since(start)
+
+	var status, reason string
+	switch {
+	case errors.Is(err, ErrModeratorUnavailable), ctx.Err() != nil:
+		return fmt.Errorf("审核服务不可用: %w", err)
+	case errors.Is(err, ErrAmbiguous):
+		status, reason = model.ModerationNeedsReview, err.Error()
+	case err != nil:
+		// 如内容超过了现在的长度上限：不是违规，保持原状
+		logger.Log.Warnw("重新审核:内容审核出错，跳过", "jobID", job.ID, "target", item.target, "id", item.id, "error", err)
+		job.Skipped++
+	case verdict.Violating:
+		status, reason = model.ModerationRejected, rejectReason(verdict)
+	}
+	job.Scanned++
+	r.advance(job, item)
+	if status == "" {
+		return nil
+	}
+
+	change := model.RemoderationChange{
+		JobID:       job.ID,
+		SubjectType: string(item.target),
+		SubjectID:   item.id,
+		UserID:      item.userID,
+		Content:     item.content,
+		OldStatus:   model.ModerationApproved,
+		NewStatus:   status,
+		Category:    verdict.Category,
+		Reason:      reason,
+	}
+	applied, err := r.apply(job, item, change, NewModerationRecord(item.target, item.id, item.userID, item.content, verdict, status, reason, latency))
+	if err != nil {
+		// 单条写回失败不中断任务，记为跳过
+		logger.Log.Errorw("重新审核:保存结论失败", "jobID", job.ID, "target", item.target, "id", item.id, "error", err)
+		job.Skipped++
+		return nil
+	}
+	if !applied {
+		job.Skipped++
+		return nil
+	}
+	if status == model.ModerationRejected {
+		job.Hidden++
+	} else {
+		job.Flagged++
+	}
+	logger.Log.Infow("重新审核:内容状态变更", "jobID", job.ID, "target", item.target, "id", item.id, "status", status, "dryRun", job.DryRun)
+	return nil
+}
+
+// apply 写回新结论、审核记录与报告条目；内容在审核期间被修改/删除/处理过时返回 false
+func (r *Remoderator) apply(job *model.RemoderationJob, item remoderationItem, change model.RemoderationChange, record *model.ModerationRecord) (bool, error) {
+	if job.DryRun {
+		return true, r.db.Create(&change).Error
+	}
+	applied := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		updates := map[string]interface{}{
+			"moderation_status": change.NewStatus,
+			"moderation_reason": change.Reason,
+		}
+		var res *gorm.DB
+		if item.target == TargetWish {
+			res = tx.Model(&model.Wish{}).
+				Where("id = ? AND moderation_status = ? AND content = ?", item.id, model.ModerationApproved, item.content).
+				Updates(updates)
+		} else {
+			res = tx.Model(&model.Comment{}).
+				Where("id = ? AND moderation_status = ? AND content = ?", item.id, model.ModerationApproved, item.content).
+				Updates(updates)
+		}
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		// 评论数只统计审核通过的评论
+		if item.target == TargetComment {
+			if err := tx.Model(&model.Wish{}).Where("id = ? AND comment_count > 0", item.wishID).
+				UpdateColumn("comment_count", gorm.Expr("comment_count - ?", 1)).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Create(record).Error; err != nil {
+			return err
+		}
+		change.RecordID = record.ID
+		if err := tx.Create(&change).Error; err != nil {
+			return err
+		}
+		applied = true
+		return nil
+	})
+	return applied, err
+}
+
+// advance 把检查点推进到 item
+func (r *Remoderator) advance(job *model.RemoderationJob, item remoderationItem) {
+	if item.target == TargetWish {
+		job.LastWishID = item.id
+	} else {
+		job.LastCommentID = item.id
+	}
+}
+
+// checkpoint 保存检查点与统计（同时作为心跳）；任务已不是 running（被取消）时返回 false
+func (r *Remoderator) checkpoint(job *model.RemoderationJob) (bool, error) {
+	res := r.db.Model(&model.RemoderationJob{}).
+		Where("id = ? AND status = ?", job.ID, model.RemoderationRunning).
+		Updates(map[string]interface{}{
+			"last_wish_id":    job.LastWishID,
+			"last_comment_id": job.LastCommentID,
+			"scanned":         job.Scanned,
+			"hidden":          job.Hidden,
+			"flagged":         job.Flagged,
+			"skipped":         job.Skipped,
+			"updated_at":      time.Now(),
+		})
+	return res.RowsAffected == 1, res.Error
+}
+
+// pause 保存检查点并把任务置为 paused，之后可以继续
+func (r *Remoderator) pause(job *model.RemoderationJob, reason string) (*model.RemoderationJob, error) {
+	if runes := []rune(reason); len(runes) > 255 {
+		reason = string(runes[:255])
+	}
+	if _, err := r.checkpoint(job); err != nil {
+		logger.Log.Errorw("重新审核:保存检查点失败", "jobID", job.ID, "error", err)
+	}
+	res := r.db.Model(&model.RemoderationJob{}).
+		Where("id = ? AND status = ?", job.ID, model.RemoderationRunning).
+		Updates(map[string]interface{}{"status": model.RemoderationPaused, "error": reason})
+	if res.Error != nil {
+		return job, res.Error
+	}
+	if res.RowsAffected == 1 {
+		job.Status, job.Error = model.RemoderationPaused, reason
+		logger.Log.Warnw("重新审核任务已暂停", "jobID", job.ID, "reason", reason, "lastWishID", job.LastWishID, "lastCommentID", job.LastCommentID)
+	}
+	return job, nil
+}
@@ -0,0 +1,30 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRemoderationTargets(t *testing.T) {
+	targets, err := ParseRemoderationTargets(" comment, wish ,comment,")
+	assert.NoError(t, err)
+	assert.Equal(t, []ModerationTarget{TargetComment, TargetWish}, targets)
+
+	_, err = ParseRemoderationTargets("wish,nickname")
+	assert.ErrorIs(t, err, ErrInvalidRemoderation)
+}
+
+func TestCreateRemoderationJobValidation(t *testing.T) {
+	// 参数校验在访问数据库之前完成
+	testCases := []RemoderationOptions{
+		{Targets: []ModerationTarget{TargetTag}},
+		{BatchSize: 5000},
+		{Rate: 0.01},
+		{Rate: -1},
+	}
+	for _, opts := range testCases {
+		_, err := CreateRemoderationJob(nil, opts)
+		assert.ErrorIs(t, err, ErrInvalidRemoderation)
+	}
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/model"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/logger"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ModerationCategories 是大模型审核使用的违规类别，与 systemPrompt 中列出的类别保持一致
+var ModerationCategories = []string{"色情", "暴力", "辱骂", "政治", "广告"}
+
+// ErrInvalidThreshold 表示管理员提交的阈值配置不合法
+var ErrInvalidThreshold = errors.New("审核阈值配置无效")
+
+// Threshold 是单个违规类别的审核阈值
+// 违规把握 >= Reject 时拒绝；Review <= 违规把握 < Reject 时转人工复核；否则放行
+type Threshold struct {
+	Reject float64
+	Review float64
+}
+
+// DefaultThreshold 是未单独配置的类别使用的阈值
+var DefaultThreshold = Threshold{Reject: 0.8, Review: 0.5}
+
+// ThresholdSource 提供各违规类别的审核阈值
+type ThresholdSource interface {
+	Threshold(category string) Threshold
+}
+
+// StaticThresholds 是固定的阈值表，未列出的类别使用 DefaultThreshold
+type StaticThresholds map[string]Threshold
+
+func (s StaticThresholds) Threshold(category string) Threshold {
+	if t, ok := s[category]; ok {
+		return t
+	}
+	return DefaultThreshold
+}
+
+// ThresholdStore 从 moderation_thresholds 表读取阈值并在内存中缓存 refresh 时长，
+// 管理员修改阈值后最多延迟一个 refresh 间隔生效（多实例部署时同样适用）
+type ThresholdStore struct {
+	db      *gorm.DB
+	refresh time.Duration
+
+	mu       sync.Mutex
+	cached   StaticThresholds
+	loadedAt time.Time
+}
+
+// NewThresholdStore 创建基于数据库的阈值表
+func NewThresholdStore(db *gorm.DB, refresh time.Duration) *ThresholdStore {
+	return &ThresholdStore{db: db, refresh: refresh}
+}
+
+func (s *ThresholdStore) Threshold(category string) Threshold {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cached == nil || time.Since(s.loadedAt) >= s.refresh {
+		thresholds, err := LoadThresholds(s.db)
+		if err != nil {
+			// 读取失败时沿用上一次的结果（首次失败则使用默认阈值），等下一个间隔再重试
+			logger.Log.Errorw("读取审核阈值失败", "error", err)
+			if s.cached == nil {
+				s.cached = StaticThresholds{}
+			}
+		} else {
+			s.cached = thresholds
+		}
+		s.loadedAt = time.Now()
+	}
+	return s.cached.Threshold(category)
+}
+
+// LoadThresholds 读取数据库中配置过的阈值
+func LoadThresholds(db *gorm.DB) (StaticThresholds, error) {
+	var rows []model.ModerationThreshold
+	if err := db.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	thresholds := make(StaticThresholds, len(rows))
+	for _, row := range rows {
+		thresholds[row.Category] = Threshold{Reject: row.RejectThreshold, Review: row.ReviewThreshold}
+	}
+	return thresholds, nil
+}
+
+// ListThresholds 返回所有违规类别当前生效的阈值，未配置的类别填入默认阈值
+func ListThresholds(db *gorm.DB) ([]model.ModerationThreshold, error) {
+	var rows []model.ModerationThreshold
+	if err := db.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	list := make([]model.ModerationThreshold, 0, len(ModerationCategories))
+	for _, category := range ModerationCategories {
+		i := slices.IndexFunc(rows, func(r model.ModerationThreshold) bool { return r.Category == category })
+		if i >= 0 {
+			list = append(list, rows[i])
+			continue
+		}
+		list = append(list, model.ModerationThreshold{
+			Category:        category,
+			RejectThreshold: DefaultThreshold.Reject,
+			ReviewThreshold: DefaultThreshold.Review,
+		})
+	}
+	return list, nil
+}
+
+// SaveThresholds 校验并保存管理员提交的阈值（按类别覆盖）
+func SaveThresholds(db *gorm.DB, thresholds []model.ModerationThreshold, updatedBy uint) error {
+	if len(thresholds) == 0 {
+		return fmt.Errorf("%w: 至少需要提交一个类别", ErrInvalidThreshold)
+	}
+	for i := range thresholds {
+		t := &thresholds[i]
+		if !slices.Contains(ModerationCategories, t.Category) {
+			return fmt.Errorf("%w: 未知的类别 %q", ErrInvalidThreshold, t.Category)
+		}
+		if t.ReviewThreshold < 0 || t.RejectThreshold > 1 || t.ReviewThreshold > t.RejectThreshold {
+			return fmt.Errorf("%w: %s 的阈值需满足 0 <= reviewThreshold <= rejectThreshold <= 1", ErrInvalidThreshold, t.Category)
+		}
+		t.UpdatedBy = updatedBy
+	}
+	return db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&thresholds).Error
+}
+
+// ThresholdModerator 按类别阈值把审核器给出的违规把握换算成最终结论：
+// 任一类别达到拒绝阈值时拒绝；否则任一类别达到复核阈值时返回 ErrAmbiguous（转人工复核）；否则放行
+// 阈值按内容类型的严格程度（Policy.Strictness）调整；
+// 没有给出违规类别的结论（关键词、放行审核器，或只回答了 true/false 的大模型）原样返回
+type ThresholdModerator struct {
+	next   Moderator
+	source ThresholdSource
+}
+
+// NewThresholdModerator 创建按阈值判定的审核器
+func NewThresholdModerator(next Moderator, source ThresholdSource) *ThresholdModerator {
+	return &ThresholdModerator{next: next, source: source}
+}
+
+func (m *ThresholdModerator) Name() string { return m.next.Name() }
+
+func (m *ThresholdModerator) Check(ctx context.Context, kind ContentKind, content string) (Verdict, error) {
+	verdict, err := m.next.Check(ctx, kind, content)
+	if err != nil || len(verdict.Categories) == 0 {
+		return verdict, err
+	}
+
+	policy := PolicyFor(kind)
+	review := ""
+	for _, category := range verdict.Categories {
+		t := policy.adjust(m.source.Threshold(category))
+		if verdict.Confidence >= t.Reject {
+			verdict.Violating, verdict.Category = true, category
+			return verdict, nil
+		}
+		if verdict.Confidence >= t.Review && review == "" {
+			review = category
+		}
+	}
+	if review != "" {
+		verdict.Violating, verdict.Category = true, review
+		logger.Log.Infow("内容审核:违规把握未达到拒绝阈值，转人工复核", "category", review, "confidence", verdict.Confidence)
+		return verdict, fmt.Errorf("%w: %s类违规把握 %.2f 未达到拒绝阈值", ErrAmbiguous, review, verdict.Confidence)
+	}
+	verdict.Violating, verdict.Category = false, ""
+	return verdict, nil
+}
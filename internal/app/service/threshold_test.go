@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThresholdModerator(t *testing.T) {
+	logger.InitLogger()
+
+	thresholds := StaticThresholds{
+		"辱骂": {Reject: 0.9, Review: 0.6},
+		"政治": {Reject: 0.5, Review: 0.2},
+	}
+
+	testCases := []struct {
+		name          string
+		verdict       Verdict
+		wantViolating bool
+		wantCategory  string
+		wantAmbiguous bool
+	}{
+		{
+			name:          "达到拒绝阈值",
+			verdict:       Verdict{Violating: true, Categories: []string{"辱骂"}, Confidence: 0.95},
+			wantViolating: true, wantCategory: "辱骂",
+		},
+		{
+			name:          "介于复核阈值与拒绝阈值之间时转人工复核",
+			verdict:       Verdict{Violating: true, Categories: []string{"辱骂"}, Confidence: 0.7},
+			wantViolating: true, wantCategory: "辱骂", wantAmbiguous: true,
+		},
+		{
+			name:    "低于复核阈值时放行",
+			verdict: Verdict{Violating: true, Categories: []string{"辱骂"}, Confidence: 0.3},
+		},
+		{
+			name:          "任一类别达到拒绝阈值即拒绝",
+			verdict:       Verdict{Violating: true, Categories: []string{"辱骂", "政治"}, Confidence: 0.6},
+			wantViolating: true, wantCategory: "政治",
+		},
+		{
+			name:          "未配置的类别使用默认阈值",
+			verdict:       Verdict{Violating: true, Categories: []string{"广告"}, Confidence: DefaultThreshold.Reject},
+			wantViolating: true, wantCategory: "广告",
+		},
+		{
+			name:          "没有类别时原样返回",
+			verdict:       Verdict{Violating: true},
+			wantViolating: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			next := &stubModerator{name: "llm", verdict: tc.verdict}
+			verdict, err := NewThresholdModerator(next, thresholds).Check(context.Background(), KindWish, "测试内容")
+			assert.Equal(t, tc.wantAmbiguous, errors.Is(err, ErrAmbiguous))
+			if !tc.wantAmbiguous {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.wantViolating, verdict.Violating)
+			assert.Equal(t, tc.wantCategory, verdict.Category)
+		})
+	}
+
+	t.Run("审核器出错时不做换算", func(t *testing.T) {
+		next := &stubModerator{name: "llm", verdict: Verdict{Violating: true}, err: ErrModeratorUnavailable}
+		_, err := NewThresholdModerator(next, thresholds).Check(context.Background(), KindWish, "测试内容")
+		assert.ErrorIs(t, err, ErrModeratorUnavailable)
+	})
+}
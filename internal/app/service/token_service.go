@@ -0,0 +1,311 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/model"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/repository"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/logger"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/util"
+)
+
+const (
+	// maxUserAgentLen 是登录会话与刷新令牌记录的 User-Agent 最大长度 (字符)
+	maxUserAgentLen = 255
+	// lastSeenInterval 是登录会话最近访问时间的更新粒度：距上次记录不足该时长的请求不写数据库
+	lastSeenInterval = time.Minute
+)
+
+// ClientInfo 是登录、刷新令牌时记录的客户端信息，用于识别登录设备
+type ClientInfo struct {
+	UserAgent string
+	IP        string
+}
+
+// TokenPair 是登录、刷新时签发的一组令牌
+type TokenPair struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresIn    time.Duration // 访问令牌的有效期
+	SessionID    string        // 登录会话的 jti，即刷新令牌族 ID
+}
+
+// TokenService 负责登录会话以及访问令牌、刷新令牌的签发与吊销。
+// 每次登录创建一个登录会话 (model.Session)，访问令牌的 jti 为会话 ID，刷新令牌按会话分族换发；
+// 会话被删除 (退出登录、在设备列表中移除、刷新令牌被重复使用) 后访问令牌立即失效
+type TokenService struct {
+	store      repository.Store
+	jwt        *util.JWT
+	refreshTTL time.Duration
+	now        func() time.Time
+}
+
+// NewTokenService 创建 TokenService，refreshTTL 为刷新令牌的有效期
+func NewTokenService(store repository.Store, jwt *util.JWT, refreshTTL time.Duration) *TokenService {
+	return &TokenService{store: store, jwt: jwt, refreshTTL: refreshTTL, now: time.Now}
+}
+
+// Issue 为登录或注册成功的用户开启新的登录会话
+func (s *TokenService) Issue(ctx context.Context, userID uint, client ClientInfo) (*TokenPair, error) {
+	now := s.now()
+	// 顺便清理该用户已过期的会话和刷新令牌，避免表无限增长
+	if err := s.deleteExpired(ctx, userID, now); err != nil {
+		logger.FromContext(ctx).Warnw("清理过期的登录会话失败", "userID", userID, "error", err)
+	}
+
+	var pair *TokenPair
+	err := s.store.Transaction(ctx, func(tx repository.Store) error {
+		session := &model.Session{
+			UserID:     userID,
+			JTI:        rand.Text(),
+			UserAgent:  truncateRunes(client.UserAgent, maxUserAgentLen),
+			IP:         client.IP,
+			LastSeenAt: now,
+			ExpiresAt:  now.Add(s.refreshTTL),
+		}
+		if err := tx.Sessions().Create(ctx, session); err != nil {
+			return err
+		}
+		var err error
+		_, pair, err = s.issue(ctx, tx, userID, session.JTI, client, now)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pair, nil
+}
+
+// Refresh 用刷新令牌换取新的访问令牌和刷新令牌，旧的刷新令牌随即作废，登录会话的有效期随之延长。
+// 已换发过的刷新令牌再次被使用时删除整个登录会话并返回 ErrRefreshTokenReused
+func (s *TokenService) Refresh(ctx context.Context, refreshToken string, client ClientInfo) (*TokenPair, error) {
+	now := s.now()
+	old, err := s.store.RefreshTokens().FindByHash(ctx, hashToken(refreshToken))
+	if err != nil {
+		return nil, notFoundAs(err, ErrInvalidRefreshToken)
+	}
+	if old.RevokedAt != nil {
+		if old.ReplacedByID == nil {
+			return nil, ErrInvalidRefreshToken // 已退出登录
+		}
+		return nil, s.reused(ctx, old, now)
+	}
+	if !old.ExpiresAt.After(now) {
+		return nil, ErrInvalidRefreshToken
+	}
+	// 用户被删除后不再续期
+	if _, err := s.store.Users().FindByID(ctx, old.UserID); err != nil {
+		return nil, notFoundAs(err, ErrInvalidRefreshToken)
+	}
+
+	var pair *TokenPair
+	err = s.store.Transaction(ctx, func(tx repository.Store) error {
+		session, err := tx.Sessions().FindByJTI(ctx, old.FamilyID)
+		if err != nil {
+			return notFoundAs(err, ErrInvalidRefreshToken)
+		}
+		next, p, err := s.issue(ctx, tx, old.UserID, old.FamilyID, client, now)
+		if err != nil {
+			return err
+		}
+		rotated, err := tx.RefreshTokens().Rotate(ctx, old.ID, next.ID, now)
+		if err != nil {
+			return err
+		}
+		if !rotated {
+			// 另一个请求已经用这个令牌换发过
+			return ErrRefreshTokenReused
+		}
+		if err := tx.Sessions().Renew(ctx, session.ID, next.ExpiresAt, client.IP); err != nil {
+			return err
+		}
+		pair = p
+		return nil
+	})
+	if errors.Is(err, ErrRefreshTokenReused) {
+		return nil, s.reused(ctx, old, now)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return pair, nil
+}
+
+// Sessions 列出用户未过期的登录会话，最近访问的在前
+func (s *TokenService) Sessions(ctx context.Context, userID uint) ([]model.Session, error) {
+	return s.store.Sessions().ListActiveByUser(ctx, userID, s.now())
+}
+
+// Logout 退出 jti 对应的登录会话，该会话的访问令牌和刷新令牌立即失效
+func (s *TokenService) Logout(ctx context.Context, userID uint, jti string) error {
+	session, err := s.store.Sessions().FindByJTI(ctx, jti)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil // 已经退出
+	}
+	if err != nil {
+		return err
+	}
+	if session.UserID != userID {
+		return nil
+	}
+	return s.endSession(ctx, session, s.now())
+}
+
+// RevokeSession 结束用户的某个登录会话 (在设备列表中移除)；会话不属于该用户时返回 ErrSessionNotFound
+func (s *TokenService) RevokeSession(ctx context.Context, userID, sessionID uint) error {
+	session, err := s.store.Sessions().FindByID(ctx, sessionID)
+	if err != nil {
+		return notFoundAs(err, ErrSessionNotFound)
+	}
+	if session.UserID != userID {
+		return ErrSessionNotFound
+	}
+	return s.endSession(ctx, session, s.now())
+}
+
+// LogoutAll 退出用户的全部登录会话，返回结束的会话数
+func (s *TokenService) LogoutAll(ctx context.Context, userID uint) (int64, error) {
+	var n int64
+	err := s.store.Transaction(ctx, func(tx repository.Store) error {
+		var err error
+		n, err = revokeSessions(ctx, tx, userID, "", s.now())
+		return err
+	})
+	return n, err
+}
+
+// SessionActive 判断访问令牌所属的登录会话是否仍然有效，供鉴权中间件调用；
+// 同时按分钟粒度更新会话的最近访问时间
+func (s *TokenService) SessionActive(ctx context.Context, userID uint, jti string) (bool, error) {
+	if jti == "" {
+		return false, nil // 旧版本签发的令牌没有会话 ID，无法吊销，不再接受
+	}
+	session, err := s.store.Sessions().FindByJTI(ctx, jti)
+	if errors.Is(err, repository.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	now := s.now()
+	if session.UserID != userID || !session.ExpiresAt.After(now) {
+		return false, nil
+	}
+	if staleBefore := now.Add(-lastSeenInterval); session.LastSeenAt.Before(staleBefore) {
+		// 最近访问时间只用于展示，更新失败不影响本次请求
+		if err := s.store.Sessions().Touch(ctx, session.ID, now, staleBefore); err != nil {
+			logger.FromContext(ctx).Warnw("更新登录会话的访问时间失败", "sessionID", session.ID, "error", err)
+		}
+	}
+	return true, nil
+}
+
+// issue 在令牌族 familyID 中签发一个新的刷新令牌，并签发对应的访问令牌
+func (s *TokenService) issue(ctx context.Context, store repository.Store, userID uint, familyID string, client ClientInfo, now time.Time) (*model.RefreshToken, *TokenPair, error) {
+	access, err := s.jwt.GenerateToken(userID, familyID)
+	if err != nil {
+		return nil, nil, err
+	}
+	raw := rand.Text()
+	token := &model.RefreshToken{
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: hashToken(raw),
+		UserAgent: truncateRunes(client.UserAgent, maxUserAgentLen),
+		IP:        client.IP,
+		ExpiresAt: now.Add(s.refreshTTL),
+	}
+	if err := store.RefreshTokens().Create(ctx, token); err != nil {
+		return nil, nil, err
+	}
+	return token, &TokenPair{AccessToken: access, RefreshToken: raw, ExpiresIn: s.jwt.TTL(), SessionID: familyID}, nil
+}
+
+// endSession 删除登录会话并作废其刷新令牌
+func (s *TokenService) endSession(ctx context.Context, session *model.Session, now time.Time) error {
+	return s.store.Transaction(ctx, func(tx repository.Store) error {
+		if _, err := tx.RefreshTokens().RevokeFamily(ctx, session.UserID, session.JTI, now); err != nil {
+			return err
+		}
+		return tx.Sessions().Delete(ctx, session.ID)
+	})
+}
+
+// revokeSessions 结束用户除 keepJTI 以外的全部登录会话并作废其刷新令牌，keepJTI 为空时全部结束，返回结束的会话数。
+// 修改、重置密码时也在同一事务中调用
+func revokeSessions(ctx context.Context, tx repository.Store, userID uint, keepJTI string, now time.Time) (int64, error) {
+	if keepJTI == "" {
+		if _, err := tx.RefreshTokens().RevokeByUser(ctx, userID, now); err != nil {
+			return 0, err
+		}
+		return tx.Sessions().DeleteByUser(ctx, userID)
+	}
+	// 已过期的会话本身已经失效，只处理未过期的
+	sessions, err := tx.Sessions().ListActiveByUser(ctx, userID, now)
+	if err != nil {
+		return 0, err
+	}
+	var n int64
+	for _, session := range sessions {
+		if session.JTI == keepJTI {
+			continue
+		}
+		if _, err := tx.RefreshTokens().RevokeFamily(ctx, userID, session.JTI, now); err != nil {
+			return n, err
+		}
+		if err := tx.Sessions().Delete(ctx, session.ID); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// reused 处理已作废的刷新令牌被再次使用：作废整个令牌族并删除登录会话
+func (s *TokenService) reused(ctx context.Context, token *model.RefreshToken, now time.Time) error {
+	err := s.store.Transaction(ctx, func(tx repository.Store) error {
+		if _, err := tx.RefreshTokens().RevokeFamily(ctx, token.UserID, token.FamilyID, now); err != nil {
+			return err
+		}
+		session, err := tx.Sessions().FindByJTI(ctx, token.FamilyID)
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return tx.Sessions().Delete(ctx, session.ID)
+	})
+	if err != nil {
+		return err
+	}
+	logger.FromContext(ctx).Warnw("刷新令牌被重复使用，已吊销整个登录会话", "userID", token.UserID, "jti", token.FamilyID)
+	return ErrRefreshTokenReused
+}
+
+// deleteExpired 删除用户已过期的登录会话和刷新令牌
+func (s *TokenService) deleteExpired(ctx context.Context, userID uint, now time.Time) error {
+	if err := s.store.Sessions().DeleteExpired(ctx, userID, now); err != nil {
+		return err
+	}
+	return s.store.RefreshTokens().DeleteExpired(ctx, userID, now)
+}
+
+// hashToken 计算刷新令牌的 SHA-256 哈希；令牌本身是高熵随机串，不需要加盐
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// truncateRunes 按字符截断字符串
+func truncateRunes(s string, n int) string {
+	if runes := []rune(s); len(runes) > n {
+		return string(runes[:n])
+	}
+	return s
+}
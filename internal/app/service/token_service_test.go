@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/model"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/repository"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/logger"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenService(t *testing.T) {
+	logger.InitLogger()
+	ctx := context.Background()
+	store := repository.NewMemoryStore()
+	user := model.User{Username: "2024000001", Password: "x"}
+	require.NoError(t, store.Users().Create(ctx, &user))
+	jwt := util.NewJWT("my_test_secret_key", 15*time.Minute)
+	svc := NewTokenService(store, jwt, time.Hour)
+	client := ClientInfo{UserAgent: "Mozilla/5.0", IP: "10.0.0.1"}
+
+	active := func(pair *TokenPair) bool {
+		claims, err := jwt.ParseToken(pair.AccessToken)
+		require.NoError(t, err)
+		ok, err := svc.SessionActive(ctx, claims.UserID, claims.SessionID())
+		require.NoError(t, err)
+		return ok
+	}
+
+	t.Run("登录与换发", func(t *testing.T) {
+		pair, err := svc.Issue(ctx, user.ID, client)
+		require.NoError(t, err)
+		assert.Equal(t, 15*time.Minute, pair.ExpiresIn)
+		assert.True(t, active(pair))
+
+		saved, err := store.RefreshTokens().FindByHash(ctx, hashToken(pair.RefreshToken))
+		require.NoError(t, err)
+		assert.NotEqual(t, pair.RefreshToken, saved.TokenHash, "只保存刷新令牌的哈希")
+		assert.Equal(t, "Mozilla/5.0", saved.UserAgent)
+
+		next, err := svc.Refresh(ctx, pair.RefreshToken, client)
+		require.NoError(t, err)
+		assert.Equal(t, pair.SessionID, next.SessionID, "换发后仍属于同一个登录会话")
+		assert.NotEqual(t, pair.RefreshToken, next.RefreshToken)
+		assert.True(t, active(next))
+
+		_, err = svc.Refresh(ctx, "not-a-token", client)
+		assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+	})
+
+	t.Run("重复使用旧令牌吊销整个会话", func(t *testing.T) {
+		pair, err := svc.Issue(ctx, user.ID, client)
+		require.NoError(t, err)
+		next, err := svc.Refresh(ctx, pair.RefreshToken, client)
+		require.NoError(t, err)
+
+		_, err = svc.Refresh(ctx, pair.RefreshToken, client)
+		assert.ErrorIs(t, err, ErrRefreshTokenReused)
+		assert.False(t, active(next), "会话被吊销后访问令牌失效")
+		_, err = svc.Refresh(ctx, next.RefreshToken, client)
+		assert.ErrorIs(t, err, ErrInvalidRefreshToken, "同一会话中最新的刷新令牌也已失效")
+	})
+
+	t.Run("退出登录", func(t *testing.T) {
+		phone, err := svc.Issue(ctx, user.ID, client)
+		require.NoError(t, err)
+		laptop, err := svc.Issue(ctx, user.ID, client)
+		require.NoError(t, err)
+
+		require.NoError(t, svc.Logout(ctx, user.ID, phone.SessionID))
+		assert.False(t, active(phone))
+		assert.True(t, active(laptop), "只退出当前会话")
+		_, err = svc.Refresh(ctx, phone.RefreshToken, client)
+		assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+
+		// 不能退出他人的会话
+		require.NoError(t, svc.Logout(ctx, user.ID+1, laptop.SessionID))
+		assert.True(t, active(laptop))
+
+		n, err := svc.LogoutAll(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Positive(t, n)
+		assert.False(t, active(laptop))
+		sessions, err := svc.Sessions(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Empty(t, sessions)
+	})
+
+	t.Run("登录会话列表与移除", func(t *testing.T) {
+		phone, err := svc.Issue(ctx, user.ID, ClientInfo{UserAgent: "iPhone", IP: "10.0.0.2"})
+		require.NoError(t, err)
+		laptop, err := svc.Issue(ctx, user.ID, ClientInfo{UserAgent: "Windows", IP: "10.0.0.3"})
+		require.NoError(t, err)
+
+		sessions, err := svc.Sessions(ctx, user.ID)
+		require.NoError(t, err)
+		require.Len(t, sessions, 2)
+		byJTI := map[string]model.Session{}
+		for _, ss := range sessions {
+			byJTI[ss.JTI] = ss
+		}
+		assert.Equal(t, "iPhone", byJTI[phone.SessionID].UserAgent)
+		assert.Equal(t, "10.0.0.3", byJTI[laptop.SessionID].IP)
+
+		// 刷新令牌后记录最新的地址并延长有效期
+		_, err = svc.Refresh(ctx, phone.RefreshToken, ClientInfo{UserAgent: "iPhone", IP: "10.0.0.9"})
+		require.NoError(t, err)
+		renewed, err := store.Sessions().FindByJTI(ctx, phone.SessionID)
+		require.NoError(t, err)
+		assert.Equal(t, "10.0.0.9", renewed.IP)
+		assert.False(t, renewed.ExpiresAt.Before(byJTI[phone.SessionID].ExpiresAt))
+
+		assert.ErrorIs(t, svc.RevokeSession(ctx, user.ID+1, renewed.ID), ErrSessionNotFound, "不能移除他人的会话")
+		require.NoError(t, svc.RevokeSession(ctx, user.ID, renewed.ID))
+		assert.False(t, active(phone))
+		assert.True(t, active(laptop))
+		assert.ErrorIs(t, svc.RevokeSession(ctx, user.ID, renewed.ID), ErrSessionNotFound)
+	})
+
+	t.Run("最近访问时间按分钟更新", func(t *testing.T) {
+		pair, err := svc.Issue(ctx, user.ID, client)
+		require.NoError(t, err)
+		before, err := store.Sessions().FindByJTI(ctx, pair.SessionID)
+		require.NoError(t, err)
+
+		assert.True(t, active(pair))
+		same, _ := store.Sessions().FindByJTI(ctx, pair.SessionID)
+		assert.Equal(t, before.LastSeenAt, same.LastSeenAt, "一分钟内的访问不更新")
+
+		later := time.Now().Add(5 * time.Minute)
+		svc.now = func() time.Time { return later }
+		defer func() { svc.now = time.Now }()
+		assert.True(t, active(pair))
+		touched, _ := store.Sessions().FindByJTI(ctx, pair.SessionID)
+		assert.Equal(t, later, touched.LastSeenAt)
+	})
+
+	t.Run("过期与无会话的令牌", func(t *testing.T) {
+		pair, err := svc.Issue(ctx, user.ID, client)
+		require.NoError(t, err)
+		svc.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+		defer func() { svc.now = time.Now }()
+		assert.False(t, active(pair))
+		_, err = svc.Refresh(ctx, pair.RefreshToken, client)
+		assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+
+		// 再次登录时清理已过期的令牌
+		_, err = svc.Issue(ctx, user.ID, client)
+		require.NoError(t, err)
+		_, err = store.RefreshTokens().FindByHash(ctx, hashToken(pair.RefreshToken))
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+
+		ok, err := svc.SessionActive(ctx, user.ID, "")
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+}
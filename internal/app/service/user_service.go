@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/model"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/repository"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/logger"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/metrics"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// 用户名为十位学号
+var studentIDPattern = regexp.MustCompile(`^[0-9]{10}$`)
+
+// dummyPasswordHash 是与真实密码相同 cost 的 bcrypt 哈希。登录的学号不存在时同样比对一次，
+// 让响应时间与密码错误时一致，避免通过耗时判断学号是否已注册
+var dummyPasswordHash = sync.OnceValue(func() []byte {
+	hashed, _ := bcrypt.GenerateFromPassword([]byte("dummy-password-for-timing"), bcrypt.DefaultCost)
+	return hashed
+})
+
+// UserService 负责注册、登录与个人资料
+type UserService struct {
+	store repository.Store
+}
+
+// NewUserService 创建 UserService
+func NewUserService(store repository.Store) *UserService {
+	return &UserService{store: store}
+}
+
+// CheckUsername 检查用户名能否注册：必须是十位学号且未被注册
+func (s *UserService) CheckUsername(ctx context.Context, username string) error {
+	if !studentIDPattern.MatchString(username) {
+		return ErrInvalidUsername
+	}
+	_, err := s.store.Users().FindByUsername(ctx, username)
+	switch {
+	case err == nil:
+		return ErrUsernameTaken
+	case errors.Is(err, repository.ErrNotFound):
+		return nil
+	}
+	return err
+}
+
+// Register 创建普通用户；nickname 应当已经过审核
+func (s *UserService) Register(ctx context.Context, username, password, nickname string) (*model.User, error) {
+	user, err := s.CreateUser(ctx, username, password, nickname, model.RoleUser)
+	if err == nil {
+		metrics.UserRegistered()
+	}
+	return user, err
+}
+
+// CreateUser 创建指定角色的用户，用户名规则与注册相同；命令行创建管理员时使用
+func (s *UserService) CreateUser(ctx context.Context, username, password, nickname, role string) (*model.User, error) {
+	if !model.ValidRole(role) {
+		return nil, ErrInvalidRole
+	}
+	if err := s.CheckUsername(ctx, username); err != nil {
+		return nil, err
+	}
+	if err := ValidatePassword(username, password); err != nil {
+		return nil, err
+	}
+	hashed, err := hashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+	user := &model.User{
+		Username: username,
+		Password: hashed,
+		Nickname: nickname,
+		Role:     role,
+	}
+	if err := s.store.Users().Create(ctx, user); err != nil {
+		// 并发注册同一学号时，唯一索引兜底
+		if errors.Is(err, repository.ErrDuplicate) {
+			return nil, ErrUsernameTaken
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+// Authenticate 校验用户名和密码；用户不存在与密码错误都返回 ErrInvalidCredentials
+func (s *UserService) Authenticate(ctx context.Context, username, password string) (*model.User, error) {
+	user, err := s.store.Users().FindByUsername(ctx, username)
+	if errors.Is(err, repository.ErrNotFound) {
+		_ = bcrypt.CompareHashAndPassword(dummyPasswordHash(), []byte(password))
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+	return user, nil
+}
+
+// SetRole 修改用户的角色
+func (s *UserService) SetRole(ctx context.Context, username, role string) (*model.User, error) {
+	if !model.ValidRole(role) {
+		return nil, ErrInvalidRole
+	}
+	user, err := s.store.Users().FindByUsername(ctx, username)
+	if err != nil {
+		return nil, notFoundAs(err, ErrUserNotFound)
+	}
+	user.Role = role
+	if err := s.store.Users().Save(ctx, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// ResetPassword 重置用户的密码 (不校验旧密码)，用户的全部登录会话和未使用的重置码随之作废
+func (s *UserService) ResetPassword(ctx context.Context, username, password string) (*model.User, error) {
+	user, err := s.store.Users().FindByUsername(ctx, username)
+	if err != nil {
+		return nil, notFoundAs(err, ErrUserNotFound)
+	}
+	if err := ValidatePassword(username, password); err != nil {
+		return nil, err
+	}
+	if user.Password, err = hashPassword(password); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	err = s.store.Transaction(ctx, func(tx repository.Store) error {
+		if err := tx.Users().Save(ctx, user); err != nil {
+			return err
+		}
+		if _, err := tx.PasswordResets().InvalidateByUser(ctx, user.ID, now); err != nil {
+			return err
+		}
+		_, err := revokeSessions(ctx, tx, user.ID, "", now)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func hashPassword(password string) (string, error) {
+	if password == "" {
+		return "", ErrEmptyPassword
+	}
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hashed), nil
+}
+
+// Get 查询用户
+func (s *UserService) Get(ctx context.Context, userID uint) (*model.User, error) {
+	user, err := s.store.Users().FindByID(ctx, userID)
+	if err != nil {
+		return nil, notFoundAs(err, ErrUserNotFound)
+	}
+	return user, nil
+}
+
+// ProfileUpdate 个人资料的修改，nil 字段不修改；Bio 为空字符串表示清空简介。
+// 昵称、简介应当已经过审核
+type ProfileUpdate struct {
+	Nickname *string
+	Bio      *string
+	AvatarID *uint
+}
+
+// UpdateProfile 保存个人资料，并把昵称、头像同步到该用户已发布愿望的冗余字段
+func (s *UserService) UpdateProfile(ctx context.Context, user *model.User, update ProfileUpdate) error {
+	if update.Nickname != nil {
+		user.Nickname = *update.Nickname
+	}
+	if update.Bio != nil {
+		if *update.Bio == "" {
+			user.Bio = nil
+		} else {
+			bio := *update.Bio
+			user.Bio = &bio
+		}
+	}
+	if update.AvatarID != nil {
+		user.AvatarID = update.AvatarID
+	}
+	if err := s.store.Users().Save(ctx, user); err != nil {
+		return err
+	}
+	if update.Nickname == nil && update.AvatarID == nil {
+		return nil
+	}
+	// 冗余字段不一致只影响展示，不让整个请求失败
+	if err := s.store.Wishes().SyncAuthor(ctx, user); err != nil {
+		logger.FromContext(ctx).Errorw("同步更新愿望冗余用户信息失败", "userID", user.ID, "error", err)
+	}
+	return nil
+}
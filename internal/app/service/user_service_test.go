@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/model"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestUserService(t *testing.T) {
+	ctx := context.Background()
+	store := repository.NewMemoryStore()
+	svc := NewUserService(store)
+
+	_, err := svc.Register(ctx, "abc", "snow-wish-42", "昵称")
+	assert.ErrorIs(t, err, ErrInvalidUsername)
+
+	user, err := svc.Register(ctx, "2024000001", "snow-wish-42", "昵称")
+	require.NoError(t, err)
+	assert.Equal(t, "user", user.Role)
+	assert.NotEqual(t, "snow-wish-42", user.Password, "密码应当哈希后保存")
+
+	_, err = svc.Register(ctx, "2024000001", "snow-wish-42", "昵称")
+	assert.ErrorIs(t, err, ErrUsernameTaken)
+
+	got, err := svc.Authenticate(ctx, "2024000001", "snow-wish-42")
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, got.ID)
+	_, err = svc.Authenticate(ctx, "2024000001", "wrong")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	_, err = svc.Authenticate(ctx, "2024000009", "snow-wish-42")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	// 学号不存在时比对的假哈希与真实密码的 cost 相同，耗时一致
+	realCost, err := bcrypt.Cost([]byte(user.Password))
+	require.NoError(t, err)
+	dummyCost, err := bcrypt.Cost(dummyPasswordHash())
+	require.NoError(t, err)
+	assert.Equal(t, realCost, dummyCost)
+
+	// 修改昵称、头像后同步到已发布的愿望；空简介表示清空
+	wish := seedWish(t, store, user.ID, true, "approved")
+	nickname, bio, avatar := "新昵称", "", uint(3)
+	require.NoError(t, svc.UpdateProfile(ctx, got, ProfileUpdate{Nickname: &nickname, Bio: &bio, AvatarID: &avatar}))
+	saved, err := svc.Get(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "新昵称", saved.Nickname)
+	assert.Nil(t, saved.Bio)
+	reloaded, _ := store.Wishes().FindByID(ctx, wish.ID)
+	assert.Equal(t, "新昵称", reloaded.UserNickname)
+	assert.Equal(t, &avatar, reloaded.UserAvatarID)
+
+	_, err = svc.Get(ctx, 9999)
+	assert.ErrorIs(t, err, ErrUserNotFound)
+}
+
+func TestUserAdministration(t *testing.T) {
+	ctx := context.Background()
+	svc := NewUserService(repository.NewMemoryStore())
+
+	_, err := svc.CreateUser(ctx, "2024000001", "snow-wish-42", "管理员", "root")
+	assert.ErrorIs(t, err, ErrInvalidRole)
+	_, err = svc.CreateUser(ctx, "2024000001", "", "管理员", model.RoleAdmin)
+	assert.ErrorIs(t, err, ErrEmptyPassword)
+
+	admin, err := svc.CreateUser(ctx, "2024000001", "snow-wish-42", "管理员", model.RoleAdmin)
+	require.NoError(t, err)
+	assert.Equal(t, model.RoleAdmin, admin.Role)
+	_, err = svc.CreateUser(ctx, "2024000001", "snow-wish-42", "管理员", model.RoleAdmin)
+	assert.ErrorIs(t, err, ErrUsernameTaken)
+
+	// 修改角色
+	_, err = svc.Register(ctx, "2024000002", "snow-wish-42", "同学")
+	require.NoError(t, err)
+	promoted, err := svc.SetRole(ctx, "2024000002", model.RoleAdmin)
+	require.NoError(t, err)
+	assert.Equal(t, model.RoleAdmin, promoted.Role)
+	_, err = svc.SetRole(ctx, "2024000002", "root")
+	assert.ErrorIs(t, err, ErrInvalidRole)
+	_, err = svc.SetRole(ctx, "2024000009", model.RoleUser)
+	assert.ErrorIs(t, err, ErrUserNotFound)
+
+	// 重置密码后旧密码失效
+	_, err = svc.ResetPassword(ctx, "2024000002", "new-password")
+	require.NoError(t, err)
+	_, err = svc.Authenticate(ctx, "2024000002", "snow-wish-42")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	_, err = svc.Authenticate(ctx, "2024000002", "new-password")
+	assert.NoError(t, err)
+	_, err = svc.ResetPassword(ctx, "2024000009", "x")
+	assert.ErrorIs(t, err, ErrUserNotFound)
+}
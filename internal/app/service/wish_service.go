@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/model"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/repository"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/logger"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/metrics"
+)
+
+// WishService 负责愿望的发布、删除与查询规则
+type WishService struct {
+	store repository.Store
+}
+
+// NewWishService 创建 WishService
+func NewWishService(store repository.Store) *WishService {
+	return &WishService{store: store}
+}
+
+// CreateWishInput 发布愿望的参数；内容与标签应当已经过审核，
+// Status 为内容的审核状态（pending 表示等待异步审核）
+type CreateWishInput struct {
+	UserID     uint
+	Content    string
+	Background string
+	IsPublic   bool
+	Tags       []string
+	Status     string
+}
+
+// WishList 是一页愿望，Liked 记录当前用户点过赞的愿望
+type WishList struct {
+	Wishes []model.Wish
+	Total  int64
+	Liked  map[uint]bool
+}
+
+// WishVisibleTo 判断愿望对当前用户是否可见：审核通过的对所有人可见，其余状态只有作者可见
+func WishVisibleTo(wish *model.Wish, userID uint) bool {
+	return wish.ModerationStatus == model.ModerationApproved || wish.UserID == userID
+}
+
+// Create 发布愿望，同时写入作者昵称/头像的冗余字段
+func (s *WishService) Create(ctx context.Context, in CreateWishInput) (*model.Wish, error) {
+	author, err := s.store.Users().FindByID(ctx, in.UserID)
+	if err != nil {
+		return nil, notFoundAs(err, ErrUserNotFound)
+	}
+	now := time.Now()
+	wish := &model.Wish{
+		UserID:       in.UserID,
+		UserNickname: author.Nickname,
+		UserAvatarID: author.AvatarID,
+		Content:      in.Content,
+		Background:   in.Background,
+		IsPublic:     in.IsPublic,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+
+		ModerationStatus: in.Status,
+	}
+	if err := s.store.Wishes().Create(ctx, wish, in.Tags); err != nil {
+		return nil, err
+	}
+	metrics.WishCreated()
+	return wish, nil
+}
+
+// Delete 删除愿望及其评论、点赞、标签；只有作者或管理员可以删除
+func (s *WishService) Delete(ctx context.Context, userID, wishID uint) error {
+	user, err := s.store.Users().FindByID(ctx, userID)
+	if err != nil {
+		return notFoundAs(err, ErrUserNotFound)
+	}
+	return s.store.Transaction(ctx, func(tx repository.Store) error {
+		wish, err := tx.Wishes().FindByID(ctx, wishID)
+		if err != nil {
+			return notFoundAs(err, ErrWishNotFound)
+		}
+		if wish.UserID != userID && !isAdmin(user) {
+			return ErrForbidden
+		}
+		if err := tx.Comments().DeleteByWish(ctx, wishID); err != nil {
+			return err
+		}
+		if err := tx.Likes().DeleteByWish(ctx, wishID); err != nil {
+			return err
+		}
+		return tx.Wishes().Delete(ctx, wishID)
+	})
+}
+
+// Get 查询 viewerID 可见的愿望（未登录时 viewerID 为 0）
+func (s *WishService) Get(ctx context.Context, wishID, viewerID uint) (*model.Wish, error) {
+	wish, err := s.store.Wishes().FindByID(ctx, wishID)
+	if err != nil {
+		return nil, notFoundAs(err, ErrWishNotFound)
+	}
+	if !WishVisibleTo(wish, viewerID) {
+		return nil, ErrWishNotFound
+	}
+	return wish, nil
+}
+
+// ListPublic 公共愿望墙；viewerID 非 0 时同时查询其点赞状态
+func (s *WishService) ListPublic(ctx context.Context, viewerID uint, tag string, page repository.Page) (WishList, error) {
+	wishes, total, err := s.store.Wishes().ListPublic(ctx, tag, page)
+	if err != nil {
+		return WishList{}, err
+	}
+	return WishList{Wishes: wishes, Total: total, Liked: s.likedBy(ctx, viewerID, wishes)}, nil
+}
+
+// ListMine 用户自己的全部愿望（含各种审核状态）及其点赞状态
+func (s *WishService) ListMine(ctx context.Context, userID uint, page repository.Page) (WishList, error) {
+	wishes, total, err := s.store.Wishes().ListByUser(ctx, userID, page)
+	if err != nil {
+		return WishList{}, err
+	}
+	return WishList{Wishes: wishes, Total: total, Liked: s.likedBy(ctx, userID, wishes)}, nil
+}
+
+// likedBy 查询 userID 对这些愿望的点赞状态；查询失败不影响列表，只记录日志
+func (s *WishService) likedBy(ctx context.Context, userID uint, wishes []model.Wish) map[uint]bool {
+	if userID == 0 || len(wishes) == 0 {
+		return map[uint]bool{}
+	}
+	liked, err := s.store.Likes().LikedWishIDs(ctx, userID, wishIDs(wishes))
+	if err != nil {
+		logger.FromContext(ctx).Errorw("查询点赞状态出错", "userID", userID, "error", err)
+		return map[uint]bool{}
+	}
+	return liked
+}
+
+func wishIDs(wishes []model.Wish) []uint {
+	ids := make([]uint, 0, len(wishes))
+	for _, w := range wishes {
+		ids = append(ids, w.ID)
+	}
+	return ids
+}
+
+func isAdmin(user *model.User) bool {
+	return user.Role == "admin"
+}
+
+// notFoundAs 把仓储的 ErrNotFound 转换为具体的业务错误，其他错误原样返回
+func notFoundAs(err, target error) error {
+	if errors.Is(err, repository.ErrNotFound) {
+		return target
+	}
+	return err
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/model"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWishService(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("发布时写入作者冗余信息", func(t *testing.T) {
+		store := repository.NewMemoryStore()
+		author, _, _ := seedUsers(t, store)
+		wish, err := NewWishService(store).Create(ctx, CreateWishInput{UserID: author.ID, Content: "考研上岸", IsPublic: true, Tags: []string{"学习"}, Status: model.ModerationApproved})
+		require.NoError(t, err)
+		assert.Equal(t, "作者", wish.UserNickname)
+
+		_, err = NewWishService(store).Create(ctx, CreateWishInput{UserID: 9999, Content: "x"})
+		assert.ErrorIs(t, err, ErrUserNotFound)
+	})
+
+	t.Run("只有作者或管理员可以删除", func(t *testing.T) {
+		store := repository.NewMemoryStore()
+		author, other, admin := seedUsers(t, store)
+		svc := NewWishService(store)
+
+		wish := seedWish(t, store, author.ID, true, model.ModerationApproved)
+		assert.ErrorIs(t, svc.Delete(ctx, other.ID, wish.ID), ErrForbidden)
+		assert.NoError(t, svc.Delete(ctx, author.ID, wish.ID))
+		assert.ErrorIs(t, svc.Delete(ctx, author.ID, wish.ID), ErrWishNotFound)
+
+		wish = seedWish(t, store, author.ID, true, model.ModerationApproved)
+		assert.NoError(t, svc.Delete(ctx, admin.ID, wish.ID))
+		assert.ErrorIs(t, svc.Delete(ctx, 9999, wish.ID), ErrUserNotFound)
+	})
+
+	t.Run("未通过审核的愿望只有作者可见", func(t *testing.T) {
+		store := repository.NewMemoryStore()
+		author, other, _ := seedUsers(t, store)
+		wish := seedWish(t, store, author.ID, true, model.ModerationPending)
+		svc := NewWishService(store)
+
+		_, err := svc.Get(ctx, wish.ID, other.ID)
+		assert.ErrorIs(t, err, ErrWishNotFound)
+		_, err = svc.Get(ctx, wish.ID, 0)
+		assert.ErrorIs(t, err, ErrWishNotFound)
+		got, err := svc.Get(ctx, wish.ID, author.ID)
+		require.NoError(t, err)
+		assert.Equal(t, wish.ID, got.ID)
+	})
+
+	t.Run("点赞切换", func(t *testing.T) {
+		store := repository.NewMemoryStore()
+		author, other, _ := seedUsers(t, store)
+		wish := seedWish(t, store, author.ID, true, model.ModerationApproved)
+		likes := NewLikeService(store)
+
+		res, err := likes.Toggle(ctx, other.ID, wish.ID)
+		require.NoError(t, err)
+		assert.Equal(t, LikeResult{Liked: true, LikeCount: 1}, res)
+
+		list, err := NewWishService(store).ListPublic(ctx, other.ID, "", repository.Page{Limit: 10})
+		require.NoError(t, err)
+		assert.True(t, list.Liked[wish.ID])
+
+		res, err = likes.Toggle(ctx, other.ID, wish.ID)
+		require.NoError(t, err)
+		assert.Equal(t, LikeResult{Liked: false, LikeCount: 0}, res)
+
+		pending := seedWish(t, store, author.ID, true, model.ModerationPending)
+		_, err = likes.Toggle(ctx, other.ID, pending.ID)
+		assert.ErrorIs(t, err, ErrWishNotFound)
+	})
+}
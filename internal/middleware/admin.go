@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/model"
+	apperr "github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/err"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/logger"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// AdminMiddleware 只允许管理员访问，必须挂在 JWTAuthMiddleware 之后
+// 角色每次从数据库读取，撤销管理员权限后立即生效
+func AdminMiddleware(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := c.Get("userID")
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"code":      apperr.ERROR_UNAUTHORIZED,
+				"requestId": c.GetString("requestID"),
+				"message":   apperr.GetMsg(apperr.ERROR_UNAUTHORIZED),
+				"data":      gin.H{},
+			})
+			c.Abort()
+			return
+		}
+
+		var user model.User
+		if err := db.Select("id", "role").First(&user, userID).Error; err != nil {
+			logger.FromContext(c.Request.Context()).Warnw("管理员鉴权：查询用户失败", "userID", userID, "error", err)
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"code":      apperr.ERROR_UNAUTHORIZED,
+				"requestId": c.GetString("requestID"),
+				"message":   apperr.GetMsg(apperr.ERROR_UNAUTHORIZED),
+				"data":      gin.H{},
+			})
+			c.Abort()
+			return
+		}
+		if user.Role != "admin" {
+			logger.FromContext(c.Request.Context()).Warnw("管理员鉴权：非管理员访问管理接口", "userID", userID, "path", c.Request.URL.Path)
+			c.JSON(http.StatusForbidden, gin.H{
+				"code":      apperr.ERROR_FORBIDDEN_ADMIN,
+				"requestId": c.GetString("requestID"),
+				"message":   apperr.GetMsg(apperr.ERROR_FORBIDDEN_ADMIN),
+				"data":      gin.H{},
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
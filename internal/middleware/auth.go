@@ -1,9 +1,8 @@
 package middleware
 
 import (
-	"fmt"
+	"context"
 	"net/http"
-	"os"
 	"strings"
 	"time"
 
@@ -14,30 +13,9 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func CORSMiddleware() gin.HandlerFunc {
-	// 支持多域名白名单，环境变量 CORS_ALLOWED_ORIGINS 以逗号分隔
-	// 例如："https://snowkeptwishes.ncuhos.com,http://localhost:5173"
-	// 若为空，使用安全默认：允许上述两个常用来源
-	allowedEnv := os.Getenv("CORS_ALLOWED_ORIGINS")
-	var allowList []string
-	if allowedEnv == "" {
-		allowList = []string{
-			"https://snowkeptwishes.ncuhos.com",
-			"http://localhost:5173",
-		}
-	} else {
-		parts := strings.Split(allowedEnv, ",")
-		for _, p := range parts {
-			o := strings.TrimSpace(p)
-			if o != "" {
-				allowList = append(allowList, o)
-			}
-		}
-		if len(allowList) == 0 {
-			allowList = []string{"https://snowkeptwishes.ncuhos.com", "http://localhost:5173"}
-		}
-	}
-
+// CORSMiddleware 按白名单回显跨域请求的 Origin，白名单来自配置项 cors.allowedOrigins
+// (环境变量 CORS_ALLOWED_ORIGINS 以逗号分隔，例如："https://snowkeptwishes.ncuhos.com,http://localhost:5173")
+func CORSMiddleware(allowList []string) gin.HandlerFunc {
 	// 小工具：判断请求 Origin 是否在白名单
 	isAllowed := func(origin string) bool {
 		if origin == "" {
@@ -59,10 +37,10 @@ func CORSMiddleware() gin.HandlerFunc {
 			c.Writer.Header().Set("Vary", "Origin")
 		}
 
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, AccessToken, X-CSRF-Token, Authorization, Token, x-token")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, AccessToken, X-CSRF-Token, Authorization, Token, x-token, X-Request-ID")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE, UPDATE")
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Expose-Headers", "Content-Length, Access-Control-Allow-Origin, Access-Control-Allow-Headers, Content-Type")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "Content-Length, Access-Control-Allow-Origin, Access-Control-Allow-Headers, Content-Type, X-Request-ID")
 
 		if c.Request.Method == http.MethodOptions {
 			// 预检请求：若来源不在白名单，拒绝；否则放行 204
@@ -78,32 +56,54 @@ func CORSMiddleware() gin.HandlerFunc {
 	}
 }
 
+// LoggerMiddleware 请求结束后用请求级的 zap 日志记录器输出一条结构化访问日志，
+// 与处理过程中的日志带有相同的请求 ID；4xx 记为 warn，5xx 记为 error。必须注册在 RequestIDMiddleware 之后
 func LoggerMiddleware() gin.HandlerFunc {
-	return gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
-		return fmt.Sprintf("%s - [%s] \"%s %s %s %d %s \"%s\" %s\"\n",
-			param.ClientIP,
-			param.TimeStamp.Format(time.RFC1123),
-			param.Method,
-			param.Path,
-			param.Request.Proto,
-			param.StatusCode,
-			param.Latency,
-			param.Request.UserAgent(),
-			param.ErrorMessage,
-		)
-	})
+	// 健康检查和指标抓取每隔几秒就会请求一次，不记录访问日志；就绪检查失败时由处理器记录
+	skip := map[string]bool{"/healthz": true, "/readyz": true, "/metrics": true}
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		if skip[c.Request.URL.Path] {
+			return
+		}
+
+		status := c.Writer.Status()
+		fields := []interface{}{
+			"method", c.Request.Method,
+			"path", c.Request.URL.Path,
+			"status", status,
+			"latency", time.Since(start),
+			"bytes", c.Writer.Size(),
+			"userAgent", c.Request.UserAgent(),
+		}
+		if errs := c.Errors.String(); errs != "" {
+			fields = append(fields, "errors", errs)
+		}
+		// 鉴权中间件会给日志记录器追加 userID，因此在请求处理完后再取
+		l := logger.FromContext(c.Request.Context())
+		switch {
+		case status >= http.StatusInternalServerError:
+			l.Errorw("请求完成", fields...)
+		case status >= http.StatusBadRequest:
+			l.Warnw("请求完成", fields...)
+		default:
+			l.Infow("请求完成", fields...)
+		}
+	}
 }
 
 func RecoveryMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		defer func() { // 捕获 Panic
 			if r := recover(); r != nil {
-				logger.Log.Errorw("服务器崩溃 (Panic)", "error", r)
+				logger.FromContext(c.Request.Context()).Errorw("服务器崩溃 (Panic)", "error", r)
 
 				c.JSON(http.StatusInternalServerError, gin.H{
-					"code":    apperr.ERROR_SERVER_ERROR, // code: 10
-					"message": apperr.GetMsg(apperr.ERROR_SERVER_ERROR),
-					"data":    gin.H{},
+					"code":      apperr.ERROR_SERVER_ERROR, // code: 10
+					"requestId": c.GetString("requestID"),
+					"message":   apperr.GetMsg(apperr.ERROR_SERVER_ERROR),
+					"data":      gin.H{},
 				})
 			}
 		}()
@@ -111,16 +111,23 @@ func RecoveryMiddleware() gin.HandlerFunc {
 	}
 }
 
-func JWTAuthMiddleware() gin.HandlerFunc {
+// SessionChecker 判断访问令牌所属的登录会话是否仍然有效 (未退出登录、未被吊销)，由 service.TokenService 实现
+type SessionChecker interface {
+	SessionActive(ctx context.Context, userID uint, sessionID string) (bool, error)
+}
+
+// JWTAuthMiddleware 校验访问令牌及其所属的登录会话，注入 "userID" 与 "sessionID"
+func JWTAuthMiddleware(tokens *util.JWT, sessions SessionChecker) gin.HandlerFunc {
 
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 
 		if authHeader == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{
-				"code":    apperr.ERROR_UNAUTHORIZED, // code: 3
-				"message": apperr.GetMsg(apperr.ERROR_UNAUTHORIZED),
-				"data":    gin.H{"error": "未提供 Authorization Header"},
+				"code":      apperr.ERROR_UNAUTHORIZED, // code: 3
+				"requestId": c.GetString("requestID"),
+				"message":   apperr.GetMsg(apperr.ERROR_UNAUTHORIZED),
+				"data":      gin.H{"error": "未提供 Authorization Header"},
 			})
 			c.Abort()
 			return
@@ -129,22 +136,48 @@ func JWTAuthMiddleware() gin.HandlerFunc {
 		parts := strings.SplitN(authHeader, " ", 2)
 		if !(len(parts) == 2 && parts[0] == "Bearer") {
 			c.JSON(http.StatusUnauthorized, gin.H{
-				"code":    apperr.ERROR_UNAUTHORIZED,
-				"message": apperr.GetMsg(apperr.ERROR_UNAUTHORIZED),
-				"data":    gin.H{"error": "缺少 'Bearer ' 前缀"},
+				"code":      apperr.ERROR_UNAUTHORIZED,
+				"requestId": c.GetString("requestID"),
+				"message":   apperr.GetMsg(apperr.ERROR_UNAUTHORIZED),
+				"data":      gin.H{"error": "缺少 'Bearer ' 前缀"},
 			})
 			c.Abort()
 			return
 		}
 
 		tokenString := parts[1]
-		claims, parseErr := util.ParseToken(tokenString)
+		claims, parseErr := tokens.ParseToken(tokenString)
 
 		if parseErr != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{
-				"code":    apperr.ERROR_UNAUTHORIZED,
-				"message": apperr.GetMsg(apperr.ERROR_UNAUTHORIZED),
-				"data":    gin.H{"error": parseErr.Error()}, // 包含具体错误
+				"code":      apperr.ERROR_UNAUTHORIZED,
+				"requestId": c.GetString("requestID"),
+				"message":   apperr.GetMsg(apperr.ERROR_UNAUTHORIZED),
+				"data":      gin.H{"error": parseErr.Error()}, // 包含具体错误
+			})
+			c.Abort()
+			return
+		}
+
+		// 签名有效还不够：退出登录或会话被吊销后，未过期的访问令牌也不能再使用
+		active, err := sessions.SessionActive(c.Request.Context(), claims.UserID, claims.SessionID())
+		if err != nil {
+			logger.FromContext(c.Request.Context()).Errorw("查询登录会话失败", "userID", claims.UserID, "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code":      apperr.ERROR_SERVER_ERROR,
+				"requestId": c.GetString("requestID"),
+				"message":   apperr.GetMsg(apperr.ERROR_SERVER_ERROR),
+				"data":      gin.H{},
+			})
+			c.Abort()
+			return
+		}
+		if !active {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"code":      apperr.ERROR_UNAUTHORIZED,
+				"requestId": c.GetString("requestID"),
+				"message":   apperr.GetMsg(apperr.ERROR_UNAUTHORIZED),
+				"data":      gin.H{"error": "登录会话已失效"},
 			})
 			c.Abort()
 			return
@@ -152,13 +185,15 @@ func JWTAuthMiddleware() gin.HandlerFunc {
 
 		// 验证成功
 		c.Set("userID", claims.UserID)
+		c.Set("sessionID", claims.SessionID())
+		withLogFields(c, "userID", claims.UserID)
 		c.Next()
 	}
 }
 
 // 检查 Token，如果有效，则注入 "userID"
 // 如果无效或不存在，它*不会*报错，而是直接放行 (c.Next())
-func JWTOptionalAuthMiddleware() gin.HandlerFunc {
+func JWTOptionalAuthMiddleware(tokens *util.JWT, sessions SessionChecker) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 
@@ -176,16 +211,23 @@ func JWTOptionalAuthMiddleware() gin.HandlerFunc {
 		}
 
 		tokenString := parts[1]
-		claims, parseErr := util.ParseToken(tokenString)
+		claims, parseErr := tokens.ParseToken(tokenString)
 
 		if parseErr != nil {
 			//  Token 过期或无效
 			c.Next()
 			return
 		}
+		if active, err := sessions.SessionActive(c.Request.Context(), claims.UserID, claims.SessionID()); err != nil || !active {
+			// 会话已退出登录，按未登录处理
+			c.Next()
+			return
+		}
 
 		// 验证成功
 		c.Set("userID", claims.UserID)
+		c.Set("sessionID", claims.SessionID())
+		withLogFields(c, "userID", claims.UserID)
 		c.Next()
 	}
 }
@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/config"
+	apperr "github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/err"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/logger"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+// MetricsMiddleware 记录每个请求的耗时，按路由模板 (而不是实际路径) 区分，避免愿望 ID 等参数产生大量时间序列
+func MetricsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		done := metrics.HTTPStarted()
+		c.Next()
+		done(c.Request.Method, c.FullPath(), c.Writer.Status())
+	}
+}
+
+// MetricsAuthMiddleware 限制 /metrics 的访问：来自 cfg.AllowedNetworks 网段的请求直接放行，
+// 其他来源需要带 Authorization: Bearer <METRICS_TOKEN>。
+// 来源地址取 TCP 连接的对端地址，不信任 X-Forwarded-For，因此 /metrics 不应经过反向代理转发
+func MetricsAuthMiddleware(cfg config.MetricsConfig) gin.HandlerFunc {
+	var networks []netip.Prefix
+	for _, n := range cfg.AllowedNetworks {
+		// 格式已由 config.Validate 校验
+		if prefix, err := netip.ParsePrefix(n); err == nil {
+			networks = append(networks, prefix)
+		}
+	}
+	allowed := func(remote string) bool {
+		addr, err := netip.ParseAddr(remote)
+		if err != nil {
+			return false
+		}
+		addr = addr.Unmap()
+		for _, n := range networks {
+			if n.Contains(addr) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return func(c *gin.Context) {
+		if allowed(c.RemoteIP()) {
+			c.Next()
+			return
+		}
+		token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if ok && cfg.Token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(cfg.Token)) == 1 {
+			c.Next()
+			return
+		}
+		logger.FromContext(c.Request.Context()).Warnw("拒绝抓取指标", "remote", c.RemoteIP())
+		c.JSON(http.StatusForbidden, gin.H{
+			"code":      apperr.ERROR_FORBIDDEN_ADMIN,
+			"requestId": c.GetString("requestID"),
+			"message":   apperr.GetMsg(apperr.ERROR_FORBIDDEN_ADMIN),
+			"data":      gin.H{},
+		})
+		c.Abort()
+	}
+}
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"crypto/rand"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader 是传递请求 ID 的请求头与响应头
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLen 是接受的请求 ID 的最大长度
+const maxRequestIDLen = 64
+
+// RequestIDMiddleware 为每个请求确定一个请求 ID：沿用上游 (如 nginx、前端) 传入的 X-Request-ID，
+// 没有或格式不合法时生成一个新的 (26 位随机字符)。请求 ID 写入响应头和 gin.Context 的 "requestID"，
+// 并把带请求 ID、路由和客户端 IP 的日志记录器放入请求的 context，供 logger.FromContext 使用。
+// 必须注册在其他中间件之前
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			id = rand.Text()
+		}
+		c.Set("requestID", id)
+		c.Header(RequestIDHeader, id)
+
+		l := logger.FromContext(c.Request.Context()).With("requestID", id, "route", c.FullPath(), "clientIP", c.ClientIP())
+		c.Request = c.Request.WithContext(logger.WithContext(c.Request.Context(), l))
+		c.Next()
+	}
+}
+
+// withLogFields 给请求级日志记录器追加字段 (如鉴权后的用户 ID)
+func withLogFields(c *gin.Context, args ...interface{}) {
+	l := logger.FromContext(c.Request.Context()).With(args...)
+	c.Request = c.Request.WithContext(logger.WithContext(c.Request.Context(), l))
+}
+
+// validRequestID 只接受长度有限的字母、数字和 -_.:，避免把任意内容写进日志和响应头
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.', r == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
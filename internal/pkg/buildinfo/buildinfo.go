@@ -0,0 +1,49 @@
+// Package buildinfo 提供程序的版本信息，用于启动日志和就绪检查。
+// 版本号在构建时注入：go build -ldflags "-X github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/buildinfo.Version=v1.2.0"，
+// 提交号与构建时间在 git 仓库中构建时由 Go 工具链自动记录
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+	"sync"
+)
+
+// Version 是构建时注入的版本号，未注入时为 dev
+var Version = "dev"
+
+// Info 是程序的版本信息
+type Info struct {
+	Version   string `json:"version"`
+	Revision  string `json:"revision,omitempty"` // git 提交号
+	Time      string `json:"time,omitempty"`     // 提交时间
+	Modified  bool   `json:"modified,omitempty"` // 构建时工作区有未提交的修改
+	GoVersion string `json:"goVersion"`
+}
+
+var (
+	once sync.Once
+	info Info
+)
+
+// Get 返回程序的版本信息
+func Get() Info {
+	once.Do(func() {
+		info = Info{Version: Version, GoVersion: runtime.Version()}
+		bi, ok := debug.ReadBuildInfo()
+		if !ok {
+			return
+		}
+		for _, s := range bi.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				info.Revision = s.Value
+			case "vcs.time":
+				info.Time = s.Value
+			case "vcs.modified":
+				info.Modified = s.Value == "true"
+			}
+		}
+	})
+	return info
+}
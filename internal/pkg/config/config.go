@@ -0,0 +1,380 @@
+// Package config 集中管理服务的全部配置。
+//
+// 配置按以下顺序加载，后者覆盖前者：内置默认值 → YAML 配置文件 (CONFIG_FILE 指定) → 环境变量 (含 .env 文件)。
+// 环境变量名与之前分散在各处的 os.Getenv 保持一致，旧的 .env 文件无需修改。
+// 加载后统一校验，配置有误时启动失败，而不是在运行中悄悄使用默认值。
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// 运行模式，与 GIN_MODE 的取值一致
+const (
+	ModeDebug   = "debug"
+	ModeRelease = "release"
+	ModeTest    = "test"
+)
+
+// 开发环境的默认值，release 模式下拒绝使用
+const (
+	devMySQLDSN  = "root:your_password@tcp(127.0.0.1:3306)/wish_wall?charset=utf8mb4&parseTime=True&loc=Local"
+	devJWTSecret = "default_secret_key"
+)
+
+// weakJWTSecrets 是文档和旧代码中出现过的示例密钥，release 模式下拒绝使用
+var weakJWTSecrets = []string{devJWTSecret, "my_strong_secret_key!", "your_jwt_secret", "secret", "changeme"}
+
+// minJWTSecretLen 是 release 模式下 JWT 密钥的最短长度
+const minJWTSecretLen = 16
+
+// Config 是服务的全部配置
+type Config struct {
+	Mode           string `yaml:"mode" env:"GIN_MODE"`                  // debug / release / test
+	ActiveActivity string `yaml:"activeActivity" env:"ACTIVE_ACTIVITY"` // v1 为读写模式，其余为只读模式
+
+	Server     ServerConfig     `yaml:"server"`
+	Database   DatabaseConfig   `yaml:"database"`
+	JWT        JWTConfig        `yaml:"jwt"`
+	CORS       CORSConfig       `yaml:"cors"`
+	Moderation ModerationConfig `yaml:"moderation"`
+	Metrics    MetricsConfig    `yaml:"metrics"`
+
+	PasswordReset PasswordResetConfig `yaml:"passwordReset"`
+	LoginLimit    LoginLimitConfig    `yaml:"loginLimit"`
+
+	warnings []string
+}
+
+// ServerConfig 是 HTTP 服务配置，超时为 0 表示不限制
+type ServerConfig struct {
+	Addr              string        `yaml:"addr" env:"SERVER_ADDR"` // 监听地址，默认 :8080
+	ReadHeaderTimeout time.Duration `yaml:"readHeaderTimeout" env:"SERVER_READ_HEADER_TIMEOUT"`
+	ReadTimeout       time.Duration `yaml:"readTimeout" env:"SERVER_READ_TIMEOUT"`
+	WriteTimeout      time.Duration `yaml:"writeTimeout" env:"SERVER_WRITE_TIMEOUT"` // 需要覆盖同步审核的耗时
+	IdleTimeout       time.Duration `yaml:"idleTimeout" env:"SERVER_IDLE_TIMEOUT"`
+	MaxHeaderBytes    int           `yaml:"maxHeaderBytes" env:"SERVER_MAX_HEADER_BYTES"`
+	ShutdownTimeout   time.Duration `yaml:"shutdownTimeout" env:"SERVER_SHUTDOWN_TIMEOUT"` // 收到退出信号后等待请求处理完的最长时间
+	TrustedProxies    []string      `yaml:"trustedProxies" env:"SERVER_TRUSTED_PROXIES"`   // 信任其 X-Forwarded-For 的反向代理 (IP 或 CIDR)，环境变量以逗号分隔
+}
+
+// DatabaseConfig 是数据库连接配置
+type DatabaseConfig struct {
+	Driver      string `yaml:"driver" env:"DB_DRIVER"`                // mysql / sqlite
+	MySQLDSN    string `yaml:"mysqlDSN" env:"MYSQL_DSN" secret:"dsn"` // 打印时隐藏密码
+	SQLiteDSN   string `yaml:"sqliteDSN" env:"SQLITE_DSN"`            // 数据库文件，默认 wish_wall.db
+	AutoMigrate bool   `yaml:"autoMigrate" env:"DB_AUTO_MIGRATE"`     // 启动时自动执行未执行的迁移，默认只校验版本
+	SeedProfile string `yaml:"seedProfile" env:"SEED_PROFILE"`        // 启动时填充的数据 (demo / load / none)，非 release 模式默认 demo
+}
+
+// JWTConfig 是登录令牌配置：短期的访问令牌 (JWT) 加上服务端保存、每次使用后换发的刷新令牌
+type JWTConfig struct {
+	Secret     string        `yaml:"secret" env:"JWT_SECRET" secret:"true"` // 未设置 KeysFile 时用于 HS256 签名
+	KeysFile   string        `yaml:"keysFile" env:"JWT_KEYS_FILE"`          // 密钥环文件，由 jwt-keys 命令生成和轮换，设置后不再使用 Secret
+	TTL        time.Duration `yaml:"ttl" env:"JWT_TTL"`                     // 访问令牌有效期，默认 15m
+	RefreshTTL time.Duration `yaml:"refreshTTL" env:"JWT_REFRESH_TTL"`      // 刷新令牌有效期，默认 720h (30 天)，每次刷新重新计算
+}
+
+// PasswordResetConfig 是找回密码配置。重置码由管理员签发，或者在设置了 WebhookURL 时由用户自助申请、
+// 通过 webhook 发送 (如校园消息推送服务)
+type PasswordResetConfig struct {
+	CodeTTL      time.Duration `yaml:"codeTTL" env:"PASSWORD_RESET_CODE_TTL"`                         // 重置码有效期，默认 30m
+	WebhookURL   string        `yaml:"webhookURL" env:"PASSWORD_RESET_WEBHOOK_URL"`                   // 为空时不开放自助找回密码
+	WebhookToken string        `yaml:"webhookToken" env:"PASSWORD_RESET_WEBHOOK_TOKEN" secret:"true"` // 请求 webhook 时带 Authorization: Bearer <token>
+	// 自助申请重置码的频率限制：窗口内同一学号 / 同一 IP 的申请次数上限，超过后返回 429，0 表示不限制
+	RequestWindow       time.Duration `yaml:"requestWindow" env:"PASSWORD_RESET_REQUEST_WINDOW"`              // 默认 1h
+	AccountRequestLimit int           `yaml:"accountRequestLimit" env:"PASSWORD_RESET_ACCOUNT_REQUEST_LIMIT"` // 默认 3
+	IPRequestLimit      int           `yaml:"ipRequestLimit" env:"PASSWORD_RESET_IP_REQUEST_LIMIT"`           // 默认 20
+}
+
+// LoginLimitConfig 是登录失败限制配置：按学号和客户端 IP 分别统计滑动窗口内的失败次数，
+// 达到上限后锁定一段时间，锁定到期后再次达到上限时锁定时长翻倍
+type LoginLimitConfig struct {
+	Store              string        `yaml:"store" env:"LOGIN_LIMIT_STORE"`                             // memory (单实例) / database (多实例共享)
+	Window             time.Duration `yaml:"window" env:"LOGIN_LIMIT_WINDOW"`                           // 统计失败次数的滑动窗口，默认 15m
+	AccountMaxFailures int           `yaml:"accountMaxFailures" env:"LOGIN_LIMIT_ACCOUNT_MAX_FAILURES"` // 同一学号的失败次数上限，默认 5，0 表示不限制
+	IPMaxFailures      int           `yaml:"ipMaxFailures" env:"LOGIN_LIMIT_IP_MAX_FAILURES"`           // 同一 IP 的失败次数上限，默认 50 (校园网出口 IP 由很多人共用)，0 表示不限制
+	BaseLockout        time.Duration `yaml:"baseLockout" env:"LOGIN_LIMIT_BASE_LOCKOUT"`                // 第一次锁定的时长，默认 1m
+	MaxLockout         time.Duration `yaml:"maxLockout" env:"LOGIN_LIMIT_MAX_LOCKOUT"`                  // 锁定时长的上限，默认 1h
+}
+
+// CORSConfig 是跨域配置
+type CORSConfig struct {
+	AllowedOrigins []string `yaml:"allowedOrigins" env:"CORS_ALLOWED_ORIGINS"` // 环境变量以逗号分隔
+}
+
+// MetricsConfig 是 Prometheus 指标接口 /metrics 的配置
+type MetricsConfig struct {
+	Enabled         bool     `yaml:"enabled" env:"METRICS_ENABLED"`
+	AllowedNetworks []string `yaml:"allowedNetworks" env:"METRICS_ALLOWED_NETWORKS"` // 可以直接抓取的网段 (CIDR)，环境变量以逗号分隔
+	Token           string   `yaml:"token" env:"METRICS_TOKEN" secret:"true"`        // 其他来源需要带 Authorization: Bearer <token>，为空时只允许上述网段
+}
+
+// ModerationConfig 是内容审核配置，各字段的含义见 README「环境变量配置」
+type ModerationConfig struct {
+	Providers            []string      `yaml:"providers" env:"MODERATION_PROVIDERS"` // 审核链顺序：llm / keyword / allow
+	Timeout              time.Duration `yaml:"timeout" env:"MODERATION_TIMEOUT" unit:"s"`
+	Prefilter            bool          `yaml:"prefilter" env:"MODERATION_PREFILTER"`
+	SensitiveWordsFile   string        `yaml:"sensitiveWordsFile" env:"SENSITIVE_WORDS_FILE"`
+	SensitiveWordsReload time.Duration `yaml:"sensitiveWordsReload" env:"SENSITIVE_WORDS_RELOAD_INTERVAL" unit:"s"`
+	PolicyFile           string        `yaml:"policyFile" env:"MODERATION_POLICY_FILE"`
+	ThresholdRefresh     time.Duration `yaml:"thresholdRefresh" env:"MODERATION_THRESHOLD_REFRESH" unit:"s"`
+
+	BreakerThreshold int           `yaml:"breakerThreshold" env:"MODERATION_BREAKER_THRESHOLD"` // 0 表示不熔断
+	BreakerCooldown  time.Duration `yaml:"breakerCooldown" env:"MODERATION_BREAKER_COOLDOWN" unit:"s"`
+
+	CacheSize    int           `yaml:"cacheSize" env:"MODERATION_CACHE_SIZE"` // 0 表示不缓存
+	CacheTTL     time.Duration `yaml:"cacheTTL" env:"MODERATION_CACHE_TTL" unit:"s"`
+	CachePersist bool          `yaml:"cachePersist" env:"MODERATION_CACHE_PERSIST"`
+
+	Async        bool          `yaml:"async" env:"MODERATION_ASYNC"`
+	Workers      int           `yaml:"workers" env:"MODERATION_WORKERS"`
+	QueueSize    int           `yaml:"queueSize" env:"MODERATION_QUEUE_SIZE"`
+	MaxRetries   int           `yaml:"maxRetries" env:"MODERATION_MAX_RETRIES"`
+	RetryBackoff time.Duration `yaml:"retryBackoff" env:"MODERATION_RETRY_BACKOFF" unit:"s"`
+	WebhookURL   string        `yaml:"webhookURL" env:"MODERATION_WEBHOOK_URL"`
+
+	LLM LLMConfig `yaml:"llm"`
+}
+
+// LLMConfig 是大模型审核服务 (Silicon Flow) 配置
+type LLMConfig struct {
+	APIKey       string        `yaml:"apiKey" env:"SILICONFLOW_API_KEY" secret:"true"`
+	BaseURL      string        `yaml:"baseURL" env:"SILICONFLOW_BASE_URL"`
+	Model        string        `yaml:"model" env:"SILICONFLOW_MODEL"`
+	Timeout      time.Duration `yaml:"timeout" env:"SILICONFLOW_TIMEOUT" unit:"s"`
+	MaxRetries   int           `yaml:"maxRetries" env:"SILICONFLOW_MAX_RETRIES"`
+	RetryBackoff time.Duration `yaml:"retryBackoff" env:"SILICONFLOW_RETRY_BACKOFF_MS" unit:"ms"`
+}
+
+// Default 返回内置默认值，不读取任何外部配置。测试可以在此基础上修改需要的字段
+func Default() *Config {
+	return &Config{
+		Mode: ModeDebug,
+		Server: ServerConfig{
+			Addr:              ":8080",
+			ReadHeaderTimeout: 5 * time.Second,
+			ReadTimeout:       15 * time.Second,
+			WriteTimeout:      60 * time.Second,
+			IdleTimeout:       60 * time.Second,
+			MaxHeaderBytes:    1 << 20,
+			ShutdownTimeout:   15 * time.Second,
+			// 默认只信任本机。docker 网桥同样需要显式配置 (docker-compose.yml 中只信任 nginx 的固定地址)：
+			// 发布到宿主机的端口收到的外部请求以网桥网关的地址到达，信任整个网桥会让客户端可以伪造 X-Forwarded-For
+			TrustedProxies: []string{"127.0.0.0/8", "::1/128"},
+		},
+		Database: DatabaseConfig{Driver: "mysql", SQLiteDSN: "wish_wall.db"},
+		JWT:      JWTConfig{TTL: 15 * time.Minute, RefreshTTL: 720 * time.Hour},
+		CORS: CORSConfig{AllowedOrigins: []string{
+			"https://snowkeptwishes.ncuhos.com",
+			"http://localhost:5173",
+		}},
+		Moderation: ModerationConfig{
+			Providers:            []string{"llm", "keyword"},
+			Timeout:              10 * time.Second,
+			Prefilter:            true,
+			SensitiveWordsReload: 30 * time.Second,
+			ThresholdRefresh:     30 * time.Second,
+			BreakerThreshold:     5,
+			BreakerCooldown:      30 * time.Second,
+			CacheSize:            1024,
+			CacheTTL:             24 * time.Hour,
+			Workers:              4,
+			QueueSize:            256,
+			MaxRetries:           3,
+			RetryBackoff:         2 * time.Second,
+			LLM: LLMConfig{
+				BaseURL:      "https://api.siliconflow.cn/v1",
+				Model:        "Qwen/Qwen3-VL-8B-Instruct",
+				Timeout:      8 * time.Second,
+				MaxRetries:   2,
+				RetryBackoff: 200 * time.Millisecond,
+			},
+		},
+		Metrics: MetricsConfig{
+			Enabled: true,
+			// 默认只放行本机，其他来源 (包括校园网和 docker 网桥) 使用 METRICS_TOKEN 抓取，或者显式配置网段
+			AllowedNetworks: []string{"127.0.0.0/8", "::1/128"},
+		},
+		PasswordReset: PasswordResetConfig{
+			CodeTTL:             30 * time.Minute,
+			RequestWindow:       time.Hour,
+			AccountRequestLimit: 3,
+			IPRequestLimit:      20,
+		},
+		LoginLimit: LoginLimitConfig{
+			Store:              "memory",
+			Window:             15 * time.Minute,
+			AccountMaxFailures: 5,
+			IPMaxFailures:      50,
+			BaseLockout:        time.Minute,
+			MaxLockout:         time.Hour,
+		},
+	}
+}
+
+// Load 加载并校验配置：先读取当前目录的 .env (不存在时忽略)，
+// 再依次应用默认值、CONFIG_FILE 指定的 YAML 文件和环境变量
+func Load() (*Config, error) {
+	if err := godotenv.Load(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("读取 .env 文件失败: %w", err)
+	}
+	return LoadFrom(os.Getenv("CONFIG_FILE"), os.LookupEnv)
+}
+
+// LoadFrom 从指定的 YAML 文件 (为空时跳过) 和环境变量查找函数加载配置，便于测试
+func LoadFrom(path string, lookupEnv func(string) (string, bool)) (*Config, error) {
+	cfg := Default()
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("读取配置文件失败: %w", err)
+		}
+		if err := yaml.Unmarshal(data, cfg); err != nil {
+			return nil, fmt.Errorf("解析配置文件 %s 失败: %w", path, err)
+		}
+	}
+	if err := applyEnv(cfg, lookupEnv); err != nil {
+		return nil, err
+	}
+	cfg.applyDevDefaults()
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// applyDevDefaults 在非 release 模式下为缺失的连接串和密钥填入开发用的默认值，并记录警告
+func (c *Config) applyDevDefaults() {
+	c.Mode = strings.ToLower(c.Mode)
+	c.Database.Driver = strings.ToLower(c.Database.Driver)
+	c.LoginLimit.Store = strings.ToLower(c.LoginLimit.Store)
+	if c.Mode == ModeRelease {
+		return
+	}
+	if c.Database.Driver == "mysql" && c.Database.MySQLDSN == "" {
+		c.Database.MySQLDSN = devMySQLDSN
+		c.warnings = append(c.warnings, "MYSQL_DSN 未设置，使用开发环境的默认连接串")
+	}
+	if c.Database.SeedProfile == "" {
+		c.Database.SeedProfile = "demo"
+	}
+	if c.JWT.Secret == "" && c.JWT.KeysFile == "" {
+		c.JWT.Secret = devJWTSecret
+		c.warnings = append(c.warnings, "JWT_SECRET 未设置，使用开发环境的默认密钥 (release 模式下会拒绝启动)")
+	}
+}
+
+// Warnings 返回加载配置时产生的警告，由调用方在日志初始化后输出
+func (c *Config) Warnings() []string {
+	return c.warnings
+}
+
+// Release 表示是否运行在生产 (release) 模式
+func (c *Config) Release() bool {
+	return c.Mode == ModeRelease
+}
+
+// Validate 校验配置，返回全部问题而不是只返回第一个
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(slices.Contains([]string{ModeDebug, ModeRelease, ModeTest}, c.Mode), "GIN_MODE 只能是 debug / release / test，当前为 %q", c.Mode)
+	check(c.Server.Addr != "", "SERVER_ADDR 不能为空")
+	check(c.Server.ShutdownTimeout > 0, "SERVER_SHUTDOWN_TIMEOUT 必须大于 0")
+	check(c.Server.WriteTimeout == 0 || c.Server.WriteTimeout > c.Moderation.Timeout,
+		"SERVER_WRITE_TIMEOUT 必须大于 MODERATION_TIMEOUT，否则同步审核的请求会在返回前被断开")
+
+	switch c.Database.Driver {
+	case "mysql":
+		check(c.Database.MySQLDSN != "", "DB_DRIVER=mysql 时必须设置 MYSQL_DSN")
+	case "sqlite":
+		check(c.Database.SQLiteDSN != "", "DB_DRIVER=sqlite 时必须设置 SQLITE_DSN")
+	default:
+		check(false, "DB_DRIVER 只能是 mysql / sqlite，当前为 %q", c.Database.Driver)
+	}
+
+	check(c.JWT.Secret != "" || c.JWT.KeysFile != "", "必须设置 JWT_SECRET 或 JWT_KEYS_FILE")
+	check(c.JWT.TTL > 0, "JWT_TTL 必须大于 0")
+	check(c.JWT.RefreshTTL > c.JWT.TTL, "JWT_REFRESH_TTL 必须大于 JWT_TTL")
+	for _, p := range c.Server.TrustedProxies {
+		_, prefixErr := netip.ParsePrefix(p)
+		_, addrErr := netip.ParseAddr(p)
+		check(prefixErr == nil || addrErr == nil, "SERVER_TRUSTED_PROXIES 中的 %q 不是 IP 或网段", p)
+	}
+
+	check(c.PasswordReset.CodeTTL > 0, "PASSWORD_RESET_CODE_TTL 必须大于 0")
+	check(c.PasswordReset.RequestWindow > 0, "PASSWORD_RESET_REQUEST_WINDOW 必须大于 0")
+	if u := c.PasswordReset.WebhookURL; u != "" {
+		parsed, err := url.Parse(u)
+		check(err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != "",
+			"PASSWORD_RESET_WEBHOOK_URL 必须是 http(s) 地址，当前为 %q", u)
+	}
+
+	l := c.LoginLimit
+	check(slices.Contains([]string{"memory", "database"}, l.Store), "LOGIN_LIMIT_STORE 只能是 memory / database，当前为 %q", l.Store)
+	check(l.Window > 0, "LOGIN_LIMIT_WINDOW 必须大于 0")
+	check(l.BaseLockout > 0, "LOGIN_LIMIT_BASE_LOCKOUT 必须大于 0")
+	check(l.MaxLockout >= l.BaseLockout, "LOGIN_LIMIT_MAX_LOCKOUT 不能小于 LOGIN_LIMIT_BASE_LOCKOUT")
+
+	m := c.Moderation
+	for _, p := range m.Providers {
+		check(slices.Contains([]string{"llm", "keyword", "allow"}, p), "MODERATION_PROVIDERS 中有未知的审核器 %q (可选 llm / keyword / allow)", p)
+	}
+	check(len(m.Providers) > 0, "MODERATION_PROVIDERS 不能为空")
+	check(m.Timeout > 0, "MODERATION_TIMEOUT 必须大于 0")
+	check(m.LLM.Timeout > 0, "SILICONFLOW_TIMEOUT 必须大于 0")
+	for _, f := range []struct {
+		name  string
+		value int
+	}{
+		{"SERVER_MAX_HEADER_BYTES", c.Server.MaxHeaderBytes},
+		{"MODERATION_BREAKER_THRESHOLD", m.BreakerThreshold},
+		{"MODERATION_CACHE_SIZE", m.CacheSize},
+		{"MODERATION_MAX_RETRIES", m.MaxRetries},
+		{"SILICONFLOW_MAX_RETRIES", m.LLM.MaxRetries},
+		{"LOGIN_LIMIT_ACCOUNT_MAX_FAILURES", l.AccountMaxFailures},
+		{"LOGIN_LIMIT_IP_MAX_FAILURES", l.IPMaxFailures},
+		{"PASSWORD_RESET_ACCOUNT_REQUEST_LIMIT", c.PasswordReset.AccountRequestLimit},
+		{"PASSWORD_RESET_IP_REQUEST_LIMIT", c.PasswordReset.IPRequestLimit},
+	} {
+		check(f.value >= 0, "%s 不能为负数", f.name)
+	}
+	for _, n := range c.Metrics.AllowedNetworks {
+		_, err := netip.ParsePrefix(n)
+		check(err == nil, "METRICS_ALLOWED_NETWORKS 中的网段 %q 格式有误 (例如 10.0.0.0/8)", n)
+	}
+	if m.Async {
+		check(m.Workers > 0, "开启异步审核时 MODERATION_WORKERS 必须大于 0")
+		check(m.QueueSize > 0, "开启异步审核时 MODERATION_QUEUE_SIZE 必须大于 0")
+	}
+
+	if c.Mode == ModeRelease {
+		check(c.JWT.Secret == "" || !slices.Contains(weakJWTSecrets, c.JWT.Secret) && len(c.JWT.Secret) >= minJWTSecretLen,
+			"release 模式下 JWT_SECRET 不能使用示例值，且长度不少于 %d 个字符", minJWTSecretLen)
+		check(!strings.Contains(c.Database.MySQLDSN, "your_password"), "release 模式下 MYSQL_DSN 不能使用示例密码")
+		check(!slices.Contains(m.Providers, "allow"), "release 模式下 MODERATION_PROVIDERS 不能包含 allow (全部放行)")
+		check(!strings.HasPrefix(c.PasswordReset.WebhookURL, "http://"), "release 模式下 PASSWORD_RESET_WEBHOOK_URL 必须使用 https，重置码不能明文传输")
+		check(c.Metrics.Token == "" || len(c.Metrics.Token) >= minJWTSecretLen, "release 模式下 METRICS_TOKEN 长度不少于 %d 个字符", minJWTSecretLen)
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("配置有误:\n%w", errors.Join(errs...))
+	}
+	return nil
+}
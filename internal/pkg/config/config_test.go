@@ -0,0 +1,185 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// envMap 把 map 包装成环境变量查找函数
+func envMap(env map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	}
+}
+
+func TestLoadFrom(t *testing.T) {
+	t.Run("默认值与开发环境回退", func(t *testing.T) {
+		cfg, err := LoadFrom("", envMap(nil))
+		require.NoError(t, err)
+		assert.Equal(t, ":8080", cfg.Server.Addr)
+		assert.Equal(t, devJWTSecret, cfg.JWT.Secret)
+		assert.Equal(t, devMySQLDSN, cfg.Database.MySQLDSN)
+		assert.Equal(t, []string{"127.0.0.0/8", "::1/128"}, cfg.Server.TrustedProxies, "docker 网桥需要显式信任")
+		assert.Equal(t, []string{"127.0.0.0/8", "::1/128"}, cfg.Metrics.AllowedNetworks, "内网需要显式放行")
+		assert.Len(t, cfg.Warnings(), 2)
+	})
+
+	t.Run("环境变量覆盖与单位换算", func(t *testing.T) {
+		cfg, err := LoadFrom("", envMap(map[string]string{
+			"GIN_MODE":                     "test",
+			"SERVER_ADDR":                  ":9090",
+			"DB_DRIVER":                    "SQLite",
+			"SQLITE_DSN":                   "file::memory:",
+			"JWT_SECRET":                   "s",
+			"JWT_TTL":                      "2h",
+			"JWT_REFRESH_TTL":              "72h",
+			"CORS_ALLOWED_ORIGINS":         " https://a.com, ,http://b.com ",
+			"MODERATION_TIMEOUT":           "5",
+			"MODERATION_CACHE_TTL":         "1m30s",
+			"MODERATION_PREFILTER":         "false",
+			"SILICONFLOW_RETRY_BACKOFF_MS": "50",
+			"MODERATION_WORKERS":           "",
+		}))
+		require.NoError(t, err)
+		assert.Equal(t, ":9090", cfg.Server.Addr)
+		assert.Equal(t, "sqlite", cfg.Database.Driver)
+		assert.Equal(t, 2*time.Hour, cfg.JWT.TTL)
+		assert.Equal(t, 72*time.Hour, cfg.JWT.RefreshTTL)
+		assert.Equal(t, []string{"https://a.com", "http://b.com"}, cfg.CORS.AllowedOrigins)
+		assert.Equal(t, 5*time.Second, cfg.Moderation.Timeout)
+		assert.Equal(t, 90*time.Second, cfg.Moderation.CacheTTL)
+		assert.False(t, cfg.Moderation.Prefilter)
+		assert.Equal(t, 50*time.Millisecond, cfg.Moderation.LLM.RetryBackoff)
+		assert.Equal(t, 4, cfg.Moderation.Workers, "空字符串视为未设置")
+		assert.Empty(t, cfg.Database.MySQLDSN, "使用 SQLite 时不回退 MySQL 连接串")
+	})
+
+	t.Run("YAML 文件被环境变量覆盖", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(`
+activeActivity: v2
+server:
+  addr: ":7070"
+jwt:
+  secret: from_yaml
+moderation:
+  providers: [keyword]
+  breakerCooldown: 1m
+`), 0o600))
+		cfg, err := LoadFrom(path, envMap(map[string]string{"ACTIVE_ACTIVITY": "v1"}))
+		require.NoError(t, err)
+		assert.Equal(t, "v1", cfg.ActiveActivity)
+		assert.Equal(t, ":7070", cfg.Server.Addr)
+		assert.Equal(t, "from_yaml", cfg.JWT.Secret)
+		assert.Equal(t, []string{"keyword"}, cfg.Moderation.Providers)
+		assert.Equal(t, time.Minute, cfg.Moderation.BreakerCooldown)
+		assert.Equal(t, 24*time.Hour, cfg.Moderation.CacheTTL, "未出现在文件中的字段保留默认值")
+
+		_, err = LoadFrom(filepath.Join(t.TempDir(), "missing.yaml"), envMap(nil))
+		assert.Error(t, err)
+	})
+
+	t.Run("无效的值汇总报错", func(t *testing.T) {
+		_, err := LoadFrom("", envMap(map[string]string{
+			"MODERATION_WORKERS": "many",
+			"MODERATION_TIMEOUT": "-3",
+		}))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "MODERATION_WORKERS")
+		assert.Contains(t, err.Error(), "MODERATION_TIMEOUT")
+
+		_, err = LoadFrom("", envMap(map[string]string{"DB_DRIVER": "postgres", "MODERATION_PROVIDERS": "llm,gpt"}))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "DB_DRIVER")
+		assert.Contains(t, err.Error(), "gpt")
+
+		_, err = LoadFrom("", envMap(map[string]string{"METRICS_ALLOWED_NETWORKS": "10.0.0.0/8,intranet"}))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "intranet")
+
+		_, err = LoadFrom("", envMap(map[string]string{"JWT_TTL": "2h", "JWT_REFRESH_TTL": "1h"}))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "JWT_REFRESH_TTL")
+
+		_, err = LoadFrom("", envMap(map[string]string{"LOGIN_LIMIT_STORE": "redis", "LOGIN_LIMIT_MAX_LOCKOUT": "10s", "SERVER_TRUSTED_PROXIES": "nginx"}))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "LOGIN_LIMIT_STORE")
+		assert.Contains(t, err.Error(), "LOGIN_LIMIT_MAX_LOCKOUT")
+		assert.Contains(t, err.Error(), "SERVER_TRUSTED_PROXIES")
+
+		_, err = LoadFrom("", envMap(map[string]string{"PASSWORD_RESET_REQUEST_WINDOW": "0s", "PASSWORD_RESET_IP_REQUEST_LIMIT": "-1"}))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "PASSWORD_RESET_REQUEST_WINDOW")
+		assert.Contains(t, err.Error(), "PASSWORD_RESET_IP_REQUEST_LIMIT")
+
+		_, err = LoadFrom("", envMap(map[string]string{"PASSWORD_RESET_WEBHOOK_URL": "push.internal/reset"}))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "PASSWORD_RESET_WEBHOOK_URL")
+	})
+}
+
+func TestReleaseValidation(t *testing.T) {
+	release := map[string]string{
+		"GIN_MODE":  "release",
+		"MYSQL_DSN": "wish:pa55@tcp(db:3306)/wish_wall",
+	}
+	with := func(extra map[string]string) map[string]string {
+		env := map[string]string{}
+		for k, v := range release {
+			env[k] = v
+		}
+		for k, v := range extra {
+			env[k] = v
+		}
+		return env
+	}
+
+	// release 模式下不回退到开发默认值
+	_, err := LoadFrom("", envMap(release))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "JWT_SECRET")
+
+	for _, secret := range []string{devJWTSecret, "my_strong_secret_key!", "short"} {
+		_, err = LoadFrom("", envMap(with(map[string]string{"JWT_SECRET": secret})))
+		assert.Error(t, err, secret)
+	}
+
+	_, err = LoadFrom("", envMap(with(map[string]string{"JWT_SECRET": "a-long-random-production-secret", "MODERATION_PROVIDERS": "allow"})))
+	assert.Error(t, err)
+	_, err = LoadFrom("", envMap(with(map[string]string{"JWT_SECRET": "a-long-random-production-secret", "METRICS_TOKEN": "short"})))
+	assert.Error(t, err)
+	_, err = LoadFrom("", envMap(with(map[string]string{"JWT_SECRET": "a-long-random-production-secret", "PASSWORD_RESET_WEBHOOK_URL": "http://push.internal/reset"})))
+	assert.Error(t, err, "重置码不能明文传输")
+
+	cfg, err := LoadFrom("", envMap(with(map[string]string{"JWT_SECRET": "a-long-random-production-secret"})))
+	require.NoError(t, err)
+	assert.True(t, cfg.Release())
+	assert.Empty(t, cfg.Warnings())
+
+	// 使用密钥环文件时不需要 JWT_SECRET
+	cfg, err = LoadFrom("", envMap(with(map[string]string{"JWT_KEYS_FILE": "/run/secrets/jwt-keys.json"})))
+	require.NoError(t, err)
+	assert.Equal(t, "/run/secrets/jwt-keys.json", cfg.JWT.KeysFile)
+	assert.Empty(t, cfg.JWT.Secret)
+}
+
+func TestRedacted(t *testing.T) {
+	cfg := Default()
+	cfg.JWT.Secret = "top-secret"
+	cfg.Database.MySQLDSN = "wish:pa55@tcp(db:3306)/wish_wall"
+
+	out := cfg.Redacted()
+	assert.Equal(t, "******", out["jwt.secret"])
+	assert.Equal(t, "", out["moderation.llm.apiKey"], "未设置的密钥显示为空")
+	assert.Equal(t, "wish:******@tcp(db:3306)/wish_wall", out["database.mysqlDSN"])
+	assert.Equal(t, "15m0s", out["jwt.ttl"])
+	assert.Equal(t, "720h0m0s", out["jwt.refreshTTL"])
+	assert.Equal(t, ":8080", out["server.addr"])
+	assert.NotContains(t, out, "warnings")
+}
@@ -0,0 +1,141 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// durationUnits 是 unit 标签可选的单位。为了兼容旧的环境变量，带单位的时长字段既可以写整数 (按单位换算)，
+// 也可以写 Go 的时长格式 (如 "1m30s")
+var durationUnits = map[string]time.Duration{
+	"ms": time.Millisecond,
+	"s":  time.Second,
+}
+
+// applyEnv 用环境变量覆盖带 env 标签的字段，空字符串视为未设置
+func applyEnv(cfg *Config, lookupEnv func(string) (string, bool)) error {
+	var errs []error
+	walk(reflect.ValueOf(cfg).Elem(), "", func(field reflect.StructField, v reflect.Value, _ string) {
+		key := field.Tag.Get("env")
+		if key == "" {
+			return
+		}
+		raw, ok := lookupEnv(key)
+		if !ok || strings.TrimSpace(raw) == "" {
+			return
+		}
+		if err := setField(v, strings.TrimSpace(raw), field.Tag.Get("unit")); err != nil {
+			errs = append(errs, fmt.Errorf("环境变量 %s=%q 无效: %w", key, raw, err))
+		}
+	})
+	if len(errs) > 0 {
+		return fmt.Errorf("配置有误:\n%w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// walk 深度优先遍历配置结构体中的叶子字段，path 为 YAML 中的路径 (如 moderation.llm.apiKey)
+func walk(v reflect.Value, prefix string, fn func(field reflect.StructField, v reflect.Value, path string)) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		path := field.Tag.Get("yaml")
+		if prefix != "" {
+			path = prefix + "." + path
+		}
+		if field.Type.Kind() == reflect.Struct {
+			walk(v.Field(i), path, fn)
+			continue
+		}
+		fn(field, v.Field(i), path)
+	}
+}
+
+func setField(v reflect.Value, raw, unit string) error {
+	switch {
+	case v.Type() == durationType:
+		if n, err := strconv.Atoi(raw); err == nil && unit != "" {
+			if n < 0 {
+				return errors.New("不能为负数")
+			}
+			v.SetInt(int64(time.Duration(n) * durationUnits[unit]))
+			return nil
+		}
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+	case v.Kind() == reflect.String:
+		v.SetString(raw)
+	case v.Kind() == reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(n))
+	case v.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.String:
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("不支持的字段类型 %s", v.Type())
+	}
+	return nil
+}
+
+// Redacted 返回用于打印的配置，键为 YAML 路径，密钥类字段只显示是否已设置，连接串隐藏密码
+func (c *Config) Redacted() map[string]interface{} {
+	out := make(map[string]interface{})
+	walk(reflect.ValueOf(c).Elem(), "", func(field reflect.StructField, v reflect.Value, path string) {
+		switch field.Tag.Get("secret") {
+		case "true":
+			if v.String() == "" {
+				out[path] = ""
+			} else {
+				out[path] = "******"
+			}
+		case "dsn":
+			out[path] = redactDSN(v.String())
+		default:
+			if v.Type() == durationType {
+				out[path] = v.Interface().(time.Duration).String()
+			} else {
+				out[path] = v.Interface()
+			}
+		}
+	})
+	return out
+}
+
+// redactDSN 隐藏 MySQL 连接串 user:password@tcp(...)/db 中的密码
+func redactDSN(dsn string) string {
+	at := strings.LastIndex(dsn, "@")
+	if at < 0 {
+		return dsn
+	}
+	userinfo := dsn[:at]
+	if colon := strings.Index(userinfo, ":"); colon >= 0 {
+		return userinfo[:colon] + ":******" + dsn[at:]
+	}
+	return dsn
+}
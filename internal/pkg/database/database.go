@@ -2,27 +2,106 @@
 package database
 
 import (
-	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/model"
+	"context"
+	"fmt"
+	"strings"
 
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/config"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/migrate"
+
+	"github.com/glebarez/sqlite"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 
 	//"log"  暂时用标准库 log 替代 zap，防止 panic
 	"go.uber.org/zap"
-	"os" // 读取环境变量
 	"time"
 )
 
 var DB *gorm.DB
 
-// InitDB 负责初始化数据库连接
-func InitDB() {
-	//调用 os 包的 Getenv 函数，读取名为 MYSQL_DSN 的环境变量。
-	dsn := os.Getenv("MYSQL_DSN")
-	if dsn == "" {
-		// 回退(Fallback) 逻辑。如果没设置环境变量，它会使用一个硬编码的、用于开发的 DSN 字符串。
-		dsn = "root:your_password@tcp(127.0.0.1:3306)/wish_wall?charset=utf8mb4&parseTime=True&loc=Local"
-		zap.S().Warn("环境变量MYSQL_DSN未设置,使用默认值连接数据库")
+// 支持的数据库驱动
+const (
+	DriverMySQL  = "mysql"
+	DriverSQLite = "sqlite" // 纯 Go 实现 (不依赖 cgo)，适合测试和单机部署
+)
+
+// Open 按驱动名打开数据库连接，driver 为空时使用 MySQL
+func Open(driver, dsn string) (*gorm.DB, error) {
+	switch strings.ToLower(driver) {
+	case "", DriverMySQL:
+		return gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	case DriverSQLite:
+		return gorm.Open(sqlite.Open(sqliteDSN(dsn)), &gorm.Config{})
+	default:
+		return nil, fmt.Errorf("不支持的数据库驱动: %s", driver)
+	}
+}
+
+// sqliteDSN 为 SQLite 连接开启外键约束 (SQLite 默认不检查外键)，并补上忙等待时间，
+// 避免并发写入时直接返回 "database is locked"
+func sqliteDSN(dsn string) string {
+	for _, pragma := range []string{"foreign_keys(1)", "busy_timeout(5000)"} {
+		name, _, _ := strings.Cut(pragma, "(")
+		if strings.Contains(dsn, name) {
+			continue
+		}
+		sep := "?"
+		if strings.Contains(dsn, "?") {
+			sep = "&"
+		}
+		dsn += sep + "_pragma=" + pragma
+	}
+	return dsn
+}
+
+// InitDB 负责初始化数据库连接，并校验表结构版本与程序一致。
+// 启动时不再修改表结构，版本落后时请先执行 migrate up
+func InitDB(cfg config.DatabaseConfig) {
+	Connect(cfg)
+	if err := PrepareSchema(context.Background(), cfg.AutoMigrate); err != nil {
+		zap.S().Fatalf("错误：%v", err)
+	}
+}
+
+// PrepareSchema 校验表结构版本与程序一致；autoMigrate 为 true 时先执行未执行的迁移 (配置项 DB_AUTO_MIGRATE)
+func PrepareSchema(ctx context.Context, autoMigrate bool) error {
+	m, err := migrate.New(DB)
+	if err != nil {
+		return fmt.Errorf("加载数据库迁移脚本失败: %w", err)
+	}
+	if autoMigrate {
+		done, err := m.Up(ctx)
+		if err != nil {
+			return fmt.Errorf("执行数据库迁移失败: %w", err)
+		}
+		zap.S().Infow("启动时自动执行数据库迁移", "count", len(done))
+	}
+	if err := m.Check(ctx); err != nil {
+		return fmt.Errorf("数据库表结构版本不匹配: %w (请先执行 `server migrate up`)", err)
+	}
+	zap.S().Infow("数据库表结构版本校验通过", "version", m.Latest())
+	return nil
+}
+
+// Close 关闭连接池，等待正在执行的查询结束。服务退出时调用，此后不能再使用 DB
+func Close() error {
+	if DB == nil {
+		return nil
+	}
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
+// Connect 连接数据库并赋值给 DB，不校验表结构，供迁移命令使用。
+// 驱动与连接串来自配置 (DB_DRIVER / MYSQL_DSN / SQLITE_DSN)，已由 config.Load 校验
+func Connect(cfg config.DatabaseConfig) {
+	driver, dsn := cfg.Driver, cfg.MySQLDSN
+	if driver == DriverSQLite {
+		dsn = cfg.SQLiteDSN
 	}
 	var err error
 	const maxRetries = 10
@@ -30,28 +109,11 @@ func InitDB() {
 
 	//  循环重试连接
 	for i := 0; i < maxRetries; i++ {
-		DB, err = gorm.Open(mysql.Open(dsn), &gorm.Config{
-			//添加gorm日志配置等
-		})
+		DB, err = Open(driver, dsn)
 
 		if err == nil {
 			//  连接成功
-			zap.S().Info("数据库连接成功！")
-
-			// 运行 Gorm 自动迁移
-			err = DB.AutoMigrate(
-				&model.User{},
-				&model.Wish{},
-				&model.Like{},
-				&model.Comment{},
-				&model.WishTag{},
-			)
-			if err != nil {
-				// 迁移失败,直接 panic
-				zap.S().Fatalf("错误：数据库迁移失败: %v", err)
-			}
-			zap.S().Info("数据库迁移成功！")
-
+			zap.S().Infow("数据库连接成功！", "driver", driver)
 			return
 		}
 
@@ -28,6 +28,48 @@ const (
 	ERROR_FORBIDDEN_COMMENT = 13
 	// 404: 评论不存在或已被删除 (用于删除评论，根据你的指定)
 	ERROR_COMMENT_NOT_FOUND = 14
+	// 404: 审核记录不存在
+	ERROR_MODERATION_RECORD_NOT_FOUND = 15
+	// 409: 审核记录已完成人工复核
+	ERROR_ALREADY_REVIEWED = 16
+	// 404: 重新审核任务不存在
+	ERROR_REMODERATION_JOB_NOT_FOUND = 17
+	// 409: 已有重新审核任务正在执行
+	ERROR_REMODERATION_RUNNING = 18
+	// 409: 重新审核任务已结束
+	ERROR_REMODERATION_FINISHED = 19
+
+	// --- 内容审核未通过 (20-25)，按违规类别区分 ---
+	// 400: 内容未通过审核 (审核器给出了不在下列之中的类别)
+	ERROR_CONTENT_REJECTED = 20
+	// 400: 色情或低俗内容
+	ERROR_CONTENT_PORN = 21
+	// 400: 暴力或血腥内容
+	ERROR_CONTENT_VIOLENCE = 22
+	// 400: 辱骂、人身攻击或仇恨言论
+	ERROR_CONTENT_ABUSE = 23
+	// 400: 政治敏感或违法信息
+	ERROR_CONTENT_POLITICS = 24
+	// 400: 广告或垃圾信息
+	ERROR_CONTENT_AD = 25
+
+	// --- 登录会话 (26) ---
+	// 404: 登录会话不存在或已退出
+	ERROR_SESSION_NOT_FOUND = 26
+
+	// --- 密码 (27-30) ---
+	// 400: 密码强度不足
+	ERROR_WEAK_PASSWORD = 27
+	// 400: 原密码错误
+	ERROR_WRONG_PASSWORD = 28
+	// 400: 重置码无效或已过期
+	ERROR_RESET_CODE_INVALID = 29
+	// 501: 未开通自助找回密码，需要联系管理员
+	ERROR_RESET_UNAVAILABLE = 30
+
+	// --- 登录限制 (31) ---
+	// 429: 登录、重置密码或修改密码失败次数过多，或者申请找回密码过于频繁，账号或 IP 被暂时锁定
+	ERROR_TOO_MANY_ATTEMPTS = 31
 
 	// --- 详细业务错误码 (10000+) ---
 	// 503: 服务器暂不可用 (发布新愿望)
@@ -41,6 +83,8 @@ const (
 
 	// 403: 无权查看此愿望的互动
 	ERROR_FORBIDDEN_INTERACTIONS = 13001
+	// 403: 需要管理员权限
+	ERROR_FORBIDDEN_ADMIN = 13002
 )
 
 // MsgFlags是一个code，message的映射
@@ -62,6 +106,32 @@ var MsgFlags = map[int]string{
 	ERROR_FORBIDDEN_COMMENT: "该愿望不允许评论",    // 对应 code: 13
 	ERROR_COMMENT_NOT_FOUND: "评论不存在或已被删除",  // 对应 code: 14 (根据你的要求)
 
+	ERROR_MODERATION_RECORD_NOT_FOUND: "审核记录不存在",      // 对应 code: 15
+	ERROR_ALREADY_REVIEWED:            "该记录已完成人工复核",   // 对应 code: 16
+	ERROR_REMODERATION_JOB_NOT_FOUND:  "重新审核任务不存在",    // 对应 code: 17
+	ERROR_REMODERATION_RUNNING:        "已有重新审核任务正在执行", // 对应 code: 18
+	ERROR_REMODERATION_FINISHED:       "重新审核任务已结束",    // 对应 code: 19
+
+	// --- 内容审核 ---
+	ERROR_CONTENT_REJECTED: "内容未通过审核",       // 对应 code: 20
+	ERROR_CONTENT_PORN:     "内容包含色情或低俗信息",   // 对应 code: 21
+	ERROR_CONTENT_VIOLENCE: "内容包含暴力或血腥信息",   // 对应 code: 22
+	ERROR_CONTENT_ABUSE:    "内容包含辱骂或人身攻击",   // 对应 code: 23
+	ERROR_CONTENT_POLITICS: "内容包含政治敏感或违法信息", // 对应 code: 24
+	ERROR_CONTENT_AD:       "内容包含广告或垃圾信息",   // 对应 code: 25
+
+	// --- 登录会话 ---
+	ERROR_SESSION_NOT_FOUND: "登录会话不存在或已退出", // 对应 code: 26
+
+	// --- 密码 ---
+	ERROR_WEAK_PASSWORD:      "密码强度不足",           // 对应 code: 27
+	ERROR_WRONG_PASSWORD:     "原密码错误",            // 对应 code: 28
+	ERROR_RESET_CODE_INVALID: "重置码无效或已过期",        // 对应 code: 29
+	ERROR_RESET_UNAVAILABLE:  "未开通自助找回密码，请联系管理员", // 对应 code: 30
+
+	// --- 登录限制 ---
+	ERROR_TOO_MANY_ATTEMPTS: "尝试次数过多，请稍后再试", // 对应 code: 31
+
 	// --- 详细业务错误码 ---
 	ERROR_SERVER_UNAVAILABLE:      "服务器暂不可用",     // 对应 code: 10001
 	ERROR_COMMENT_FAILED:          "评论失败，请稍后再试",  // 对应 code: 10002
@@ -69,6 +139,7 @@ var MsgFlags = map[int]string{
 	ERROR_LIKE_FAILED:             "操作失败，服务器出错了", // 对应 code: 10004
 
 	ERROR_FORBIDDEN_INTERACTIONS: "无权查看此愿望的互动", // 对应 code: 13001
+	ERROR_FORBIDDEN_ADMIN:        "需要管理员权限",    // 对应 code: 13002
 }
 
 // GetMsg 获取错误码对应的信息
@@ -0,0 +1,129 @@
+// Package export 把用户、愿望、评论、点赞导出为 JSON Lines 或 CSV，用于备份和数据分析。
+// 只导出未删除的记录，不导出密码等敏感字段
+package export
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 导出格式
+const (
+	FormatJSONL = "jsonl"
+	FormatCSV   = "csv"
+)
+
+// tables 是可以导出的表及其导出的列
+var tables = map[string][]string{
+	"users":    {"id", "username", "nickname", "role", "avatar_id", "wish_value", "bio", "created_at"},
+	"wishes":   {"id", "user_id", "content", "is_public", "background", "like_count", "comment_count", "moderation_status", "moderation_reason", "created_at"},
+	"comments": {"id", "wish_id", "parent_id", "user_id", "content", "moderation_status", "moderation_reason", "created_at"},
+	"likes":    {"id", "wish_id", "user_id", "created_at"},
+}
+
+// Tables 返回可以导出的表名
+func Tables() []string {
+	names := make([]string, 0, len(tables))
+	for name := range tables {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Write 按 ID 顺序导出一张表到 w，返回导出的行数
+func Write(ctx context.Context, db *gorm.DB, table, format string, w io.Writer) (int, error) {
+	columns, ok := tables[table]
+	if !ok {
+		return 0, fmt.Errorf("不能导出表 %q (可选 %s)", table, strings.Join(Tables(), " / "))
+	}
+	var write func(values []interface{}) error
+	var flush func() error
+	switch format {
+	case FormatJSONL:
+		enc := json.NewEncoder(w)
+		write = func(values []interface{}) error {
+			row := make(map[string]interface{}, len(columns))
+			for i, col := range columns {
+				row[col] = values[i]
+			}
+			return enc.Encode(row)
+		}
+		flush = func() error { return nil }
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(columns); err != nil {
+			return 0, err
+		}
+		record := make([]string, len(columns))
+		write = func(values []interface{}) error {
+			for i, v := range values {
+				record[i] = csvValue(v)
+			}
+			return cw.Write(record)
+		}
+		flush = func() error {
+			cw.Flush()
+			return cw.Error()
+		}
+	default:
+		return 0, fmt.Errorf("不支持的导出格式 %q (可选 %s / %s)", format, FormatJSONL, FormatCSV)
+	}
+
+	rows, err := db.WithContext(ctx).Table(table).Select(columns).Where("deleted_at IS NULL").Order("id").Rows()
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	n := 0
+	values := make([]interface{}, len(columns))
+	ptrs := make([]interface{}, len(columns))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(ptrs...); err != nil {
+			return n, err
+		}
+		for i, v := range values {
+			// 不同驱动的文本列可能以 []byte 返回
+			if b, ok := v.([]byte); ok {
+				values[i] = string(b)
+			}
+		}
+		if err := write(values); err != nil {
+			return n, err
+		}
+		n++
+	}
+	if err := rows.Err(); err != nil {
+		return n, err
+	}
+	return n, flush()
+}
+
+// csvValue 把一列的值转换为 CSV 文本，NULL 为空字符串
+func csvValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case time.Time:
+		return v.Format(time.RFC3339)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
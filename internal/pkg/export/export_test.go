@@ -0,0 +1,65 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/model"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/database"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/logger"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/migrate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrite(t *testing.T) {
+	logger.InitLogger()
+	ctx := context.Background()
+	db, err := database.Open(database.DriverSQLite, "file:export_test?mode=memory&cache=shared")
+	require.NoError(t, err)
+	m, err := migrate.New(db)
+	require.NoError(t, err)
+	_, err = m.Up(ctx)
+	require.NoError(t, err)
+
+	users := []model.User{
+		{Username: "2024000001", Password: "hash-1", Nickname: "甲"},
+		{Username: "2024000002", Password: "hash-2", Nickname: "乙,\"丙\""},
+		{Username: "2024000003", Password: "hash-3", Nickname: "已删除"},
+	}
+	require.NoError(t, db.Create(&users).Error)
+	require.NoError(t, db.Delete(&users[2]).Error)
+
+	var buf bytes.Buffer
+	n, err := Write(ctx, db, "users", FormatJSONL, &buf)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n, "已删除的用户不导出")
+	assert.NotContains(t, buf.String(), "hash-", "不导出密码")
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	var row map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &row))
+	assert.Equal(t, "2024000002", row["username"])
+	assert.Equal(t, "乙,\"丙\"", row["nickname"])
+	assert.Nil(t, row["bio"])
+
+	buf.Reset()
+	n, err = Write(ctx, db, "users", FormatCSV, &buf)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	assert.Equal(t, tables["users"], records[0])
+	assert.Equal(t, "乙,\"丙\"", records[2][2])
+	assert.Equal(t, "", records[2][6], "NULL 导出为空字符串")
+
+	_, err = Write(ctx, db, "users", "xml", &buf)
+	assert.Error(t, err)
+	_, err = Write(ctx, db, "moderation_records", FormatCSV, &buf)
+	assert.Error(t, err)
+}
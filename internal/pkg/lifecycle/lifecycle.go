@@ -0,0 +1,146 @@
+// Package lifecycle 按顺序启动服务的各个组件 (数据库、迁移、数据填充、后台协程、HTTP 服务)，
+// 收到退出信号或某个组件运行出错时，在限定时间内按相反的顺序停止它们。
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/logger"
+)
+
+// Hook 是一个组件的启动与停止步骤，Start 和 Stop 都可以为 nil (如只需启动一次的数据填充)
+type Hook struct {
+	Name  string
+	Start func(ctx context.Context) error
+	Stop  func(ctx context.Context) error
+}
+
+// Manager 管理组件的启动顺序与停止顺序
+type Manager struct {
+	hooks   []Hook
+	started int // 已成功启动的组件数，停止时只停止这些组件
+
+	failOnce sync.Once
+	failed   chan error // 组件在运行过程中出错时写入，触发停止
+}
+
+// New 创建生命周期管理器
+func New() *Manager {
+	return &Manager{failed: make(chan error, 1)}
+}
+
+// Append 添加一个组件，组件按添加顺序启动、按相反顺序停止
+func (m *Manager) Append(h Hook) {
+	m.hooks = append(m.hooks, h)
+}
+
+// Fail 报告组件在运行过程中出错 (如 HTTP 服务意外退出)，Run 会随即开始停止流程，只记录第一个错误
+func (m *Manager) Fail(name string, err error) {
+	m.failOnce.Do(func() {
+		m.failed <- fmt.Errorf("%s: %w", name, err)
+	})
+}
+
+// Start 按顺序启动组件；某个组件启动失败时停止已启动的组件并返回错误
+func (m *Manager) Start(ctx context.Context) error {
+	for _, h := range m.hooks[m.started:] {
+		if h.Start != nil {
+			logger.Log.Infow("正在启动", "component", h.Name)
+			if err := h.Start(ctx); err != nil {
+				startErr := fmt.Errorf("启动 %s 失败: %w", h.Name, err)
+				if stopErr := m.Stop(context.Background()); stopErr != nil {
+					return errors.Join(startErr, stopErr)
+				}
+				return startErr
+			}
+		}
+		m.started++
+	}
+	return nil
+}
+
+// Stop 按启动的相反顺序停止已启动的组件。某个组件停止失败或超时不影响其余组件，返回全部错误
+func (m *Manager) Stop(ctx context.Context) error {
+	var errs []error
+	for ; m.started > 0; m.started-- {
+		h := m.hooks[m.started-1]
+		if h.Stop == nil {
+			continue
+		}
+		logger.Log.Infow("正在停止", "component", h.Name)
+		if err := h.Stop(ctx); err != nil {
+			logger.Log.Errorw("停止组件失败", "component", h.Name, "error", err)
+			errs = append(errs, fmt.Errorf("停止 %s 失败: %w", h.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Run 启动全部组件，然后等待 ctx 结束 (通常是收到 SIGINT/SIGTERM) 或某个组件报告错误，
+// 再在 shutdownTimeout 内停止全部组件。返回启动、运行与停止过程中的错误
+func (m *Manager) Run(ctx context.Context, shutdownTimeout time.Duration) error {
+	if err := m.Start(ctx); err != nil {
+		return err
+	}
+
+	var runErr error
+	select {
+	case <-ctx.Done():
+		logger.Log.Infow("收到退出信号，开始停止服务", "timeout", shutdownTimeout)
+	case runErr = <-m.failed:
+		logger.Log.Errorw("组件运行出错，开始停止服务", "error", runErr)
+	}
+
+	stopCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	return errors.Join(runErr, m.Stop(stopCtx))
+}
+
+// Serve 添加 HTTP 服务组件：启动时先监听端口 (端口被占用等错误会使启动失败)，再在后台处理请求；
+// 停止时不再接受新连接，等待处理中的请求完成，超过期限后强制关闭剩余连接
+func (m *Manager) Serve(name string, srv *http.Server) {
+	m.Append(Hook{
+		Name: name,
+		Start: func(ctx context.Context) error {
+			ln, err := net.Listen("tcp", srv.Addr)
+			if err != nil {
+				return err
+			}
+			logger.Log.Infow("HTTP 服务开始监听", "component", name, "addr", ln.Addr().String())
+			go func() {
+				if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					m.Fail(name, err)
+				}
+			}()
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			if err := srv.Shutdown(ctx); err != nil {
+				// 超过期限仍有未完成的请求，强制关闭
+				return errors.Join(err, srv.Close())
+			}
+			return nil
+		},
+	})
+}
+
+// Wait 在后台执行不支持 ctx 的阻塞停止函数 (如等待协程退出)，ctx 结束时不再等待并返回 ctx 的错误
+func Wait(ctx context.Context, stop func()) error {
+	done := make(chan struct{})
+	go func() {
+		stop()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
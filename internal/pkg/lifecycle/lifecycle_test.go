@@ -0,0 +1,159 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recorder 记录组件的启动与停止顺序
+type recorder struct{ events []string }
+
+func (r *recorder) hook(name string, startErr error) Hook {
+	return Hook{
+		Name: name,
+		Start: func(ctx context.Context) error {
+			r.events = append(r.events, "start "+name)
+			return startErr
+		},
+		Stop: func(ctx context.Context) error {
+			r.events = append(r.events, "stop "+name)
+			return nil
+		},
+	}
+}
+
+func TestManager(t *testing.T) {
+	logger.InitLogger()
+
+	t.Run("按顺序启动、按相反顺序停止", func(t *testing.T) {
+		rec := &recorder{}
+		m := New()
+		m.Append(rec.hook("db", nil))
+		m.Append(Hook{Name: "seed", Start: func(ctx context.Context) error {
+			rec.events = append(rec.events, "start seed")
+			return nil
+		}})
+		m.Append(rec.hook("http", nil))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel() // 模拟收到退出信号
+		require.NoError(t, m.Run(ctx, time.Second))
+		assert.Equal(t, []string{"start db", "start seed", "start http", "stop http", "stop db"}, rec.events)
+	})
+
+	t.Run("启动失败时停止已启动的组件", func(t *testing.T) {
+		rec := &recorder{}
+		m := New()
+		m.Append(rec.hook("db", nil))
+		m.Append(rec.hook("migrations", errors.New("版本不匹配")))
+		m.Append(rec.hook("http", nil))
+
+		err := m.Run(context.Background(), time.Second)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "migrations")
+		assert.Equal(t, []string{"start db", "start migrations", "stop db"}, rec.events)
+	})
+
+	t.Run("组件运行出错时停止服务并返回错误", func(t *testing.T) {
+		rec := &recorder{}
+		m := New()
+		m.Append(rec.hook("db", nil))
+		m.Append(Hook{Name: "worker", Start: func(ctx context.Context) error {
+			go m.Fail("worker", errors.New("崩溃"))
+			return nil
+		}})
+
+		err := m.Run(context.Background(), time.Second)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "崩溃")
+		assert.Equal(t, []string{"start db", "stop db"}, rec.events)
+	})
+
+	t.Run("停止超时", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		release := make(chan struct{})
+		defer close(release)
+		assert.ErrorIs(t, Wait(ctx, func() { <-release }), context.DeadlineExceeded)
+		assert.NoError(t, Wait(context.Background(), func() {}))
+	})
+}
+
+func TestServe(t *testing.T) {
+	logger.InitLogger()
+
+	started := make(chan struct{})
+	finish := make(chan struct{})
+	srv := &http.Server{
+		Addr: "127.0.0.1:0",
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			<-finish
+			io.WriteString(w, "done")
+		}),
+	}
+
+	// 先占用一个端口，确认监听失败会使启动失败
+	busy, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer busy.Close()
+	m := New()
+	m.Serve("http", &http.Server{Addr: busy.Addr().String()})
+	assert.Error(t, m.Start(context.Background()))
+
+	// 用确定的端口启动，便于客户端连接
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv.Addr = ln.Addr().String()
+	ln.Close()
+
+	m = New()
+	m.Serve("http", srv)
+	require.NoError(t, m.Start(context.Background()))
+
+	type result struct {
+		body string
+		err  error
+	}
+	resp := make(chan result, 1)
+	go func() {
+		res, err := http.Get("http://" + srv.Addr)
+		if err != nil {
+			resp <- result{err: err}
+			return
+		}
+		defer res.Body.Close()
+		body, err := io.ReadAll(res.Body)
+		resp <- result{string(body), err}
+	}()
+	<-started
+
+	// 停止时等待处理中的请求完成
+	stopped := make(chan error, 1)
+	go func() { stopped <- m.Stop(context.Background()) }()
+	time.Sleep(50 * time.Millisecond)
+	select {
+	case <-stopped:
+		t.Fatal("处理中的请求尚未完成，服务不应停止")
+	default:
+	}
+	close(finish)
+
+	got := <-resp
+	require.NoError(t, got.err)
+	assert.Equal(t, "done", got.body)
+	require.NoError(t, <-stopped)
+
+	// 停止后不再接受新连接
+	_, err = http.Get("http://" + srv.Addr)
+	assert.Error(t, err)
+}
@@ -1,21 +1,27 @@
 package logger
 
 import (
+	"context"
+	"errors"
 	"log"
-	"os"
+	"syscall"
 
 	"go.uber.org/zap"
 )
 
-// InitLogger 初始化全局 zap 日志记录器
+// InitLogger 以开发配置初始化全局 zap 日志记录器，供测试使用
 func InitLogger() {
+	InitLoggerWithMode(false)
+}
+
+// InitLoggerWithMode 初始化全局 zap 日志记录器，release 由配置的运行模式 (GIN_MODE) 决定
+func InitLoggerWithMode(release bool) {
 	var (
 		logger *zap.Logger
 		err    error
 	)
 
-	// 根据 GIN_MODE 环境变量判断运行模式：release 
-	if os.Getenv("GIN_MODE") == "release" {
+	if release {
 		// 生产环境，使用更严格的生产配置（JSON 格式）
 		logger, err = zap.NewProduction()
 	} else {
@@ -33,9 +39,37 @@ func InitLogger() {
 	Log.Info("zap logger 初始化成功")
 }
 
+// Sync 把缓冲中的日志写出，服务退出前调用。
+// 输出到终端时 fsync 会返回 EINVAL/ENOTTY，这类错误不影响日志内容，直接忽略
+func Sync() error {
+	if Log == nil {
+		return nil
+	}
+	if err := Log.Sync(); err != nil && !errors.Is(err, syscall.EINVAL) && !errors.Is(err, syscall.ENOTTY) {
+		return err
+	}
+	return nil
+}
+
 // Log 是包级导出的 SugaredLogger，供项目其他包直接调用
 var Log *zap.SugaredLogger
 
+type ctxKey struct{}
+
+// WithContext 返回带有日志记录器 l 的 ctx，之后 FromContext(ctx) 返回 l
+func WithContext(ctx context.Context, l *zap.SugaredLogger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, l)
+}
+
+// FromContext 返回 ctx 中的请求级日志记录器 (带请求 ID、用户 ID、路由等字段)，
+// ctx 中没有时 (如后台任务) 返回全局的 Log
+func FromContext(ctx context.Context) *zap.SugaredLogger {
+	if l, ok := ctx.Value(ctxKey{}).(*zap.SugaredLogger); ok {
+		return l
+	}
+	return Log
+}
+
 // GetLogger 返回底层 *zap.Logger
 func GetLogger() *zap.Logger {
 	return zap.L()
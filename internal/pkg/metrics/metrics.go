@@ -0,0 +1,216 @@
+// Package metrics 定义服务的 Prometheus 指标：HTTP 请求、数据库查询、内容审核与业务事件，
+// 由 /metrics 接口输出。指标注册在独立的 Registry 中，不使用全局默认的注册表
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"gorm.io/gorm"
+)
+
+// namespace 是所有业务指标名的前缀
+const namespace = "wishwall"
+
+// Registry 是本服务的指标注册表，包含 Go 运行时与进程指标
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP 请求耗时，按路由模板、方法与状态码区分",
+		Buckets:   []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+	}, []string{"method", "route", "status"})
+	httpInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "http_requests_in_flight",
+		Help:      "正在处理的 HTTP 请求数",
+	})
+
+	dbDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "GORM 数据库操作耗时，按操作类型、表与结果区分",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"operation", "table", "status"})
+
+	moderationDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "moderation_check_duration_seconds",
+		Help:      "审核器单次审核的耗时，按审核器与结论区分",
+		Buckets:   []float64{.001, .01, .05, .1, .25, .5, 1, 2, 4, 8, 16},
+	}, []string{"provider", "verdict"})
+	llmRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "moderation_llm_requests_total",
+		Help:      "调用大模型 API 的次数 (含重试)，按结果区分",
+	}, []string{"result"})
+	llmTokens = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "moderation_llm_tokens_total",
+		Help:      "大模型审核消耗的 token 数，按提示词 (prompt) 与回答 (completion) 区分",
+	}, []string{"type"})
+
+	wishesCreated = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "wishes_created_total",
+		Help:      "发布的愿望数",
+	})
+	likesToggled = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "likes_toggled_total",
+		Help:      "点赞与取消点赞的次数",
+	}, []string{"action"})
+	commentsPosted = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "comments_posted_total",
+		Help:      "发布的评论数，按评论 (comment) 与回复 (reply) 区分",
+	}, []string{"kind"})
+	registrations = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "registrations_total",
+		Help:      "注册的用户数",
+	})
+	loginLockouts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "login_lockouts_total",
+		Help:      "因登录失败次数过多而锁定的次数，按学号 (account) 与 IP (ip) 区分",
+	}, []string{"scope"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpDuration, httpInFlight, dbDuration,
+		moderationDuration, llmRequests, llmTokens,
+		wishesCreated, likesToggled, commentsPosted, registrations, loginLockouts,
+	)
+}
+
+// Handler 返回输出全部指标的 HTTP 处理器
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// knownMethods 是记录到指标中的请求方法，其余方法归为 OTHER，避免任意方法名产生大量时间序列
+var knownMethods = map[string]bool{
+	http.MethodGet: true, http.MethodPost: true, http.MethodPut: true, http.MethodDelete: true,
+	http.MethodPatch: true, http.MethodHead: true, http.MethodOptions: true,
+}
+
+// HTTPStarted 记录开始处理一个请求，返回的函数在请求处理完成后调用。
+// route 为路由模板 (如 /api/wishes/:id/like)，未匹配任何路由时传空字符串
+func HTTPStarted() func(method, route string, status int) {
+	start := time.Now()
+	httpInFlight.Inc()
+	return func(method, route string, status int) {
+		httpInFlight.Dec()
+		if !knownMethods[method] {
+			method = "OTHER"
+		}
+		if route == "" {
+			route = "unmatched"
+		}
+		httpDuration.WithLabelValues(method, route, strconv.Itoa(status)).Observe(time.Since(start).Seconds())
+	}
+}
+
+// ObserveModeration 记录一次审核的结论与耗时，verdict 为 approved / rejected / ambiguous / unavailable / error
+func ObserveModeration(provider, verdict string, d time.Duration) {
+	moderationDuration.WithLabelValues(provider, verdict).Observe(d.Seconds())
+}
+
+// ObserveLLMRequest 记录一次大模型 API 调用及其消耗的 token
+func ObserveLLMRequest(err error, promptTokens, completionTokens int) {
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	llmRequests.WithLabelValues(result).Inc()
+	llmTokens.WithLabelValues("prompt").Add(float64(promptTokens))
+	llmTokens.WithLabelValues("completion").Add(float64(completionTokens))
+}
+
+// WishCreated 记录发布了一条愿望
+func WishCreated() { wishesCreated.Inc() }
+
+// LikeToggled 记录一次点赞 (liked 为 true) 或取消点赞
+func LikeToggled(liked bool) {
+	action := "unlike"
+	if liked {
+		action = "like"
+	}
+	likesToggled.WithLabelValues(action).Inc()
+}
+
+// CommentPosted 记录发布了一条评论或回复
+func CommentPosted(reply bool) {
+	kind := "comment"
+	if reply {
+		kind = "reply"
+	}
+	commentsPosted.WithLabelValues(kind).Inc()
+}
+
+// UserRegistered 记录注册了一个用户
+func UserRegistered() { registrations.Inc() }
+
+// LoginLocked 记录一次登录锁定，scope 为 account 或 ip
+func LoginLocked(scope string) { loginLockouts.WithLabelValues(scope).Inc() }
+
+// startKey 是 GORM 语句中记录开始时间的键
+const startKey = "metrics:start"
+
+// InstrumentDB 为数据库连接注册 GORM 回调，记录每次增删改查的耗时；同时输出连接池状态。
+// 每个连接只能调用一次
+func InstrumentDB(db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	if err := Registry.Register(collectors.NewDBStatsCollector(sqlDB, db.Dialector.Name())); err != nil {
+		return err
+	}
+
+	start := func(tx *gorm.DB) { tx.InstanceSet(startKey, time.Now()) }
+	observe := func(operation string) func(tx *gorm.DB) {
+		return func(tx *gorm.DB) {
+			v, ok := tx.InstanceGet(startKey)
+			if !ok {
+				return
+			}
+			status := "ok"
+			if tx.Error != nil && !errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+				status = "error"
+			}
+			table := tx.Statement.Table
+			if table == "" {
+				table = "unknown" // 原生 SQL
+			}
+			dbDuration.WithLabelValues(operation, table, status).Observe(time.Since(v.(time.Time)).Seconds())
+		}
+	}
+
+	cb := db.Callback()
+	return errors.Join(
+		cb.Create().Before("gorm:create").Register("metrics:before_create", start),
+		cb.Create().After("gorm:create").Register("metrics:after_create", observe("create")),
+		cb.Query().Before("gorm:query").Register("metrics:before_query", start),
+		cb.Query().After("gorm:query").Register("metrics:after_query", observe("query")),
+		cb.Update().Before("gorm:update").Register("metrics:before_update", start),
+		cb.Update().After("gorm:update").Register("metrics:after_update", observe("update")),
+		cb.Delete().Before("gorm:delete").Register("metrics:before_delete", start),
+		cb.Delete().After("gorm:delete").Register("metrics:after_delete", observe("delete")),
+		cb.Row().Before("gorm:row").Register("metrics:before_row", start),
+		cb.Row().After("gorm:row").Register("metrics:after_row", observe("row")),
+		cb.Raw().Before("gorm:raw").Register("metrics:before_raw", start),
+		cb.Raw().After("gorm:raw").Register("metrics:after_raw", observe("raw")),
+	)
+}
@@ -0,0 +1,79 @@
+package metrics
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/database"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// histogramCount 返回直方图中某组标签的样本数，没有该组标签时为 0
+func histogramCount(t *testing.T, name string, labels map[string]string) uint64 {
+	families, err := Registry.Gather()
+	require.NoError(t, err)
+	for _, f := range families {
+		if f.GetName() != name {
+			continue
+		}
+	next:
+		for _, m := range f.GetMetric() {
+			for _, l := range m.GetLabel() {
+				if v, ok := labels[l.GetName()]; ok && v != l.GetValue() {
+					continue next
+				}
+			}
+			return m.GetHistogram().GetSampleCount()
+		}
+	}
+	return 0
+}
+
+func TestInstrumentDB(t *testing.T) {
+	db, err := database.Open(database.DriverSQLite, "file:metrics_test?mode=memory&cache=shared")
+	require.NoError(t, err)
+	require.NoError(t, InstrumentDB(db))
+
+	type item struct {
+		ID   uint
+		Name string
+	}
+	require.NoError(t, db.AutoMigrate(&item{}))
+	require.NoError(t, db.Create(&item{Name: "a"}).Error)
+	var got item
+	require.NoError(t, db.First(&got).Error)
+	assert.Error(t, db.Table("missing").Find(&[]item{}).Error)
+
+	assert.EqualValues(t, 1, histogramCount(t, "wishwall_db_query_duration_seconds", map[string]string{"operation": "create", "table": "items", "status": "ok"}))
+	assert.EqualValues(t, 1, histogramCount(t, "wishwall_db_query_duration_seconds", map[string]string{"operation": "query", "table": "items", "status": "ok"}))
+	assert.EqualValues(t, 1, histogramCount(t, "wishwall_db_query_duration_seconds", map[string]string{"operation": "query", "table": "missing", "status": "error"}))
+
+	// 连接池状态
+	n, err := testutil.GatherAndCount(Registry, "go_sql_open_connections")
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+}
+
+func TestObserve(t *testing.T) {
+	done := HTTPStarted()
+	assert.Equal(t, 1.0, testutil.ToFloat64(httpInFlight))
+	done("BREW", "", 404)
+	assert.Equal(t, 0.0, testutil.ToFloat64(httpInFlight))
+	assert.EqualValues(t, 1, histogramCount(t, "wishwall_http_request_duration_seconds", map[string]string{"method": "OTHER", "route": "unmatched", "status": "404"}))
+
+	ObserveLLMRequest(nil, 120, 8)
+	ObserveLLMRequest(errors.New("timeout"), 0, 0)
+	assert.Equal(t, 1.0, testutil.ToFloat64(llmRequests.WithLabelValues("ok")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(llmRequests.WithLabelValues("error")))
+	assert.Equal(t, 120.0, testutil.ToFloat64(llmTokens.WithLabelValues("prompt")))
+
+	LikeToggled(true)
+	LikeToggled(false)
+	LikeToggled(true)
+	assert.Equal(t, 2.0, testutil.ToFloat64(likesToggled.WithLabelValues("like")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(likesToggled.WithLabelValues("unlike")))
+	CommentPosted(true)
+	assert.Equal(t, 1.0, testutil.ToFloat64(commentsPosted.WithLabelValues("reply")))
+}
@@ -0,0 +1,464 @@
+// Package migrate 管理数据库表结构的版本。
+//
+// 迁移脚本以 SQL 文件的形式嵌入程序，MySQL 和 SQLite 各有一套 (sql/<方言>/)，
+// 文件名为 <版本号>_<名称>.up.sql / <版本号>_<名称>.down.sql，版本号递增。
+// 已执行的版本记录在 schema_migrations 表中；执行迁移前会在 schema_migrations_lock 表中加锁，
+// 防止多个实例同时迁移。服务启动时只调用 Check 校验版本，不会修改表结构。
+package migrate
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/logger"
+	"gorm.io/gorm"
+)
+
+//go:embed sql
+var scripts embed.FS
+
+var (
+	// ErrLocked 表示已有其他进程在执行迁移
+	ErrLocked = errors.New("数据库迁移已被锁定")
+	// ErrPending 表示数据库缺少程序需要的迁移
+	ErrPending = errors.New("数据库有未执行的迁移")
+	// ErrUnknownVersion 表示数据库中存在程序不认识的版本，通常是用较旧的程序连接了已升级的数据库
+	ErrUnknownVersion = errors.New("数据库版本比程序新")
+)
+
+// Migration 是一个版本的迁移脚本
+type Migration struct {
+	Version uint
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status 是某个版本的执行情况
+type Status struct {
+	Version   uint
+	Name      string
+	Applied   bool
+	AppliedAt *time.Time
+	Unknown   bool // 数据库中有记录，但程序中没有对应的脚本
+}
+
+// appliedVersion 是 schema_migrations 表中的一行
+type appliedVersion struct {
+	Version   uint      `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"size:255;not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+func (appliedVersion) TableName() string { return "schema_migrations" }
+
+// migrationLock 是 schema_migrations_lock 表中的锁，最多只有一行
+type migrationLock struct {
+	ID       uint      `gorm:"primaryKey;autoIncrement:false"`
+	Owner    string    `gorm:"size:255;not null"`
+	LockedAt time.Time `gorm:"not null"`
+}
+
+func (migrationLock) TableName() string { return "schema_migrations_lock" }
+
+const lockID = 1
+
+// Migrator 对一个数据库执行迁移
+type Migrator struct {
+	db         *gorm.DB
+	dialect    string
+	migrations []Migration
+}
+
+// New 按数据库方言加载内置的迁移脚本
+func New(db *gorm.DB) (*Migrator, error) {
+	dialect := db.Dialector.Name()
+	migrations, err := Load(scripts, path.Join("sql", dialect))
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, dialect: dialect, migrations: migrations}, nil
+}
+
+// Load 读取目录下的迁移脚本并按版本号排序，每个版本必须同时有 up 和 down 脚本
+func Load(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("读取迁移脚本目录 %s 失败: %w", dir, err)
+	}
+	byVersion := make(map[uint]*Migration)
+	for _, entry := range entries {
+		file := entry.Name()
+		var direction string
+		switch {
+		case strings.HasSuffix(file, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(file, ".down.sql"):
+			direction = "down"
+		default:
+			continue
+		}
+		base := strings.TrimSuffix(file, "."+direction+".sql")
+		prefix, name, ok := strings.Cut(base, "_")
+		version, err := strconv.ParseUint(prefix, 10, 32)
+		if !ok || err != nil || version == 0 {
+			return nil, fmt.Errorf("迁移脚本文件名不合法: %s (应为 <版本号>_<名称>.up.sql)", file)
+		}
+		content, err := fs.ReadFile(fsys, path.Join(dir, file))
+		if err != nil {
+			return nil, err
+		}
+
+		m := byVersion[uint(version)]
+		if m == nil {
+			m = &Migration{Version: uint(version), Name: name}
+			byVersion[uint(version)] = m
+		} else if m.Name != name {
+			return nil, fmt.Errorf("版本 %d 有多个迁移脚本: %s / %s", version, m.Name, name)
+		}
+		if direction == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("版本 %d (%s) 缺少 up 或 down 脚本", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Latest 返回程序中最新的版本号，没有迁移时为 0
+func (m *Migrator) Latest() uint {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Version 返回数据库当前的版本号，即已执行的最大版本，从未迁移过时为 0
+func (m *Migrator) Version(ctx context.Context) (uint, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var version uint
+	for v := range applied {
+		version = max(version, v)
+	}
+	return version, nil
+}
+
+// Status 列出所有版本的执行情况，包括数据库中存在但程序不认识的版本
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		s := Status{Version: mig.Version, Name: mig.Name}
+		if row, ok := applied[mig.Version]; ok {
+			s.Applied, s.AppliedAt = true, &row.AppliedAt
+			delete(applied, mig.Version)
+		}
+		statuses = append(statuses, s)
+	}
+	for _, row := range applied {
+		statuses = append(statuses, Status{Version: row.Version, Name: row.Name, Applied: true, AppliedAt: &row.AppliedAt, Unknown: true})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, nil
+}
+
+// Check 校验数据库的版本与程序一致，供服务启动时调用
+func (m *Migrator) Check(ctx context.Context) error {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+	var pending []string
+	for _, s := range statuses {
+		if s.Unknown {
+			return fmt.Errorf("%w: 数据库中有版本 %d (%s)，程序最新版本为 %d", ErrUnknownVersion, s.Version, s.Name, m.Latest())
+		}
+		if !s.Applied {
+			pending = append(pending, fmt.Sprintf("%d_%s", s.Version, s.Name))
+		}
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%w: %s", ErrPending, strings.Join(pending, ", "))
+	}
+	return nil
+}
+
+// Up 执行所有未执行的迁移，返回本次执行的版本
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	return m.To(ctx, m.Latest())
+}
+
+// Down 回滚最近执行的 steps 个版本
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	if steps <= 0 {
+		return nil, nil
+	}
+	var done []Migration
+	err := m.withLock(ctx, func() error {
+		applied, err := m.applied(ctx)
+		if err != nil {
+			return err
+		}
+		for i := len(m.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+			mig := m.migrations[i]
+			if _, ok := applied[mig.Version]; !ok {
+				continue
+			}
+			if err := m.run(ctx, mig, false); err != nil {
+				return err
+			}
+			done = append(done, mig)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// To 把数据库迁移到指定版本：高于当前版本时向上执行，低于时回滚，0 表示全部回滚
+func (m *Migrator) To(ctx context.Context, target uint) ([]Migration, error) {
+	if target != 0 && !m.known(target) {
+		return nil, fmt.Errorf("不存在版本 %d", target)
+	}
+	var done []Migration
+	err := m.withLock(ctx, func() error {
+		applied, err := m.applied(ctx)
+		if err != nil {
+			return err
+		}
+		for v := range applied {
+			if !m.known(v) {
+				return fmt.Errorf("%w: 数据库中有版本 %d，请使用新版程序迁移", ErrUnknownVersion, v)
+			}
+		}
+		// 先回滚高于目标的版本 (从新到旧)，再执行不高于目标且未执行的版本 (从旧到新)
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			mig := m.migrations[i]
+			if _, ok := applied[mig.Version]; ok && mig.Version > target {
+				if err := m.run(ctx, mig, false); err != nil {
+					return err
+				}
+				done = append(done, mig)
+			}
+		}
+		for _, mig := range m.migrations {
+			if _, ok := applied[mig.Version]; !ok && mig.Version <= target {
+				if err := m.run(ctx, mig, true); err != nil {
+					return err
+				}
+				done = append(done, mig)
+			}
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Unlock 强制释放迁移锁，用于迁移进程崩溃后遗留的锁
+func (m *Migrator) Unlock(ctx context.Context) error {
+	if err := m.ensureTables(ctx); err != nil {
+		return err
+	}
+	return m.db.WithContext(ctx).Delete(&migrationLock{ID: lockID}).Error
+}
+
+func (m *Migrator) known(version uint) bool {
+	for _, mig := range m.migrations {
+		if mig.Version == version {
+			return true
+		}
+	}
+	return false
+}
+
+// applied 读取已执行的版本，schema_migrations 表不存在时视为没有执行过任何迁移
+func (m *Migrator) applied(ctx context.Context) (map[uint]appliedVersion, error) {
+	applied := make(map[uint]appliedVersion)
+	if !m.db.WithContext(ctx).Migrator().HasTable(&appliedVersion{}) {
+		return applied, nil
+	}
+	var rows []appliedVersion
+	if err := m.db.WithContext(ctx).Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("读取 schema_migrations 失败: %w", err)
+	}
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+	return applied, nil
+}
+
+// ensureTables 创建迁移工具自身使用的两张表
+func (m *Migrator) ensureTables(ctx context.Context) error {
+	for _, stmt := range []string{
+		"CREATE TABLE IF NOT EXISTS schema_migrations (version BIGINT NOT NULL PRIMARY KEY, name VARCHAR(255) NOT NULL, applied_at DATETIME NOT NULL)",
+		"CREATE TABLE IF NOT EXISTS schema_migrations_lock (id INT NOT NULL PRIMARY KEY, owner VARCHAR(255) NOT NULL, locked_at DATETIME NOT NULL)",
+	} {
+		if err := m.db.WithContext(ctx).Exec(stmt).Error; err != nil {
+			return fmt.Errorf("创建迁移记录表失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// withLock 在持有迁移锁期间执行 fn。锁是 schema_migrations_lock 表中 id 为 1 的行，
+// 利用主键唯一性保证同一时间只有一个进程能插入成功
+func (m *Migrator) withLock(ctx context.Context, fn func() error) error {
+	if err := m.ensureTables(ctx); err != nil {
+		return err
+	}
+	hostname, _ := os.Hostname()
+	lock := migrationLock{ID: lockID, Owner: fmt.Sprintf("%s:%d", hostname, os.Getpid()), LockedAt: time.Now()}
+	if err := m.db.WithContext(ctx).Create(&lock).Error; err != nil {
+		var holder migrationLock
+		if m.db.WithContext(ctx).First(&holder, lockID).Error == nil {
+			return fmt.Errorf("%w: 持有者 %s，加锁时间 %s (确认该进程已退出后可执行 migrate unlock)",
+				ErrLocked, holder.Owner, holder.LockedAt.Format(time.DateTime))
+		}
+		return fmt.Errorf("获取迁移锁失败: %w", err)
+	}
+	defer func() {
+		// 使用新的 context，保证 ctx 被取消时仍能释放锁
+		if err := m.db.WithContext(context.Background()).Delete(&migrationLock{ID: lockID}).Error; err != nil {
+			logger.Log.Errorw("释放迁移锁失败，请执行 migrate unlock", "error", err)
+		}
+	}()
+	return fn()
+}
+
+// run 在同一个数据库连接上执行一个版本的 up 或 down 脚本，并更新 schema_migrations
+func (m *Migrator) run(ctx context.Context, mig Migration, up bool) error {
+	script, direction := mig.Up, "up"
+	if !up {
+		script, direction = mig.Down, "down"
+	}
+	statements := splitStatements(script)
+	start := time.Now()
+
+	record := func(tx *gorm.DB) error {
+		if up {
+			return tx.Create(&appliedVersion{Version: mig.Version, Name: mig.Name, AppliedAt: time.Now()}).Error
+		}
+		return tx.Delete(&appliedVersion{Version: mig.Version}).Error
+	}
+
+	// 脚本中可能用到会话变量 (MySQL 的 SET @x / PREPARE)，所有语句必须在同一个连接上执行
+	err := m.db.WithContext(ctx).Connection(func(conn *gorm.DB) error {
+		// NewDB 让每次调用都从新的语句开始，仍然使用同一个连接
+		conn = conn.Session(&gorm.Session{NewDB: true})
+		if up && mig.Version == initVersion {
+			if err := m.takeover(conn); err != nil {
+				return err
+			}
+		}
+		if m.dialect == "sqlite" {
+			return runSQLite(conn, statements, record)
+		}
+		// MySQL 的 DDL 会隐式提交，无法放进事务，脚本需要保证可以重复执行
+		for i, stmt := range statements {
+			if err := conn.Exec(stmt).Error; err != nil {
+				return fmt.Errorf("第 %d 条语句执行失败: %w", i+1, err)
+			}
+		}
+		return record(conn)
+	})
+	if err != nil {
+		return fmt.Errorf("迁移 %d_%s (%s) 失败: %w", mig.Version, mig.Name, direction, err)
+	}
+	logger.Log.Infow("数据库迁移完成",
+		"version", mig.Version,
+		"name", mig.Name,
+		"direction", direction,
+		"duration", time.Since(start).String(),
+	)
+	return nil
+}
+
+// runSQLite 在一个事务中执行脚本。SQLite 修改外键需要重建表，按官方文档的做法，
+// 执行期间关闭外键检查 (PRAGMA foreign_keys 在事务内无效，必须在事务外设置)，提交前再整体校验一次。
+// 历史数据中可能已有孤儿数据 (由后续迁移清理)，只有迁移引入了新的外键冲突时才回滚
+func runSQLite(conn *gorm.DB, statements []string, record func(tx *gorm.DB) error) error {
+	var foreignKeys int
+	if err := conn.Raw("PRAGMA foreign_keys").Scan(&foreignKeys).Error; err != nil {
+		return err
+	}
+	if foreignKeys == 1 {
+		if err := conn.Exec("PRAGMA foreign_keys = OFF").Error; err != nil {
+			return err
+		}
+		defer conn.Exec("PRAGMA foreign_keys = ON")
+	}
+
+	return conn.Transaction(func(tx *gorm.DB) error {
+		before, err := foreignKeyViolations(tx)
+		if err != nil {
+			return err
+		}
+		for i, stmt := range statements {
+			if err := tx.Exec(stmt).Error; err != nil {
+				return fmt.Errorf("第 %d 条语句执行失败: %w", i+1, err)
+			}
+		}
+		after, err := foreignKeyViolations(tx)
+		if err != nil {
+			return err
+		}
+		if len(after) > len(before) {
+			return fmt.Errorf("迁移引入了 %d 处外键约束冲突，例如 %v", len(after)-len(before), after[0])
+		}
+		return record(tx)
+	})
+}
+
+func foreignKeyViolations(tx *gorm.DB) ([]map[string]interface{}, error) {
+	var violations []map[string]interface{}
+	err := tx.Raw("PRAGMA foreign_key_check").Scan(&violations).Error
+	return violations, err
+}
+
+// splitStatements 按行尾的分号拆分脚本，忽略 -- 开头的注释行。
+// 脚本中的字符串不能在行尾包含分号
+func splitStatements(script string) []string {
+	var (
+		statements []string
+		current    strings.Builder
+	)
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		current.WriteString(line)
+		current.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			statements = append(statements, strings.TrimSuffix(strings.TrimSpace(current.String()), ";"))
+			current.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(current.String()); rest != "" {
+		statements = append(statements, rest)
+	}
+	return statements
+}
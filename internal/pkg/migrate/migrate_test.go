@@ -0,0 +1,338 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/model"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/logger"
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+var dbSeq int
+
+// openSQLite 打开一个独立的内存数据库
+func openSQLite(t *testing.T, foreignKeys bool) *gorm.DB {
+	t.Helper()
+	dbSeq++
+	dsn := fmt.Sprintf("file:migrate_test_%d?mode=memory&cache=shared", dbSeq)
+	if foreignKeys {
+		dsn += "&_pragma=foreign_keys(1)"
+	}
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+func newMigrator(t *testing.T, db *gorm.DB) *Migrator {
+	t.Helper()
+	m, err := New(db)
+	require.NoError(t, err)
+	return m
+}
+
+func TestMigrator(t *testing.T) {
+	logger.InitLogger()
+	ctx := context.Background()
+
+	t.Run("迁移后的表结构覆盖全部模型字段", func(t *testing.T) {
+		db := openSQLite(t, true)
+		m := newMigrator(t, db)
+		assert.ErrorIs(t, m.Check(ctx), ErrPending)
+
+		done, err := m.Up(ctx)
+		require.NoError(t, err)
+		assert.Len(t, done, len(m.migrations))
+		require.NoError(t, m.Check(ctx))
+		version, err := m.Version(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, m.Latest(), version)
+
+		for _, mdl := range []interface{}{
+			&model.User{}, &model.Wish{}, &model.Like{}, &model.Comment{}, &model.WishTag{},
+			&model.ModerationRecord{}, &model.ModerationThreshold{}, &model.ModerationCacheEntry{},
+			&model.RemoderationJob{}, &model.RemoderationChange{}, &model.RefreshToken{}, &model.Session{}, &model.PasswordReset{},
+			&model.LoginFailure{}, &model.LoginLockout{},
+		} {
+			stmt := &gorm.Statement{DB: db}
+			require.NoError(t, stmt.Parse(mdl))
+			for _, field := range stmt.Schema.Fields {
+				if field.DBName != "" {
+					assert.True(t, db.Migrator().HasColumn(mdl, field.DBName), "%s.%s 缺少对应的列", stmt.Table, field.DBName)
+				}
+			}
+		}
+
+		done, err = m.Up(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, done, "重复执行 up 不应再执行任何迁移")
+	})
+
+	t.Run("删除愿望级联删除点赞评论和标签", func(t *testing.T) {
+		db := openSQLite(t, true)
+		_, err := newMigrator(t, db).Up(ctx)
+		require.NoError(t, err)
+
+		user := model.User{Username: "2024000001", Password: "x"}
+		require.NoError(t, db.Create(&user).Error)
+		wish := model.Wish{UserID: user.ID, Content: "愿望"}
+		require.NoError(t, db.Create(&wish).Error)
+		parent := model.Comment{WishID: wish.ID, UserID: user.ID, Content: "评论"}
+		require.NoError(t, db.Create(&parent).Error)
+		require.NoError(t, db.Create(&model.Comment{WishID: wish.ID, ParentID: &parent.ID, UserID: user.ID, Content: "回复"}).Error)
+		require.NoError(t, db.Create(&model.Like{WishID: wish.ID, UserID: user.ID}).Error)
+		require.NoError(t, db.Create(&model.WishTag{WishID: wish.ID, TagName: "学习"}).Error)
+
+		// 外键生效：不能给不存在的愿望点赞
+		assert.Error(t, db.Create(&model.Like{WishID: 9999, UserID: user.ID}).Error)
+
+		require.NoError(t, db.Unscoped().Delete(&model.Wish{}, wish.ID).Error)
+		for _, table := range []string{"likes", "comments", "wish_tags"} {
+			var count int64
+			require.NoError(t, db.Table(table).Count(&count).Error)
+			assert.Zero(t, count, table)
+		}
+	})
+
+	t.Run("回滚与迁移到指定版本", func(t *testing.T) {
+		db := openSQLite(t, true)
+		m := newMigrator(t, db)
+		_, err := m.Up(ctx)
+		require.NoError(t, err)
+
+		done, err := m.Down(ctx, 1)
+		require.NoError(t, err)
+		require.Len(t, done, 1)
+		assert.Equal(t, m.Latest(), done[0].Version)
+		assert.ErrorIs(t, m.Check(ctx), ErrPending)
+
+		_, err = m.To(ctx, 0)
+		require.NoError(t, err)
+		assert.False(t, db.Migrator().HasTable("wishes"))
+		version, _ := m.Version(ctx)
+		assert.Zero(t, version)
+
+		_, err = m.To(ctx, m.Latest())
+		require.NoError(t, err)
+		assert.NoError(t, m.Check(ctx))
+
+		_, err = m.To(ctx, 999)
+		assert.Error(t, err)
+	})
+
+	t.Run("接管 AutoMigrate 建立的数据库并清理孤儿数据", func(t *testing.T) {
+		// 不开启外键检查，模拟历史数据中已经存在的孤儿点赞
+		db := openSQLite(t, false)
+		require.NoError(t, db.AutoMigrate(&baselineUser{}, &baselineWish{}, &baselineLike{}, &baselineComment{}, &baselineWishTag{}))
+		require.False(t, db.Migrator().HasColumn("wishes", "moderation_status"))
+		user := baselineUser{Username: "2024000001", Password: "x"}
+		require.NoError(t, db.Create(&user).Error)
+		wish := baselineWish{UserID: user.ID, Content: "愿望"}
+		require.NoError(t, db.Create(&wish).Error)
+		comment := baselineComment{WishID: wish.ID, UserID: user.ID, Content: "评论"}
+		require.NoError(t, db.Create(&comment).Error)
+		require.NoError(t, db.Create(&baselineLike{WishID: wish.ID, UserID: user.ID}).Error)
+		require.NoError(t, db.Create(&baselineLike{WishID: 9999, UserID: user.ID}).Error)
+
+		m := newMigrator(t, db)
+		_, err := m.Up(ctx)
+		require.NoError(t, err)
+		require.NoError(t, m.Check(ctx))
+
+		for _, table := range []string{"wishes", "comments"} {
+			for _, column := range []string{"moderation_status", "moderation_reason"} {
+				assert.True(t, db.Migrator().HasColumn(table, column), "%s.%s", table, column)
+			}
+			assert.True(t, db.Migrator().HasIndex(table, "idx_"+table+"_moderation_status"), table)
+		}
+		var likes []model.Like
+		require.NoError(t, db.Find(&likes).Error)
+		require.Len(t, likes, 1)
+		assert.Equal(t, wish.ID, likes[0].WishID)
+		var got model.Wish
+		require.NoError(t, db.First(&got, wish.ID).Error)
+		assert.Equal(t, "愿望", got.Content)
+		assert.Equal(t, model.ModerationApproved, got.ModerationStatus, "升级前的愿望保持可见")
+		var gotComment model.Comment
+		require.NoError(t, db.First(&gotComment, comment.ID).Error)
+		assert.Equal(t, model.ModerationApproved, gotComment.ModerationStatus)
+
+		// 补齐的列不会重复添加
+		require.NoError(t, m.takeover(db))
+	})
+
+	t.Run("为升级前的刷新令牌补建登录会话", func(t *testing.T) {
+		db := openSQLite(t, true)
+		m := newMigrator(t, db)
+		_, err := m.To(ctx, 3)
+		require.NoError(t, err)
+		user := model.User{Username: "2024000001", Password: "x"}
+		require.NoError(t, db.Create(&user).Error)
+		now := time.Now()
+		revokedAt := now.Add(-time.Hour)
+		for _, token := range []model.RefreshToken{
+			{UserID: user.ID, FamilyID: "active", TokenHash: "a", UserAgent: "iPhone", ExpiresAt: now.Add(24 * time.Hour)},
+			{UserID: user.ID, FamilyID: "revoked", TokenHash: "b", ExpiresAt: now.Add(24 * time.Hour), RevokedAt: &revokedAt},
+			{UserID: user.ID, FamilyID: "expired", TokenHash: "c", ExpiresAt: now.Add(-24 * time.Hour)},
+		} {
+			require.NoError(t, db.Create(&token).Error)
+		}
+
+		_, err = m.Up(ctx)
+		require.NoError(t, err)
+		var sessions []model.Session
+		require.NoError(t, db.Find(&sessions).Error)
+		require.Len(t, sessions, 1)
+		assert.Equal(t, "active", sessions[0].JTI)
+		assert.Equal(t, "iPhone", sessions[0].UserAgent)
+	})
+
+	t.Run("迁移锁与未知版本", func(t *testing.T) {
+		db := openSQLite(t, true)
+		m := newMigrator(t, db)
+		require.NoError(t, m.ensureTables(ctx))
+		require.NoError(t, db.Create(&migrationLock{ID: lockID, Owner: "other:1", LockedAt: time.Now()}).Error)
+
+		_, err := m.Up(ctx)
+		assert.ErrorIs(t, err, ErrLocked)
+		assert.False(t, db.Migrator().HasTable("wishes"))
+
+		require.NoError(t, m.Unlock(ctx))
+		_, err = m.Up(ctx)
+		require.NoError(t, err)
+		var locks int64
+		require.NoError(t, db.Model(&migrationLock{}).Count(&locks).Error)
+		assert.Zero(t, locks, "迁移结束后应释放锁")
+
+		require.NoError(t, db.Create(&appliedVersion{Version: 999, Name: "future", AppliedAt: time.Now()}).Error)
+		assert.ErrorIs(t, m.Check(ctx), ErrUnknownVersion)
+		_, err = m.Up(ctx)
+		assert.ErrorIs(t, err, ErrUnknownVersion)
+	})
+}
+
+func TestLoad(t *testing.T) {
+	// 两种方言的迁移版本必须一一对应
+	mysql, err := Load(scripts, "sql/mysql")
+	require.NoError(t, err)
+	sqlite, err := Load(scripts, "sql/sqlite")
+	require.NoError(t, err)
+	names := func(ms []Migration) []string {
+		var out []string
+		for _, m := range ms {
+			out = append(out, fmt.Sprintf("%d_%s", m.Version, m.Name))
+		}
+		return out
+	}
+	assert.True(t, reflect.DeepEqual(names(mysql), names(sqlite)), "mysql: %v, sqlite: %v", names(mysql), names(sqlite))
+
+	_, err = Load(fstest.MapFS{"x/0001_init.up.sql": {Data: []byte("SELECT 1;")}}, "x")
+	assert.Error(t, err, "缺少 down 脚本")
+	_, err = Load(fstest.MapFS{"x/init.up.sql": {Data: []byte("SELECT 1;")}}, "x")
+	assert.Error(t, err, "文件名缺少版本号")
+}
+
+func TestSplitStatements(t *testing.T) {
+	script := "-- 注释\nCREATE TABLE a (\n  id int\n);\n\nSET @s = 'x';\nSELECT 1"
+	assert.Equal(t, []string{"CREATE TABLE a (\n  id int\n)", "SET @s = 'x'", "SELECT 1"}, splitStatements(script))
+}
+
+// 以下是引入迁移之前的模型，AutoMigrate 用它们建立的表结构与升级前的线上数据库一致
+
+type baselineUser struct {
+	ID        uint    `gorm:"primaryKey"`
+	Password  string  `gorm:"size:255;not null"`
+	Nickname  string  `gorm:"size:50;not null;default:''"`
+	AvatarID  *uint   `gorm:"default:null"`
+	WishValue int     `gorm:"not null;default:0"`
+	Bio       *string `gorm:"type:text"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+	Username  string         `gorm:"size:50;not null;uniqueIndex"`
+
+	Wishes   []baselineWish    `gorm:"foreignKey:UserID"`
+	Likes    []baselineLike    `gorm:"foreignKey:UserID"`
+	Comments []baselineComment `gorm:"foreignKey:UserID"`
+
+	Role string `gorm:"size:16;default:'user'"`
+}
+
+func (baselineUser) TableName() string { return "users" }
+
+type baselineWish struct {
+	ID           uint   `gorm:"primaryKey"`
+	UserID       uint   `gorm:"not null;index"`
+	UserNickname string `gorm:"size:50;not null;default:''"`
+	UserAvatarID *uint
+	Content      string `gorm:"type:text;not null"`
+	IsPublic     bool   `gorm:"not null;default:true"`
+	Background   string `gorm:"size:50;default:'default'"`
+	LikeCount    int    `gorm:"not null;default:0"`
+	CommentCount int    `gorm:"not null;default:0"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	DeletedAt    gorm.DeletedAt `gorm:"index"`
+
+	User     baselineUser      `gorm:"foreignKey:UserID"`
+	Tags     []baselineWishTag `gorm:"foreignKey:WishID"`
+	Likes    []baselineLike    `gorm:"foreignKey:WishID"`
+	Comments []baselineComment `gorm:"foreignKey:WishID"`
+}
+
+func (baselineWish) TableName() string { return "wishes" }
+
+type baselineWishTag struct {
+	ID        uint   `gorm:"primaryKey"`
+	WishID    uint   `gorm:"not null;index"`
+	TagName   string `gorm:"size:20;not null;index"`
+	CreatedAt time.Time
+
+	Wish baselineWish `gorm:"foreignKey:WishID"`
+}
+
+func (baselineWishTag) TableName() string { return "wish_tags" }
+
+type baselineComment struct {
+	ID        uint   `gorm:"primaryKey"`
+	WishID    uint   `gorm:"not null;index"`
+	ParentID  *uint  `gorm:"index;default:null"`
+	UserID    uint   `gorm:"not null;index"`
+	Content   string `gorm:"type:text;not null"`
+	LikeCount int    `gorm:"not null;default:0"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+
+	Wish    *baselineWish      `gorm:"foreignKey:WishID"`
+	User    *baselineUser      `gorm:"foreignKey:UserID"`
+	Replies []*baselineComment `gorm:"foreignKey:ParentID"`
+}
+
+func (baselineComment) TableName() string { return "comments" }
+
+type baselineLike struct {
+	ID        uint `gorm:"primaryKey"`
+	WishID    uint `gorm:"not null;uniqueIndex:idx_user_wish"`
+	UserID    uint `gorm:"not null;uniqueIndex:idx_user_wish"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+
+	User baselineUser `gorm:"foreignKey:UserID"`
+	Wish baselineWish `gorm:"foreignKey:WishID"`
+}
+
+func (baselineLike) TableName() string { return "likes" }
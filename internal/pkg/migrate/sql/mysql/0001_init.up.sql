@@ -0,0 +1,180 @@
+-- 初始表结构，与之前 AutoMigrate 生成的结构一致。
+-- 使用 IF NOT EXISTS，已经由 AutoMigrate 建好的表不会重建；这些表上后来新增的列 (愿望和评论的审核状态)
+-- 由迁移工具在执行本脚本前检查并补齐，已有的内容视为审核通过 (见 takeover.go)
+
+CREATE TABLE IF NOT EXISTS `users` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `password` varchar(255) NOT NULL,
+  `nickname` varchar(50) NOT NULL DEFAULT '',
+  `avatar_id` bigint unsigned DEFAULT NULL,
+  `wish_value` bigint NOT NULL DEFAULT 0,
+  `bio` text,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  `deleted_at` datetime(3) NULL,
+  `username` varchar(50) NOT NULL,
+  `role` varchar(16) DEFAULT 'user',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_users_username` (`username`),
+  KEY `idx_users_deleted_at` (`deleted_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `wishes` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `user_id` bigint unsigned NOT NULL,
+  `user_nickname` varchar(50) NOT NULL DEFAULT '',
+  `user_avatar_id` bigint unsigned DEFAULT NULL,
+  `content` text NOT NULL,
+  `is_public` boolean NOT NULL DEFAULT true,
+  `background` varchar(50) DEFAULT 'default',
+  `like_count` bigint NOT NULL DEFAULT 0,
+  `comment_count` bigint NOT NULL DEFAULT 0,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  `deleted_at` datetime(3) NULL,
+  `moderation_status` varchar(16) NOT NULL DEFAULT 'approved',
+  `moderation_reason` varchar(255) NOT NULL DEFAULT '',
+  PRIMARY KEY (`id`),
+  KEY `idx_wishes_user_id` (`user_id`),
+  KEY `idx_wishes_deleted_at` (`deleted_at`),
+  KEY `idx_wishes_moderation_status` (`moderation_status`),
+  CONSTRAINT `fk_users_wishes` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `likes` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `wish_id` bigint unsigned NOT NULL,
+  `user_id` bigint unsigned NOT NULL,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  `deleted_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_user_wish` (`wish_id`, `user_id`),
+  KEY `idx_likes_deleted_at` (`deleted_at`),
+  CONSTRAINT `fk_wishes_likes` FOREIGN KEY (`wish_id`) REFERENCES `wishes` (`id`),
+  CONSTRAINT `fk_users_likes` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `comments` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `wish_id` bigint unsigned NOT NULL,
+  `parent_id` bigint unsigned DEFAULT NULL,
+  `user_id` bigint unsigned NOT NULL,
+  `content` text NOT NULL,
+  `like_count` bigint NOT NULL DEFAULT 0,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  `deleted_at` datetime(3) NULL,
+  `moderation_status` varchar(16) NOT NULL DEFAULT 'approved',
+  `moderation_reason` varchar(255) NOT NULL DEFAULT '',
+  PRIMARY KEY (`id`),
+  KEY `idx_comments_wish_id` (`wish_id`),
+  KEY `idx_comments_parent_id` (`parent_id`),
+  KEY `idx_comments_user_id` (`user_id`),
+  KEY `idx_comments_deleted_at` (`deleted_at`),
+  KEY `idx_comments_moderation_status` (`moderation_status`),
+  CONSTRAINT `fk_comments_replies` FOREIGN KEY (`parent_id`) REFERENCES `comments` (`id`),
+  CONSTRAINT `fk_wishes_comments` FOREIGN KEY (`wish_id`) REFERENCES `wishes` (`id`),
+  CONSTRAINT `fk_users_comments` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `wish_tags` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `wish_id` bigint unsigned NOT NULL,
+  `tag_name` varchar(20) NOT NULL,
+  `created_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_wish_tags_wish_id` (`wish_id`),
+  KEY `idx_wish_tags_tag_name` (`tag_name`),
+  CONSTRAINT `fk_wishes_tags` FOREIGN KEY (`wish_id`) REFERENCES `wishes` (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `moderation_records` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `subject_type` varchar(16) NOT NULL,
+  `subject_id` bigint unsigned NOT NULL DEFAULT 0,
+  `user_id` bigint unsigned NOT NULL,
+  `content_hash` varchar(64) NOT NULL,
+  `content` text NOT NULL,
+  `provider` varchar(64) NOT NULL DEFAULT '',
+  `raw_answer` text,
+  `verdict` varchar(16) NOT NULL,
+  `category` varchar(32) NOT NULL DEFAULT '',
+  `reason` varchar(255) NOT NULL DEFAULT '',
+  `confidence` double NOT NULL DEFAULT 0,
+  `latency_ms` bigint NOT NULL DEFAULT 0,
+  `review_status` varchar(16) NOT NULL DEFAULT '',
+  `reviewer_id` bigint unsigned DEFAULT NULL,
+  `review_note` varchar(255) NOT NULL DEFAULT '',
+  `reviewed_at` datetime(3) NULL,
+  `created_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_moderation_subject` (`subject_type`, `subject_id`),
+  KEY `idx_moderation_records_user_id` (`user_id`),
+  KEY `idx_moderation_records_content_hash` (`content_hash`),
+  KEY `idx_moderation_records_verdict` (`verdict`),
+  KEY `idx_moderation_records_review_status` (`review_status`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `moderation_thresholds` (
+  `category` varchar(32) NOT NULL,
+  `reject_threshold` double NOT NULL,
+  `review_threshold` double NOT NULL,
+  `updated_by` bigint unsigned NOT NULL DEFAULT 0,
+  `updated_at` datetime(3) NULL,
+  PRIMARY KEY (`category`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `moderation_cache` (
+  `cache_key` varchar(64) NOT NULL,
+  `violating` boolean NOT NULL,
+  `provider` varchar(64) NOT NULL DEFAULT '',
+  `category` varchar(32) NOT NULL DEFAULT '',
+  `categories` varchar(255) NOT NULL DEFAULT '',
+  `confidence` double NOT NULL DEFAULT 0,
+  `raw` text,
+  `expires_at` datetime(3) NOT NULL,
+  `created_at` datetime(3) NULL,
+  PRIMARY KEY (`cache_key`),
+  KEY `idx_moderation_cache_expires_at` (`expires_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `remoderation_jobs` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `status` varchar(16) NOT NULL,
+  `targets` varchar(32) NOT NULL,
+  `dry_run` boolean NOT NULL DEFAULT false,
+  `batch_size` bigint NOT NULL,
+  `rate` double NOT NULL,
+  `prompt_version` varchar(16) NOT NULL DEFAULT '',
+  `started_by` bigint unsigned NOT NULL DEFAULT 0,
+  `last_wish_id` bigint unsigned NOT NULL DEFAULT 0,
+  `last_comment_id` bigint unsigned NOT NULL DEFAULT 0,
+  `scanned` bigint NOT NULL DEFAULT 0,
+  `hidden` bigint NOT NULL DEFAULT 0,
+  `flagged` bigint NOT NULL DEFAULT 0,
+  `skipped` bigint NOT NULL DEFAULT 0,
+  `error` varchar(255) NOT NULL DEFAULT '',
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  `finished_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_remoderation_jobs_status` (`status`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `remoderation_changes` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `job_id` bigint unsigned NOT NULL,
+  `subject_type` varchar(16) NOT NULL,
+  `subject_id` bigint unsigned NOT NULL,
+  `user_id` bigint unsigned NOT NULL,
+  `content` text NOT NULL,
+  `old_status` varchar(16) NOT NULL,
+  `new_status` varchar(16) NOT NULL,
+  `category` varchar(32) NOT NULL DEFAULT '',
+  `reason` varchar(255) NOT NULL DEFAULT '',
+  `record_id` bigint unsigned NOT NULL DEFAULT 0,
+  `created_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_remoderation_changes_job_id` (`job_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
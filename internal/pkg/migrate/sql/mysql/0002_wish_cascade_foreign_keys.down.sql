@@ -0,0 +1,33 @@
+-- 恢复为不带级联删除的外键
+
+SET @stmt = IF((SELECT COUNT(*) FROM information_schema.TABLE_CONSTRAINTS
+  WHERE CONSTRAINT_SCHEMA = DATABASE() AND TABLE_NAME = 'likes' AND CONSTRAINT_NAME = 'fk_wishes_likes' AND CONSTRAINT_TYPE = 'FOREIGN KEY') > 0,
+  'ALTER TABLE `likes` DROP FOREIGN KEY `fk_wishes_likes`', 'DO 0');
+PREPARE stmt FROM @stmt;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+ALTER TABLE `likes` ADD CONSTRAINT `fk_wishes_likes` FOREIGN KEY (`wish_id`) REFERENCES `wishes` (`id`);
+
+SET @stmt = IF((SELECT COUNT(*) FROM information_schema.TABLE_CONSTRAINTS
+  WHERE CONSTRAINT_SCHEMA = DATABASE() AND TABLE_NAME = 'comments' AND CONSTRAINT_NAME = 'fk_wishes_comments' AND CONSTRAINT_TYPE = 'FOREIGN KEY') > 0,
+  'ALTER TABLE `comments` DROP FOREIGN KEY `fk_wishes_comments`', 'DO 0');
+PREPARE stmt FROM @stmt;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+ALTER TABLE `comments` ADD CONSTRAINT `fk_wishes_comments` FOREIGN KEY (`wish_id`) REFERENCES `wishes` (`id`);
+
+SET @stmt = IF((SELECT COUNT(*) FROM information_schema.TABLE_CONSTRAINTS
+  WHERE CONSTRAINT_SCHEMA = DATABASE() AND TABLE_NAME = 'comments' AND CONSTRAINT_NAME = 'fk_comments_replies' AND CONSTRAINT_TYPE = 'FOREIGN KEY') > 0,
+  'ALTER TABLE `comments` DROP FOREIGN KEY `fk_comments_replies`', 'DO 0');
+PREPARE stmt FROM @stmt;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+ALTER TABLE `comments` ADD CONSTRAINT `fk_comments_replies` FOREIGN KEY (`parent_id`) REFERENCES `comments` (`id`);
+
+SET @stmt = IF((SELECT COUNT(*) FROM information_schema.TABLE_CONSTRAINTS
+  WHERE CONSTRAINT_SCHEMA = DATABASE() AND TABLE_NAME = 'wish_tags' AND CONSTRAINT_NAME = 'fk_wishes_tags' AND CONSTRAINT_TYPE = 'FOREIGN KEY') > 0,
+  'ALTER TABLE `wish_tags` DROP FOREIGN KEY `fk_wishes_tags`', 'DO 0');
+PREPARE stmt FROM @stmt;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+ALTER TABLE `wish_tags` ADD CONSTRAINT `fk_wishes_tags` FOREIGN KEY (`wish_id`) REFERENCES `wishes` (`id`);
@@ -0,0 +1,41 @@
+-- 愿望与点赞、评论、标签之间的外键改为级联删除，回复随父评论级联删除。
+-- 早期由 AutoMigrate 建表的数据库可能缺少这些外键，先清理孤儿数据，再按需删除旧外键后重新创建。
+-- MySQL 的 DDL 不能回滚，本脚本的每一步都可以重复执行，失败后修复问题再次执行 migrate up 即可
+
+DELETE FROM `likes` WHERE `wish_id` NOT IN (SELECT `id` FROM `wishes`);
+DELETE FROM `wish_tags` WHERE `wish_id` NOT IN (SELECT `id` FROM `wishes`);
+DELETE FROM `comments` WHERE `wish_id` NOT IN (SELECT `id` FROM `wishes`);
+UPDATE `comments` SET `parent_id` = NULL
+  WHERE `parent_id` IS NOT NULL AND `parent_id` NOT IN (SELECT `id` FROM (SELECT `id` FROM `comments`) AS `parents`);
+
+SET @stmt = IF((SELECT COUNT(*) FROM information_schema.TABLE_CONSTRAINTS
+  WHERE CONSTRAINT_SCHEMA = DATABASE() AND TABLE_NAME = 'likes' AND CONSTRAINT_NAME = 'fk_wishes_likes' AND CONSTRAINT_TYPE = 'FOREIGN KEY') > 0,
+  'ALTER TABLE `likes` DROP FOREIGN KEY `fk_wishes_likes`', 'DO 0');
+PREPARE stmt FROM @stmt;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+ALTER TABLE `likes` ADD CONSTRAINT `fk_wishes_likes` FOREIGN KEY (`wish_id`) REFERENCES `wishes` (`id`) ON DELETE CASCADE;
+
+SET @stmt = IF((SELECT COUNT(*) FROM information_schema.TABLE_CONSTRAINTS
+  WHERE CONSTRAINT_SCHEMA = DATABASE() AND TABLE_NAME = 'comments' AND CONSTRAINT_NAME = 'fk_wishes_comments' AND CONSTRAINT_TYPE = 'FOREIGN KEY') > 0,
+  'ALTER TABLE `comments` DROP FOREIGN KEY `fk_wishes_comments`', 'DO 0');
+PREPARE stmt FROM @stmt;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+ALTER TABLE `comments` ADD CONSTRAINT `fk_wishes_comments` FOREIGN KEY (`wish_id`) REFERENCES `wishes` (`id`) ON DELETE CASCADE;
+
+SET @stmt = IF((SELECT COUNT(*) FROM information_schema.TABLE_CONSTRAINTS
+  WHERE CONSTRAINT_SCHEMA = DATABASE() AND TABLE_NAME = 'comments' AND CONSTRAINT_NAME = 'fk_comments_replies' AND CONSTRAINT_TYPE = 'FOREIGN KEY') > 0,
+  'ALTER TABLE `comments` DROP FOREIGN KEY `fk_comments_replies`', 'DO 0');
+PREPARE stmt FROM @stmt;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+ALTER TABLE `comments` ADD CONSTRAINT `fk_comments_replies` FOREIGN KEY (`parent_id`) REFERENCES `comments` (`id`) ON DELETE CASCADE;
+
+SET @stmt = IF((SELECT COUNT(*) FROM information_schema.TABLE_CONSTRAINTS
+  WHERE CONSTRAINT_SCHEMA = DATABASE() AND TABLE_NAME = 'wish_tags' AND CONSTRAINT_NAME = 'fk_wishes_tags' AND CONSTRAINT_TYPE = 'FOREIGN KEY') > 0,
+  'ALTER TABLE `wish_tags` DROP FOREIGN KEY `fk_wishes_tags`', 'DO 0');
+PREPARE stmt FROM @stmt;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+ALTER TABLE `wish_tags` ADD CONSTRAINT `fk_wishes_tags` FOREIGN KEY (`wish_id`) REFERENCES `wishes` (`id`) ON DELETE CASCADE;
@@ -0,0 +1,21 @@
+-- 登录会话：每次登录一条记录，用于设备列表和吊销访问令牌。
+-- 为升级前登录、刷新令牌仍然有效的令牌族补建会话，避免这些用户被迫重新登录
+
+CREATE TABLE IF NOT EXISTS `sessions` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `user_id` bigint unsigned NOT NULL,
+  `jti` varchar(32) NOT NULL,
+  `user_agent` varchar(255) NOT NULL DEFAULT '',
+  `ip` varchar(64) NOT NULL DEFAULT '',
+  `created_at` datetime(3) NULL,
+  `last_seen_at` datetime(3) NOT NULL,
+  `expires_at` datetime(3) NOT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_sessions_jti` (`jti`),
+  KEY `idx_sessions_user_id` (`user_id`),
+  CONSTRAINT `fk_users_sessions` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+INSERT IGNORE INTO `sessions` (`user_id`, `jti`, `user_agent`, `ip`, `created_at`, `last_seen_at`, `expires_at`)
+  SELECT `user_id`, `family_id`, `user_agent`, `ip`, `created_at`, `created_at`, `expires_at`
+  FROM `refresh_tokens` WHERE `revoked_at` IS NULL AND `expires_at` > NOW(3);
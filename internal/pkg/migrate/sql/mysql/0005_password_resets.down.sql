@@ -0,0 +1,3 @@
+-- 删除密码重置码表；未使用的重置码随之作废
+
+DROP TABLE IF EXISTS `password_resets`;
@@ -0,0 +1,16 @@
+-- 密码重置码：只保存哈希，一次性使用，过期后失效
+
+CREATE TABLE IF NOT EXISTS `password_resets` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `user_id` bigint unsigned NOT NULL,
+  `code_hash` varchar(64) NOT NULL,
+  `issued_by` bigint unsigned NULL,
+  `channel` varchar(32) NOT NULL DEFAULT '',
+  `expires_at` datetime(3) NOT NULL,
+  `used_at` datetime(3) NULL,
+  `created_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_password_resets_code_hash` (`code_hash`),
+  KEY `idx_password_resets_user_id` (`user_id`),
+  CONSTRAINT `fk_users_password_resets` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,19 @@
+-- 登录失败限制：失败记录 (滑动窗口计数) 与锁定状态，多个实例共享
+
+CREATE TABLE IF NOT EXISTS `login_failures` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `throttle_key` varchar(128) NOT NULL,
+  `created_at` datetime(3) NOT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_login_failures_key_created` (`throttle_key`, `created_at`),
+  KEY `idx_login_failures_created_at` (`created_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `login_lockouts` (
+  `throttle_key` varchar(128) NOT NULL,
+  `lockouts` bigint NOT NULL DEFAULT 0,
+  `locked_until` datetime(3) NOT NULL,
+  `updated_at` datetime(3) NULL,
+  PRIMARY KEY (`throttle_key`),
+  KEY `idx_login_lockouts_locked_until` (`locked_until`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,12 @@
+-- 按外键依赖的相反顺序删除全部表，会清空所有数据
+
+DROP TABLE IF EXISTS `remoderation_changes`;
+DROP TABLE IF EXISTS `remoderation_jobs`;
+DROP TABLE IF EXISTS `moderation_cache`;
+DROP TABLE IF EXISTS `moderation_thresholds`;
+DROP TABLE IF EXISTS `moderation_records`;
+DROP TABLE IF EXISTS `wish_tags`;
+DROP TABLE IF EXISTS `comments`;
+DROP TABLE IF EXISTS `likes`;
+DROP TABLE IF EXISTS `wishes`;
+DROP TABLE IF EXISTS `users`;
@@ -0,0 +1,45 @@
+-- 初始表结构，与之前 AutoMigrate 生成的结构一致。
+-- 使用 IF NOT EXISTS，已经由 AutoMigrate 建好的表不会重建；这些表上后来新增的列 (愿望和评论的审核状态)
+-- 由迁移工具在执行本脚本前检查并补齐，已有的内容视为审核通过 (见 takeover.go)
+
+CREATE TABLE IF NOT EXISTS `users` (`id` integer PRIMARY KEY AUTOINCREMENT,`password` text NOT NULL,`nickname` text NOT NULL DEFAULT '',`avatar_id` integer DEFAULT null,`wish_value` integer NOT NULL DEFAULT 0,`bio` text,`created_at` datetime,`updated_at` datetime,`deleted_at` datetime,`username` text NOT NULL,`role` text DEFAULT 'user');
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_users_username` ON `users`(`username`);
+CREATE INDEX IF NOT EXISTS `idx_users_deleted_at` ON `users`(`deleted_at`);
+
+CREATE TABLE IF NOT EXISTS `wishes` (`id` integer PRIMARY KEY AUTOINCREMENT,`user_id` integer NOT NULL,`user_nickname` text NOT NULL DEFAULT '',`user_avatar_id` integer,`content` text NOT NULL,`is_public` numeric NOT NULL DEFAULT true,`background` text DEFAULT 'default',`like_count` integer NOT NULL DEFAULT 0,`comment_count` integer NOT NULL DEFAULT 0,`created_at` datetime,`updated_at` datetime,`deleted_at` datetime,`moderation_status` text NOT NULL DEFAULT 'approved',`moderation_reason` text NOT NULL DEFAULT '',CONSTRAINT `fk_users_wishes` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`));
+CREATE INDEX IF NOT EXISTS `idx_wishes_moderation_status` ON `wishes`(`moderation_status`);
+CREATE INDEX IF NOT EXISTS `idx_wishes_deleted_at` ON `wishes`(`deleted_at`);
+CREATE INDEX IF NOT EXISTS `idx_wishes_user_id` ON `wishes`(`user_id`);
+
+CREATE TABLE IF NOT EXISTS `likes` (`id` integer PRIMARY KEY AUTOINCREMENT,`wish_id` integer NOT NULL,`user_id` integer NOT NULL,`created_at` datetime,`updated_at` datetime,`deleted_at` datetime,CONSTRAINT `fk_wishes_likes` FOREIGN KEY (`wish_id`) REFERENCES `wishes`(`id`),CONSTRAINT `fk_users_likes` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`));
+CREATE INDEX IF NOT EXISTS `idx_likes_deleted_at` ON `likes`(`deleted_at`);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_user_wish` ON `likes`(`wish_id`,`user_id`);
+
+CREATE TABLE IF NOT EXISTS `comments` (`id` integer PRIMARY KEY AUTOINCREMENT,`wish_id` integer NOT NULL,`parent_id` integer DEFAULT null,`user_id` integer NOT NULL,`content` text NOT NULL,`like_count` integer NOT NULL DEFAULT 0,`created_at` datetime,`updated_at` datetime,`deleted_at` datetime,`moderation_status` text NOT NULL DEFAULT 'approved',`moderation_reason` text NOT NULL DEFAULT '',CONSTRAINT `fk_comments_replies` FOREIGN KEY (`parent_id`) REFERENCES `comments`(`id`),CONSTRAINT `fk_wishes_comments` FOREIGN KEY (`wish_id`) REFERENCES `wishes`(`id`),CONSTRAINT `fk_users_comments` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`));
+CREATE INDEX IF NOT EXISTS `idx_comments_moderation_status` ON `comments`(`moderation_status`);
+CREATE INDEX IF NOT EXISTS `idx_comments_deleted_at` ON `comments`(`deleted_at`);
+CREATE INDEX IF NOT EXISTS `idx_comments_user_id` ON `comments`(`user_id`);
+CREATE INDEX IF NOT EXISTS `idx_comments_parent_id` ON `comments`(`parent_id`);
+CREATE INDEX IF NOT EXISTS `idx_comments_wish_id` ON `comments`(`wish_id`);
+
+CREATE TABLE IF NOT EXISTS `wish_tags` (`id` integer PRIMARY KEY AUTOINCREMENT,`wish_id` integer NOT NULL,`tag_name` text NOT NULL,`created_at` datetime,CONSTRAINT `fk_wishes_tags` FOREIGN KEY (`wish_id`) REFERENCES `wishes`(`id`));
+CREATE INDEX IF NOT EXISTS `idx_wish_tags_tag_name` ON `wish_tags`(`tag_name`);
+CREATE INDEX IF NOT EXISTS `idx_wish_tags_wish_id` ON `wish_tags`(`wish_id`);
+
+CREATE TABLE IF NOT EXISTS `moderation_records` (`id` integer PRIMARY KEY AUTOINCREMENT,`subject_type` text NOT NULL,`subject_id` integer NOT NULL DEFAULT 0,`user_id` integer NOT NULL,`content_hash` text NOT NULL,`content` text NOT NULL,`provider` text NOT NULL DEFAULT '',`raw_answer` text,`verdict` text NOT NULL,`category` text NOT NULL DEFAULT '',`reason` text NOT NULL DEFAULT '',`confidence` real NOT NULL DEFAULT 0,`latency_ms` integer NOT NULL DEFAULT 0,`review_status` text NOT NULL DEFAULT '',`reviewer_id` integer,`review_note` text NOT NULL DEFAULT '',`reviewed_at` datetime,`created_at` datetime);
+CREATE INDEX IF NOT EXISTS `idx_moderation_records_review_status` ON `moderation_records`(`review_status`);
+CREATE INDEX IF NOT EXISTS `idx_moderation_records_verdict` ON `moderation_records`(`verdict`);
+CREATE INDEX IF NOT EXISTS `idx_moderation_records_content_hash` ON `moderation_records`(`content_hash`);
+CREATE INDEX IF NOT EXISTS `idx_moderation_records_user_id` ON `moderation_records`(`user_id`);
+CREATE INDEX IF NOT EXISTS `idx_moderation_subject` ON `moderation_records`(`subject_type`,`subject_id`);
+
+CREATE TABLE IF NOT EXISTS `moderation_thresholds` (`category` text,`reject_threshold` real NOT NULL,`review_threshold` real NOT NULL,`updated_by` integer NOT NULL DEFAULT 0,`updated_at` datetime,PRIMARY KEY (`category`));
+
+CREATE TABLE IF NOT EXISTS `moderation_cache` (`cache_key` text,`violating` numeric NOT NULL,`provider` text NOT NULL DEFAULT '',`category` text NOT NULL DEFAULT '',`categories` text NOT NULL DEFAULT '',`confidence` real NOT NULL DEFAULT 0,`raw` text,`expires_at` datetime NOT NULL,`created_at` datetime,PRIMARY KEY (`cache_key`));
+CREATE INDEX IF NOT EXISTS `idx_moderation_cache_expires_at` ON `moderation_cache`(`expires_at`);
+
+CREATE TABLE IF NOT EXISTS `remoderation_jobs` (`id` integer PRIMARY KEY AUTOINCREMENT,`status` text NOT NULL,`targets` text NOT NULL,`dry_run` numeric NOT NULL DEFAULT false,`batch_size` integer NOT NULL,`rate` real NOT NULL,`prompt_version` text NOT NULL DEFAULT '',`started_by` integer NOT NULL DEFAULT 0,`last_wish_id` integer NOT NULL DEFAULT 0,`last_comment_id` integer NOT NULL DEFAULT 0,`scanned` integer NOT NULL DEFAULT 0,`hidden` integer NOT NULL DEFAULT 0,`flagged` integer NOT NULL DEFAULT 0,`skipped` integer NOT NULL DEFAULT 0,`error` text NOT NULL DEFAULT '',`created_at` datetime,`updated_at` datetime,`finished_at` datetime);
+CREATE INDEX IF NOT EXISTS `idx_remoderation_jobs_status` ON `remoderation_jobs`(`status`);
+
+CREATE TABLE IF NOT EXISTS `remoderation_changes` (`id` integer PRIMARY KEY AUTOINCREMENT,`job_id` integer NOT NULL,`subject_type` text NOT NULL,`subject_id` integer NOT NULL,`user_id` integer NOT NULL,`content` text NOT NULL,`old_status` text NOT NULL,`new_status` text NOT NULL,`category` text NOT NULL DEFAULT '',`reason` text NOT NULL DEFAULT '',`record_id` integer NOT NULL DEFAULT 0,`created_at` datetime);
+CREATE INDEX IF NOT EXISTS `idx_remoderation_changes_job_id` ON `remoderation_changes`(`job_id`);
@@ -0,0 +1,25 @@
+-- 恢复为不带级联删除的外键
+
+CREATE TABLE `likes_new` (`id` integer PRIMARY KEY AUTOINCREMENT,`wish_id` integer NOT NULL,`user_id` integer NOT NULL,`created_at` datetime,`updated_at` datetime,`deleted_at` datetime,CONSTRAINT `fk_wishes_likes` FOREIGN KEY (`wish_id`) REFERENCES `wishes`(`id`),CONSTRAINT `fk_users_likes` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`));
+INSERT INTO `likes_new` SELECT * FROM `likes`;
+DROP TABLE `likes`;
+ALTER TABLE `likes_new` RENAME TO `likes`;
+CREATE INDEX `idx_likes_deleted_at` ON `likes`(`deleted_at`);
+CREATE UNIQUE INDEX `idx_user_wish` ON `likes`(`wish_id`,`user_id`);
+
+CREATE TABLE `comments_new` (`id` integer PRIMARY KEY AUTOINCREMENT,`wish_id` integer NOT NULL,`parent_id` integer DEFAULT null,`user_id` integer NOT NULL,`content` text NOT NULL,`like_count` integer NOT NULL DEFAULT 0,`created_at` datetime,`updated_at` datetime,`deleted_at` datetime,`moderation_status` text NOT NULL DEFAULT 'approved',`moderation_reason` text NOT NULL DEFAULT '',CONSTRAINT `fk_comments_replies` FOREIGN KEY (`parent_id`) REFERENCES `comments`(`id`),CONSTRAINT `fk_wishes_comments` FOREIGN KEY (`wish_id`) REFERENCES `wishes`(`id`),CONSTRAINT `fk_users_comments` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`));
+INSERT INTO `comments_new` SELECT * FROM `comments`;
+DROP TABLE `comments`;
+ALTER TABLE `comments_new` RENAME TO `comments`;
+CREATE INDEX `idx_comments_moderation_status` ON `comments`(`moderation_status`);
+CREATE INDEX `idx_comments_deleted_at` ON `comments`(`deleted_at`);
+CREATE INDEX `idx_comments_user_id` ON `comments`(`user_id`);
+CREATE INDEX `idx_comments_parent_id` ON `comments`(`parent_id`);
+CREATE INDEX `idx_comments_wish_id` ON `comments`(`wish_id`);
+
+CREATE TABLE `wish_tags_new` (`id` integer PRIMARY KEY AUTOINCREMENT,`wish_id` integer NOT NULL,`tag_name` text NOT NULL,`created_at` datetime,CONSTRAINT `fk_wishes_tags` FOREIGN KEY (`wish_id`) REFERENCES `wishes`(`id`));
+INSERT INTO `wish_tags_new` SELECT * FROM `wish_tags`;
+DROP TABLE `wish_tags`;
+ALTER TABLE `wish_tags_new` RENAME TO `wish_tags`;
+CREATE INDEX `idx_wish_tags_tag_name` ON `wish_tags`(`tag_name`);
+CREATE INDEX `idx_wish_tags_wish_id` ON `wish_tags`(`wish_id`);
@@ -0,0 +1,29 @@
+-- 愿望与点赞、评论、标签之间的外键改为级联删除，回复随父评论级联删除。
+-- SQLite 不支持修改外键，按官方推荐的方式重建表：建新表、复制数据、删除旧表、重命名。
+-- 迁移工具执行 SQLite 迁移前会关闭外键检查，提交前用 PRAGMA foreign_key_check 校验
+
+UPDATE `comments` SET `parent_id` = NULL WHERE `parent_id` IS NOT NULL AND `parent_id` NOT IN (SELECT `id` FROM `comments`);
+
+CREATE TABLE `likes_new` (`id` integer PRIMARY KEY AUTOINCREMENT,`wish_id` integer NOT NULL,`user_id` integer NOT NULL,`created_at` datetime,`updated_at` datetime,`deleted_at` datetime,CONSTRAINT `fk_wishes_likes` FOREIGN KEY (`wish_id`) REFERENCES `wishes`(`id`) ON DELETE CASCADE,CONSTRAINT `fk_users_likes` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`));
+INSERT INTO `likes_new` SELECT * FROM `likes` WHERE `wish_id` IN (SELECT `id` FROM `wishes`);
+DROP TABLE `likes`;
+ALTER TABLE `likes_new` RENAME TO `likes`;
+CREATE INDEX `idx_likes_deleted_at` ON `likes`(`deleted_at`);
+CREATE UNIQUE INDEX `idx_user_wish` ON `likes`(`wish_id`,`user_id`);
+
+CREATE TABLE `comments_new` (`id` integer PRIMARY KEY AUTOINCREMENT,`wish_id` integer NOT NULL,`parent_id` integer DEFAULT null,`user_id` integer NOT NULL,`content` text NOT NULL,`like_count` integer NOT NULL DEFAULT 0,`created_at` datetime,`updated_at` datetime,`deleted_at` datetime,`moderation_status` text NOT NULL DEFAULT 'approved',`moderation_reason` text NOT NULL DEFAULT '',CONSTRAINT `fk_comments_replies` FOREIGN KEY (`parent_id`) REFERENCES `comments`(`id`) ON DELETE CASCADE,CONSTRAINT `fk_wishes_comments` FOREIGN KEY (`wish_id`) REFERENCES `wishes`(`id`) ON DELETE CASCADE,CONSTRAINT `fk_users_comments` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`));
+INSERT INTO `comments_new` SELECT * FROM `comments` WHERE `wish_id` IN (SELECT `id` FROM `wishes`);
+DROP TABLE `comments`;
+ALTER TABLE `comments_new` RENAME TO `comments`;
+CREATE INDEX `idx_comments_moderation_status` ON `comments`(`moderation_status`);
+CREATE INDEX `idx_comments_deleted_at` ON `comments`(`deleted_at`);
+CREATE INDEX `idx_comments_user_id` ON `comments`(`user_id`);
+CREATE INDEX `idx_comments_parent_id` ON `comments`(`parent_id`);
+CREATE INDEX `idx_comments_wish_id` ON `comments`(`wish_id`);
+
+CREATE TABLE `wish_tags_new` (`id` integer PRIMARY KEY AUTOINCREMENT,`wish_id` integer NOT NULL,`tag_name` text NOT NULL,`created_at` datetime,CONSTRAINT `fk_wishes_tags` FOREIGN KEY (`wish_id`) REFERENCES `wishes`(`id`) ON DELETE CASCADE);
+INSERT INTO `wish_tags_new` SELECT * FROM `wish_tags` WHERE `wish_id` IN (SELECT `id` FROM `wishes`);
+DROP TABLE `wish_tags`;
+ALTER TABLE `wish_tags_new` RENAME TO `wish_tags`;
+CREATE INDEX `idx_wish_tags_tag_name` ON `wish_tags`(`tag_name`);
+CREATE INDEX `idx_wish_tags_wish_id` ON `wish_tags`(`wish_id`);
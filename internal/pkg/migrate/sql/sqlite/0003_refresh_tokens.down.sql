@@ -0,0 +1,3 @@
+-- 删除刷新令牌表，所有用户需要重新登录
+
+DROP TABLE IF EXISTS `refresh_tokens`;
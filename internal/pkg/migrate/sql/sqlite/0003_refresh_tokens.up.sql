@@ -0,0 +1,6 @@
+-- 刷新令牌：只保存哈希，按令牌族 (一次登录) 换发与吊销；用户被删除时一并删除
+
+CREATE TABLE IF NOT EXISTS `refresh_tokens` (`id` integer PRIMARY KEY AUTOINCREMENT,`user_id` integer NOT NULL,`family_id` text NOT NULL,`token_hash` text NOT NULL,`user_agent` text NOT NULL DEFAULT '',`ip` text NOT NULL DEFAULT '',`expires_at` datetime NOT NULL,`revoked_at` datetime,`replaced_by_id` integer,`created_at` datetime,CONSTRAINT `fk_users_refresh_tokens` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_refresh_tokens_token_hash` ON `refresh_tokens`(`token_hash`);
+CREATE INDEX IF NOT EXISTS `idx_refresh_tokens_user_id` ON `refresh_tokens`(`user_id`);
+CREATE INDEX IF NOT EXISTS `idx_refresh_tokens_family_id` ON `refresh_tokens`(`family_id`);
@@ -0,0 +1,3 @@
+-- 删除登录会话表；刷新令牌仍然保留，回滚后的程序按令牌族判断会话是否有效
+
+DROP TABLE IF EXISTS `sessions`;
@@ -0,0 +1,10 @@
+-- 登录会话：每次登录一条记录，用于设备列表和吊销访问令牌。
+-- 为升级前登录、刷新令牌仍然有效的令牌族补建会话，避免这些用户被迫重新登录
+
+CREATE TABLE IF NOT EXISTS `sessions` (`id` integer PRIMARY KEY AUTOINCREMENT,`user_id` integer NOT NULL,`jti` text NOT NULL,`user_agent` text NOT NULL DEFAULT '',`ip` text NOT NULL DEFAULT '',`created_at` datetime,`last_seen_at` datetime NOT NULL,`expires_at` datetime NOT NULL,CONSTRAINT `fk_users_sessions` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_sessions_jti` ON `sessions`(`jti`);
+CREATE INDEX IF NOT EXISTS `idx_sessions_user_id` ON `sessions`(`user_id`);
+
+INSERT OR IGNORE INTO `sessions` (`user_id`, `jti`, `user_agent`, `ip`, `created_at`, `last_seen_at`, `expires_at`)
+  SELECT `user_id`, `family_id`, `user_agent`, `ip`, `created_at`, `created_at`, `expires_at`
+  FROM `refresh_tokens` WHERE `revoked_at` IS NULL AND `expires_at` > CURRENT_TIMESTAMP;
@@ -0,0 +1,5 @@
+-- 密码重置码：只保存哈希，一次性使用，过期后失效
+
+CREATE TABLE IF NOT EXISTS `password_resets` (`id` integer PRIMARY KEY AUTOINCREMENT,`user_id` integer NOT NULL,`code_hash` text NOT NULL,`issued_by` integer,`channel` text NOT NULL DEFAULT '',`expires_at` datetime NOT NULL,`used_at` datetime,`created_at` datetime,CONSTRAINT `fk_users_password_resets` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_password_resets_code_hash` ON `password_resets`(`code_hash`);
+CREATE INDEX IF NOT EXISTS `idx_password_resets_user_id` ON `password_resets`(`user_id`);
@@ -0,0 +1,4 @@
+-- 删除登录失败限制的表；已锁定的学号和 IP 随之解锁
+
+DROP TABLE IF EXISTS `login_lockouts`;
+DROP TABLE IF EXISTS `login_failures`;
@@ -0,0 +1,8 @@
+-- 登录失败限制：失败记录 (滑动窗口计数) 与锁定状态，多个实例共享
+
+CREATE TABLE IF NOT EXISTS `login_failures` (`id` integer PRIMARY KEY AUTOINCREMENT,`throttle_key` text NOT NULL,`created_at` datetime NOT NULL);
+CREATE INDEX IF NOT EXISTS `idx_login_failures_key_created` ON `login_failures`(`throttle_key`,`created_at`);
+CREATE INDEX IF NOT EXISTS `idx_login_failures_created_at` ON `login_failures`(`created_at`);
+
+CREATE TABLE IF NOT EXISTS `login_lockouts` (`throttle_key` text,`lockouts` integer NOT NULL DEFAULT 0,`locked_until` datetime NOT NULL,`updated_at` datetime,PRIMARY KEY (`throttle_key`));
+CREATE INDEX IF NOT EXISTS `idx_login_lockouts_locked_until` ON `login_lockouts`(`locked_until`);
@@ -0,0 +1,78 @@
+package migrate
+
+import (
+	"fmt"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/logger"
+	"gorm.io/gorm"
+)
+
+// initVersion 是初始表结构的版本 (0001_init)
+const initVersion = 1
+
+// addedColumn 是 AutoMigrate 时代的表上后来新增的列
+type addedColumn struct {
+	table, column string
+	mysql, sqlite string // 两种方言的列定义
+	backfill      string // 不为空时把已有的行设为该值
+	index         string // 不为空时同时建立该索引 (只用于 MySQL；SQLite 的索引由 0001 的 CREATE INDEX IF NOT EXISTS 补齐)
+}
+
+// addedColumns 列出 0001_init 中有、而之前由 AutoMigrate 建立的表中没有的列。
+// 0001 使用 CREATE TABLE IF NOT EXISTS，对已有的表不起作用，接管时需要先补齐这些列，
+// 之后的迁移 (如 0002 在 SQLite 上重建表) 才能按 0001 的表结构执行
+var addedColumns = []addedColumn{
+	{table: "wishes", column: "moderation_status", mysql: "varchar(16) NOT NULL DEFAULT 'approved'", sqlite: "text NOT NULL DEFAULT 'approved'", backfill: "approved", index: "idx_wishes_moderation_status"},
+	{table: "wishes", column: "moderation_reason", mysql: "varchar(255) NOT NULL DEFAULT ''", sqlite: "text NOT NULL DEFAULT ''"},
+	{table: "comments", column: "moderation_status", mysql: "varchar(16) NOT NULL DEFAULT 'approved'", sqlite: "text NOT NULL DEFAULT 'approved'", backfill: "approved", index: "idx_comments_moderation_status"},
+	{table: "comments", column: "moderation_reason", mysql: "varchar(255) NOT NULL DEFAULT ''", sqlite: "text NOT NULL DEFAULT ''"},
+}
+
+// takeover 在执行 0001_init 前为 AutoMigrate 建立的旧表补齐缺少的列。
+// 升级前已经公开的愿望和评论视为审核通过，保持可见。每一列都先检查是否存在，可以重复执行
+func (m *Migrator) takeover(conn *gorm.DB) error {
+	for _, c := range addedColumns {
+		if !conn.Migrator().HasTable(c.table) {
+			continue
+		}
+		exists, err := m.hasColumn(conn, c.table, c.column)
+		if err != nil {
+			return fmt.Errorf("检查 %s.%s 是否存在失败: %w", c.table, c.column, err)
+		}
+		if exists {
+			continue
+		}
+
+		definition := c.mysql
+		if m.dialect == "sqlite" {
+			definition = c.sqlite
+		}
+		statements := []string{fmt.Sprintf("ALTER TABLE `%s` ADD COLUMN `%s` %s", c.table, c.column, definition)}
+		if c.backfill != "" {
+			statements = append(statements, fmt.Sprintf("UPDATE `%s` SET `%s` = '%s'", c.table, c.column, c.backfill))
+		}
+		if c.index != "" && m.dialect != "sqlite" {
+			statements = append(statements, fmt.Sprintf("ALTER TABLE `%s` ADD INDEX `%s` (`%s`)", c.table, c.index, c.column))
+		}
+		for _, stmt := range statements {
+			if err := conn.Exec(stmt).Error; err != nil {
+				return fmt.Errorf("为 %s 补充列 %s 失败: %w", c.table, c.column, err)
+			}
+		}
+		logger.Log.Infow("接管 AutoMigrate 建立的表，已补充缺少的列", "table", c.table, "column", c.column)
+	}
+	return nil
+}
+
+// hasColumn 检查表中是否有某一列：MySQL 查询 information_schema，SQLite 使用 PRAGMA table_info
+func (m *Migrator) hasColumn(conn *gorm.DB, table, column string) (bool, error) {
+	var n int64
+	query := "SELECT COUNT(*) FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND COLUMN_NAME = ?"
+	if m.dialect == "sqlite" {
+		query = "SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?"
+	}
+	if err := conn.Raw(query, table, column).Scan(&n).Error; err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
@@ -0,0 +1,66 @@
+package mockllm
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// DefaultRules 与 handler 测试中的 fakeModerator 保持一致：辱骂、广告各一条，"模棱两可" 返回无法解析的回答
+func DefaultRules() []Rule {
+	return []Rule{
+		{Match: "我恨这个世界", Categories: []string{"辱骂"}, Confidence: 0.95},
+		{Match: "加我微信", Categories: []string{"广告"}, Confidence: 0.9},
+		{Match: "模棱两可", Reply: "我不确定"},
+	}
+}
+
+// ruleJSON 是规则文件中的一条规则，latency 使用 time.ParseDuration 的格式（如 "300ms"）
+type ruleJSON struct {
+	Match      string   `json:"match"`
+	Categories []string `json:"categories"`
+	Confidence float64  `json:"confidence"`
+	Reply      string   `json:"reply"`
+	Empty      bool     `json:"empty"`
+	Status     int      `json:"status"`
+	Latency    string   `json:"latency"`
+	Times      int      `json:"times"`
+}
+
+// LoadRules 读取 JSON 格式的规则文件：
+//
+//	[
+//	  { "match": "加我微信", "categories": ["广告"], "confidence": 0.9 },
+//	  { "match": "超时", "latency": "15s" },
+//	  { "match": "", "status": 503, "times": 2 }
+//	]
+func LoadRules(path string) ([]Rule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var raw []ruleJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("解析规则文件失败: %w", err)
+	}
+	rules := make([]Rule, 0, len(raw))
+	for i, r := range raw {
+		rule := Rule{
+			Match:      r.Match,
+			Categories: r.Categories,
+			Confidence: r.Confidence,
+			Reply:      r.Reply,
+			Empty:      r.Empty,
+			Status:     r.Status,
+			Times:      r.Times,
+		}
+		if r.Latency != "" {
+			if rule.Latency, err = time.ParseDuration(r.Latency); err != nil {
+				return nil, fmt.Errorf("第 %d 条规则的 latency 无效: %w", i+1, err)
+			}
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
@@ -0,0 +1,218 @@
+// Package mockllm 是兼容 OpenAI chat-completions 协议的假大模型服务，用于离线测试与本地开发。
+// 按规则（正则 → 审核结论）回答审核请求，并支持注入延迟、HTTP 错误、空回答和无法解析的回答：
+//
+//	srv := mockllm.NewTestServer(
+//		mockllm.Rule{Match: "加我微信", Categories: []string{"广告"}, Confidence: 0.9},
+//		mockllm.Rule{Match: "模棱两可", Reply: "我不确定"},
+//	)
+//	defer srv.Close()
+//	moderator := service.NewLLMModerator(service.LLMConfig{APIKey: "test", BaseURL: srv.BaseURL()})
+package mockllm
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// Rule 是一条应答规则，按顺序匹配，第一条命中的规则生效；没有规则命中时回答安全
+type Rule struct {
+	Match      string        // 匹配用户消息的正则，为空时匹配所有请求
+	Categories []string      // 命中的违规类别，为空表示内容安全
+	Confidence float64       // 违规把握
+	Reply      string        // 非空时原样作为模型的回答，用于模拟无法解析的回答
+	Empty      bool          // 返回空的 choices
+	Status     int           // 非 0 时返回该 HTTP 状态码与 OpenAI 格式的错误
+	Latency    time.Duration // 应答前的等待时间，客户端断开时提前结束
+	Times      int           // 只对前 Times 次匹配生效，0 表示不限次数，用于模拟"失败几次后恢复"
+}
+
+type rule struct {
+	Rule
+	re   *regexp.Regexp
+	hits int
+}
+
+// Request 是服务收到的一次审核请求
+type Request struct {
+	Model   string
+	System  string // 系统提示词
+	Content string // 最后一条用户消息
+	Rule    int    // 命中的规则下标，-1 表示没有命中
+}
+
+// Server 按规则应答 chat-completions 请求，实现 http.Handler，可以被多个 goroutine 同时使用
+type Server struct {
+	// Logger 非 nil 时记录每个请求的命中规则与应答
+	Logger *log.Logger
+
+	mu       sync.Mutex
+	rules    []*rule
+	requests []Request
+}
+
+// New 创建服务；规则中的正则无效时返回错误
+func New(rules ...Rule) (*Server, error) {
+	s := &Server{}
+	if err := s.SetRules(rules...); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// SetRules 替换全部规则并清零命中计数
+func (s *Server) SetRules(rules ...Rule) error {
+	compiled := make([]*rule, 0, len(rules))
+	for i, r := range rules {
+		re, err := regexp.Compile(r.Match)
+		if err != nil {
+			return fmt.Errorf("第 %d 条规则的正则无效: %w", i+1, err)
+		}
+		compiled = append(compiled, &rule{Rule: r, re: re})
+	}
+	s.mu.Lock()
+	s.rules = compiled
+	s.mu.Unlock()
+	return nil
+}
+
+// Requests 返回目前收到的所有请求
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || !strings.HasSuffix(r.URL.Path, "/chat/completions") {
+		writeError(w, http.StatusNotFound, "invalid_request_error", "unknown endpoint "+r.Method+" "+r.URL.Path)
+		return
+	}
+	var req openai.ChatCompletionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", "invalid JSON body: "+err.Error())
+		return
+	}
+	record := Request{Model: req.Model, Rule: -1}
+	for _, m := range req.Messages {
+		switch m.Role {
+		case openai.ChatMessageRoleSystem:
+			record.System = m.Content
+		case openai.ChatMessageRoleUser:
+			record.Content = m.Content
+		}
+	}
+
+	matched := s.match(&record)
+	s.logf("%s %q -> rule %d", req.Model, record.Content, record.Rule)
+
+	if matched.Latency > 0 {
+		select {
+		case <-time.After(matched.Latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	if matched.Status != 0 {
+		writeError(w, matched.Status, "server_error", fmt.Sprintf("mock error %d", matched.Status))
+		return
+	}
+
+	resp := openai.ChatCompletionResponse{
+		ID:      "mock-" + fmt.Sprint(time.Now().UnixNano()),
+		Object:  "chat.completion",
+		Created: time.Now().Unix(),
+		Model:   req.Model,
+	}
+	if !matched.Empty {
+		resp.Choices = []openai.ChatCompletionChoice{{
+			Message:      openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: matched.answer()},
+			FinishReason: openai.FinishReasonStop,
+		}}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// match 找到第一条命中的规则并记录请求；没有命中时返回空规则（回答安全）
+func (s *Server) match(record *Request) Rule {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer func() { s.requests = append(s.requests, *record) }()
+	for i, r := range s.rules {
+		if r.Times > 0 && r.hits >= r.Times {
+			continue
+		}
+		if r.re.MatchString(record.Content) {
+			r.hits++
+			record.Rule = i
+			return r.Rule
+		}
+	}
+	return Rule{}
+}
+
+// answer 生成与 systemPrompt 要求一致的 JSON 回答
+func (r Rule) answer() string {
+	if r.Reply != "" {
+		return r.Reply
+	}
+	categories := r.Categories
+	if categories == nil {
+		categories = []string{}
+	}
+	confidence := r.Confidence
+	if len(categories) == 0 && confidence == 0 {
+		confidence = 0.01
+	}
+	data, _ := json.Marshal(map[string]interface{}{
+		"safe":       len(categories) == 0,
+		"categories": categories,
+		"confidence": confidence,
+	})
+	return string(data)
+}
+
+func (s *Server) logf(format string, args ...interface{}) {
+	if s.Logger != nil {
+		s.Logger.Printf(format, args...)
+	}
+}
+
+// writeError 按 OpenAI 的错误格式应答，go-openai 会解析为 *openai.APIError
+func writeError(w http.ResponseWriter, status int, errType, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": map[string]interface{}{"message": message, "type": errType},
+	})
+}
+
+// TestServer 是运行在本地随机端口上的 Server
+type TestServer struct {
+	*Server
+	HTTP *httptest.Server
+}
+
+// NewTestServer 启动服务，规则中的正则无效时 panic；用完后调用 Close
+func NewTestServer(rules ...Rule) *TestServer {
+	s, err := New(rules...)
+	if err != nil {
+		panic("mockllm: " + err.Error())
+	}
+	return &TestServer{Server: s, HTTP: httptest.NewServer(s)}
+}
+
+// BaseURL 返回 go-openai 客户端（SILICONFLOW_BASE_URL）使用的地址
+func (t *TestServer) BaseURL() string { return t.HTTP.URL + "/v1" }
+
+// Close 关闭服务
+func (t *TestServer) Close() { t.HTTP.Close() }
@@ -0,0 +1,87 @@
+package mockllm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func chat(srv *TestServer, content string) (openai.ChatCompletionResponse, error) {
+	config := openai.DefaultConfig("test")
+	config.BaseURL = srv.BaseURL()
+	return openai.NewClientWithConfig(config).CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{
+		Model:    "mock",
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: content}},
+	})
+}
+
+func TestServerRules(t *testing.T) {
+	srv := NewTestServer(
+		Rule{Match: "^fail", Status: http.StatusTooManyRequests, Times: 1},
+		Rule{Match: "微信", Categories: []string{"广告"}, Confidence: 0.9},
+		Rule{Match: "empty", Empty: true},
+		Rule{Match: "胡言乱语", Reply: "我不确定"},
+	)
+	defer srv.Close()
+
+	resp, err := chat(srv, "加我微信")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"safe": false, "categories": ["广告"], "confidence": 0.9}`, resp.Choices[0].Message.Content)
+
+	resp, err = chat(srv, "你好")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"safe": true, "categories": [], "confidence": 0.01}`, resp.Choices[0].Message.Content)
+
+	resp, err = chat(srv, "胡言乱语")
+	require.NoError(t, err)
+	assert.Equal(t, "我不确定", resp.Choices[0].Message.Content)
+
+	resp, err = chat(srv, "empty")
+	require.NoError(t, err)
+	assert.Empty(t, resp.Choices)
+
+	// 错误规则只生效一次
+	_, err = chat(srv, "fail once")
+	var apiErr *openai.APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusTooManyRequests, apiErr.HTTPStatusCode)
+	_, err = chat(srv, "fail once")
+	assert.NoError(t, err)
+
+	requests := srv.Requests()
+	require.Len(t, requests, 6)
+	assert.Equal(t, "mock", requests[0].Model)
+	assert.Equal(t, 1, requests[0].Rule)
+	assert.Equal(t, -1, requests[1].Rule)
+	assert.Equal(t, -1, requests[5].Rule)
+}
+
+func TestLoadRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.json")
+	require.NoError(t, os.WriteFile(path, []byte(`[
+		{"match": "微信", "categories": ["广告"], "confidence": 0.9},
+		{"match": "慢", "latency": "300ms", "times": 2}
+	]`), 0o644))
+
+	rules, err := LoadRules(path)
+	require.NoError(t, err)
+	assert.Equal(t, []Rule{
+		{Match: "微信", Categories: []string{"广告"}, Confidence: 0.9},
+		{Match: "慢", Latency: 300 * time.Millisecond, Times: 2},
+	}, rules)
+
+	require.NoError(t, os.WriteFile(path, []byte(`[{"latency": "soon"}]`), 0o644))
+	_, err = LoadRules(path)
+	assert.ErrorContains(t, err, "latency 无效")
+
+	_, err = New(Rule{Match: "("})
+	assert.ErrorContains(t, err, "正则无效")
+}
@@ -0,0 +1,105 @@
+package seeder
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/model"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// 压测数据的规模
+const (
+	loadUsers         = 200
+	loadWishesPerUser = 10
+	loadMaxLikes      = 20 // 每条愿望最多的点赞数
+	loadMaxComments   = 5  // 每条愿望最多的评论数
+	loadPassword      = "loadtest"
+	loadUsernameBase  = 9900000000 // 压测用户的学号为 9900000001 起，可以用 loadtest 登录
+)
+
+// seedLoad 填充压测数据。使用固定的随机种子，每次生成的数据相同；点赞数和评论数与实际记录一致
+func seedLoad(db *gorm.DB) error {
+	first := fmt.Sprintf("%d", loadUsernameBase+1)
+	var count int64
+	if err := db.Model(&model.User{}).Where("username = ?", first).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		zap.S().Info("Seeder: 压测数据已存在，跳过数据填充。")
+		return nil
+	}
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte(loadPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	rng := rand.New(rand.NewSource(1))
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		users := make([]model.User, loadUsers)
+		for i := range users {
+			users[i] = model.User{
+				Username: fmt.Sprintf("%d", loadUsernameBase+i+1),
+				Password: string(hashed),
+				Nickname: fmt.Sprintf("压测用户%d", i+1),
+				Role:     model.RoleUser,
+			}
+		}
+		if err := tx.CreateInBatches(&users, 100).Error; err != nil {
+			return fmt.Errorf("Seeder: 创建压测用户失败: %w", err)
+		}
+
+		// 愿望的发布时间分散在最近 30 天内，便于调试按时间排序的分页
+		now := time.Now()
+		wishes := make([]model.Wish, 0, loadUsers*loadWishesPerUser)
+		for i := 0; i < loadUsers*loadWishesPerUser; i++ {
+			author := users[rng.Intn(len(users))]
+			created := now.Add(-time.Duration(rng.Int63n(int64(30 * 24 * time.Hour))))
+			wishes = append(wishes, model.Wish{
+				UserID:       author.ID,
+				UserNickname: author.Nickname,
+				Content:      fmt.Sprintf("压测愿望 #%d", i+1),
+				IsPublic:     rng.Intn(10) > 0,
+				Background:   "default",
+				LikeCount:    rng.Intn(loadMaxLikes + 1),
+				CommentCount: rng.Intn(loadMaxComments + 1),
+				CreatedAt:    created,
+				UpdatedAt:    created,
+			})
+		}
+		if err := tx.CreateInBatches(&wishes, 200).Error; err != nil {
+			return fmt.Errorf("Seeder: 创建压测愿望失败: %w", err)
+		}
+
+		var likes []model.Like
+		var comments []model.Comment
+		for _, wish := range wishes {
+			// 从随机位置开始连续取用户，保证同一条愿望的点赞者不重复
+			start := rng.Intn(len(users))
+			for j := 0; j < wish.LikeCount; j++ {
+				likes = append(likes, model.Like{WishID: wish.ID, UserID: users[(start+j)%len(users)].ID})
+			}
+			for j := 0; j < wish.CommentCount; j++ {
+				comments = append(comments, model.Comment{
+					WishID:           wish.ID,
+					UserID:           users[rng.Intn(len(users))].ID,
+					Content:          fmt.Sprintf("压测评论 #%d", j+1),
+					ModerationStatus: model.ModerationApproved,
+				})
+			}
+		}
+		if err := tx.CreateInBatches(&likes, 500).Error; err != nil {
+			return fmt.Errorf("Seeder: 创建压测点赞失败: %w", err)
+		}
+		if err := tx.CreateInBatches(&comments, 500).Error; err != nil {
+			return fmt.Errorf("Seeder: 创建压测评论失败: %w", err)
+		}
+		zap.S().Infof("Seeder: 成功创建压测数据: %d 个用户 (密码 %s)，%d 条愿望，%d 个点赞，%d 条评论。",
+			len(users), loadPassword, len(wishes), len(likes), len(comments))
+		return nil
+	})
+}
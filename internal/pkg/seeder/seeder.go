@@ -1,20 +1,62 @@
 package seeder
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/model"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
-// SeedData 负责填充初始数据
-func SeedData(db *gorm.DB) {
+// ProfileNone 表示不填充任何数据
+const ProfileNone = "none"
+
+// Profile 是一组可以填充的初始数据，重复填充同一组数据时会跳过
+type Profile struct {
+	Name        string
+	Description string
+	seed        func(db *gorm.DB) error
+}
+
+var profiles = []Profile{
+	{Name: "demo", Description: "10 个机器人用户和 55 条公开愿望，用于本地开发和演示", seed: seedDemo},
+	{Name: "load", Description: "200 个用户、2000 条愿望以及点赞和评论，用于压测和分页调试", seed: seedLoad},
+}
+
+// Profiles 返回全部数据填充方案
+func Profiles() []Profile {
+	return profiles
+}
+
+// Run 按名称执行数据填充，name 为 none 或空字符串时不填充
+func Run(db *gorm.DB, name string) error {
+	if name == "" || name == ProfileNone {
+		return nil
+	}
+	for _, p := range profiles {
+		if p.Name == name {
+			return p.seed(db)
+		}
+	}
+	names := make([]string, 0, len(profiles))
+	for _, p := range profiles {
+		names = append(names, p.Name)
+	}
+	return fmt.Errorf("未知的数据填充方案 %q (可选 %s)", name, strings.Join(names, " / "))
+}
+
+// seedDemo 填充演示数据
+func seedDemo(db *gorm.DB) error {
 	//  检查是否需要填充 
 	var count int64
-	db.Model(&model.User{}).Where("role = ?", "bot").Count(&count)
+	if err := db.Model(&model.User{}).Where("username = ?", "bot_1").Count(&count).Error; err != nil {
+		return err
+	}
 
 	if count > 0 {
 		zap.S().Info("Seeder: 数据库中已存在 'bot' 用户，跳过数据填充。")
-		return
+		return nil
 	}
 	zap.S().Info("Seeder: 未发现 'bot' 用户，开始执行数据填充...")
 
@@ -32,7 +74,7 @@ func SeedData(db *gorm.DB) {
 		{Username: "bot_10", Password: "bot_fake_password_hash", Nickname: "海的女儿", Role: "bot"},
 	}
 	if err := db.Create(&bots).Error; err != nil {
-		zap.S().Fatalf("Seeder: 创建 'bot' 用户失败: %v", err)
+		return fmt.Errorf("Seeder: 创建 'bot' 用户失败: %w", err)
 	}
 	zap.S().Infof("Seeder: 成功创建 %d 个 'bot' 用户。", len(bots))
 
@@ -58,8 +100,9 @@ func SeedData(db *gorm.DB) {
 		})
 	}
 	if err := db.Create(&fakeWishes).Error; err != nil {
-		zap.S().Fatalf("Seeder: 创建 'fake' 愿望失败: %v", err)
+		return fmt.Errorf("Seeder: 创建 'fake' 愿望失败: %w", err)
 	}
 	zap.S().Infof("Seeder: 成功创建 %d 条 'fake' 愿望。", len(fakeWishes))
 	zap.S().Info("Seeder: 数据填充成功完成！")
+	return nil
 }
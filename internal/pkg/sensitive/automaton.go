@@ -0,0 +1,92 @@
+package sensitive
+
+// automaton 是基于 rune 的 Aho-Corasick 多模式匹配自动机
+// 构建后只读，可被多个 goroutine 并发使用
+type automaton struct {
+	nodes    []acNode
+	patterns [][]rune
+}
+
+type acNode struct {
+	next map[rune]int32
+	fail int32
+	out  []int32 // 以该节点结尾的模式下标（含 fail 链上继承的输出）
+}
+
+// match 表示一次命中：patterns[pattern] 在文本中占据 [start, end)
+type match struct {
+	pattern    int
+	start, end int
+}
+
+func newAutomaton(patterns [][]rune) *automaton {
+	a := &automaton{nodes: []acNode{{next: map[rune]int32{}}}, patterns: patterns}
+
+	// 1. 构建 trie
+	for i, p := range patterns {
+		if len(p) == 0 {
+			continue
+		}
+		cur := int32(0)
+		for _, r := range p {
+			nxt, ok := a.nodes[cur].next[r]
+			if !ok {
+				a.nodes = append(a.nodes, acNode{next: map[rune]int32{}})
+				nxt = int32(len(a.nodes) - 1)
+				a.nodes[cur].next[r] = nxt
+			}
+			cur = nxt
+		}
+		a.nodes[cur].out = append(a.nodes[cur].out, int32(i))
+	}
+
+	// 2. BFS 计算 fail 指针
+	queue := make([]int32, 0, len(a.nodes))
+	for _, child := range a.nodes[0].next {
+		queue = append(queue, child)
+	}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for r, child := range a.nodes[cur].next {
+			f := a.nodes[cur].fail
+			for {
+				if nxt, ok := a.nodes[f].next[r]; ok && nxt != child {
+					a.nodes[child].fail = nxt
+					break
+				}
+				if f == 0 {
+					a.nodes[child].fail = 0
+					break
+				}
+				f = a.nodes[f].fail
+			}
+			a.nodes[child].out = append(a.nodes[child].out, a.nodes[a.nodes[child].fail].out...)
+			queue = append(queue, child)
+		}
+	}
+	return a
+}
+
+// findAll 返回文本中所有（可重叠的）命中
+func (a *automaton) findAll(text []rune) []match {
+	var matches []match
+	cur := int32(0)
+	for i, r := range text {
+		for {
+			if nxt, ok := a.nodes[cur].next[r]; ok {
+				cur = nxt
+				break
+			}
+			if cur == 0 {
+				break
+			}
+			cur = a.nodes[cur].fail
+		}
+		for _, p := range a.nodes[cur].out {
+			n := len(a.patterns[p])
+			matches = append(matches, match{pattern: int(p), start: i - n + 1, end: i + 1})
+		}
+	}
+	return matches
+}
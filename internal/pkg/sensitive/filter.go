@@ -0,0 +1,307 @@
+// Package sensitive 实现本地敏感词过滤：基于 Aho-Corasick 的多模式匹配，
+// 支持类别、全角/大小写/形近字归一化、可选的拼音匹配以及词表热更新。
+package sensitive
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Action 表示命中后的处理方式
+type Action string
+
+const (
+	ActionReject Action = "reject" // 直接拒绝
+	ActionMask   Action = "mask"   // 用 * 打码后放行
+)
+
+// Word 是词表中的一个词条
+type Word struct {
+	Text     string
+	Category string
+	Action   Action
+	Pinyin   bool // 是否同时按拼音匹配（识别 "沙比"、"shabi" 这类谐音写法）
+}
+
+// Hit 是一次命中，Start/End 为原文中的 rune 下标区间 [Start, End)
+type Hit struct {
+	Word     string
+	Category string
+	Action   Action
+	Start    int
+	End      int
+}
+
+// Result 是一次过滤的结果
+type Result struct {
+	Hits   []Hit
+	Masked string // 所有命中位置替换为 * 之后的文本
+}
+
+// Rejected 返回第一个需要拒绝的命中
+func (r Result) Rejected() (Hit, bool) {
+	for _, h := range r.Hits {
+		if h.Action == ActionReject {
+			return h, true
+		}
+	}
+	return Hit{}, false
+}
+
+// minPinyinLength 拼音模式的最短长度，过短的拼音（如 "sb"）误伤太多，不参与拼音匹配
+const minPinyinLength = 4
+
+// dictionary 是一份编译好的词表，构建后只读
+type dictionary struct {
+	words     []Word
+	direct    *automaton // 归一化字面匹配
+	phonetic  *automaton // 拼音匹配
+	phoneticW []int      // phonetic 中第 i 个模式对应的 words 下标
+}
+
+func compile(words []Word) *dictionary {
+	d := &dictionary{words: words}
+	directPatterns := make([][]rune, len(words))
+	var phoneticPatterns [][]rune
+	for i, w := range words {
+		norm, idx := normalize(w.Text)
+		directPatterns[i] = norm
+		if w.Pinyin {
+			py, _, _ := toPinyin(norm, idx)
+			if len(py) >= minPinyinLength {
+				phoneticPatterns = append(phoneticPatterns, py)
+				d.phoneticW = append(d.phoneticW, i)
+			}
+		}
+	}
+	d.direct = newAutomaton(directPatterns)
+	d.phonetic = newAutomaton(phoneticPatterns)
+	return d
+}
+
+// Filter 是并发安全的敏感词过滤器，词表可在运行时整体替换
+type Filter struct {
+	dict atomic.Pointer[dictionary]
+
+	mu      sync.Mutex // 保护下面的文件状态
+	path    string
+	modTime time.Time
+}
+
+// New 使用给定词条创建过滤器
+func New(words []Word) *Filter {
+	f := &Filter{}
+	f.dict.Store(compile(words))
+	return f
+}
+
+//go:embed default_words.txt
+var defaultWords string
+
+// Default 使用内置词表创建过滤器
+func Default() *Filter {
+	words, err := Parse(strings.NewReader(defaultWords))
+	if err != nil {
+		// 内置词表由我们自己维护，解析失败属于编程错误
+		panic(fmt.Sprintf("sensitive: 内置词表解析失败: %v", err))
+	}
+	return New(words)
+}
+
+// LoadFile 从词表文件创建过滤器，之后可通过 Reload/Watch 热更新
+func LoadFile(path string) (*Filter, error) {
+	f := &Filter{path: path}
+	if err := f.Reload(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Parse 解析词表。格式：
+//
+//	# 注释
+//	[辱骂]              <- 类别，默认动作为 reject
+//	傻逼
+//	[广告:mask,pinyin]  <- 类别:选项，选项可为 reject / mask / pinyin，逗号分隔
+//	加微信
+func Parse(r io.Reader) ([]Word, error) {
+	var (
+		words    []Word
+		category = "违规"
+		action   = ActionReject
+		usePy    = false
+	)
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			header := strings.TrimSuffix(strings.TrimPrefix(line, "["), "]")
+			name, opts, _ := strings.Cut(header, ":")
+			category = strings.TrimSpace(name)
+			if category == "" {
+				return nil, fmt.Errorf("第 %d 行: 类别名不能为空", lineNo)
+			}
+			action, usePy = ActionReject, false
+			for _, opt := range strings.Split(opts, ",") {
+				switch strings.TrimSpace(opt) {
+				case "":
+				case string(ActionReject):
+					action = ActionReject
+				case string(ActionMask):
+					action = ActionMask
+				case "pinyin":
+					usePy = true
+				default:
+					return nil, fmt.Errorf("第 %d 行: 未知选项 %q", lineNo, opt)
+				}
+			}
+			continue
+		}
+		if norm, _ := normalize(line); len(norm) == 0 {
+			return nil, fmt.Errorf("第 %d 行: 词条 %q 归一化后为空", lineNo, line)
+		}
+		words = append(words, Word{Text: line, Category: category, Action: action, Pinyin: usePy})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return words, nil
+}
+
+// Reload 从文件重新加载词表；失败时保留旧词表
+func (f *Filter) Reload() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.reloadLocked()
+}
+
+func (f *Filter) reloadLocked() error {
+	if f.path == "" {
+		return fmt.Errorf("sensitive: 过滤器未关联词表文件")
+	}
+	file, err := os.Open(f.path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	stat, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	words, err := Parse(file)
+	if err != nil {
+		return fmt.Errorf("sensitive: 解析词表 %s 失败: %w", f.path, err)
+	}
+	f.dict.Store(compile(words))
+	f.modTime = stat.ModTime()
+	return nil
+}
+
+// Watch 每隔 interval 检查一次词表文件的修改时间，有变化则热更新。
+// 返回的 stop 函数用于停止监听
+func (f *Filter) Watch(interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				f.reloadIfChanged()
+			}
+		}
+	}()
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }
+}
+
+func (f *Filter) reloadIfChanged() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	stat, err := os.Stat(f.path)
+	if err != nil {
+		zap.S().Warnw("敏感词表热更新:读取文件信息失败", "path", f.path, "error", err)
+		return
+	}
+	if stat.ModTime().Equal(f.modTime) {
+		return
+	}
+	if err := f.reloadLocked(); err != nil {
+		zap.S().Errorw("敏感词表热更新失败，继续使用旧词表", "path", f.path, "error", err)
+		return
+	}
+	zap.S().Infow("敏感词表热更新成功", "path", f.path, "words", f.Len())
+}
+
+// Len 返回当前词表的词条数
+func (f *Filter) Len() int {
+	return len(f.dict.Load().words)
+}
+
+// Check 检查文本，返回所有命中及打码后的文本
+func (f *Filter) Check(text string) Result {
+	d := f.dict.Load()
+	norm, index := normalize(text)
+
+	var hits []Hit
+	seen := make(map[[3]int]bool)
+	add := func(wordIdx, start, end int) {
+		key := [3]int{wordIdx, start, end}
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		w := d.words[wordIdx]
+		hits = append(hits, Hit{Word: w.Text, Category: w.Category, Action: w.Action, Start: start, End: end})
+	}
+
+	for _, m := range d.direct.findAll(norm) {
+		add(m.pattern, index[m.start], index[m.end-1]+1)
+	}
+	if len(d.phoneticW) > 0 {
+		py, pyIndex, bounds := toPinyin(norm, index)
+		for _, m := range d.phonetic.findAll(py) {
+			// 只接受落在完整音节上的命中，拒绝从音节中间开始或结束的半截匹配
+			if bounds[m.start]&syllableStart == 0 || bounds[m.end-1]&syllableEnd == 0 {
+				continue
+			}
+			add(d.phoneticW[m.pattern], pyIndex[m.start], pyIndex[m.end-1]+1)
+		}
+	}
+
+	sort.Slice(hits, func(i, j int) bool { return hits[i].Start < hits[j].Start })
+	return Result{Hits: hits, Masked: mask(text, hits)}
+}
+
+// mask 把命中区间内的非分隔符字符替换为 *
+func mask(text string, hits []Hit) string {
+	if len(hits) == 0 {
+		return text
+	}
+	runes := []rune(text)
+	for _, h := range hits {
+		for i := h.Start; i < h.End && i < len(runes); i++ {
+			if _, ok := foldRune(runes[i]); ok {
+				runes[i] = '*'
+			}
+		}
+	}
+	return string(runes)
+}
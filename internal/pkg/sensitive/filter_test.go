@@ -0,0 +1,119 @@
+package sensitive
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testWords = `
+# 测试词表
+[辱骂:reject,pinyin]
+傻逼
+[广告]
+代开发票
+[联系方式:mask]
+加微信
+`
+
+func newTestFilter(t *testing.T) *Filter {
+	words, err := Parse(strings.NewReader(testWords))
+	require.NoError(t, err)
+	return New(words)
+}
+
+func TestFilterCheck(t *testing.T) {
+	f := newTestFilter(t)
+
+	testCases := []struct {
+		name         string
+		input        string
+		wantCategory string // 为空表示不应被拒绝
+		wantMasked   string
+	}{
+		{name: "正常内容", input: "希望期末考试顺利通过!", wantMasked: "希望期末考试顺利通过!"},
+		{name: "字面命中", input: "你是傻逼吗", wantCategory: "辱骂", wantMasked: "你是**吗"},
+		{name: "插入分隔符", input: "你是傻 * 逼吗", wantCategory: "辱骂", wantMasked: "你是* * *吗"},
+		{name: "全角与大小写", input: "ＳＨＡＢＩ", wantCategory: "辱骂", wantMasked: "*****"},
+		{name: "拼音谐音", input: "你真沙比", wantCategory: "辱骂", wantMasked: "你真**"},
+		{name: "拼音字母", input: "you are shabi", wantCategory: "辱骂", wantMasked: "you are *****"},
+		{name: "零宽字符", input: "代​开发票", wantCategory: "广告", wantMasked: "*​***"},
+		{name: "打码类别只打码不拒绝", input: "有事加微信聊", wantMasked: "有事***聊"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res := f.Check(tc.input)
+			hit, rejected := res.Rejected()
+			if tc.wantCategory == "" {
+				assert.False(t, rejected, "不应被拒绝，命中: %+v", res.Hits)
+			} else if assert.True(t, rejected, "应被拒绝") {
+				assert.Equal(t, tc.wantCategory, hit.Category)
+			}
+			assert.Equal(t, tc.wantMasked, res.Masked)
+		})
+	}
+}
+
+func TestFilterPinyinRequiresWholeSyllables(t *testing.T) {
+	f := New([]Word{{Text: "anbi", Category: "测试", Action: ActionReject, Pinyin: true}})
+
+	// "山比" 的拼音是 shan|bi，"anbi" 从音节中间开始，不应命中
+	_, rejected := f.Check("山比").Rejected()
+	assert.False(t, rejected)
+
+	// "安比" 的拼音是 an|bi，完整音节，应命中
+	_, rejected = f.Check("安比").Rejected()
+	assert.True(t, rejected)
+}
+
+func TestDefaultWordList(t *testing.T) {
+	f := Default()
+	assert.Greater(t, f.Len(), 0)
+	_, rejected := f.Check("草泥马").Rejected()
+	assert.True(t, rejected)
+}
+
+func TestParseErrors(t *testing.T) {
+	_, err := Parse(strings.NewReader("[辱骂:unknown]\n傻逼"))
+	assert.Error(t, err)
+
+	_, err = Parse(strings.NewReader("[辱骂]\n* * *"))
+	assert.Error(t, err, "归一化后为空的词条应报错")
+}
+
+func TestFilterWatchReloadsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "words.txt")
+	require.NoError(t, os.WriteFile(path, []byte("[广告]\n代开发票\n"), 0o644))
+
+	f, err := LoadFile(path)
+	require.NoError(t, err)
+	_, rejected := f.Check("刷单兼职").Rejected()
+	assert.False(t, rejected)
+
+	stop := f.Watch(10 * time.Millisecond)
+	defer stop()
+
+	require.NoError(t, os.WriteFile(path, []byte("[广告]\n代开发票\n刷单兼职\n"), 0o644))
+	// 确保修改时间一定变化（部分文件系统时间精度较低）
+	future := time.Now().Add(time.Second)
+	require.NoError(t, os.Chtimes(path, future, future))
+
+	assert.Eventually(t, func() bool {
+		_, rejected := f.Check("刷单兼职").Rejected()
+		return rejected
+	}, time.Second, 10*time.Millisecond)
+
+	// 写入无效词表时继续使用旧词表
+	require.NoError(t, os.WriteFile(path, []byte("[广告:bad]\n"), 0o644))
+	later := future.Add(time.Second)
+	require.NoError(t, os.Chtimes(path, later, later))
+	time.Sleep(50 * time.Millisecond)
+	_, rejected = f.Check("刷单兼职").Rejected()
+	assert.True(t, rejected)
+}
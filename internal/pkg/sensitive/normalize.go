@@ -0,0 +1,91 @@
+package sensitive
+
+import (
+	"unicode"
+
+	"github.com/mozillazg/go-pinyin"
+)
+
+// homoglyphs 把常见的形近字符（西里尔/希腊字母、数字替身）折叠成拉丁字母，
+// 用于识别 "ѕb"、"5b" 这类绕过写法
+var homoglyphs = map[rune]rune{
+	'а': 'a', 'в': 'b', 'е': 'e', 'к': 'k', 'м': 'm', 'н': 'h', 'о': 'o',
+	'р': 'p', 'с': 'c', 'т': 't', 'у': 'y', 'х': 'x', 'ѕ': 's', 'і': 'i',
+	'α': 'a', 'β': 'b', 'ε': 'e', 'ι': 'i', 'κ': 'k', 'ν': 'v', 'ο': 'o',
+	'ρ': 'p', 'τ': 't', 'υ': 'u', 'χ': 'x',
+	'0': 'o', '1': 'i', '3': 'e', '4': 'a', '5': 's', '7': 't',
+	'@': 'a', '$': 's',
+}
+
+// foldRune 做全角转半角、转小写、形近字折叠；返回 false 表示该字符是分隔符，应被忽略
+func foldRune(r rune) (rune, bool) {
+	switch {
+	case r == 0x3000:
+		return 0, false // 全角空格
+	case r >= 0xFF01 && r <= 0xFF5E:
+		r -= 0xFEE0 // 全角 ASCII -> 半角
+	}
+	r = unicode.ToLower(r)
+	if h, ok := homoglyphs[r]; ok {
+		return h, true
+	}
+	// 空白、标点、符号、零宽字符等分隔符一律跳过，用于识别 "傻 逼"、"傻*逼"、"傻​逼"
+	if unicode.IsSpace(r) || unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.Is(unicode.Cf, r) {
+		return 0, false
+	}
+	return r, true
+}
+
+// normalize 返回归一化后的字符序列，以及每个字符在原文中的 rune 下标
+func normalize(text string) ([]rune, []int) {
+	src := []rune(text)
+	out := make([]rune, 0, len(src))
+	index := make([]int, 0, len(src))
+	for i, r := range src {
+		if f, ok := foldRune(r); ok {
+			out = append(out, f)
+			index = append(index, i)
+		}
+	}
+	return out, index
+}
+
+var pinyinArgs = pinyin.NewArgs() // 默认 Normal 风格：不带声调
+
+// toPinyin 把归一化序列中的汉字展开为不带声调的拼音字母（多音字取第一个读音），
+// 其他字符保持不变；index 同步展开，保证每个字母都能映射回原文位置。
+// bounds[i] 的第 0/1 位分别表示第 i 个字母是否为音节的开头/结尾，用于拒绝跨音节的半截命中
+func toPinyin(norm []rune, index []int) (out []rune, outIndex []int, bounds []uint8) {
+	out = make([]rune, 0, len(norm)*3)
+	outIndex = make([]int, 0, len(norm)*3)
+	bounds = make([]uint8, 0, len(norm)*3)
+	for i, r := range norm {
+		if unicode.Is(unicode.Han, r) {
+			if py := pinyin.SinglePinyin(r, pinyinArgs); len(py) > 0 && py[0] != "" {
+				letters := []rune(py[0])
+				for j, c := range letters {
+					var b uint8
+					if j == 0 {
+						b |= syllableStart
+					}
+					if j == len(letters)-1 {
+						b |= syllableEnd
+					}
+					out = append(out, c)
+					outIndex = append(outIndex, index[i])
+					bounds = append(bounds, b)
+				}
+				continue
+			}
+		}
+		out = append(out, r)
+		outIndex = append(outIndex, index[i])
+		bounds = append(bounds, syllableStart|syllableEnd)
+	}
+	return out, outIndex, bounds
+}
+
+const (
+	syllableStart uint8 = 1 << iota
+	syllableEnd
+)
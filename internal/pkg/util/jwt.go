@@ -2,57 +2,103 @@ package util
 
 import (
 	"errors"
-	"os"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// MyCustomClaims 是访问令牌的内容；RegisteredClaims.ID (jti) 是签发该令牌的登录会话，
+// 同一会话刷新后签发的访问令牌 jti 不变，会话被删除后这些令牌全部失效
 type MyCustomClaims struct {
 	UserID uint `json:"userId"`
 	jwt.RegisteredClaims
 }
 
-func getJWTSecret() []byte {
-	secret := os.Getenv("JWT_SECRET")
+// SessionID 返回令牌所属的登录会话 (jti)
+func (c *MyCustomClaims) SessionID() string {
+	return c.ID
+}
+
+// JWT 负责签发和校验访问令牌，密钥来自密钥环 (JWT_KEYS_FILE) 或 JWT_SECRET，有效期来自配置 (config.JWTConfig)
+type JWT struct {
+	keys KeySource
+	ttl  time.Duration
+}
+
+// NewJWT 用 JWT_SECRET 创建 HS256 令牌签发器，secret 不能为空 (由配置校验保证)
+func NewJWT(secret string, ttl time.Duration) *JWT {
 	if secret == "" {
-		//防止崩溃整了个默认的
-		return []byte("default_secret_key")
+		return NewJWTWithKeys(NewKeyring(), ttl)
 	}
-	return []byte(secret)
+	return NewJWTWithKeys(NewKeyring(SecretKey(secret)), ttl)
+}
+
+// NewJWTWithKeys 用密钥环创建令牌签发器，签发时使用当前生效的密钥，并在令牌头部写入 kid
+func NewJWTWithKeys(keys KeySource, ttl time.Duration) *JWT {
+	return &JWT{keys: keys, ttl: ttl}
+}
+
+// TTL 返回访问令牌的有效期
+func (j *JWT) TTL() time.Duration {
+	return j.ttl
+}
+
+// Keyring 返回当前的密钥环，供 JWKS 接口输出公钥
+func (j *JWT) Keyring() *Keyring {
+	return j.keys.Keyring()
 }
 
-func GenerateToken(userID uint) (string, error) {
+// GenerateToken 为登录会话 sessionID 中的用户签发访问令牌
+func (j *JWT) GenerateToken(userID uint, sessionID string) (string, error) {
+	now := time.Now()
+	key, ok := j.keys.Keyring().Signer(now)
+	if !ok {
+		return "", errors.New("JWT 密钥未配置")
+	}
 	claims := MyCustomClaims{
 		userID,
 		jwt.RegisteredClaims{
-			//过期时间设置为10天
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * 10 * time.Hour)),
+			ID: sessionID,
+			//过期时间由配置决定，默认15分钟，过期后用刷新令牌换取新的访问令牌
+			ExpiresAt: jwt.NewNumericDate(now.Add(j.ttl)),
 			Issuer:    "wish_wall_app",
 			//签发时间
-			IssuedAt: jwt.NewNumericDate(time.Now()),
+			IssuedAt: jwt.NewNumericDate(now),
 		},
 	}
-	//使用HS256算法生成token
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	//使用密钥对应的算法生成token，kid 告诉校验方用哪把密钥
+	token := jwt.NewWithClaims(key.Method(), claims)
+	token.Header["kid"] = key.ID
 
 	//用密钥签名，获取完整token字符串
-	tokenString, err := token.SignedString(getJWTSecret())
+	tokenString, err := token.SignedString(key.signKey)
 	if err != nil {
 		return "", err
 	}
 	return tokenString, nil
 }
 
-// ParseToken解析并验证一个token字符串
-func ParseToken(tokenString string) (*MyCustomClaims, error) {
+// ParseToken解析并验证一个token字符串，按头部的 kid 选择密钥，且算法必须与密钥一致
+func (j *JWT) ParseToken(tokenString string) (*MyCustomClaims, error) {
+	keyring := j.keys.Keyring()
+	if len(keyring.Keys) == 0 {
+		return nil, errors.New("JWT 密钥未配置")
+	}
 	//解析token
 	token, err := jwt.ParseWithClaims(tokenString, &MyCustomClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" {
+			return nil, errors.New("令牌缺少 kid")
+		}
+		key, ok := keyring.Lookup(kid, time.Now())
+		if !ok {
+			return nil, errors.New("未知或已停用的密钥")
+		}
+		if token.Method.Alg() != key.Algorithm {
 			return nil, errors.New("unexpected signing method")
 		}
-		return getJWTSecret(), nil
-	})
+		return key.verifyKey, nil
+	}, jwt.WithValidMethods(Algorithms))
 
 	if err != nil {
 		return nil, err //可能是token过期，签名无效
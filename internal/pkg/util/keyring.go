@@ -0,0 +1,383 @@
+package util
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/logger"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// 支持的签名算法
+const (
+	AlgHS256 = "HS256" // HMAC-SHA256，密钥只能由本服务持有，不会出现在 JWKS 中
+	AlgRS256 = "RS256" // RSA 2048 位
+	AlgEdDSA = "EdDSA" // Ed25519
+)
+
+// Algorithms 是 jwt-keys rotate 可以生成的算法
+var Algorithms = []string{AlgEdDSA, AlgRS256, AlgHS256}
+
+// Key 是密钥环中的一把签名密钥。NotBefore 之后才用于签发令牌 (之前已经出现在 JWKS 中，
+// 让其他服务提前缓存公钥)；NotAfter 之后不再接受它签发的令牌，为空表示一直有效
+type Key struct {
+	ID        string     `json:"kid"`
+	Algorithm string     `json:"alg"`
+	Material  string     `json:"key"` // HS256 为 base64 编码的密钥，RS256/EdDSA 为 PKCS#8 PEM 私钥
+	NotBefore time.Time  `json:"notBefore"`
+	NotAfter  *time.Time `json:"notAfter,omitempty"`
+
+	signKey   interface{} // 解析后的签名密钥
+	verifyKey interface{} // 解析后的校验密钥 (HS256 与签名密钥相同)
+}
+
+// Method 返回密钥对应的 JWT 签名方法
+func (k *Key) Method() jwt.SigningMethod {
+	return jwt.GetSigningMethod(k.Algorithm)
+}
+
+// Expired 表示密钥在 at 时已经停止使用
+func (k *Key) Expired(at time.Time) bool {
+	return k.NotAfter != nil && !at.Before(*k.NotAfter)
+}
+
+// parse 解析密钥材料
+func (k *Key) parse() error {
+	if k.ID == "" {
+		return errors.New("密钥缺少 kid")
+	}
+	switch k.Algorithm {
+	case AlgHS256:
+		secret, err := base64.StdEncoding.DecodeString(k.Material)
+		if err != nil {
+			return fmt.Errorf("密钥 %s 不是合法的 base64: %w", k.ID, err)
+		}
+		if len(secret) == 0 {
+			return fmt.Errorf("密钥 %s 为空", k.ID)
+		}
+		k.signKey, k.verifyKey = secret, secret
+		return nil
+	case AlgRS256, AlgEdDSA:
+		block, _ := pem.Decode([]byte(k.Material))
+		if block == nil {
+			return fmt.Errorf("密钥 %s 不是 PEM 格式", k.ID)
+		}
+		priv, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return fmt.Errorf("解析密钥 %s 失败: %w", k.ID, err)
+		}
+		switch priv := priv.(type) {
+		case *rsa.PrivateKey:
+			if k.Algorithm != AlgRS256 {
+				break
+			}
+			k.signKey, k.verifyKey = priv, &priv.PublicKey
+			return nil
+		case ed25519.PrivateKey:
+			if k.Algorithm != AlgEdDSA {
+				break
+			}
+			k.signKey, k.verifyKey = priv, priv.Public()
+			return nil
+		}
+		return fmt.Errorf("密钥 %s 的类型与算法 %s 不符", k.ID, k.Algorithm)
+	default:
+		return fmt.Errorf("密钥 %s 的算法 %q 不受支持 (可选 %v)", k.ID, k.Algorithm, Algorithms)
+	}
+}
+
+// GenerateKey 生成一把新密钥，kid 随机生成
+func GenerateKey(alg string, notBefore time.Time) (*Key, error) {
+	var material string
+	switch alg {
+	case AlgHS256:
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, err
+		}
+		material = base64.StdEncoding.EncodeToString(secret)
+	case AlgRS256, AlgEdDSA:
+		var priv crypto.Signer
+		var err error
+		if alg == AlgRS256 {
+			priv, err = rsa.GenerateKey(rand.Reader, 2048)
+		} else {
+			_, priv, err = ed25519.GenerateKey(rand.Reader)
+		}
+		if err != nil {
+			return nil, err
+		}
+		der, err := x509.MarshalPKCS8PrivateKey(priv)
+		if err != nil {
+			return nil, err
+		}
+		material = string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	default:
+		return nil, fmt.Errorf("不支持的算法 %q (可选 %v)", alg, Algorithms)
+	}
+
+	key := &Key{ID: rand.Text()[:16], Algorithm: alg, Material: material, NotBefore: notBefore.UTC().Truncate(time.Second)}
+	if err := key.parse(); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// SecretKey 把 JWT_SECRET 包装成 HS256 密钥。kid 由密钥的哈希得到，多个实例使用同一个 JWT_SECRET 时 kid 相同
+func SecretKey(secret string) *Key {
+	sum := sha256.Sum256([]byte(secret))
+	return &Key{
+		ID:        "hs-" + hex.EncodeToString(sum[:4]),
+		Algorithm: AlgHS256,
+		Material:  base64.StdEncoding.EncodeToString([]byte(secret)),
+		signKey:   []byte(secret),
+		verifyKey: []byte(secret),
+	}
+}
+
+// KeySource 提供当前的密钥环，密钥文件被 jwt-keys 命令修改后返回新的密钥环
+type KeySource interface {
+	Keyring() *Keyring
+}
+
+// Keyring 是一组签名密钥：最新生效的一把用于签发，其余未过期的用于校验轮换前签发的令牌
+type Keyring struct {
+	Keys []*Key `json:"keys"`
+}
+
+// NewKeyring 用已解析的密钥创建密钥环
+func NewKeyring(keys ...*Key) *Keyring {
+	return &Keyring{Keys: keys}
+}
+
+// Keyring 实现 KeySource，密钥环不会变化
+func (r *Keyring) Keyring() *Keyring { return r }
+
+// ParseKeyring 解析并校验密钥文件的内容
+func ParseKeyring(data []byte) (*Keyring, error) {
+	var r Keyring
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("密钥文件格式有误: %w", err)
+	}
+	seen := make(map[string]bool, len(r.Keys))
+	for _, k := range r.Keys {
+		if err := k.parse(); err != nil {
+			return nil, err
+		}
+		if seen[k.ID] {
+			return nil, fmt.Errorf("kid %s 重复", k.ID)
+		}
+		seen[k.ID] = true
+	}
+	return &r, nil
+}
+
+// LoadKeyring 读取密钥文件
+func LoadKeyring(path string) (*Keyring, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseKeyring(data)
+}
+
+// Save 把密钥环写入文件 (权限 0600)。先写临时文件再改名，正在读取的服务不会读到写了一半的文件
+func (r *Keyring) Save(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".jwt-keys-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Signer 返回 at 时用于签发令牌的密钥：已经生效且未过期的密钥中 NotBefore 最晚的一把，相同时取后加入的
+func (r *Keyring) Signer(at time.Time) (*Key, bool) {
+	var signer *Key
+	for _, k := range r.Keys {
+		if k.NotBefore.After(at) || k.Expired(at) {
+			continue
+		}
+		if signer == nil || !k.NotBefore.Before(signer.NotBefore) {
+			signer = k
+		}
+	}
+	return signer, signer != nil
+}
+
+// Lookup 按 kid 查找 at 时可以用于校验的密钥。尚未开始签发的密钥也可以校验，
+// 这样滚动发布时先读到新密钥文件的实例签发的令牌，其他实例同样接受
+func (r *Keyring) Lookup(kid string, at time.Time) (*Key, bool) {
+	for _, k := range r.Keys {
+		if k.ID == kid && !k.Expired(at) {
+			return k, true
+		}
+	}
+	return nil, false
+}
+
+// Rotate 生成一把在 now+delay 开始签发的新密钥。其他没有截止时间的密钥在新密钥生效后再保留 ttl
+// (访问令牌的有效期)，让轮换前签发的令牌自然过期；已经过期的密钥和还未生效 (没有签发过令牌) 的密钥从密钥环中移除
+func (r *Keyring) Rotate(alg string, now time.Time, delay, ttl time.Duration) (*Key, error) {
+	key, err := GenerateKey(alg, now.Add(delay))
+	if err != nil {
+		return nil, err
+	}
+	retireAt := key.NotBefore.Add(ttl)
+	r.Keys = slices.DeleteFunc(r.Keys, func(k *Key) bool { return k.Expired(now) || k.NotBefore.After(now) })
+	for _, k := range r.Keys {
+		if k.NotAfter == nil || k.NotAfter.After(retireAt) {
+			k.NotAfter = &retireAt
+		}
+	}
+	r.Keys = append(r.Keys, key)
+	return key, nil
+}
+
+// Retire 立即停用 kid 对应的密钥，用它签发的令牌全部失效 (密钥泄露时使用)
+func (r *Keyring) Retire(kid string, now time.Time) error {
+	for _, k := range r.Keys {
+		if k.ID == kid {
+			at := now.UTC().Truncate(time.Second)
+			k.NotAfter = &at
+			return nil
+		}
+	}
+	return fmt.Errorf("密钥 %s 不存在", kid)
+}
+
+// JWK 是 JWKS 中的一把公钥 (RFC 7517)
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	Curve     string `json:"crv,omitempty"` // OKP
+	X         string `json:"x,omitempty"`   // OKP
+	N         string `json:"n,omitempty"`   // RSA
+	E         string `json:"e,omitempty"`   // RSA
+}
+
+// JWKS 是 /.well-known/jwks.json 的内容
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS 返回 at 时未过期的非对称密钥的公钥，包括尚未开始签发的密钥。HS256 密钥不公开
+func (r *Keyring) JWKS(at time.Time) JWKS {
+	set := JWKS{Keys: []JWK{}}
+	for _, k := range r.Keys {
+		if k.Expired(at) {
+			continue
+		}
+		jwk := JWK{KeyID: k.ID, Use: "sig", Algorithm: k.Algorithm}
+		switch pub := k.verifyKey.(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.KeyType, jwk.Curve = "OKP", "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		default:
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
+
+// keyringReloadInterval 是检查密钥文件是否被修改的间隔，需要小于 jwt-keys rotate 的 -delay
+const keyringReloadInterval = 30 * time.Second
+
+// KeyringFile 从密钥文件读取密钥环，文件被修改后自动重新读取，轮换密钥不需要重启服务
+type KeyringFile struct {
+	path string
+
+	mu      sync.Mutex
+	keyring *Keyring
+	modTime time.Time
+	checked time.Time
+}
+
+// NewKeyringFile 创建密钥文件的读取器，第一次使用时读取文件
+func NewKeyringFile(path string) *KeyringFile {
+	return &KeyringFile{path: path, keyring: NewKeyring()}
+}
+
+// OpenKeyringFile 立即读取密钥文件，文件不存在、格式有误或没有可用于签发的密钥时返回错误
+func OpenKeyringFile(path string) (*KeyringFile, error) {
+	f := NewKeyringFile(path)
+	if err := f.load(); err != nil {
+		return nil, err
+	}
+	if _, ok := f.keyring.Signer(time.Now()); !ok {
+		return nil, fmt.Errorf("密钥文件 %s 中没有可用于签发的密钥，请执行 jwt-keys rotate", path)
+	}
+	return f, nil
+}
+
+// load 文件的修改时间变化时重新读取，调用方持有锁
+func (f *KeyringFile) load() error {
+	f.checked = time.Now()
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return err
+	}
+	if info.ModTime().Equal(f.modTime) {
+		return nil
+	}
+	keyring, err := LoadKeyring(f.path)
+	if err != nil {
+		return err
+	}
+	f.keyring, f.modTime = keyring, info.ModTime()
+	return nil
+}
+
+// Keyring 返回当前的密钥环。重新读取失败时继续使用原来的密钥环并记录警告
+func (f *KeyringFile) Keyring() *Keyring {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if time.Since(f.checked) < keyringReloadInterval {
+		return f.keyring
+	}
+	modTime := f.modTime
+	if err := f.load(); err != nil {
+		logger.Log.Warnw("读取 JWT 密钥文件失败，继续使用已加载的密钥", "path", f.path, "error", err)
+	} else if !f.modTime.Equal(modTime) {
+		logger.Log.Infow("已读取 JWT 密钥文件", "path", f.path, "keys", len(f.keyring.Keys))
+	}
+	return f.keyring
+}
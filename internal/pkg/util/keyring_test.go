@@ -0,0 +1,158 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/logger"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyringAlgorithms(t *testing.T) {
+	for _, alg := range Algorithms {
+		t.Run(alg, func(t *testing.T) {
+			key, err := GenerateKey(alg, time.Now().Add(-time.Minute))
+			require.NoError(t, err)
+			tokens := NewJWTWithKeys(NewKeyring(key), time.Hour)
+
+			token, err := tokens.GenerateToken(7, "session-1")
+			require.NoError(t, err)
+			parsed, _, err := jwt.NewParser().ParseUnverified(token, &MyCustomClaims{})
+			require.NoError(t, err)
+			assert.Equal(t, key.ID, parsed.Header["kid"])
+			assert.Equal(t, alg, parsed.Header["alg"])
+
+			claims, err := tokens.ParseToken(token)
+			require.NoError(t, err)
+			assert.Equal(t, uint(7), claims.UserID)
+
+			// 其他密钥环不认识这个 kid
+			other, err := GenerateKey(alg, time.Now().Add(-time.Minute))
+			require.NoError(t, err)
+			_, err = NewJWTWithKeys(NewKeyring(other), time.Hour).ParseToken(token)
+			assert.Error(t, err)
+		})
+	}
+
+	t.Run("算法必须与 kid 对应的密钥一致", func(t *testing.T) {
+		key, err := GenerateKey(AlgEdDSA, time.Now().Add(-time.Minute))
+		require.NoError(t, err)
+		// 伪造者用公钥当作 HMAC 密钥签名
+		forged := jwt.NewWithClaims(jwt.SigningMethodHS256, MyCustomClaims{UserID: 1})
+		forged.Header["kid"] = key.ID
+		signed, err := forged.SignedString([]byte(key.Material))
+		require.NoError(t, err)
+		_, err = NewJWTWithKeys(NewKeyring(key), time.Hour).ParseToken(signed)
+		assert.Error(t, err)
+	})
+
+	t.Run("不带 kid 的令牌", func(t *testing.T) {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, MyCustomClaims{UserID: 1}).SignedString([]byte("my_test_secret_key"))
+		require.NoError(t, err)
+		_, err = NewJWT("my_test_secret_key", time.Hour).ParseToken(token)
+		assert.Error(t, err)
+	})
+}
+
+func TestKeyringRotation(t *testing.T) {
+	now := time.Now()
+	ttl := 15 * time.Minute
+	keyring := NewKeyring(SecretKey("a-long-random-production-secret"))
+	old := keyring.Keys[0]
+	oldToken, err := NewJWTWithKeys(keyring, ttl).GenerateToken(1, "session-1")
+	require.NoError(t, err)
+
+	next, err := keyring.Rotate(AlgEdDSA, now, 10*time.Minute, ttl)
+	require.NoError(t, err)
+	require.NotNil(t, old.NotAfter)
+	assert.Equal(t, next.NotBefore.Add(ttl), *old.NotAfter, "旧密钥在新密钥生效后再保留一个访问令牌有效期")
+
+	// 新密钥生效前仍由旧密钥签发，但新密钥已经出现在 JWKS 中
+	signer, ok := keyring.Signer(now)
+	require.True(t, ok)
+	assert.Equal(t, old.ID, signer.ID)
+	jwks := keyring.JWKS(now)
+	require.Len(t, jwks.Keys, 1, "HS256 密钥不公开")
+	assert.Equal(t, next.ID, jwks.Keys[0].KeyID)
+	assert.Equal(t, "OKP", jwks.Keys[0].KeyType)
+
+	// 新密钥生效后由它签发，轮换前签发的令牌仍然有效
+	signer, _ = keyring.Signer(next.NotBefore)
+	assert.Equal(t, next.ID, signer.ID)
+	_, ok = keyring.Lookup(old.ID, next.NotBefore.Add(ttl-time.Second))
+	assert.True(t, ok)
+	_, ok = keyring.Lookup(old.ID, next.NotBefore.Add(ttl))
+	assert.False(t, ok, "旧密钥到期后不再接受")
+	_, err = NewJWTWithKeys(keyring, ttl).ParseToken(oldToken)
+	assert.NoError(t, err)
+
+	// 再次轮换时移除已经过期的密钥
+	_, err = keyring.Rotate(AlgRS256, next.NotBefore.Add(ttl), 0, ttl)
+	require.NoError(t, err)
+	require.Len(t, keyring.Keys, 2)
+	assert.Equal(t, next.ID, keyring.Keys[0].ID)
+	assert.Equal(t, "RSA", keyring.JWKS(next.NotBefore.Add(ttl)).Keys[1].KeyType)
+
+	// 还未生效的密钥没有签发过令牌，再次轮换时直接移除
+	pending, err := keyring.Rotate(AlgEdDSA, next.NotBefore.Add(ttl), time.Hour, ttl)
+	require.NoError(t, err)
+	_, err = keyring.Rotate(AlgEdDSA, next.NotBefore.Add(ttl), time.Hour, ttl)
+	require.NoError(t, err)
+	_, ok = keyring.Lookup(pending.ID, next.NotBefore.Add(ttl))
+	assert.False(t, ok)
+
+	// 停用密钥后它签发的令牌立即失效
+	require.NoError(t, NewKeyring(old).Retire(old.ID, now))
+	_, err = NewJWTWithKeys(NewKeyring(old), ttl).ParseToken(oldToken)
+	assert.Error(t, err)
+	assert.Error(t, keyring.Retire("unknown", now))
+}
+
+func TestKeyringFile(t *testing.T) {
+	logger.InitLogger()
+	path := filepath.Join(t.TempDir(), "jwt-keys.json")
+
+	_, err := OpenKeyringFile(path)
+	assert.Error(t, err, "文件不存在")
+	assert.Empty(t, NewKeyringFile(path).Keyring().Keys, "文件不存在时没有可用的密钥")
+
+	keyring := NewKeyring()
+	first, err := keyring.Rotate(AlgEdDSA, time.Now(), 0, time.Minute)
+	require.NoError(t, err)
+	require.NoError(t, keyring.Save(path))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	file, err := OpenKeyringFile(path)
+	require.NoError(t, err)
+	tokens := NewJWTWithKeys(file, time.Hour)
+	token, err := tokens.GenerateToken(1, "session-1")
+	require.NoError(t, err)
+
+	// 文件被修改后重新读取
+	loaded, err := LoadKeyring(path)
+	require.NoError(t, err)
+	second, err := loaded.Rotate(AlgHS256, time.Now(), 0, time.Hour)
+	require.NoError(t, err)
+	require.NoError(t, loaded.Save(path))
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(time.Second)))
+	file.checked = time.Time{}
+	signer, _ := file.Keyring().Signer(time.Now().Add(time.Second))
+	assert.Equal(t, second.ID, signer.ID)
+	_, err = tokens.ParseToken(token)
+	assert.NoError(t, err, "%s 仍在保留期内", first.ID)
+
+	// 文件内容有误时继续使用已加载的密钥
+	require.NoError(t, os.WriteFile(path, []byte("{"), 0o600))
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(2*time.Second)))
+	file.checked = time.Time{}
+	assert.Len(t, file.Keyring().Keys, 2)
+
+	_, err = ParseKeyring([]byte(`{"keys":[{"kid":"a","alg":"none","key":""}]}`))
+	assert.Error(t, err)
+}
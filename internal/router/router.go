@@ -2,95 +2,177 @@
 package router
 
 import (
-	"os"
+	"fmt"
 
 	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/handler"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/repository"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/app/service"
 	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/middleware"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/config"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/metrics"
+	"github.com/NCUHOME-Y/25-HACK-2-xueluocangyuan_wish_wall-BE/internal/pkg/util"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
 // SetupRouter 负责配置所有 API 路由
-func SetupRouter(db *gorm.DB) *gin.Engine {
+// cfg 为已校验的配置，路由只读取其中的活动、跨域与 JWT 配置
+// moderator 为内容审核器，由调用方注入（生产环境使用审核链，测试可注入假实现）
+// queue 为异步审核队列，为 nil 时愿望和评论在请求内同步审核
+// passwords 为密码服务，由调用方创建，以便停止服务时等待后台发送中的重置码
+// 可信代理设置失败时返回错误：按 IP 的登录限制依赖正确的客户端 IP，不能带着错误的设置启动
+func SetupRouter(cfg *config.Config, db *gorm.DB, moderator service.Moderator, queue service.ModerationQueue, passwords *service.PasswordService) (*gin.Engine, error) {
 	r := gin.New()
+	// 只信任反向代理转发的 X-Forwarded-For，否则客户端可以伪造 IP 绕过按 IP 的登录限制
+	if err := r.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		return nil, fmt.Errorf("设置可信代理 SERVER_TRUSTED_PROXIES 失败: %w", err)
+	}
+	jwt := util.NewJWT(cfg.JWT.Secret, cfg.JWT.TTL)
+	if cfg.JWT.KeysFile != "" {
+		// 使用密钥环签发和校验令牌 (启动时已经校验过密钥文件)，jwt-keys rotate 修改文件后自动重新读取
+		jwt = util.NewJWTWithKeys(util.NewKeyringFile(cfg.JWT.KeysFile), cfg.JWT.TTL)
+	}
+	// 用户、愿望、点赞、评论的业务规则由 service 负责，数据通过仓储访问
+	store := repository.NewGormStore(db)
+	tokens := service.NewTokenService(store, jwt, cfg.JWT.RefreshTTL)
+	users := service.NewUserService(store)
+	limiter := service.NewLoginLimiterFromConfig(service.NewThrottleStoreFromConfig(db, cfg.LoginLimit), cfg.LoginLimit)
+	wishes := service.NewWishService(store)
+	likes := service.NewLikeService(store)
+	comments := service.NewCommentService(store)
+	health := service.NewHealthChecker(db)
 
 	//  注册全局中间件
-	r.Use(middleware.CORSMiddleware())//跨域资源共享（CORS）中间件。允许或拒绝来自不同域名的前端页面访问你的 API。
+	r.Use(middleware.RequestIDMiddleware()) // 请求 ID 与请求级日志记录器，必须最先注册
+	r.Use(middleware.CORSMiddleware(cfg.CORS.AllowedOrigins))//跨域资源共享（CORS）中间件。允许或拒绝来自不同域名的前端页面访问你的 API。
 	r.Use(middleware.LoggerMiddleware())
+	r.Use(middleware.MetricsMiddleware())
 	r.Use(middleware.RecoveryMiddleware())//这个中间件会“接住”这个崩溃，防止整个服务器停止服务，并通常会返回一个 500 错误给客户端。
 
+	// 存活与就绪检查，供 docker-compose 健康检查和负载均衡探测，不经过 nginx 的 /api/ 转发
+	r.GET("/healthz", handler.Healthz)
+	r.GET("/readyz", func(c *gin.Context) { handler.Readyz(c, health) })
+	// 访问令牌的公钥 (JWKS)，供校园其他服务校验本服务签发的令牌
+	r.GET("/.well-known/jwks.json", func(c *gin.Context) { handler.JWKS(c, jwt) })
+	// Prometheus 指标，只允许内网或带 METRICS_TOKEN 的请求抓取
+	if cfg.Metrics.Enabled {
+		r.GET("/metrics", middleware.MetricsAuthMiddleware(cfg.Metrics), gin.WrapH(metrics.Handler()))
+	}
+
 	//  创建 /api 根路由组
 	api := r.Group("/api")
 	{	// 匿名函数可以使用它被定义时所在作用域的变量（这里就是 db）。
 		// 注册 (提升到公共区域，防止 ACTIVE_ACTIVITY 未设置时 404)
-		api.POST("/register", func(c *gin.Context) { handler.Register(c, db) })//调用 handler.Register 函数，并把 gin.Context 和数据库连接 db 传递给它。
+		api.POST("/register", func(c *gin.Context) { handler.Register(c, db, users, moderator, tokens) })//调用 handler.Register 函数，并把 gin.Context、数据库连接 db 和审核器传递给它。
 
 		// 登录 (V1 和 V2 都需要)
-		api.POST("/login", func(c *gin.Context) { handler.Login(c, db) })
+		api.POST("/login", func(c *gin.Context) { handler.Login(c, users, tokens, limiter) })
+		// 用刷新令牌换取新的访问令牌 (访问令牌有效期较短，过期后由前端调用)
+		api.POST("/token/refresh", func(c *gin.Context) { handler.RefreshToken(c, tokens) })
+		// 找回密码：申请重置码 (需要配置 PASSWORD_RESET_WEBHOOK_URL)，用重置码设置新密码
+		api.POST("/password/forgot", func(c *gin.Context) { handler.ForgotPassword(c, passwords) })
+		api.POST("/password/reset", func(c *gin.Context) { handler.ResetPassword(c, passwords, limiter) })
 		// 获取应用状态 (V1 和 V2 都需要)
-		api.GET("/app-state", handler.GetAppState)
+		api.GET("/app-state", func(c *gin.Context) { handler.GetAppState(c, cfg.ActiveActivity) })
 		// 内部 AI 测试 (V1 和 V2 都保留)
-		api.POST("/test-ai", func(c *gin.Context) { handler.TestAI(c) })
+		api.POST("/test-ai", func(c *gin.Context) { handler.TestAI(c, moderator) })
 
 		// 公共：获取某个愿望的评论列表
-		api.GET("/wishes/:id/comments", func(c *gin.Context) { handler.ListCommentsByWish(c, db) })
+		api.GET("/wishes/:id/comments", func(c *gin.Context) { handler.ListCommentsByWish(c, comments) })
 
 		// 公共：获取公共愿望列表（可带 Token，用于 liked 状态；不强制，可选鉴权）
 		public := api.Group("/")
-		public.Use(middleware.JWTOptionalAuthMiddleware())
+		public.Use(middleware.JWTOptionalAuthMiddleware(jwt, tokens))
 		{
-			public.GET("/wishes/public", func(c *gin.Context) { handler.GetPublicWishes(c, db) })
+			public.GET("/wishes/public", func(c *gin.Context) { handler.GetPublicWishes(c, wishes) })
 		}
 
 		//受保护的基础路由 (V1 和 V2 都需要)
 		auth := api.Group("/")
-		auth.Use(middleware.JWTAuthMiddleware())
+		auth.Use(middleware.JWTAuthMiddleware(jwt, tokens))
 		{
+			// 退出当前设备 / 退出全部设备
+			auth.POST("/logout", func(c *gin.Context) { handler.Logout(c, tokens) })
+			auth.POST("/logout/all", func(c *gin.Context) { handler.LogoutAll(c, tokens) })
+			// 已登录的设备列表，可以移除其中的设备
+			auth.GET("/user/sessions", func(c *gin.Context) { handler.ListSessions(c, tokens) })
+			auth.DELETE("/user/sessions/:id", func(c *gin.Context) { handler.DeleteSession(c, tokens) })
+			// 修改密码 (V1 和 V2 都需要)
+			auth.PUT("/user/password", func(c *gin.Context) { handler.ChangePassword(c, passwords, limiter) })
 			// 获取用户信息 (V1 和 V2 都需要)
-			auth.GET("/user/me", func(c *gin.Context) { handler.GetUserMe(c, db) })
+			auth.GET("/user/me", func(c *gin.Context) { handler.GetUserMe(c, users) })
 			// 查看个人星河 (V2 "只读" 的核心功能)
-			auth.GET("/wishes/me", func(c *gin.Context) { handler.GetMyWishes(c, db) })
+			auth.GET("/wishes/me", func(c *gin.Context) { handler.GetMyWishes(c, wishes) })
 			// 兼容测试用评论创建路由 (无论活动状态都提供)
-			auth.POST("/comments", func(c *gin.Context) { handler.CreateComment(c, db) })
+			auth.POST("/comments", func(c *gin.Context) { handler.CreateComment(c, db, comments, moderator, queue) })
+			// 查询自己发布内容的审核状态 (异步审核模式下前端轮询)
+			auth.GET("/wishes/:id/moderation", func(c *gin.Context) { handler.GetWishModeration(c, db) })
+			auth.GET("/comments/:id/moderation", func(c *gin.Context) { handler.GetCommentModeration(c, db) })
+		}
+
+		// 管理员：审核记录与人工复核队列 (V1 和 V2 都需要)
+		admin := api.Group("/admin")
+		admin.Use(middleware.JWTAuthMiddleware(jwt, tokens), middleware.AdminMiddleware(db))
+		{
+			admin.GET("/moderation/queue", func(c *gin.Context) { handler.ListModerationQueue(c, db) })
+			admin.GET("/moderation/records", func(c *gin.Context) { handler.ListModerationRecords(c, db) })
+			admin.POST("/moderation/records/:id/approve", func(c *gin.Context) { handler.ReviewModeration(c, db, true) })
+			admin.POST("/moderation/records/:id/reject", func(c *gin.Context) { handler.ReviewModeration(c, db, false) })
+			admin.GET("/moderation/users/:id/rejections", func(c *gin.Context) { handler.ListUserRejections(c, db) })
+			admin.GET("/moderation/thresholds", func(c *gin.Context) { handler.GetModerationThresholds(c, db) })
+			admin.PUT("/moderation/thresholds", func(c *gin.Context) { handler.UpdateModerationThresholds(c, db) })
+			admin.GET("/moderation/cache", handler.GetModerationCacheStats)
+			admin.GET("/moderation/health", handler.GetModerationHealth)
+			// 重新审核存量内容 (修改审核提示词或阈值后使用)
+			admin.POST("/moderation/remoderate", func(c *gin.Context) { handler.StartRemoderation(c, db, moderator) })
+			admin.GET("/moderation/remoderate", func(c *gin.Context) { handler.ListRemoderationJobs(c, db) })
+			admin.GET("/moderation/remoderate/:id", func(c *gin.Context) { handler.GetRemoderationReport(c, db) })
+			admin.POST("/moderation/remoderate/:id/resume", func(c *gin.Context) { handler.ResumeRemoderation(c, db, moderator) })
+			admin.POST("/moderation/remoderate/:id/cancel", func(c *gin.Context) { handler.CancelRemoderation(c, db) })
+			// 为忘记密码的用户签发重置码
+			admin.POST("/users/:id/password-reset", func(c *gin.Context) { handler.IssueResetCode(c, passwords) })
+			// 因登录失败次数过多被锁定的学号和 IP
+			admin.GET("/login-locks", func(c *gin.Context) { handler.ListLoginLocks(c, limiter) })
+			admin.DELETE("/login-locks/:username", func(c *gin.Context) { handler.UnlockLogin(c, limiter) })
 		}
 
 		// V1 / V2 动态功能路由
 
-		activity := os.Getenv("ACTIVE_ACTIVITY")
+		activity := cfg.ActiveActivity
 
 		if activity == "v1" {
 
 			// V1 受保护路由
 			{
 				// 更新用户信息 (V1 允许)
-				auth.PUT("/user", func(c *gin.Context) { handler.UpdateUser(c, db) })
+				auth.PUT("/user", func(c *gin.Context) { handler.UpdateUser(c, db, users, moderator) })
 
 				// 发布新愿望
 				auth.POST("/wishes", func(c *gin.Context) {
-					handler.CreateWish(c, db)
+					handler.CreateWish(c, db, wishes, moderator, queue)
 				})
 
 				// 删除愿望
 				auth.DELETE("/wishes/:id", func(c *gin.Context) {
-					 handler.DeleteWish(c, db) // (确保 handler.DeleteWish 存在)
+					 handler.DeleteWish(c, wishes) // (确保 handler.DeleteWish 存在)
 				})
 
 				// 点赞/取消点赞
 				auth.POST("/wishes/:id/like", func(c *gin.Context) {
-					handler.LikeWish(c, db)
+					handler.LikeWish(c, likes)
 				})
 
 				// 获取愿望互动详情
 				auth.GET("/wishes/:id/interactions", func(c *gin.Context) {
-					handler.GetInteractions(c, db)
+					handler.GetInteractions(c, wishes, likes)
 				})
 
 				// 创建评论或回复 
-				auth.POST("/wishes/:id/comment", func(c *gin.Context) { handler.CreateComment(c, db) })
+				auth.POST("/wishes/:id/comment", func(c *gin.Context) { handler.CreateComment(c, db, comments, moderator, queue) })
 				//auth.PUT("/comments/:id", func(c *gin.Context) { handler.UpdateComment(c, db) })
-				auth.DELETE("/comments/:id", func(c *gin.Context) { handler.DeleteComment(c, db) })
+				auth.DELETE("/comments/:id", func(c *gin.Context) { handler.DeleteComment(c, comments) })
 
-				auth.POST("/comments/reply", func(c *gin.Context) { handler.CreateReplyAI(c, db) })
+				auth.POST("/comments/reply", func(c *gin.Context) { handler.CreateReplyAI(c, db, comments, moderator, queue) })
 			}
 
 		} else {
@@ -109,5 +191,5 @@ func SetupRouter(db *gorm.DB) *gin.Engine {
 		}
 	}
 
-	return r
+	return r, nil
 }